@@ -22,6 +22,8 @@ var (
 	temperatureHumidityInterval time.Duration
 	temperatureValue            float64
 	humidityValue               float64
+	publishCommandAck           bool
+	waterQueueSize              int
 
 	controllerCommand = &cobra.Command{
 		Use:     "controller",
@@ -75,6 +77,12 @@ func init() {
 	controllerCommand.PersistentFlags().Float64Var(&humidityValue, "humidity-value", 100, "The value to use for humidity data publishing")
 	viper.BindPFlag("controller.humidity_value", controllerCommand.PersistentFlags().Lookup("humidity-value"))
 
+	controllerCommand.PersistentFlags().BoolVar(&publishCommandAck, "publish-command-ack", false, "Whether or not to acknowledge commands with their status")
+	viper.BindPFlag("controller.publish_command_ack", controllerCommand.PersistentFlags().Lookup("publish-command-ack"))
+
+	controllerCommand.PersistentFlags().IntVar(&waterQueueSize, "water-queue-size", 10, "Number of WaterActions that can be queued before they are rejected")
+	viper.BindPFlag("controller.water_queue_size", controllerCommand.PersistentFlags().Lookup("water-queue-size"))
+
 	rootCommand.AddCommand(controllerCommand)
 }
 
@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/action"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/mqtt"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultWaterQueueSize matches the QUEUE_SIZE used by the garden-controller firmware
+	defaultWaterQueueSize = 10
+	// seenCommandRetention is how long correlation IDs are kept to detect duplicates. The garden-app stops retrying
+	// commands long before this, so older correlation IDs can be forgotten
+	seenCommandRetention = mqtt.DefaultAckRetention
+)
+
+// queuedWater is a WaterMessage waiting to be "watered" and the correlation ID used to acknowledge it
+type queuedWater struct {
+	action.WaterMessage
+	correlationID string
+}
+
+func (c NestedConfig) waterQueueSize() int {
+	if c.WaterQueueSize <= 0 {
+		return defaultWaterQueueSize
+	}
+	return c.WaterQueueSize
+}
+
+// processWaterQueue emulates watering by waiting for the duration of each WaterMessage in the queue. It publishes
+// acknowledgements when watering is started and finished. Like the garden-controller, a water event is published
+// with the expected duration when watering starts and with the actual duration after watering
+func (c *Controller) processWaterQueue(done chan struct{}) {
+	for {
+		select {
+		case <-done:
+			return
+		case water := <-c.waterQueue:
+			c.publishAck(water.correlationID, mqtt.CommandStarted, "")
+			c.publishWaterEvent(action.WaterStartedMeasurement, water.WaterMessage)
+			start := time.Now()
+			select {
+			case <-time.After(time.Duration(water.Duration) * time.Millisecond):
+			case <-c.stopWater:
+			case <-done:
+				return
+			}
+			water.Duration = time.Since(start).Milliseconds()
+			c.publishWaterEvent(action.WaterMeasurement, water.WaterMessage)
+			c.publishAck(water.correlationID, mqtt.CommandFinished, "")
+		}
+	}
+}
+
+// stopWatering ends the current watering, if there is one. If clearQueue is true, all queued WaterMessages are
+// removed and rejected
+func (c *Controller) stopWatering(clearQueue bool) {
+	select {
+	case c.stopWater <- struct{}{}:
+	default:
+	}
+
+	if !clearQueue {
+		return
+	}
+	for {
+		select {
+		case water := <-c.waterQueue:
+			c.publishAck(water.correlationID, mqtt.CommandRejected, "stopped")
+		default:
+			return
+		}
+	}
+}
+
+// duplicateCommand returns true if a command with the correlation ID was already received. This prevents executing
+// commands more than once when they are retried by the garden-app. Correlation IDs are forgotten after the
+// seenCommandRetention so they do not grow forever
+func (c *Controller) duplicateCommand(correlationID string, now time.Time) bool {
+	if correlationID == "" {
+		return false
+	}
+
+	c.seenCommandsMu.Lock()
+	defer c.seenCommandsMu.Unlock()
+
+	for id, receivedAt := range c.seenCommands {
+		if now.Sub(receivedAt) > seenCommandRetention {
+			delete(c.seenCommands, id)
+		}
+	}
+
+	if _, ok := c.seenCommands[correlationID]; ok {
+		return true
+	}
+	c.seenCommands[correlationID] = now
+	return false
+}
+
+// publishAck publishes the status of a command if acknowledgements are enabled
+func (c *Controller) publishAck(correlationID string, status mqtt.CommandStatus, reason string) {
+	if !c.PublishCommandAck || correlationID == "" {
+		return
+	}
+
+	topic, err := c.MQTTConfig.AckTopic(c.TopicPrefix)
+	if err != nil {
+		c.pubLogger.WithError(err).Error("unable to fill ack topic template")
+		return
+	}
+	ackLogger := c.pubLogger.WithFields(logrus.Fields{
+		"topic":          topic,
+		"correlation_id": correlationID,
+		"status":         status,
+	})
+
+	msg, err := json.Marshal(mqtt.CommandAck{CorrelationID: correlationID, Status: status, Reason: reason})
+	if err != nil {
+		ackLogger.WithError(err).Error("unable to marshal command ack")
+		return
+	}
+
+	ackLogger.Info("publishing command ack")
+	err = c.mqttClient.Publish(topic, msg)
+	if err != nil {
+		ackLogger.WithError(err).Error("unable to publish command ack")
+	}
+}
@@ -0,0 +1,156 @@
+package controller
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/action"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/mqtt"
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// testMessage implements paho.Message for testing handlers
+type testMessage struct {
+	paho.Message
+	payload []byte
+}
+
+func (m testMessage) Payload() []byte { return m.payload }
+
+func newTestController(mqttClient mqtt.Client, queueSize int) *Controller {
+	return &Controller{
+		Config: Config{
+			NestedConfig: NestedConfig{
+				TopicPrefix:       "garden",
+				PublishCommandAck: true,
+			},
+		},
+		mqttClient:   mqttClient,
+		logger:       logrus.New(),
+		pubLogger:    logrus.New(),
+		subLogger:    logrus.New(),
+		waterQueue:   make(chan queuedWater, queueSize),
+		stopWater:    make(chan struct{}),
+		seenCommands: map[string]time.Time{},
+		seenNonces:   map[string]time.Time{},
+	}
+}
+
+func ackMessage(status, reason string) []byte {
+	if reason != "" {
+		return []byte(fmt.Sprintf(`{"correlation_id":"id","status":%q,"reason":%q}`, status, reason))
+	}
+	return []byte(fmt.Sprintf(`{"correlation_id":"id","status":%q}`, status))
+}
+
+func TestWaterHandlerAcks(t *testing.T) {
+	tests := []struct {
+		name          string
+		payload       string
+		setup         func(*Controller)
+		expectedAck   []byte
+		expectedQueue int
+	}{
+		{
+			"Accepted",
+			`{"duration":1000,"id":"chkodpg3lcj13q82mq40","position":0,"correlation_id":"id"}`,
+			func(c *Controller) {},
+			ackMessage("accepted", ""),
+			1,
+		},
+		{
+			"RejectedQueueFull",
+			`{"duration":1000,"id":"chkodpg3lcj13q82mq40","position":0,"correlation_id":"id"}`,
+			func(c *Controller) {
+				c.waterQueue <- queuedWater{}
+			},
+			ackMessage("rejected", "queue full"),
+			1,
+		},
+		{
+			"RejectedExpired",
+			`{"duration":1000,"id":"chkodpg3lcj13q82mq40","position":0,"correlation_id":"id","expires_at":1}`,
+			func(c *Controller) {},
+			ackMessage("rejected", "expired"),
+			0,
+		},
+		{
+			"DuplicateIsAcceptedButNotQueued",
+			`{"duration":1000,"id":"chkodpg3lcj13q82mq40","position":0,"correlation_id":"id"}`,
+			func(c *Controller) {
+				c.seenCommands["id"] = time.Now()
+			},
+			ackMessage("accepted", ""),
+			0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mqttClient := mqtt.NewMockClient(t)
+			mqttClient.On("Publish", "garden/command/ack", tt.expectedAck).Return(nil)
+
+			c := newTestController(mqttClient, 1)
+			tt.setup(c)
+
+			c.waterHandler("garden/command/water")(nil, testMessage{payload: []byte(tt.payload)})
+
+			assert.Len(t, c.waterQueue, tt.expectedQueue)
+		})
+	}
+}
+
+func TestDuplicateCommand(t *testing.T) {
+	c := newTestController(nil, 1)
+	now := time.Now()
+
+	assert.False(t, c.duplicateCommand("", now))
+	assert.False(t, c.duplicateCommand("id", now))
+	assert.True(t, c.duplicateCommand("id", now.Add(time.Minute)))
+
+	// Correlation IDs are forgotten after the retention
+	assert.False(t, c.duplicateCommand("id", now.Add(seenCommandRetention+time.Second)))
+	assert.Len(t, c.seenCommands, 1)
+}
+
+func TestProcessWaterQueue(t *testing.T) {
+	finished := make(chan struct{})
+	mqttClient := mqtt.NewMockClient(t)
+	mqttClient.On("Publish", "garden/command/ack", ackMessage("started", "")).Return(nil).Once()
+	mqttClient.On("Publish", "garden/command/ack", ackMessage("finished", "")).Return(nil).Once().Run(func(mock.Arguments) {
+		close(finished)
+	})
+
+	c := newTestController(mqttClient, 1)
+	c.waterQueue <- queuedWater{WaterMessage: action.WaterMessage{Duration: time.Hour.Milliseconds()}, correlationID: "id"}
+
+	done := make(chan struct{})
+	defer close(done)
+	go c.processWaterQueue(done)
+
+	// Wait until watering is started so it can be stopped
+	assert.Eventually(t, func() bool { return len(c.waterQueue) == 0 }, time.Second, time.Millisecond)
+	c.stopWater <- struct{}{}
+
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Error("timed out waiting for finished ack")
+	}
+}
+
+func TestStopWateringClearQueue(t *testing.T) {
+	mqttClient := mqtt.NewMockClient(t)
+	mqttClient.On("Publish", "garden/command/ack", ackMessage("rejected", "stopped")).Return(nil).Once()
+
+	c := newTestController(mqttClient, 2)
+	c.waterQueue <- queuedWater{correlationID: "id"}
+	c.waterQueue <- queuedWater{}
+
+	c.stopWatering(true)
+	assert.Empty(t, c.waterQueue)
+}
@@ -5,7 +5,6 @@ import (
 	"math/rand"
 	"os"
 	"os/signal"
-	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -37,6 +36,8 @@ type NestedConfig struct {
 	TemperatureValue                float64 `mapstructure:"temperature_value"`
 	HumidityValue                   float64 `mapstructure:"humidity_value"`
 	TemperatureHumidityDisableNoise bool    `mapstructure:"temperature_humidity_disable_noise"`
+	PublishCommandAck               bool    `mapstructure:"publish_command_ack" survey:"publish_command_ack"`
+	WaterQueueSize                  int     `mapstructure:"water_queue_size"`
 
 	// Configs used for both
 	TopicPrefix                 string        `mapstructure:"topic_prefix" survey:"topic_prefix"`
@@ -74,14 +75,33 @@ type Controller struct {
 
 	quit chan os.Signal
 
+	// waterQueue holds WaterMessages until they are done "watering" so the controller can acknowledge when commands
+	// are started and finished. stopWater is used to end the current watering early
+	waterQueue chan queuedWater
+	stopWater  chan struct{}
+
+	// seenCommands holds the correlation IDs of received commands and when they were received so retried commands
+	// are only executed once
+	seenCommandsMu sync.Mutex
+	seenCommands   map[string]time.Time
+
+	// seenNoncesMu protects seenNonces, which holds the nonces of signed commands and when they were received so
+	// replayed commands are rejected
+	seenNoncesMu sync.Mutex
+	seenNonces   map[string]time.Time
+
 	assertionData
 }
 
 // NewController creates and initializes everything needed to run a Controller based on config
 func NewController(cfg Config) (*Controller, error) {
 	controller := &Controller{
-		Config: cfg,
-		quit:   make(chan os.Signal, 1),
+		Config:       cfg,
+		quit:         make(chan os.Signal, 1),
+		waterQueue:   make(chan queuedWater, cfg.waterQueueSize()),
+		stopWater:    make(chan struct{}),
+		seenCommands: map[string]time.Time{},
+		seenNonces:   map[string]time.Time{},
 	}
 
 	controller.logger = setupLogger(cfg.LogConfig)
@@ -98,6 +118,10 @@ func NewController(cfg Config) (*Controller, error) {
 		controller.pubLogger.Infof("publishing moisture data for %d Zones", cfg.NumZones)
 	}
 
+	err := controller.MQTTConfig.ParseTopicTemplates()
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse topic templates: %w", err)
+	}
 	topics, err := controller.topics()
 	if err != nil {
 		return nil, fmt.Errorf("unable to determine topics: %w", err)
@@ -106,11 +130,12 @@ func NewController(cfg Config) (*Controller, error) {
 
 	// Build TopicHandlers to handle subscription to each topic
 	var handlers []mqtt.TopicHandler
-	for _, topic := range topics {
-		controller.subLogger.WithField("topic", topic).Info("initializing handler for MQTT messages")
+	for _, t := range topics {
+		controller.subLogger.WithField("topic", t.topic).Info("initializing handler for MQTT messages")
 		handlers = append(handlers, mqtt.TopicHandler{
-			Topic:   topic,
-			Handler: controller.getHandlerForTopic(topic),
+			Topic:       t.topic,
+			Handler:     controller.getHandlerForCommand(t.commandType, t.topic),
+			CommandType: t.commandType,
 		})
 	}
 
@@ -174,6 +199,9 @@ func (c *Controller) Start() {
 	}
 	scheduler.StartAsync()
 
+	done := make(chan struct{})
+	go c.processWaterQueue(done)
+
 	// Shutdown gracefully on Ctrl+C
 	wg := &sync.WaitGroup{}
 	wg.Add(1)
@@ -185,6 +213,7 @@ func (c *Controller) Start() {
 		c.logger.Info("gracefully shutting down controller")
 
 		scheduler.Stop()
+		close(done)
 
 		// Disconnect mqttClient
 		c.logger.Info("disconnecting MQTT Client")
@@ -346,40 +375,41 @@ func (c *Controller) createMoistureData() int {
 	}
 }
 
-// publishWaterEvent logs moisture data to InfluxDB via Telegraf and MQTT
-func (c *Controller) publishWaterEvent(waterMsg action.WaterMessage, cmdTopic string) {
+// publishWaterEvent publishes a water event with the measurement for when watering is started or finished, which
+// is used to log water data to InfluxDB via Telegraf and track the controller's state
+func (c *Controller) publishWaterEvent(measurement string, waterMsg action.WaterMessage) {
 	if !c.PublishWaterEvent {
 		c.pubLogger.Debug("publishing water events is disabled")
 		return
 	}
-	// Incoming topic is "{{.TopicPrefix}}/command/water" but we need to publish on "{{.TopicPrefix}}/data/water"
-	dataTopic := strings.ReplaceAll(cmdTopic, "command", "data")
+	dataTopic := fmt.Sprintf("%s/data/water", c.TopicPrefix)
 	waterEventLogger := c.pubLogger.WithFields(logrus.Fields{
 		"topic":         dataTopic,
+		"measurement":   measurement,
 		"zone_position": waterMsg.Position,
 		"duration":      waterMsg.Duration,
 	})
 	waterEventLogger.Info("publishing watering event for Zone")
 	err := c.mqttClient.Publish(
 		dataTopic,
-		[]byte(fmt.Sprintf("water,zone=%d millis=%d", waterMsg.Position, waterMsg.Duration)),
+		[]byte(fmt.Sprintf("%s,zone=%d millis=%d", measurement, waterMsg.Position, waterMsg.Duration)),
 	)
 	if err != nil {
 		waterEventLogger.WithError(err).Error("unable to publish watering event")
 	}
 }
 
-// getHandlerForTopic provides a different MessageHandler function for each of the expected
-// topics to be able to handle them in different ways
-func (c *Controller) getHandlerForTopic(topic string) paho.MessageHandler {
-	switch t := strings.Split(topic, "/")[2]; t {
-	case "water":
+// getHandlerForCommand provides a different MessageHandler function for each of the expected
+// commands to be able to handle them in different ways
+func (c *Controller) getHandlerForCommand(commandType mqtt.CommandType, topic string) paho.MessageHandler {
+	switch commandType {
+	case mqtt.WaterCommand:
 		return c.waterHandler(topic)
-	case "stop":
+	case mqtt.StopCommand:
 		return c.stopHandler(topic)
-	case "stop_all":
+	case mqtt.StopAllCommand:
 		return c.stopAllHandler(topic)
-	case "light":
+	case mqtt.LightCommand:
 		return c.lightHandler(topic)
 	default:
 		return paho.MessageHandler(func(pc paho.Client, msg paho.Message) {
@@ -391,21 +421,48 @@ func (c *Controller) getHandlerForTopic(topic string) paho.MessageHandler {
 	}
 }
 
-// topics returns a list of topics based on the Config values and provided TopicPrefix
-func (c *Controller) topics() ([]string, error) {
-	topics := []string{}
-	templateFuncs := []func(string) (string, error){
-		c.MQTTConfig.WaterTopic,
-		c.MQTTConfig.StopTopic,
-		c.MQTTConfig.StopAllTopic,
-		c.MQTTConfig.LightTopic,
-	}
-	for _, templateFunc := range templateFuncs {
-		topic, err := templateFunc(c.TopicPrefix)
+// commandTopic is a command topic that the Controller subscribes to
+type commandTopic struct {
+	topic       string
+	commandType mqtt.CommandType
+}
+
+// topics returns a list of topics based on the Config values and provided TopicPrefix. If the water topic uses the
+// Zone position, there is a water topic for each of the NumZones
+func (c *Controller) topics() ([]commandTopic, error) {
+	topics := []commandTopic{}
+	seen := map[string]bool{}
+	addTopic := func(commandType mqtt.CommandType, templateFunc func(mqtt.TopicData) (string, error), data mqtt.TopicData) error {
+		topic, err := templateFunc(data)
 		if err != nil {
+			return err
+		}
+		if !seen[topic] {
+			seen[topic] = true
+			topics = append(topics, commandTopic{topic, commandType})
+		}
+		return nil
+	}
+
+	data := mqtt.TopicData{Garden: c.TopicPrefix}
+	for position := 0; position == 0 || position < c.NumZones; position++ {
+		data.ZonePosition = uint(position)
+		if err := addTopic(mqtt.WaterCommand, c.MQTTConfig.WaterTopic, data); err != nil {
+			return topics, err
+		}
+	}
+	data.ZonePosition = 0
+	for _, t := range []struct {
+		commandType  mqtt.CommandType
+		templateFunc func(mqtt.TopicData) (string, error)
+	}{
+		{mqtt.StopCommand, c.MQTTConfig.StopTopic},
+		{mqtt.StopAllCommand, c.MQTTConfig.StopAllTopic},
+		{mqtt.LightCommand, c.MQTTConfig.LightTopic},
+	} {
+		if err := addTopic(t.commandType, t.templateFunc, data); err != nil {
 			return topics, err
 		}
-		topics = append(topics, topic)
 	}
 	return topics, nil
 }
@@ -3,6 +3,7 @@ package controller
 import (
 	"testing"
 
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/mqtt"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -15,3 +16,66 @@ func TestAddNoise(t *testing.T) {
 		assert.GreaterOrEqual(t, r, base-float64(percentRange))
 	}
 }
+
+func TestTopics(t *testing.T) {
+	tests := []struct {
+		name       string
+		numZones   int
+		waterTopic string
+		expected   []commandTopic
+	}{
+		{
+			"SingleWaterTopic",
+			3,
+			"{{.Garden}}/command/water",
+			[]commandTopic{
+				{"garden/command/water", mqtt.WaterCommand},
+				{"garden/command/stop", mqtt.StopCommand},
+				{"garden/command/stop_all", mqtt.StopAllCommand},
+				{"garden/command/light", mqtt.LightCommand},
+			},
+		},
+		{
+			"WaterTopicForEachZone",
+			2,
+			"{{.Garden}}/zone/{{ add .ZonePosition 1 }}/water",
+			[]commandTopic{
+				{"garden/zone/1/water", mqtt.WaterCommand},
+				{"garden/zone/2/water", mqtt.WaterCommand},
+				{"garden/command/stop", mqtt.StopCommand},
+				{"garden/command/stop_all", mqtt.StopAllCommand},
+				{"garden/command/light", mqtt.LightCommand},
+			},
+		},
+		{
+			"WaterTopicForEachZoneWithoutNumZones",
+			0,
+			"{{.Garden}}/zone/{{.ZonePosition}}/water",
+			[]commandTopic{
+				{"garden/zone/0/water", mqtt.WaterCommand},
+				{"garden/command/stop", mqtt.StopCommand},
+				{"garden/command/stop_all", mqtt.StopAllCommand},
+				{"garden/command/light", mqtt.LightCommand},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Controller{Config: Config{
+				MQTTConfig: mqtt.Config{
+					WaterTopicTemplate:   tt.waterTopic,
+					StopTopicTemplate:    "{{.Garden}}/command/stop",
+					StopAllTopicTemplate: "{{.Garden}}/command/stop_all",
+					LightTopicTemplate:   "{{.Garden}}/command/light",
+				},
+				NestedConfig: NestedConfig{TopicPrefix: "garden", NumZones: tt.numZones},
+			}}
+			assert.NoError(t, c.MQTTConfig.ParseTopicTemplates())
+
+			topics, err := c.topics()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, topics)
+		})
+	}
+}
@@ -2,13 +2,16 @@ package controller
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
-	"html/template"
 	"os"
 	"regexp"
+	"strings"
+	"text/template"
 	"time"
 
 	"github.com/AlecAivazis/survey/v2"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/mqtt"
 	"github.com/sirupsen/logrus"
 )
 
@@ -24,6 +27,21 @@ const (
 #ifdef ENABLE_WIFI
 #define MQTT_ADDRESS "{{ .MQTTConfig.Broker }}"
 #define MQTT_PORT {{ .MQTTConfig.Port }}
+{{- if .TLS }}
+
+#define ENABLE_MQTT_TLS
+#ifdef ENABLE_MQTT_TLS
+{{ if .TLS.CACert -}}
+#define MQTT_CA_CERT {{ cString .TLS.CACert }}
+{{ else -}}
+#define MQTT_TLS_INSECURE
+{{ end -}}
+{{ if .TLS.ClientCert -}}
+#define MQTT_CLIENT_CERT {{ cString .TLS.ClientCert }}
+#define MQTT_CLIENT_KEY {{ cString .TLS.ClientKey }}
+{{ end -}}
+#endif
+{{ end }}
 #define MQTT_CLIENT_NAME TOPIC_PREFIX
 #define MQTT_WATER_TOPIC TOPIC_PREFIX"/command/water"
 #define MQTT_STOP_TOPIC TOPIC_PREFIX"/command/stop"
@@ -45,7 +63,7 @@ const (
 #define MQTT_LOGGING_TOPIC TOPIC_PREFIX"/data/logs"
 #endif
 
-#define JSON_CAPACITY 48
+#define JSON_CAPACITY 96
 #endif
 
 {{ if .DisableWatering }}
@@ -89,8 +107,15 @@ const (
 	wifiConfigTemplate = `#ifndef wifi_config_h
 #define wifi_config_h
 
-#define SSID "{{ .SSID }}"
-#define PASSWORD "{{ .Password }}"
+#define SSID {{ cString .SSID }}
+#define PASSWORD {{ cString .Password }}
+{{ if .MQTT.Username }}
+#define MQTT_USERNAME {{ cString .MQTT.Username }}
+#define MQTT_PASSWORD {{ cString .MQTT.Password }}
+{{ end }}
+{{- if .CommandSecret }}
+#define COMMAND_SECRET {{ cString .CommandSecret }}
+{{ end }}
 
 #endif
 `
@@ -153,7 +178,12 @@ func GenerateConfig(config Config, writeFile, genWifiConfig, genMainConfig, over
 
 	if genWifiConfig {
 		logger.Debug("generating 'wifi_config.h'")
-		wifiConfig, err := generateWiFiConfig(config.WifiConfig, interactive)
+		commandSecret, err := commandSigningSecret(logger, config)
+		if err != nil {
+			logger.WithError(err).Error("error generating command signing secret")
+			return
+		}
+		wifiConfig, err := generateWiFiConfig(config.WifiConfig, config.MQTTConfig, commandSecret, interactive)
 		if err != nil {
 			logger.WithError(err).Error("error generating 'wifi_config.h'")
 			return
@@ -227,24 +257,52 @@ func generateMainConfig(config Config, interactive bool) (string, error) {
 		}
 	}
 
+	tlsConfig, err := newFirmwareTLSConfig(config.MQTTConfig)
+	if err != nil {
+		return "", err
+	}
+
 	milliseconds := func(interval time.Duration) string {
 		return fmt.Sprintf("%d", interval.Milliseconds())
 	}
 	t := template.Must(template.
 		New("config.h").
-		Funcs(template.FuncMap{"milliseconds": milliseconds}).
+		Funcs(template.FuncMap{"milliseconds": milliseconds, "cString": cString}).
 		Parse(configTemplate))
 
 	var result bytes.Buffer
-	data := config
-	err := t.Execute(&result, data)
+	data := struct {
+		Config
+		TLS *firmwareTLSConfig
+	}{config, tlsConfig}
+	err = t.Execute(&result, data)
 	if err != nil {
 		return "", err
 	}
 	return removeExtraNewlines(result.String()), nil
 }
 
-func generateWiFiConfig(config WifiConfig, interactive bool) (string, error) {
+// commandSigningSecret returns the Garden's secret for verifying signed commands if signing is enabled. A new secret is
+// generated if the Garden does not have one yet, and it must also be added to the server's config
+func commandSigningSecret(logger *logrus.Logger, config Config) (string, error) {
+	if !config.MQTTConfig.Signing.Enabled {
+		return "", nil
+	}
+	if secret, ok := config.MQTTConfig.Signing.Secret(config.TopicPrefix); ok {
+		return secret, nil
+	}
+
+	secret, err := mqtt.GenerateSecret()
+	if err != nil {
+		return "", err
+	}
+	logger.WithField("topic_prefix", config.TopicPrefix).Warn(
+		"generated a new command signing secret, add COMMAND_SECRET from 'wifi_config.h' to mqtt.signing.secrets in the server config",
+	)
+	return secret, nil
+}
+
+func generateWiFiConfig(config WifiConfig, mqttConfig mqtt.Config, commandSecret string, interactive bool) (string, error) {
 	qs := []*survey.Question{
 		{
 			Name: "ssid",
@@ -278,15 +336,87 @@ func generateWiFiConfig(config WifiConfig, interactive bool) (string, error) {
 		}
 	}
 
-	t := template.Must(template.New("wifi_config.h").Parse(wifiConfigTemplate))
+	t := template.Must(template.New("wifi_config.h").
+		Funcs(template.FuncMap{"cString": cString}).
+		Parse(wifiConfigTemplate))
 	var result bytes.Buffer
-	err := t.Execute(&result, config)
+	data := struct {
+		WifiConfig
+		MQTT          mqtt.Config
+		CommandSecret string
+	}{config, mqttConfig, commandSecret}
+	err := t.Execute(&result, data)
 	if err != nil {
 		return "", err
 	}
 	return removeExtraNewlines(result.String()), nil
 }
 
+// firmwareTLSConfig holds the PEM-encoded certificates and key that are embedded in the garden-controller's config
+type firmwareTLSConfig struct {
+	CACert     string
+	ClientCert string
+	ClientKey  string
+}
+
+// newFirmwareTLSConfig reads the MQTT TLS files so they can be used by the garden-controller. It returns nil if the
+// MQTT connection does not use TLS
+func newFirmwareTLSConfig(config mqtt.Config) (*firmwareTLSConfig, error) {
+	brokerURL, err := config.BrokerURL()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case strings.HasPrefix(brokerURL, mqtt.SchemeTCP+"://"):
+		return nil, nil
+	case strings.HasPrefix(brokerURL, mqtt.SchemeSSL+"://"):
+	default:
+		return nil, fmt.Errorf("scheme %q is not supported by garden-controller, use %s or %s", config.Scheme, mqtt.SchemeTCP, mqtt.SchemeSSL)
+	}
+
+	if config.TLS.CAFile == "" && !config.TLS.InsecureSkipVerify {
+		return nil, errors.New("ca_file or insecure_skip_verify is required for garden-controller TLS")
+	}
+	if (config.TLS.CertFile == "") != (config.TLS.KeyFile == "") {
+		return nil, errors.New("cert_file and key_file must both be provided")
+	}
+
+	result := &firmwareTLSConfig{}
+	for _, f := range []struct {
+		filename string
+		dest     *string
+	}{
+		{config.TLS.CAFile, &result.CACert},
+		{config.TLS.CertFile, &result.ClientCert},
+		{config.TLS.KeyFile, &result.ClientKey},
+	} {
+		if f.filename == "" {
+			continue
+		}
+		data, err := os.ReadFile(f.filename)
+		if err != nil {
+			return nil, fmt.Errorf("error reading TLS file: %w", err)
+		}
+		*f.dest = string(data)
+	}
+
+	return result, nil
+}
+
+// cString formats input as a C string literal that can be used in a macro. Quotes and backslashes are escaped.
+// Multi-line input, like a PEM file, is split into one literal per line
+func cString(input string) string {
+	if !strings.Contains(input, "\n") {
+		return fmt.Sprintf("%q", input)
+	}
+
+	lines := strings.Split(strings.TrimSpace(input), "\n")
+	for i, line := range lines {
+		lines[i] = fmt.Sprintf("%q", strings.TrimSpace(line)+"\n")
+	}
+	return "\\\n" + strings.Join(lines, " \\\n")
+}
+
 func removeExtraNewlines(input string) string {
 	return regexp.MustCompile(`(?m)^\n{2,}`).ReplaceAllLiteralString(input, "\n")
 }
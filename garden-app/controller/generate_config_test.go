@@ -2,10 +2,12 @@ package controller
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/calvinmclean/automated-garden/garden-app/pkg/mqtt"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -63,7 +65,7 @@ func TestGenerateMainConfig(t *testing.T) {
 #define MQTT_LOGGING_TOPIC TOPIC_PREFIX"/data/logs"
 #endif
 
-#define JSON_CAPACITY 48
+#define JSON_CAPACITY 96
 #endif
 
 #define NUM_ZONES 1
@@ -133,7 +135,7 @@ func TestGenerateMainConfig(t *testing.T) {
 #define MQTT_LOGGING_TOPIC TOPIC_PREFIX"/data/logs"
 #endif
 
-#define JSON_CAPACITY 48
+#define JSON_CAPACITY 96
 #endif
 
 #define NUM_ZONES 1
@@ -207,7 +209,7 @@ func TestGenerateMainConfig(t *testing.T) {
 #define MQTT_LOGGING_TOPIC TOPIC_PREFIX"/data/logs"
 #endif
 
-#define JSON_CAPACITY 48
+#define JSON_CAPACITY 96
 #endif
 
 #define DISABLE_WATERING
@@ -272,7 +274,7 @@ func TestGenerateMainConfig(t *testing.T) {
 #define MQTT_LOGGING_TOPIC TOPIC_PREFIX"/data/logs"
 #endif
 
-#define JSON_CAPACITY 48
+#define JSON_CAPACITY 96
 #endif
 
 #define NUM_ZONES 4
@@ -293,18 +295,208 @@ func TestGenerateMainConfig(t *testing.T) {
 	}
 }
 
+func TestGenerateMainConfigMQTTTLS(t *testing.T) {
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	assert.NoError(t, os.WriteFile(caFile, []byte("-----BEGIN CERTIFICATE-----\nMIIB+/a=\n-----END CERTIFICATE-----\n"), 0o600))
+
+	tests := []struct {
+		name           string
+		mqttConfig     mqtt.Config
+		expectedOutput string
+		expectedError  string
+	}{
+		{
+			"CACert",
+			mqtt.Config{Broker: "localhost", Port: 8883, TLS: mqtt.TLSConfig{CAFile: caFile}},
+			`#define MQTT_PORT 8883
+
+#define ENABLE_MQTT_TLS
+#ifdef ENABLE_MQTT_TLS
+#define MQTT_CA_CERT \
+"-----BEGIN CERTIFICATE-----\n" \
+"MIIB+/a=\n" \
+"-----END CERTIFICATE-----\n"
+#endif
+
+#define MQTT_CLIENT_NAME TOPIC_PREFIX`,
+			"",
+		},
+		{
+			"MutualTLS",
+			mqtt.Config{Broker: "localhost", Port: 8883, TLS: mqtt.TLSConfig{CAFile: caFile, CertFile: caFile, KeyFile: caFile}},
+			`#define MQTT_CLIENT_CERT \
+"-----BEGIN CERTIFICATE-----\n" \
+"MIIB+/a=\n" \
+"-----END CERTIFICATE-----\n"
+#define MQTT_CLIENT_KEY \`,
+			"",
+		},
+		{
+			"Insecure",
+			mqtt.Config{Broker: "localhost", Port: 8883, Scheme: "ssl", TLS: mqtt.TLSConfig{InsecureSkipVerify: true}},
+			`#ifdef ENABLE_MQTT_TLS
+#define MQTT_TLS_INSECURE
+#endif`,
+			"",
+		},
+		{
+			"MissingCA",
+			mqtt.Config{Broker: "localhost", Port: 8883, Scheme: "ssl"},
+			"",
+			"ca_file or insecure_skip_verify is required for garden-controller TLS",
+		},
+		{
+			"WebSocketNotSupported",
+			mqtt.Config{Broker: "localhost", Port: 443, Scheme: "wss"},
+			"",
+			`scheme "wss" is not supported by garden-controller, use tcp or ssl`,
+		},
+		{
+			"MissingFile",
+			mqtt.Config{Broker: "localhost", Port: 8883, TLS: mqtt.TLSConfig{CAFile: filepath.Join(dir, "missing.pem")}},
+			"",
+			"error reading TLS file: open " + filepath.Join(dir, "missing.pem") + ": no such file or directory",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := generateMainConfig(Config{
+				NestedConfig: NestedConfig{TopicPrefix: "garden"},
+				MQTTConfig:   tt.mqttConfig,
+			}, false)
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Contains(t, config, tt.expectedOutput)
+		})
+	}
+}
+
 func TestGenerateWifiConfig(t *testing.T) {
-	config, err := generateWiFiConfig(WifiConfig{
-		SSID:     "ssid",
-		Password: "password",
-	}, false)
-	assert.NoError(t, err)
-	assert.Equal(t, `#ifndef wifi_config_h
+	tests := []struct {
+		name           string
+		mqttConfig     mqtt.Config
+		commandSecret  string
+		expectedOutput string
+	}{
+		{
+			"NoMQTTAuth",
+			mqtt.Config{},
+			"",
+			`#ifndef wifi_config_h
 #define wifi_config_h
 
 #define SSID "ssid"
 #define PASSWORD "password"
 
 #endif
-`, config)
+`,
+		},
+		{
+			"MQTTAuth",
+			mqtt.Config{Username: "garden", Password: "p&ss"},
+			"",
+			`#ifndef wifi_config_h
+#define wifi_config_h
+
+#define SSID "ssid"
+#define PASSWORD "password"
+
+#define MQTT_USERNAME "garden"
+#define MQTT_PASSWORD "p&ss"
+
+#endif
+`,
+		},
+		{
+			"MQTTAuthEscaped",
+			mqtt.Config{Username: "garden", Password: `p"ss\word`},
+			"",
+			`#ifndef wifi_config_h
+#define wifi_config_h
+
+#define SSID "ssid"
+#define PASSWORD "password"
+
+#define MQTT_USERNAME "garden"
+#define MQTT_PASSWORD "p\"ss\\word"
+
+#endif
+`,
+		},
+		{
+			"CommandSecret",
+			mqtt.Config{},
+			"secret",
+			`#ifndef wifi_config_h
+#define wifi_config_h
+
+#define SSID "ssid"
+#define PASSWORD "password"
+
+#define COMMAND_SECRET "secret"
+
+#endif
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := generateWiFiConfig(WifiConfig{
+				SSID:     "ssid",
+				Password: "password",
+			}, tt.mqttConfig, tt.commandSecret, false)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedOutput, config)
+		})
+	}
+}
+
+func TestCommandSigningSecret(t *testing.T) {
+	tests := []struct {
+		name           string
+		signing        mqtt.SigningConfig
+		expectedSecret string
+		expectedLength int
+	}{
+		{
+			"Disabled",
+			mqtt.SigningConfig{Secrets: []mqtt.GardenSecret{{TopicPrefix: "garden", Secret: "secret"}}},
+			"",
+			0,
+		},
+		{
+			"ExistingSecret",
+			mqtt.SigningConfig{Enabled: true, Secrets: []mqtt.GardenSecret{{TopicPrefix: "garden", Secret: "secret"}}},
+			"secret",
+			6,
+		},
+		{
+			"GeneratedSecret",
+			mqtt.SigningConfig{Enabled: true, Secrets: []mqtt.GardenSecret{{TopicPrefix: "other-garden", Secret: "secret"}}},
+			"",
+			64,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := Config{
+				NestedConfig: NestedConfig{TopicPrefix: "garden"},
+				MQTTConfig:   mqtt.Config{Signing: tt.signing},
+			}
+			secret, err := commandSigningSecret(logrus.New(), config)
+			assert.NoError(t, err)
+			assert.Len(t, secret, tt.expectedLength)
+			if tt.expectedSecret != "" {
+				assert.Equal(t, tt.expectedSecret, secret)
+			}
+		})
+	}
 }
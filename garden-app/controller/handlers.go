@@ -2,21 +2,36 @@ package controller
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/calvinmclean/automated-garden/garden-app/pkg/action"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/mqtt"
 	paho "github.com/eclipse/paho.mqtt.golang"
 	"github.com/sirupsen/logrus"
 )
 
 func (c *Controller) waterHandler(topic string) paho.MessageHandler {
 	return func(pc paho.Client, msg paho.Message) {
-		waterLogger := c.subLogger.WithField("topic", topic)
+		waterLogger, correlationID, ok := c.receiveCommand(topic, msg)
+		if !ok {
+			return
+		}
 		var waterMsg action.WaterMessage
 		err := json.Unmarshal(msg.Payload(), &waterMsg)
 		if err != nil {
 			waterLogger.WithError(err).Error("unable to unmarshal WaterMessage JSON")
+			c.publishAck(correlationID, mqtt.CommandRejected, "invalid message")
+			return
+		}
+
+		select {
+		case c.waterQueue <- queuedWater{waterMsg, correlationID}:
+		default:
+			waterLogger.Warn("rejecting WaterAction because the queue is full")
+			c.publishAck(correlationID, mqtt.CommandRejected, "queue full")
 			return
 		}
+		c.publishAck(correlationID, mqtt.CommandAccepted, "")
 
 		c.assertionData.Lock()
 		c.assertionData.waterActions = append(c.assertionData.waterActions, waterMsg)
@@ -27,43 +42,59 @@ func (c *Controller) waterHandler(topic string) paho.MessageHandler {
 			"position": waterMsg.Position,
 			"duration": waterMsg.Duration,
 		}).Info("received WaterAction")
-		c.publishWaterEvent(waterMsg, topic)
 	}
 }
 
-func (c *Controller) stopHandler(_ string) paho.MessageHandler {
+func (c *Controller) stopHandler(topic string) paho.MessageHandler {
 	return func(pc paho.Client, msg paho.Message) {
+		stopLogger, correlationID, ok := c.receiveCommand(topic, msg)
+		if !ok {
+			return
+		}
+		c.publishAck(correlationID, mqtt.CommandAccepted, "")
+		c.stopWatering(false)
+
 		c.assertionData.Lock()
 		c.assertionData.stopActions++
 		c.assertionData.Unlock()
 
-		c.subLogger.WithFields(logrus.Fields{
-			"topic": msg.Topic(),
-		}).Info("received StopAction")
+		stopLogger.Info("received StopAction")
+		c.publishAck(correlationID, mqtt.CommandFinished, "")
 	}
 }
 
-func (c *Controller) stopAllHandler(_ string) paho.MessageHandler {
+func (c *Controller) stopAllHandler(topic string) paho.MessageHandler {
 	return paho.MessageHandler(func(pc paho.Client, msg paho.Message) {
+		stopAllLogger, correlationID, ok := c.receiveCommand(topic, msg)
+		if !ok {
+			return
+		}
+		c.publishAck(correlationID, mqtt.CommandAccepted, "")
+		c.stopWatering(true)
+
 		c.assertionData.Lock()
 		c.assertionData.stopAllActions++
 		c.assertionData.Unlock()
 
-		c.subLogger.WithFields(logrus.Fields{
-			"topic": msg.Topic(),
-		}).Info("received StopAllAction")
+		stopAllLogger.Info("received StopAllAction")
+		c.publishAck(correlationID, mqtt.CommandFinished, "")
 	})
 }
 
 func (c *Controller) lightHandler(topic string) paho.MessageHandler {
 	return paho.MessageHandler(func(pc paho.Client, msg paho.Message) {
-		lightLogger := c.subLogger.WithField("topic", topic)
+		lightLogger, correlationID, ok := c.receiveCommand(topic, msg)
+		if !ok {
+			return
+		}
 		var action action.LightAction
 		err := json.Unmarshal(msg.Payload(), &action)
 		if err != nil {
 			lightLogger.WithError(err).Error("unable to unmarshal LightAction JSON")
+			c.publishAck(correlationID, mqtt.CommandRejected, "invalid message")
 			return
 		}
+		c.publishAck(correlationID, mqtt.CommandAccepted, "")
 
 		c.assertionData.Lock()
 		c.assertionData.lightActions = append(c.assertionData.lightActions, action)
@@ -72,5 +103,41 @@ func (c *Controller) lightHandler(topic string) paho.MessageHandler {
 		lightLogger.WithFields(logrus.Fields{
 			"state": action.State,
 		}).Info("received LightAction")
+		c.publishAck(correlationID, mqtt.CommandFinished, "")
 	})
 }
+
+// receiveCommand reads the command's metadata to create a logger with its correlation ID. It returns false if the
+// command has an invalid signature, is expired, or is a duplicate and should be dropped
+func (c *Controller) receiveCommand(topic string, msg paho.Message) (*logrus.Entry, string, bool) {
+	logger := c.subLogger.WithField("topic", topic)
+
+	var metadata mqtt.CommandMetadata
+	err := json.Unmarshal(msg.Payload(), &metadata)
+	if err != nil {
+		logger.WithError(err).Warn("unable to read command metadata")
+		return logger, "", true
+	}
+	logger = logger.WithField("correlation_id", metadata.CorrelationID)
+
+	err = c.verifyCommand(msg.Payload(), time.Now())
+	if err != nil {
+		logger.WithError(err).Warn("dropping command that failed verification")
+		c.publishAck(metadata.CorrelationID, mqtt.CommandRejected, err.Error())
+		return logger, metadata.CorrelationID, false
+	}
+
+	if metadata.Expired(time.Now()) {
+		logger.WithField("expires_at", time.Unix(metadata.ExpiresAt, 0)).Warn("dropping expired command")
+		c.publishAck(metadata.CorrelationID, mqtt.CommandRejected, "expired")
+		return logger, metadata.CorrelationID, false
+	}
+
+	// Acknowledge duplicates again in case the original acknowledgement was lost
+	if c.duplicateCommand(metadata.CorrelationID, time.Now()) {
+		logger.Info("dropping duplicate command")
+		c.publishAck(metadata.CorrelationID, mqtt.CommandAccepted, "")
+		return logger, metadata.CorrelationID, false
+	}
+	return logger, metadata.CorrelationID, true
+}
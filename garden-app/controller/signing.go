@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// verifyCommand checks the command's signature if signing is enabled and rejects commands with a nonce that was
+// already received
+func (c *Controller) verifyCommand(payload []byte, now time.Time) error {
+	signing := c.MQTTConfig.Signing
+	if !signing.Enabled {
+		return nil
+	}
+
+	secret, ok := signing.Secret(c.TopicPrefix)
+	if !ok {
+		return fmt.Errorf("no signing secret for topic prefix %q", c.TopicPrefix)
+	}
+
+	nonce, err := signing.VerifyCommand(payload, secret, now)
+	if err != nil {
+		return err
+	}
+
+	if c.replayedNonce(nonce, now, signing.SignatureMaxAge()) {
+		return errors.New("replayed command")
+	}
+	return nil
+}
+
+// replayedNonce returns true if the nonce was already received. Nonces are forgotten once they are older than twice
+// the max age since commands signed before then are rejected anyway
+func (c *Controller) replayedNonce(nonce string, now time.Time, maxAge time.Duration) bool {
+	c.seenNoncesMu.Lock()
+	defer c.seenNoncesMu.Unlock()
+
+	for n, receivedAt := range c.seenNonces {
+		if now.Sub(receivedAt) > 2*maxAge {
+			delete(c.seenNonces, n)
+		}
+	}
+
+	if _, ok := c.seenNonces[nonce]; ok {
+		return true
+	}
+	c.seenNonces[nonce] = now
+	return false
+}
@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/mqtt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLightHandlerSignedCommands(t *testing.T) {
+	message := []byte(`{"state":"ON","correlation_id":"id"}`)
+	sign := func(secret string) []byte {
+		signed, err := mqtt.SignCommand(message, secret, time.Now())
+		assert.NoError(t, err)
+		return signed
+	}
+	signed := sign("secret")
+
+	tests := []struct {
+		name        string
+		signing     mqtt.SigningConfig
+		payload     []byte
+		expectedAck []byte
+	}{
+		{
+			"SigningDisabled",
+			mqtt.SigningConfig{},
+			message,
+			ackMessage("accepted", ""),
+		},
+		{
+			"ValidSignature",
+			mqtt.SigningConfig{Enabled: true, Secrets: []mqtt.GardenSecret{{TopicPrefix: "garden", Secret: "secret"}}},
+			signed,
+			ackMessage("accepted", ""),
+		},
+		{
+			"MissingSignature",
+			mqtt.SigningConfig{Enabled: true, Secrets: []mqtt.GardenSecret{{TopicPrefix: "garden", Secret: "secret"}}},
+			message,
+			ackMessage("rejected", "missing signature"),
+		},
+		{
+			"WrongSecret",
+			mqtt.SigningConfig{Enabled: true, Secrets: []mqtt.GardenSecret{{TopicPrefix: "garden", Secret: "secret"}}},
+			sign("other-secret"),
+			ackMessage("rejected", "invalid signature"),
+		},
+		{
+			"MissingSecret",
+			mqtt.SigningConfig{Enabled: true},
+			signed,
+			ackMessage("rejected", `no signing secret for topic prefix "garden"`),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mqttClient := mqtt.NewMockClient(t)
+			mqttClient.On("Publish", "garden/command/ack", tt.expectedAck).Return(nil).Once()
+			if string(tt.expectedAck) == string(ackMessage("accepted", "")) {
+				mqttClient.On("Publish", "garden/command/ack", ackMessage("finished", "")).Return(nil).Once()
+			}
+
+			c := newTestController(mqttClient, 1)
+			c.MQTTConfig.Signing = tt.signing
+
+			c.lightHandler("garden/command/light")(nil, testMessage{payload: tt.payload})
+		})
+	}
+}
+
+func TestReplayedNonce(t *testing.T) {
+	c := newTestController(nil, 1)
+	now := time.Now()
+
+	assert.False(t, c.replayedNonce("nonce", now, time.Minute))
+	assert.True(t, c.replayedNonce("nonce", now.Add(time.Minute), time.Minute))
+
+	// Nonces are forgotten after twice the max age
+	assert.False(t, c.replayedNonce("nonce", now.Add(3*time.Minute), time.Minute))
+}
+
+func TestVerifyCommandRejectsReplay(t *testing.T) {
+	c := newTestController(nil, 1)
+	c.MQTTConfig.Signing = mqtt.SigningConfig{Enabled: true, Secrets: []mqtt.GardenSecret{{TopicPrefix: "garden", Secret: "secret"}}}
+
+	signed, err := mqtt.SignCommand([]byte(`{"correlation_id":"id"}`), "secret", time.Now())
+	assert.NoError(t, err)
+
+	assert.NoError(t, c.verifyCommand(signed, time.Now()))
+	err = c.verifyCommand(signed, time.Now())
+	assert.Error(t, err)
+	assert.Equal(t, "replayed command", err.Error())
+}
@@ -10,13 +10,16 @@ require (
 	github.com/go-chi/render v1.0.2
 	github.com/go-co-op/gocron v1.28.2
 	github.com/influxdata/influxdb-client-go/v2 v2.12.2
+	github.com/influxdata/line-protocol v0.0.0-20210922203350-b1ad95c89adf
 	github.com/madflojo/hord v0.2.1-0.20230525172437-4609e296badd
 	github.com/mitchellh/mapstructure v1.5.0
+	github.com/mochi-mqtt/server/v2 v2.3.0
 	github.com/patrickmn/go-cache v2.1.0+incompatible
 	github.com/prometheus/client_golang v1.14.0
 	github.com/rivo/tview v0.0.0-20230226195229-47e7db7885b4
 	github.com/robfig/cron/v3 v3.0.1
 	github.com/rs/xid v1.4.0
+	github.com/rs/zerolog v1.28.0
 	github.com/sirupsen/logrus v1.9.0
 	github.com/slok/go-http-metrics v0.10.0
 	github.com/spf13/cobra v1.6.1
@@ -42,7 +45,6 @@ require (
 	github.com/gorilla/websocket v1.5.0 // indirect
 	github.com/hashicorp/hcl v1.0.0 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
-	github.com/influxdata/line-protocol v0.0.0-20210922203350-b1ad95c89adf // indirect
 	github.com/kballard/go-shellquote v0.0.0-20180428030007-95032a82bc51 // indirect
 	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
 	github.com/magiconair/properties v1.8.7 // indirect
@@ -66,6 +66,10 @@ func getConfigs(t *testing.T) (server.Config, controller.Config) {
 	require.NoError(t, err)
 	controllerConfig.LogConfig.Level = logrus.DebugLevel.String()
 
+	// The controller connects to the server's embedded MQTT broker
+	controllerConfig.MQTTConfig.Broker = "localhost"
+	controllerConfig.MQTTConfig.Port = serverConfig.MQTTBrokerConfig.Port
+
 	return serverConfig, controllerConfig
 }
 
@@ -106,14 +110,16 @@ func GardenTests(t *testing.T) {
 		assert.Equal(t, uint(0), g.NumPlants)
 	})
 	t.Run("ExecuteStopAction", func(t *testing.T) {
+		var actionResponse server.ActionResponse
 		status, err := makeRequest(
 			http.MethodPost,
 			fmt.Sprintf("/gardens/%s/action", gardenID),
 			action.GardenAction{Stop: &action.StopAction{}},
-			&struct{}{},
+			&actionResponse,
 		)
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusAccepted, status)
+		assert.Len(t, actionResponse.CommandIDs, 1)
 
 		time.Sleep(100 * time.Millisecond)
 
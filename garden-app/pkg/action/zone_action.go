@@ -25,6 +25,15 @@ type WaterAction struct {
 	IgnoreWeather  bool          `json:"ignore_weather"`
 }
 
+const (
+	// WaterMeasurement is the line protocol measurement published by garden controllers after a Zone is done
+	// watering, with the actual duration in the "millis" field
+	WaterMeasurement = "water"
+	// WaterStartedMeasurement is the line protocol measurement published by garden controllers when a Zone starts
+	// watering, with the expected duration in the "millis" field
+	WaterStartedMeasurement = "water_started"
+)
+
 // WaterMessage is the message being sent over MQTT to the embedded garden controller
 type WaterMessage struct {
 	Duration int64  `json:"duration"`
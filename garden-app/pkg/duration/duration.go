@@ -0,0 +1,141 @@
+package duration
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-co-op/gocron"
+	"github.com/robfig/cron/v3"
+	"gopkg.in/yaml.v3"
+)
+
+const cronPrefix = "cron:"
+
+// Duration is a wrapper around the time.Duration that allows it to be used as interger or string representation. It also
+// supports inputting a cron string as an interval instead if using the prefix "cron:"
+type Duration struct {
+	time.Duration
+	Cron string
+}
+
+// SchedulerFunc is a wrapper around gocron's fluent style to easily choose the cron or duration-based scheduling
+func (d *Duration) SchedulerFunc(s *gocron.Scheduler) *gocron.Scheduler {
+	if d.Cron != "" {
+		return s.Cron(d.Cron)
+	}
+	return s.Every(d.Duration)
+}
+
+// Window returns how much time one interval covers at the provided time. For a cron interval, this is the time
+// between the previous and next runs. It is zero for an invalid cron
+func (d *Duration) Window(now time.Time) time.Duration {
+	if d.Cron == "" {
+		return d.Duration
+	}
+
+	schedule, err := cron.ParseStandard(d.Cron)
+	if err != nil {
+		return 0
+	}
+	next := schedule.Next(now)
+
+	// The cron library cannot calculate previous runs, so look back until a run before now is found and then move
+	// forward to the latest one
+	for lookback := time.Minute; lookback <= maxCronLookback; lookback *= 2 {
+		prev := schedule.Next(now.Add(-lookback))
+		if prev.After(now) {
+			continue
+		}
+		for candidate := schedule.Next(prev); !candidate.After(now); candidate = schedule.Next(candidate) {
+			prev = candidate
+		}
+		return next.Sub(prev)
+	}
+
+	return 0
+}
+
+// maxCronLookback limits how far back Window searches for a cron's previous run
+const maxCronLookback = 2 * 366 * 24 * time.Hour
+
+// MarshalJSON will convert Duration into the string representation
+func (d *Duration) MarshalJSON() ([]byte, error) {
+	if d.Cron != "" {
+		return json.Marshal(cronPrefix + d.Cron)
+	}
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON with allow reading a Duration as a string or integer into time.Duration
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	err := json.Unmarshal(data, &value)
+	if err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case string:
+		d.Duration, d.Cron, err = parseString(v)
+		if err != nil {
+			return fmt.Errorf("invalid input for Duration: %w", err)
+		}
+	case float64:
+		d.Duration = time.Duration(v)
+	default:
+		return fmt.Errorf("unexpected type %T, must be string or number", v)
+	}
+
+	return nil
+}
+
+// UnmarshalYAML with allow reading a Duration as a string or integer into time.Duration
+func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+	switch value.Tag {
+	case "!!str":
+		var err error
+		d.Duration, d.Cron, err = parseString(value.Value)
+		if err != nil {
+			return fmt.Errorf("invalid input for Duration: %w", err)
+		}
+	case "!!int":
+		v, err := strconv.Atoi(value.Value)
+		if err != nil {
+			return err
+		}
+		d.Duration = time.Duration(v)
+	default:
+		return fmt.Errorf("unexpected type %s, must be string or number", value.Tag)
+	}
+
+	return nil
+}
+
+// MarshalYAML will convert Duration into the string representation
+func (d *Duration) MarshalYAML() (interface{}, error) {
+	if d.Cron != "" {
+		return cronPrefix + d.Cron, nil
+	}
+	return d.String(), nil
+}
+
+func parseString(input string) (time.Duration, string, error) {
+	if !strings.HasPrefix(input, cronPrefix) {
+		d, err := time.ParseDuration(strings.Trim(input, `"`))
+		if err != nil {
+			return 0, "", fmt.Errorf("invalid format for time.Duration: %w", err)
+		}
+		return d, "", nil
+	}
+
+	cronStr := strings.TrimPrefix(input, cronPrefix)
+	_, err := cron.ParseStandard(cronStr)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid cron expression: %w", err)
+	}
+
+	return 0, cronStr, nil
+}
@@ -1,4 +1,4 @@
-package pkg
+package duration
 
 import (
 	"encoding/json"
@@ -158,3 +158,26 @@ func TestDurationYAMLMarshal(t *testing.T) {
 		assert.Equal(t, "cron:*/5 * * * 1\n", string(result))
 	})
 }
+
+func TestDurationWindow(t *testing.T) {
+	now := time.Date(2023, time.June, 14, 10, 30, 0, 0, time.Local)
+
+	tests := []struct {
+		name     string
+		duration Duration
+		expected time.Duration
+	}{
+		{"Duration", Duration{Duration: 72 * time.Hour}, 72 * time.Hour},
+		{"DailyCron", Duration{Cron: "0 8 * * *"}, 24 * time.Hour},
+		{"WeekdayCron", Duration{Cron: "0 8 * * 1-5"}, 24 * time.Hour},
+		{"WeeklyCron", Duration{Cron: "0 8 * * 1"}, 7 * 24 * time.Hour},
+		{"EveryFiveMinutesCron", Duration{Cron: "*/5 * * * *"}, 5 * time.Minute},
+		{"InvalidCron", Duration{Cron: "invalid"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.duration.Window(now))
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/calvinmclean/automated-garden/garden-app/pkg/influxdb"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/units"
 	"github.com/rs/xid"
 )
 
@@ -73,6 +74,10 @@ type Garden struct {
 	EndDate                   *time.Time        `json:"end_date,omitempty" yaml:"end_date,omitempty"`
 	LightSchedule             *LightSchedule    `json:"light_schedule,omitempty" yaml:"light_schedule,omitempty"`
 	TemperatureHumiditySensor *bool             `json:"temperature_humidity_sensor,omitempty" yaml:"temperature_humidity_sensor,omitempty"`
+	HealthConfig              *HealthConfig     `json:"health_config,omitempty" yaml:"health_config,omitempty"`
+	SensorConfig              *SensorConfig     `json:"sensor_config,omitempty" yaml:"sensor_config,omitempty"`
+	// UnitSystem is used for sensor and weather data in responses about the Garden and its Zones
+	UnitSystem units.System `json:"unit_system,omitempty" yaml:"unit_system,omitempty"`
 }
 
 // String...
@@ -87,6 +92,42 @@ type GardenHealth struct {
 	LastContact *time.Time `json:"last_contact,omitempty"`
 }
 
+// HealthConfig controls how the Garden controller's health is determined
+// Window is how far back to look for the last contact (default 15m). The up threshold is used instead if it is longer
+// UpThreshold is the maximum time since last contact for the Garden to be considered UP (default 5m)
+type HealthConfig struct {
+	Window      *Duration `json:"window,omitempty" yaml:"window,omitempty"`
+	UpThreshold *Duration `json:"up_threshold,omitempty" yaml:"up_threshold,omitempty"`
+}
+
+// Patch allows modifying the struct in-place with values from a different instance
+func (hc *HealthConfig) Patch(new *HealthConfig) {
+	if new.Window != nil {
+		hc.Window = new.Window
+	}
+	if new.UpThreshold != nil {
+		hc.UpThreshold = new.UpThreshold
+	}
+}
+
+// SensorConfig controls how the Garden controller's temperature and humidity data is read from InfluxDB
+// Window is how far back to look for temperature/humidity data (default 15m)
+// Aggregation is used to reduce temperature/humidity data in the Window to a single value (default mean)
+type SensorConfig struct {
+	Window      *Duration            `json:"window,omitempty" yaml:"window,omitempty"`
+	Aggregation influxdb.Aggregation `json:"aggregation,omitempty" yaml:"aggregation,omitempty"`
+}
+
+// Patch allows modifying the struct in-place with values from a different instance
+func (sc *SensorConfig) Patch(new *SensorConfig) {
+	if new.Window != nil {
+		sc.Window = new.Window
+	}
+	if new.Aggregation != "" {
+		sc.Aggregation = new.Aggregation
+	}
+}
+
 // LightSchedule allows the user to control when the Garden light is turned on and off
 // "Time" should be in the format of LightTimeFormat constant ("15:04:05-07:00")
 type LightSchedule struct {
@@ -115,7 +156,8 @@ func (ls *LightSchedule) Patch(new *LightSchedule) {
 
 // Health returns a GardenHealth struct after querying InfluxDB for the Garden controller's last contact time
 func (g *Garden) Health(ctx context.Context, influxdbClient influxdb.Client) *GardenHealth {
-	lastContact, err := influxdbClient.GetLastContact(ctx, g.TopicPrefix)
+	upThreshold := g.upThreshold()
+	lastContact, err := influxdbClient.GetLastContact(ctx, g.TopicPrefix, g.lastContactWindow(upThreshold))
 	if err != nil {
 		return &GardenHealth{
 			Status:  "N/A",
@@ -130,9 +172,8 @@ func (g *Garden) Health(ctx context.Context, influxdbClient influxdb.Client) *Ga
 		}
 	}
 
-	// Garden is considered "UP" if it's last contact was less than 5 minutes ago
 	between := time.Since(lastContact)
-	up := between < 5*time.Minute
+	up := between < upThreshold
 
 	status := "UP"
 	if !up {
@@ -184,6 +225,60 @@ func (g *Garden) Patch(newGarden *Garden) {
 	if newGarden.TemperatureHumiditySensor != nil {
 		g.TemperatureHumiditySensor = newGarden.TemperatureHumiditySensor
 	}
+	if newGarden.HealthConfig != nil {
+		if g.HealthConfig == nil {
+			g.HealthConfig = &HealthConfig{}
+		}
+		g.HealthConfig.Patch(newGarden.HealthConfig)
+	}
+	if newGarden.SensorConfig != nil {
+		if g.SensorConfig == nil {
+			g.SensorConfig = &SensorConfig{}
+		}
+		g.SensorConfig.Patch(newGarden.SensorConfig)
+	}
+	if newGarden.UnitSystem != "" {
+		g.UnitSystem = newGarden.UnitSystem
+	}
+}
+
+// upThreshold returns the maximum time since last contact for the Garden to be considered "UP". It is 5 minutes
+// unless configured otherwise
+func (g *Garden) upThreshold() time.Duration {
+	if g.HealthConfig == nil || g.HealthConfig.UpThreshold == nil {
+		return 5 * time.Minute
+	}
+	return g.HealthConfig.UpThreshold.Duration
+}
+
+// lastContactWindow returns how far back to look for the Garden's last contact. It is never shorter than the up
+// threshold so a Garden that contacted the server within the threshold is not reported as having no last contact
+func (g *Garden) lastContactWindow(upThreshold time.Duration) time.Duration {
+	window := influxdb.DefaultWindow
+	if g.HealthConfig != nil && g.HealthConfig.Window != nil {
+		window = g.HealthConfig.Window.Duration
+	}
+	if upThreshold > window {
+		return upThreshold
+	}
+	return window
+}
+
+// SensorWindow returns the configured time window for reading the Garden's temperature and humidity data from
+// InfluxDB. If it is not configured, zero is returned so the InfluxDB client will use its default
+func (g *Garden) SensorWindow() time.Duration {
+	if g.SensorConfig == nil || g.SensorConfig.Window == nil {
+		return 0
+	}
+	return g.SensorConfig.Window.Duration
+}
+
+// SensorAggregation returns the configured aggregation for the Garden's temperature and humidity data
+func (g *Garden) SensorAggregation() influxdb.Aggregation {
+	if g.SensorConfig == nil {
+		return ""
+	}
+	return g.SensorConfig.Aggregation
 }
 
 // NumPlants returns the number of non-end-dated Plants that are part of this Garden
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/calvinmclean/automated-garden/garden-app/pkg/influxdb"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/units"
 	"github.com/rs/xid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -16,42 +17,83 @@ import (
 func TestHealth(t *testing.T) {
 	tests := []struct {
 		name            string
+		healthConfig    *HealthConfig
 		lastContactTime time.Time
 		err             error
+		expectedWindow  time.Duration
 		expectedStatus  string
 	}{
 		{
 			"GardenIsUp",
+			nil,
 			time.Now(),
 			nil,
+			influxdb.DefaultWindow,
 			"UP",
 		},
 		{
 			"GardenIsDown",
+			nil,
 			time.Now().Add(-5 * time.Minute),
 			nil,
+			influxdb.DefaultWindow,
 			"DOWN",
 		},
 		{
 			"InfluxDBError",
+			nil,
 			time.Time{},
 			errors.New("influxdb error"),
+			influxdb.DefaultWindow,
 			"N/A",
 		},
 		{
 			"ZeroTime",
+			nil,
 			time.Time{},
 			nil,
+			influxdb.DefaultWindow,
 			"DOWN",
 		},
+		{
+			"GardenIsUpWithConfiguredThreshold",
+			&HealthConfig{
+				Window:      &Duration{Duration: time.Hour},
+				UpThreshold: &Duration{Duration: 45 * time.Minute},
+			},
+			time.Now().Add(-30 * time.Minute),
+			nil,
+			time.Hour,
+			"UP",
+		},
+		{
+			"GardenIsDownWithConfiguredThreshold",
+			&HealthConfig{
+				UpThreshold: &Duration{Duration: time.Minute},
+			},
+			time.Now().Add(-2 * time.Minute),
+			nil,
+			influxdb.DefaultWindow,
+			"DOWN",
+		},
+		{
+			"LastContactWindowIncludesUpThreshold",
+			&HealthConfig{
+				UpThreshold: &Duration{Duration: time.Hour},
+			},
+			time.Now().Add(-30 * time.Minute),
+			nil,
+			time.Hour,
+			"UP",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			influxdbClient := new(influxdb.MockClient)
-			influxdbClient.On("GetLastContact", mock.Anything, "garden").Return(tt.lastContactTime, tt.err)
+			influxdbClient.On("GetLastContact", mock.Anything, "garden", tt.expectedWindow).Return(tt.lastContactTime, tt.err)
 
-			g := Garden{TopicPrefix: "garden"}
+			g := Garden{TopicPrefix: "garden", HealthConfig: tt.healthConfig}
 
 			gardenHealth := g.Health(context.Background(), influxdbClient)
 			if gardenHealth.Status != tt.expectedStatus {
@@ -113,7 +155,7 @@ func TestGardenPatch(t *testing.T) {
 		{
 			"PatchLightSchedule.Duration",
 			&Garden{LightSchedule: &LightSchedule{
-				Duration: &Duration{2 * time.Hour, ""},
+				Duration: &Duration{Duration: 2 * time.Hour},
 			}},
 		},
 		{
@@ -136,6 +178,24 @@ func TestGardenPatch(t *testing.T) {
 			"PatchTemperatureHumiditySensorFalse",
 			&Garden{TemperatureHumiditySensor: &falseBool},
 		},
+		{
+			"PatchUnitSystem",
+			&Garden{UnitSystem: units.Imperial},
+		},
+		{
+			"PatchHealthConfig",
+			&Garden{HealthConfig: &HealthConfig{
+				Window:      &Duration{Duration: time.Hour},
+				UpThreshold: &Duration{Duration: 45 * time.Minute},
+			}},
+		},
+		{
+			"PatchSensorConfig",
+			&Garden{SensorConfig: &SensorConfig{
+				Window:      &Duration{Duration: time.Hour},
+				Aggregation: influxdb.AggregationLast,
+			}},
+		},
 	}
 
 	for _, tt := range tests {
@@ -151,6 +211,12 @@ func TestGardenPatch(t *testing.T) {
 			if g.CreatedAt != tt.newGarden.CreatedAt {
 				t.Errorf("Unexpected result for CreatedAt: expected=%v, actual=%v", tt.newGarden.CreatedAt, g.CreatedAt)
 			}
+			if g.HealthConfig != nil && *g.HealthConfig != *tt.newGarden.HealthConfig {
+				t.Errorf("Unexpected result for HealthConfig: expected=%v, actual=%v", tt.newGarden.HealthConfig, g.HealthConfig)
+			}
+			if g.SensorConfig != nil && *g.SensorConfig != *tt.newGarden.SensorConfig {
+				t.Errorf("Unexpected result for SensorConfig: expected=%v, actual=%v", tt.newGarden.SensorConfig, g.SensorConfig)
+			}
 		})
 	}
 
@@ -158,7 +224,7 @@ func TestGardenPatch(t *testing.T) {
 		g := &Garden{
 			LightSchedule: &LightSchedule{
 				StartTime: "START TIME",
-				Duration:  &Duration{2 * time.Hour, ""},
+				Duration:  &Duration{Duration: 2 * time.Hour},
 			},
 		}
 		g.Patch(&Garden{LightSchedule: &LightSchedule{}})
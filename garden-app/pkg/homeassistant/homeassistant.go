@@ -0,0 +1,277 @@
+package homeassistant
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg"
+	"github.com/rs/xid"
+)
+
+const (
+	// DefaultDiscoveryPrefix is the topic prefix that Home Assistant uses for MQTT discovery by default
+	DefaultDiscoveryPrefix = "homeassistant"
+	// DefaultTopicPrefix is the prefix for command and state topics used by the garden-app's entities
+	DefaultTopicPrefix = "garden-app"
+	// DefaultWaterDuration is used when a Zone is turned on before a duration is set in Home Assistant
+	DefaultWaterDuration = time.Minute
+
+	// PayloadOn is the payload Home Assistant uses to turn on a switch or light
+	PayloadOn = "ON"
+	// PayloadOff is the payload Home Assistant uses to turn off a switch or light
+	PayloadOff = "OFF"
+)
+
+// valueTemplate reads the value field from the InfluxDB line protocol published by the garden-controller
+const valueTemplate = "{{ value | regex_findall_index('value=([0-9.-]+)') | float }}"
+
+// Config is used to enable Home Assistant MQTT discovery and commands
+type Config struct {
+	Enabled              bool          `mapstructure:"enabled"`
+	DiscoveryPrefix      string        `mapstructure:"discovery_prefix"`
+	TopicPrefix          string        `mapstructure:"topic_prefix"`
+	DefaultWaterDuration time.Duration `mapstructure:"default_water_duration"`
+}
+
+func (c Config) discoveryPrefix() string {
+	if c.DiscoveryPrefix == "" {
+		return DefaultDiscoveryPrefix
+	}
+	return c.DiscoveryPrefix
+}
+
+func (c Config) topicPrefix() string {
+	if c.TopicPrefix == "" {
+		return DefaultTopicPrefix
+	}
+	return c.TopicPrefix
+}
+
+// WaterDuration returns the configured default duration for watering a Zone
+func (c Config) WaterDuration() time.Duration {
+	if c.DefaultWaterDuration <= 0 {
+		return DefaultWaterDuration
+	}
+	return c.DefaultWaterDuration
+}
+
+// SubscriptionTopics returns the topics that receive commands from Home Assistant. The Zone duration state topic is
+// included so retained durations are restored when the garden-app restarts
+func (c Config) SubscriptionTopics() []string {
+	return []string{
+		fmt.Sprintf("%s/+/light/set", c.topicPrefix()),
+		fmt.Sprintf("%s/+/zone/+/+/set", c.topicPrefix()),
+		fmt.Sprintf("%s/+/zone/+/duration", c.topicPrefix()),
+	}
+}
+
+// LightStateTopic returns the topic used to report a Garden's light state to Home Assistant
+func (c Config) LightStateTopic(gardenID xid.ID) string {
+	return fmt.Sprintf("%s/%s/light", c.topicPrefix(), gardenID)
+}
+
+// WaterStateTopic returns the topic used to report if a Zone is watering to Home Assistant
+func (c Config) WaterStateTopic(gardenID, zoneID xid.ID) string {
+	return fmt.Sprintf("%s/%s/zone/%s/water", c.topicPrefix(), gardenID, zoneID)
+}
+
+// DurationStateTopic returns the topic used to report a Zone's watering duration to Home Assistant
+func (c Config) DurationStateTopic(gardenID, zoneID xid.ID) string {
+	return fmt.Sprintf("%s/%s/zone/%s/duration", c.topicPrefix(), gardenID, zoneID)
+}
+
+// CommandType is the kind of message received from Home Assistant
+type CommandType string
+
+const (
+	// LightCommand turns a Garden's light on or off
+	LightCommand CommandType = "light"
+	// WaterCommand starts or stops watering a Zone
+	WaterCommand CommandType = "water"
+	// DurationCommand sets the duration used for watering a Zone
+	DurationCommand CommandType = "duration"
+	// DurationState is the retained duration for a Zone
+	DurationState CommandType = "duration_state"
+)
+
+// Command is a message received from Home Assistant
+type Command struct {
+	Type     CommandType
+	GardenID xid.ID
+	ZoneID   xid.ID
+}
+
+// ParseTopic gets the Command from a topic that was received from Home Assistant
+func (c Config) ParseTopic(topic string) (Command, error) {
+	prefix := c.topicPrefix() + "/"
+	if !strings.HasPrefix(topic, prefix) {
+		return Command{}, fmt.Errorf("topic %q does not start with prefix %q", topic, prefix)
+	}
+	parts := strings.Split(strings.TrimPrefix(topic, prefix), "/")
+
+	gardenID, err := xid.FromString(parts[0])
+	if err != nil {
+		return Command{}, fmt.Errorf("invalid Garden ID %q: %w", parts[0], err)
+	}
+
+	switch {
+	case len(parts) == 3 && parts[1] == "light" && parts[2] == "set":
+		return Command{Type: LightCommand, GardenID: gardenID}, nil
+	case len(parts) >= 4 && parts[1] == "zone":
+		zoneID, err := xid.FromString(parts[2])
+		if err != nil {
+			return Command{}, fmt.Errorf("invalid Zone ID %q: %w", parts[2], err)
+		}
+		cmd := Command{GardenID: gardenID, ZoneID: zoneID}
+		switch strings.Join(parts[3:], "/") {
+		case "water/set":
+			cmd.Type = WaterCommand
+		case "duration/set":
+			cmd.Type = DurationCommand
+		case "duration":
+			cmd.Type = DurationState
+		default:
+			return Command{}, fmt.Errorf("unexpected topic %q", topic)
+		}
+		return cmd, nil
+	}
+	return Command{}, fmt.Errorf("unexpected topic %q", topic)
+}
+
+// Device groups a Garden's entities in Home Assistant
+type Device struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+	Model        string   `json:"model"`
+}
+
+// EntityConfig is the discovery payload for a Home Assistant entity
+type EntityConfig struct {
+	Name              string   `json:"name"`
+	UniqueID          string   `json:"unique_id"`
+	Device            Device   `json:"device"`
+	CommandTopic      string   `json:"command_topic,omitempty"`
+	StateTopic        string   `json:"state_topic,omitempty"`
+	ValueTemplate     string   `json:"value_template,omitempty"`
+	DeviceClass       string   `json:"device_class,omitempty"`
+	StateClass        string   `json:"state_class,omitempty"`
+	UnitOfMeasurement string   `json:"unit_of_measurement,omitempty"`
+	Icon              string   `json:"icon,omitempty"`
+	Min               *float64 `json:"min,omitempty"`
+	Max               *float64 `json:"max,omitempty"`
+	Step              *float64 `json:"step,omitempty"`
+	Mode              string   `json:"mode,omitempty"`
+}
+
+// Entity is a Home Assistant component that is published with MQTT discovery. Remove is true when the Garden or
+// Zone is end-dated and the entity should be removed from Home Assistant
+type Entity struct {
+	Component string
+	Config    EntityConfig
+	Remove    bool
+}
+
+// DiscoveryTopic returns the topic that the Entity's config is published on
+func (c Config) DiscoveryTopic(e Entity) string {
+	return fmt.Sprintf("%s/%s/%s/config", c.discoveryPrefix(), e.Component, e.Config.UniqueID)
+}
+
+// Entities returns all of the Home Assistant entities for a Garden and its Zones
+func (c Config) Entities(g *pkg.Garden) []Entity {
+	device := Device{
+		Identifiers:  []string{fmt.Sprintf("garden-app_%s", g.ID)},
+		Name:         g.Name,
+		Manufacturer: "automated-garden",
+		Model:        "garden-controller",
+	}
+	newEntity := func(component, objectID, name string, remove bool) Entity {
+		return Entity{
+			Component: component,
+			Remove:    remove,
+			Config: EntityConfig{
+				Name:     name,
+				UniqueID: fmt.Sprintf("%s_%s", g.ID, objectID),
+				Device:   device,
+			},
+		}
+	}
+
+	light := newEntity("light", "light", "Light", g.EndDated())
+	light.Config.CommandTopic = fmt.Sprintf("%s/set", c.LightStateTopic(g.ID))
+	light.Config.StateTopic = c.LightStateTopic(g.ID)
+	entities := []Entity{light}
+
+	// Sensors are removed if the Garden does not have them so disabling the sensor cleans up Home Assistant
+	removeSensors := g.EndDated() || !g.HasTemperatureHumiditySensor()
+	temperature := newEntity("sensor", "temperature", "Temperature", removeSensors)
+	temperature.Config.StateTopic = fmt.Sprintf("%s/data/temperature", g.TopicPrefix)
+	temperature.Config.ValueTemplate = valueTemplate
+	temperature.Config.DeviceClass = "temperature"
+	temperature.Config.StateClass = "measurement"
+	temperature.Config.UnitOfMeasurement = "°C"
+
+	humidity := newEntity("sensor", "humidity", "Humidity", removeSensors)
+	humidity.Config.StateTopic = fmt.Sprintf("%s/data/humidity", g.TopicPrefix)
+	humidity.Config.ValueTemplate = valueTemplate
+	humidity.Config.DeviceClass = "humidity"
+	humidity.Config.StateClass = "measurement"
+	humidity.Config.UnitOfMeasurement = "%"
+
+	entities = append(entities, temperature, humidity)
+
+	for _, z := range sortedZones(g) {
+		remove := g.EndDated() || z.EndDated()
+
+		water := newEntity("switch", fmt.Sprintf("zone_%s_water", z.ID), fmt.Sprintf("%s Water", z.Name), remove)
+		water.Config.CommandTopic = fmt.Sprintf("%s/set", c.WaterStateTopic(g.ID, z.ID))
+		water.Config.StateTopic = c.WaterStateTopic(g.ID, z.ID)
+		water.Config.Icon = "mdi:water"
+
+		duration := newEntity("number", fmt.Sprintf("zone_%s_duration", z.ID), fmt.Sprintf("%s Water Duration", z.Name), remove)
+		duration.Config.CommandTopic = fmt.Sprintf("%s/set", c.DurationStateTopic(g.ID, z.ID))
+		duration.Config.StateTopic = c.DurationStateTopic(g.ID, z.ID)
+		duration.Config.UnitOfMeasurement = "s"
+		duration.Config.Icon = "mdi:timer"
+		duration.Config.Min, duration.Config.Max, duration.Config.Step = float64Pointer(1), float64Pointer(3600), float64Pointer(1)
+		duration.Config.Mode = "box"
+
+		moisture := newEntity("sensor", fmt.Sprintf("zone_%s_moisture", z.ID), fmt.Sprintf("%s Moisture", z.Name), remove)
+		moisture.Config.StateTopic = fmt.Sprintf("%s/data/moisture", g.TopicPrefix)
+		// Moisture for all Zones is published on the same topic, so keep the current state for other Zones
+		moisture.Config.ValueTemplate = fmt.Sprintf(
+			"{%% if value is search('zone=%d ') %%}%s{%% else %%}{{ this.state }}{%% endif %%}",
+			*z.Position, valueTemplate,
+		)
+		moisture.Config.DeviceClass = "moisture"
+		moisture.Config.StateClass = "measurement"
+		moisture.Config.UnitOfMeasurement = "%"
+
+		entities = append(entities, water, duration, moisture)
+	}
+
+	return entities
+}
+
+// sortedZones returns the Garden's Zones sorted by position so entities are always published in the same order
+func sortedZones(g *pkg.Garden) []*pkg.Zone {
+	zones := make([]*pkg.Zone, 0, len(g.Zones))
+	for _, z := range g.Zones {
+		if z.Position != nil {
+			zones = append(zones, z)
+		}
+	}
+	sort.Slice(zones, func(i, j int) bool {
+		if *zones[i].Position != *zones[j].Position {
+			return *zones[i].Position < *zones[j].Position
+		}
+		return zones[i].ID.Compare(zones[j].ID) < 0
+	})
+	return zones
+}
+
+func float64Pointer(n float64) *float64 {
+	return &n
+}
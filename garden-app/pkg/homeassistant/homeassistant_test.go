@@ -0,0 +1,196 @@
+package homeassistant
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTopic(t *testing.T) {
+	gardenID, _ := xid.FromString("c5cvhpcbcv45e8bp16dg")
+	zoneID, _ := xid.FromString("chkodpg3lcj13q82mq40")
+
+	tests := []struct {
+		name          string
+		topic         string
+		expected      Command
+		expectedError string
+	}{
+		{
+			"Light",
+			"garden-app/c5cvhpcbcv45e8bp16dg/light/set",
+			Command{Type: LightCommand, GardenID: gardenID},
+			"",
+		},
+		{
+			"Water",
+			"garden-app/c5cvhpcbcv45e8bp16dg/zone/chkodpg3lcj13q82mq40/water/set",
+			Command{Type: WaterCommand, GardenID: gardenID, ZoneID: zoneID},
+			"",
+		},
+		{
+			"Duration",
+			"garden-app/c5cvhpcbcv45e8bp16dg/zone/chkodpg3lcj13q82mq40/duration/set",
+			Command{Type: DurationCommand, GardenID: gardenID, ZoneID: zoneID},
+			"",
+		},
+		{
+			"DurationState",
+			"garden-app/c5cvhpcbcv45e8bp16dg/zone/chkodpg3lcj13q82mq40/duration",
+			Command{Type: DurationState, GardenID: gardenID, ZoneID: zoneID},
+			"",
+		},
+		{
+			"WrongPrefix",
+			"garden/c5cvhpcbcv45e8bp16dg/light/set",
+			Command{},
+			`topic "garden/c5cvhpcbcv45e8bp16dg/light/set" does not start with prefix "garden-app/"`,
+		},
+		{
+			"InvalidGardenID",
+			"garden-app/abc/light/set",
+			Command{},
+			`invalid Garden ID "abc": xid: invalid ID`,
+		},
+		{
+			"InvalidZoneID",
+			"garden-app/c5cvhpcbcv45e8bp16dg/zone/abc/water/set",
+			Command{},
+			`invalid Zone ID "abc": xid: invalid ID`,
+		},
+		{
+			"UnexpectedTopic",
+			"garden-app/c5cvhpcbcv45e8bp16dg/zone/chkodpg3lcj13q82mq40/water",
+			Command{},
+			`unexpected topic "garden-app/c5cvhpcbcv45e8bp16dg/zone/chkodpg3lcj13q82mq40/water"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := Config{}.ParseTopic(tt.topic)
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, cmd)
+		})
+	}
+}
+
+func TestEntities(t *testing.T) {
+	gardenID, _ := xid.FromString("c5cvhpcbcv45e8bp16dg")
+	zoneID, _ := xid.FromString("chkodpg3lcj13q82mq40")
+	endDatedZoneID := xid.New()
+	position, otherPosition := uint(0), uint(1)
+	past := time.Now().Add(-1 * time.Hour)
+	sensor := true
+
+	newGarden := func() *pkg.Garden {
+		return &pkg.Garden{
+			Name:        "My Garden",
+			TopicPrefix: "garden",
+			ID:          gardenID,
+			Zones: map[xid.ID]*pkg.Zone{
+				zoneID:         {Name: "Zone", ID: zoneID, Position: &position},
+				endDatedZoneID: {Name: "Old Zone", ID: endDatedZoneID, Position: &otherPosition, EndDate: &past},
+			},
+		}
+	}
+
+	tests := []struct {
+		name           string
+		config         Config
+		garden         func() *pkg.Garden
+		expectedRemove map[string]bool
+	}{
+		{
+			"Default",
+			Config{},
+			newGarden,
+			map[string]bool{
+				"homeassistant/light/c5cvhpcbcv45e8bp16dg_light/config":                                          false,
+				"homeassistant/sensor/c5cvhpcbcv45e8bp16dg_temperature/config":                                   true,
+				"homeassistant/sensor/c5cvhpcbcv45e8bp16dg_humidity/config":                                      true,
+				"homeassistant/switch/c5cvhpcbcv45e8bp16dg_zone_chkodpg3lcj13q82mq40_water/config":               false,
+				"homeassistant/number/c5cvhpcbcv45e8bp16dg_zone_chkodpg3lcj13q82mq40_duration/config":            false,
+				"homeassistant/sensor/c5cvhpcbcv45e8bp16dg_zone_chkodpg3lcj13q82mq40_moisture/config":            false,
+				"homeassistant/switch/c5cvhpcbcv45e8bp16dg_zone_" + endDatedZoneID.String() + "_water/config":    true,
+				"homeassistant/number/c5cvhpcbcv45e8bp16dg_zone_" + endDatedZoneID.String() + "_duration/config": true,
+				"homeassistant/sensor/c5cvhpcbcv45e8bp16dg_zone_" + endDatedZoneID.String() + "_moisture/config": true,
+			},
+		},
+		{
+			"EndDatedGardenWithSensorAndCustomPrefix",
+			Config{DiscoveryPrefix: "ha"},
+			func() *pkg.Garden {
+				g := newGarden()
+				g.TemperatureHumiditySensor = &sensor
+				g.EndDate = &past
+				delete(g.Zones, endDatedZoneID)
+				return g
+			},
+			map[string]bool{
+				"ha/light/c5cvhpcbcv45e8bp16dg_light/config":                               true,
+				"ha/sensor/c5cvhpcbcv45e8bp16dg_temperature/config":                        true,
+				"ha/sensor/c5cvhpcbcv45e8bp16dg_humidity/config":                           true,
+				"ha/switch/c5cvhpcbcv45e8bp16dg_zone_chkodpg3lcj13q82mq40_water/config":    true,
+				"ha/number/c5cvhpcbcv45e8bp16dg_zone_chkodpg3lcj13q82mq40_duration/config": true,
+				"ha/sensor/c5cvhpcbcv45e8bp16dg_zone_chkodpg3lcj13q82mq40_moisture/config": true,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := map[string]bool{}
+			for _, e := range tt.config.Entities(tt.garden()) {
+				result[tt.config.DiscoveryTopic(e)] = e.Remove
+			}
+			assert.Equal(t, tt.expectedRemove, result)
+		})
+	}
+}
+
+func TestEntityConfigJSON(t *testing.T) {
+	gardenID, _ := xid.FromString("c5cvhpcbcv45e8bp16dg")
+	zoneID, _ := xid.FromString("chkodpg3lcj13q82mq40")
+	position := uint(2)
+	garden := &pkg.Garden{
+		Name:        "My Garden",
+		TopicPrefix: "garden",
+		ID:          gardenID,
+		Zones: map[xid.ID]*pkg.Zone{
+			zoneID: {Name: "Zone", ID: zoneID, Position: &position},
+		},
+	}
+
+	expected := map[string]string{
+		"light":  `{"name":"Light","unique_id":"c5cvhpcbcv45e8bp16dg_light","device":{"identifiers":["garden-app_c5cvhpcbcv45e8bp16dg"],"name":"My Garden","manufacturer":"automated-garden","model":"garden-controller"},"command_topic":"garden-app/c5cvhpcbcv45e8bp16dg/light/set","state_topic":"garden-app/c5cvhpcbcv45e8bp16dg/light"}`,
+		"number": `{"name":"Zone Water Duration","unique_id":"c5cvhpcbcv45e8bp16dg_zone_chkodpg3lcj13q82mq40_duration","device":{"identifiers":["garden-app_c5cvhpcbcv45e8bp16dg"],"name":"My Garden","manufacturer":"automated-garden","model":"garden-controller"},"command_topic":"garden-app/c5cvhpcbcv45e8bp16dg/zone/chkodpg3lcj13q82mq40/duration/set","state_topic":"garden-app/c5cvhpcbcv45e8bp16dg/zone/chkodpg3lcj13q82mq40/duration","unit_of_measurement":"s","icon":"mdi:timer","min":1,"max":3600,"step":1,"mode":"box"}`,
+	}
+
+	for _, e := range (Config{}).Entities(garden) {
+		exp, ok := expected[e.Component]
+		if !ok {
+			continue
+		}
+		t.Run(e.Component, func(t *testing.T) {
+			result, err := json.Marshal(e.Config)
+			assert.NoError(t, err)
+			assert.Equal(t, exp, string(result))
+		})
+	}
+
+	for _, e := range (Config{}).Entities(garden) {
+		if e.Config.DeviceClass == "moisture" {
+			assert.Equal(t, "{% if value is search('zone=2 ') %}{{ value | regex_findall_index('value=([0-9.-]+)') | float }}{% else %}{{ this.state }}{% endif %}", e.Config.ValueTemplate)
+		}
+	}
+}
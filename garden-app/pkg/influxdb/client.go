@@ -3,6 +3,9 @@ package influxdb
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"sync"
 	"text/template"
 	"time"
 
@@ -12,14 +15,16 @@ import (
 
 const (
 	// QueryTimeout is the default time to use for a query's context timeout
-	QueryTimeout          = time.Millisecond * 1000
+	QueryTimeout = time.Millisecond * 1000
+	// DefaultWindow is the time range used for sensor data queries when no window is provided
+	DefaultWindow         = time.Minute * 15
 	moistureQueryTemplate = `from(bucket: "{{.Bucket}}")
 |> range(start: -{{.Start}})
 |> filter(fn: (r) => r["_measurement"] == "moisture")
 |> filter(fn: (r) => r["_field"] == "value")
 |> filter(fn: (r) => r["zone"] == "{{.ZonePosition}}")
 |> filter(fn: (r) => r["topic"] == "{{.TopicPrefix}}/data/moisture")
-|> mean()`
+|> {{.Aggregation}}()`
 	healthQueryTemplate = `from(bucket: "{{.Bucket}}")
 |> range(start: -{{.Start}})
 |> filter(fn: (r) => r["_measurement"] == "health")
@@ -41,21 +46,69 @@ const (
 |> filter(fn: (r) => r["_measurement"] == "temperature" or r["_measurement"] == "humidity")
 |> filter(fn: (r) => r["_field"] == "value")
 |> filter(fn: (r) => r["topic"] == "{{.TopicPrefix}}/data/temperature" or r["topic"] == "{{.TopicPrefix}}/data/humidity")
-|> mean()`
+|> {{.Aggregation}}()`
+	measurementQueryTemplate = `from(bucket: "{{.Bucket}}")
+|> range(start: -{{.Start}}{{ if .ExcludeToday }}, stop: today(){{ end }})
+|> filter(fn: (r) => r["_measurement"] == "{{.Measurement}}")
+|> filter(fn: (r) => r["_field"] == "{{.Field}}")
+{{- range $key, $value := .Tags }}
+|> filter(fn: (r) => r["{{$key}}"] == "{{$value}}")
+{{- end }}
+{{- if .DailyAggregation }}
+|> aggregateWindow(every: 1d, fn: {{.DailyAggregation}}, createEmpty: false)
+{{- end }}
+|> group()
+|> {{.Aggregation}}()`
+)
+
+// ErrNoData is returned by GetMeasurement when there are no values in the query's time range
+var ErrNoData = errors.New("no data found")
+
+// Aggregation is the name of a Flux function used to reduce sensor data in a time window to a single value
+type Aggregation string
+
+const (
+	// AggregationMean uses the average of all values in the window
+	AggregationMean Aggregation = "mean"
+	// AggregationMin uses the lowest value in the window
+	AggregationMin Aggregation = "min"
+	// AggregationMedian uses the median of all values in the window
+	AggregationMedian Aggregation = "median"
+	// AggregationLast uses the most recent value in the window
+	AggregationLast Aggregation = "last"
+	// AggregationMax uses the highest value in the window. It is only used by GetMeasurement
+	AggregationMax Aggregation = "max"
+	// AggregationSum uses the total of all values in the window. It is only used by GetMeasurement
+	AggregationSum Aggregation = "sum"
 )
 
-var influxDBClientSummary = prometheus.NewSummaryVec(prometheus.SummaryOpts{
-	Namespace: "garden_app",
-	Name:      "influxdb_client_duration_seconds",
-	Help:      "summary of influxdb client calls",
-}, []string{"function"})
+// Validate returns an error if the Aggregation is not one of the supported values. An empty Aggregation is valid
+// and results in AggregationMean
+func (a Aggregation) Validate() error {
+	switch a {
+	case "", AggregationMean, AggregationMin, AggregationMedian, AggregationLast:
+		return nil
+	default:
+		return fmt.Errorf("invalid aggregation %q, must be one of: mean, min, median, last", string(a))
+	}
+}
+
+var (
+	influxDBClientSummary = prometheus.NewSummaryVec(prometheus.SummaryOpts{
+		Namespace: "garden_app",
+		Name:      "influxdb_client_duration_seconds",
+		Help:      "summary of influxdb client calls",
+	}, []string{"function"})
+	registerMetrics sync.Once
+)
 
 // Client is an interface that allows querying InfluxDB for data
 type Client interface {
-	GetMoisture(context.Context, uint, string) (float64, error)
-	GetLastContact(context.Context, string) (time.Time, error)
+	GetMoisture(context.Context, uint, string, time.Duration, Aggregation) (float64, error)
+	GetLastContact(context.Context, string, time.Duration) (time.Time, error)
 	GetWaterHistory(context.Context, uint, string, time.Duration, uint64) ([]map[string]interface{}, error)
-	GetTemperatureAndHumidity(context.Context, string) (float64, float64, error)
+	GetTemperatureAndHumidity(context.Context, string, time.Duration, Aggregation) (float64, float64, error)
+	GetMeasurement(context.Context, MeasurementQuery) (float64, error)
 	influxdb2.Client
 }
 
@@ -67,13 +120,47 @@ type Config struct {
 	Bucket  string `mapstructure:"bucket"`
 }
 
+// MeasurementQuery is used to get a single value from any measurement and field, such as data written by Telegraf
+// instead of a Garden controller
+type MeasurementQuery struct {
+	Measurement string
+	Field       string
+	// Tags are used to filter the data to specific series. All tags must match
+	Tags map[string]string
+	// Window is the time range to query. If it is not provided, the last 15 minutes are used
+	Window time.Duration
+	// ExcludeToday ends the time range at the start of the current day (UTC) instead of now
+	ExcludeToday bool
+	// DailyAggregation reduces each day's values to a single value before Aggregation is applied
+	DailyAggregation Aggregation
+	// Aggregation reduces all values in the time range to a single value. Defaults to AggregationMean
+	Aggregation Aggregation
+}
+
 // queryData is used to fill out any of the query templates
 type queryData struct {
-	Bucket       string
-	Start        time.Duration
-	ZonePosition uint
-	TopicPrefix  string
-	Limit        uint64
+	Bucket           string
+	Start            time.Duration
+	ZonePosition     uint
+	TopicPrefix      string
+	Limit            uint64
+	Aggregation      Aggregation
+	Measurement      string
+	Field            string
+	Tags             map[string]string
+	ExcludeToday     bool
+	DailyAggregation Aggregation
+}
+
+// withDefaults sets the default Start and Aggregation if they are not already set
+func (q queryData) withDefaults() queryData {
+	if q.Start == 0 {
+		q.Start = DefaultWindow
+	}
+	if q.Aggregation == "" {
+		q.Aggregation = AggregationMean
+	}
+	return q
 }
 
 // Render executes the specified template with the queryData to create a string
@@ -95,25 +182,29 @@ type client struct {
 
 // NewClient creates an InfluxDB client from the viper config
 func NewClient(config Config) Client {
-	prometheus.MustRegister(influxDBClientSummary)
+	registerMetrics.Do(func() {
+		prometheus.MustRegister(influxDBClientSummary)
+	})
 	return &client{
 		influxdb2.NewClient(config.Address, config.Token),
 		config,
 	}
 }
 
-// GetMoisture returns the plant's average soil moisture in the last 15 minutes
-func (client *client) GetMoisture(ctx context.Context, zonePosition uint, topicPrefix string) (float64, error) {
+// GetMoisture returns the Zone's soil moisture in the window, reduced using the aggregation. If these are not provided,
+// the average in the last 15 minutes is used
+func (client *client) GetMoisture(ctx context.Context, zonePosition uint, topicPrefix string, window time.Duration, aggregation Aggregation) (float64, error) {
 	timer := prometheus.NewTimer(influxDBClientSummary.WithLabelValues("GetMoisture"))
 	defer timer.ObserveDuration()
 
 	// Prepare query
 	queryString, err := queryData{
 		Bucket:       client.config.Bucket,
-		Start:        time.Minute * 15,
+		Start:        window,
 		ZonePosition: zonePosition,
 		TopicPrefix:  topicPrefix,
-	}.Render(moistureQueryTemplate)
+		Aggregation:  aggregation,
+	}.withDefaults().Render(moistureQueryTemplate)
 	if err != nil {
 		return 0, err
 	}
@@ -134,16 +225,18 @@ func (client *client) GetMoisture(ctx context.Context, zonePosition uint, topicP
 	return result, queryResult.Err()
 }
 
-func (client *client) GetLastContact(ctx context.Context, topicPrefix string) (time.Time, error) {
+// GetLastContact returns the time of the Garden's most recent health message in the window. If window is not provided,
+// the last 15 minutes are used
+func (client *client) GetLastContact(ctx context.Context, topicPrefix string, window time.Duration) (time.Time, error) {
 	timer := prometheus.NewTimer(influxDBClientSummary.WithLabelValues("GetLastContact"))
 	defer timer.ObserveDuration()
 
 	// Prepare query
 	queryString, err := queryData{
 		Bucket:      client.config.Bucket,
-		Start:       time.Minute * 15,
+		Start:       window,
 		TopicPrefix: topicPrefix,
-	}.Render(healthQueryTemplate)
+	}.withDefaults().Render(healthQueryTemplate)
 	if err != nil {
 		return time.Time{}, err
 	}
@@ -200,16 +293,18 @@ func (client *client) GetWaterHistory(ctx context.Context, zonePosition uint, to
 	return result, queryResult.Err()
 }
 
-// GetTemperatureAndHumidity gets the recent temperature and humidity data for a Garden
-func (client *client) GetTemperatureAndHumidity(ctx context.Context, topicPrefix string) (float64, float64, error) {
-	timer := prometheus.NewTimer(influxDBClientSummary.WithLabelValues("GetMoisture"))
+// GetTemperatureAndHumidity gets the recent temperature and humidity data for a Garden in the window, reduced using
+// the aggregation. If these are not provided, the average in the last 15 minutes is used
+func (client *client) GetTemperatureAndHumidity(ctx context.Context, topicPrefix string, window time.Duration, aggregation Aggregation) (float64, float64, error) {
+	timer := prometheus.NewTimer(influxDBClientSummary.WithLabelValues("GetTemperatureAndHumidity"))
 	defer timer.ObserveDuration()
 
 	queryString, err := queryData{
 		Bucket:      client.config.Bucket,
-		Start:       time.Minute * 15,
+		Start:       window,
 		TopicPrefix: topicPrefix,
-	}.Render(temperatureAndHumidityQueryTemplate)
+		Aggregation: aggregation,
+	}.withDefaults().Render(temperatureAndHumidityQueryTemplate)
 	if err != nil {
 		return 0, 0, err
 	}
@@ -233,3 +328,49 @@ func (client *client) GetTemperatureAndHumidity(ctx context.Context, topicPrefix
 
 	return temperature, humidity, queryResult.Err()
 }
+
+// GetMeasurement queries any measurement and field, filtered by tags, and reduces the values to a single value. If there
+// are no values in the time range, ErrNoData is returned
+func (client *client) GetMeasurement(ctx context.Context, query MeasurementQuery) (float64, error) {
+	timer := prometheus.NewTimer(influxDBClientSummary.WithLabelValues("GetMeasurement"))
+	defer timer.ObserveDuration()
+
+	queryString, err := queryData{
+		Bucket:           client.config.Bucket,
+		Start:            query.Window,
+		Aggregation:      query.Aggregation,
+		Measurement:      query.Measurement,
+		Field:            query.Field,
+		Tags:             query.Tags,
+		ExcludeToday:     query.ExcludeToday,
+		DailyAggregation: query.DailyAggregation,
+	}.withDefaults().Render(measurementQueryTemplate)
+	if err != nil {
+		return 0, err
+	}
+
+	queryAPI := client.QueryAPI(client.config.Org)
+	queryResult, err := queryAPI.Query(ctx, queryString)
+	if err != nil {
+		return 0, err
+	}
+
+	if !queryResult.Next() {
+		if queryResult.Err() != nil {
+			return 0, queryResult.Err()
+		}
+		return 0, ErrNoData
+	}
+
+	// Fields written by Telegraf are not always floats
+	switch value := queryResult.Record().Value().(type) {
+	case float64:
+		return value, nil
+	case int64:
+		return float64(value), nil
+	case uint64:
+		return float64(value), nil
+	default:
+		return 0, fmt.Errorf("unexpected value type %T for field %q", value, query.Field)
+	}
+}
@@ -0,0 +1,61 @@
+package influxdb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderMeasurementQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     queryData
+		expected string
+	}{
+		{
+			"Defaults",
+			queryData{
+				Bucket:      "telegraf",
+				Measurement: "outdoor",
+				Field:       "temperature",
+			},
+			`from(bucket: "telegraf")
+|> range(start: -15m0s)
+|> filter(fn: (r) => r["_measurement"] == "outdoor")
+|> filter(fn: (r) => r["_field"] == "temperature")
+|> group()
+|> mean()`,
+		},
+		{
+			"TagsAndDailyAggregation",
+			queryData{
+				Bucket:           "telegraf",
+				Start:            72 * time.Hour,
+				Measurement:      "outdoor",
+				Field:            "temperature",
+				Tags:             map[string]string{"station": "backyard", "host": "pi"},
+				ExcludeToday:     true,
+				DailyAggregation: AggregationMax,
+				Aggregation:      AggregationMean,
+			},
+			`from(bucket: "telegraf")
+|> range(start: -72h0m0s, stop: today())
+|> filter(fn: (r) => r["_measurement"] == "outdoor")
+|> filter(fn: (r) => r["_field"] == "temperature")
+|> filter(fn: (r) => r["host"] == "pi")
+|> filter(fn: (r) => r["station"] == "backyard")
+|> aggregateWindow(every: 1d, fn: max, createEmpty: false)
+|> group()
+|> mean()`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := tt.data.withDefaults().Render(measurementQueryTemplate)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
@@ -92,22 +92,46 @@ func (_m *MockClient) DeleteAPI() api.DeleteAPI {
 	return r0
 }
 
-// GetLastContact provides a mock function with given fields: _a0, _a1
-func (_m *MockClient) GetLastContact(_a0 context.Context, _a1 string) (time.Time, error) {
-	ret := _m.Called(_a0, _a1)
+// GetLastContact provides a mock function with given fields: _a0, _a1, _a2
+func (_m *MockClient) GetLastContact(_a0 context.Context, _a1 string, _a2 time.Duration) (time.Time, error) {
+	ret := _m.Called(_a0, _a1, _a2)
 
 	var r0 time.Time
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (time.Time, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration) (time.Time, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration) time.Time); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(time.Time)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Duration) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMeasurement provides a mock function with given fields: _a0, _a1
+func (_m *MockClient) GetMeasurement(_a0 context.Context, _a1 MeasurementQuery) (float64, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 float64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, MeasurementQuery) (float64, error)); ok {
 		return rf(_a0, _a1)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) time.Time); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, MeasurementQuery) float64); ok {
 		r0 = rf(_a0, _a1)
 	} else {
-		r0 = ret.Get(0).(time.Time)
+		r0 = ret.Get(0).(float64)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, MeasurementQuery) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -116,23 +140,23 @@ func (_m *MockClient) GetLastContact(_a0 context.Context, _a1 string) (time.Time
 	return r0, r1
 }
 
-// GetMoisture provides a mock function with given fields: _a0, _a1, _a2
-func (_m *MockClient) GetMoisture(_a0 context.Context, _a1 uint, _a2 string) (float64, error) {
-	ret := _m.Called(_a0, _a1, _a2)
+// GetMoisture provides a mock function with given fields: _a0, _a1, _a2, _a3, _a4
+func (_m *MockClient) GetMoisture(_a0 context.Context, _a1 uint, _a2 string, _a3 time.Duration, _a4 Aggregation) (float64, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3, _a4)
 
 	var r0 float64
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, uint, string) (float64, error)); ok {
-		return rf(_a0, _a1, _a2)
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string, time.Duration, Aggregation) (float64, error)); ok {
+		return rf(_a0, _a1, _a2, _a3, _a4)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, uint, string) float64); ok {
-		r0 = rf(_a0, _a1, _a2)
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string, time.Duration, Aggregation) float64); ok {
+		r0 = rf(_a0, _a1, _a2, _a3, _a4)
 	} else {
 		r0 = ret.Get(0).(float64)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, uint, string) error); ok {
-		r1 = rf(_a0, _a1, _a2)
+	if rf, ok := ret.Get(1).(func(context.Context, uint, string, time.Duration, Aggregation) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3, _a4)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -140,30 +164,30 @@ func (_m *MockClient) GetMoisture(_a0 context.Context, _a1 uint, _a2 string) (fl
 	return r0, r1
 }
 
-// GetTemperatureAndHumidity provides a mock function with given fields: _a0, _a1
-func (_m *MockClient) GetTemperatureAndHumidity(_a0 context.Context, _a1 string) (float64, float64, error) {
-	ret := _m.Called(_a0, _a1)
+// GetTemperatureAndHumidity provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *MockClient) GetTemperatureAndHumidity(_a0 context.Context, _a1 string, _a2 time.Duration, _a3 Aggregation) (float64, float64, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
 
 	var r0 float64
 	var r1 float64
 	var r2 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (float64, float64, error)); ok {
-		return rf(_a0, _a1)
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration, Aggregation) (float64, float64, error)); ok {
+		return rf(_a0, _a1, _a2, _a3)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) float64); ok {
-		r0 = rf(_a0, _a1)
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration, Aggregation) float64); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
 	} else {
 		r0 = ret.Get(0).(float64)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string) float64); ok {
-		r1 = rf(_a0, _a1)
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Duration, Aggregation) float64); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
 	} else {
 		r1 = ret.Get(1).(float64)
 	}
 
-	if rf, ok := ret.Get(2).(func(context.Context, string) error); ok {
-		r2 = rf(_a0, _a1)
+	if rf, ok := ret.Get(2).(func(context.Context, string, time.Duration, Aggregation) error); ok {
+		r2 = rf(_a0, _a1, _a2, _a3)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -0,0 +1,208 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/mqtt"
+	paho "github.com/eclipse/paho.mqtt.golang"
+	protocol "github.com/influxdata/line-protocol"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// TopicTag is added to every ingested Point to match the tag added by Telegraf's mqtt_consumer, which is used
+	// when querying data for a Garden
+	TopicTag = "topic"
+	// writeTimeout is the maximum time allowed for writing a message's Points to the Sink
+	writeTimeout = 5 * time.Second
+	// writeBufferSize is how many messages can be waiting to be written before new messages are dropped
+	writeBufferSize = 1000
+)
+
+// DefaultTopics are the data topics published by garden-controllers for all Gardens
+var DefaultTopics = []string{
+	"+/data/water",
+	"+/data/moisture",
+	"+/data/health",
+	"+/data/temperature",
+	"+/data/humidity",
+	"+/data/light",
+	"+/data/logs",
+}
+
+var (
+	ingestedMessages = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "garden_app",
+		Name:      "ingested_messages_total",
+		Help:      "count of MQTT data messages ingested, labeled by result",
+	}, []string{"result"})
+	registerMetrics sync.Once
+)
+
+// Config is used to enable ingesting data from garden-controllers directly from MQTT instead of using Telegraf.
+// Topics defaults to DefaultTopics and Sink is "influxdb" (default) or "log"
+type Config struct {
+	Enabled bool     `mapstructure:"enabled"`
+	Topics  []string `mapstructure:"topics"`
+	Sink    string   `mapstructure:"sink"`
+}
+
+// Point is a single parsed line of data
+type Point struct {
+	Measurement string
+	Tags        map[string]string
+	Fields      map[string]interface{}
+	Time        time.Time
+}
+
+// Sink is used to store ingested Points
+type Sink interface {
+	Write(context.Context, []Point) error
+}
+
+// Ingester parses data messages from MQTT and writes them to a Sink. Points are written in the background so a slow
+// Sink does not hold up the MQTT client's other subscriptions
+type Ingester struct {
+	sink   Sink
+	logger *logrus.Entry
+
+	// mu protects stopped, which is set when points is closed so no more messages are sent to it
+	mu      sync.RWMutex
+	stopped bool
+	points  chan []Point
+	done    chan struct{}
+}
+
+// NewIngester creates an Ingester that writes to the Sink. Stop must be used to write the remaining Points
+func NewIngester(sink Sink, logger *logrus.Logger) *Ingester {
+	return newIngester(sink, logger, writeBufferSize)
+}
+
+func newIngester(sink Sink, logger *logrus.Logger, bufferSize int) *Ingester {
+	registerMetrics.Do(func() {
+		prometheus.MustRegister(ingestedMessages)
+	})
+	i := &Ingester{
+		sink:   sink,
+		logger: logger.WithField("component", "ingest"),
+		points: make(chan []Point, bufferSize),
+		done:   make(chan struct{}),
+	}
+	go i.writePoints()
+	return i
+}
+
+// Stop stops accepting messages and waits for the Points that are already received to be written
+func (i *Ingester) Stop() {
+	i.mu.Lock()
+	if !i.stopped {
+		i.stopped = true
+		close(i.points)
+	}
+	i.mu.Unlock()
+
+	<-i.done
+}
+
+// TopicHandlers creates handlers for subscribing to the configured topics
+func (i *Ingester) TopicHandlers(config Config) []mqtt.TopicHandler {
+	topics := config.Topics
+	if len(topics) == 0 {
+		topics = DefaultTopics
+	}
+
+	handlers := []mqtt.TopicHandler{}
+	for _, topic := range topics {
+		handlers = append(handlers, mqtt.TopicHandler{Topic: topic, Handler: i.handleMessage})
+	}
+	return handlers
+}
+
+// handleMessage parses a message and queues it to be written. Errors are logged since there is no way to return them
+// to the publisher. Messages are dropped if too many are waiting to be written
+func (i *Ingester) handleMessage(_ paho.Client, msg paho.Message) {
+	logger := i.logger.WithField("topic", msg.Topic())
+
+	points, err := ParseLineProtocol(msg.Topic(), msg.Payload(), time.Now())
+	if err != nil {
+		ingestedMessages.WithLabelValues("parse_error").Inc()
+		logger.WithError(err).WithField("message", string(msg.Payload())).Warn("unable to parse data message")
+		return
+	}
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	if i.stopped {
+		ingestedMessages.WithLabelValues("dropped").Inc()
+		logger.Warn("dropping data message because ingestion is stopped")
+		return
+	}
+	select {
+	case i.points <- points:
+	default:
+		ingestedMessages.WithLabelValues("dropped").Inc()
+		logger.Warn("dropping data message because too many are waiting to be written")
+	}
+}
+
+// writePoints writes queued Points to the Sink until the Ingester is stopped
+func (i *Ingester) writePoints() {
+	defer close(i.done)
+	for points := range i.points {
+		i.write(points)
+	}
+}
+
+func (i *Ingester) write(points []Point) {
+	logger := i.logger.WithField("topic", points[0].Tags[TopicTag])
+
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+
+	err := i.sink.Write(ctx, points)
+	if err != nil {
+		ingestedMessages.WithLabelValues("write_error").Inc()
+		logger.WithError(err).Error("unable to write data to sink")
+		return
+	}
+
+	ingestedMessages.WithLabelValues("success").Inc()
+	logger.WithField("points", len(points)).Debug("ingested data message")
+}
+
+// ParseLineProtocol parses InfluxDB line protocol into Points. The topic is added as a tag and lines without a
+// timestamp use the provided time
+func ParseLineProtocol(topic string, payload []byte, now time.Time) ([]Point, error) {
+	handler := protocol.NewMetricHandler()
+	handler.SetTimeFunc(func() time.Time { return now })
+
+	metrics, err := protocol.NewParser(handler).Parse(payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid line protocol: %w", err)
+	}
+	if len(metrics) == 0 {
+		return nil, fmt.Errorf("invalid line protocol: no data")
+	}
+
+	points := make([]Point, 0, len(metrics))
+	for _, m := range metrics {
+		point := Point{
+			Measurement: m.Name(),
+			Tags:        map[string]string{TopicTag: topic},
+			Fields:      map[string]interface{}{},
+			Time:        m.Time(),
+		}
+		for _, tag := range m.TagList() {
+			point.Tags[tag.Key] = tag.Value
+		}
+		for _, field := range m.FieldList() {
+			point.Fields[field.Key] = field.Value
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
@@ -0,0 +1,213 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/influxdb"
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// testMessage implements paho.Message for testing handlers
+type testMessage struct {
+	paho.Message
+	topic   string
+	payload []byte
+}
+
+func (m testMessage) Topic() string   { return m.topic }
+func (m testMessage) Payload() []byte { return m.payload }
+
+func TestParseLineProtocol(t *testing.T) {
+	now := time.Date(2023, time.August, 23, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		topic         string
+		payload       string
+		expected      []Point
+		expectedError string
+	}{
+		{
+			"WaterEvent",
+			"garden/data/water",
+			"water,zone=1 millis=5000",
+			[]Point{{
+				Measurement: "water",
+				Tags:        map[string]string{"topic": "garden/data/water", "zone": "1"},
+				Fields:      map[string]interface{}{"millis": float64(5000)},
+				Time:        now,
+			}},
+			"",
+		},
+		{
+			"HealthWithTimestamp",
+			"garden/data/health",
+			`health garden="garden" 1692784800000000000`,
+			[]Point{{
+				Measurement: "health",
+				Tags:        map[string]string{"topic": "garden/data/health"},
+				Fields:      map[string]interface{}{"garden": "garden"},
+				Time:        time.Unix(0, 1692784800000000000),
+			}},
+			"",
+		},
+		{
+			"MultipleLines",
+			"garden/data/moisture",
+			"moisture,zone=0 value=45.5\nmoisture,zone=1 value=50i",
+			[]Point{
+				{
+					Measurement: "moisture",
+					Tags:        map[string]string{"topic": "garden/data/moisture", "zone": "0"},
+					Fields:      map[string]interface{}{"value": 45.5},
+					Time:        now,
+				},
+				{
+					Measurement: "moisture",
+					Tags:        map[string]string{"topic": "garden/data/moisture", "zone": "1"},
+					Fields:      map[string]interface{}{"value": int64(50)},
+					Time:        now,
+				},
+			},
+			"",
+		},
+		{
+			"InvalidLineProtocol",
+			"garden/data/logs",
+			"not line protocol",
+			nil,
+			`invalid line protocol: metric parse error: expected field at 1:9: "not line protocol"`,
+		},
+		{
+			"Empty",
+			"garden/data/logs",
+			"",
+			nil,
+			"invalid line protocol: no data",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points, err := ParseLineProtocol(tt.topic, []byte(tt.payload), now)
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, points)
+		})
+	}
+}
+
+func TestHandleMessage(t *testing.T) {
+	tests := []struct {
+		name      string
+		payload   string
+		setupMock func(*MockSink)
+	}{
+		{
+			"Successful",
+			"light,state=ON value=1",
+			func(sink *MockSink) {
+				sink.On("Write", mock.Anything, mock.MatchedBy(func(points []Point) bool {
+					return len(points) == 1 && points[0].Measurement == "light" && points[0].Tags["topic"] == "garden/data/light"
+				})).Return(nil)
+			},
+		},
+		{
+			"WriteError",
+			"light,state=ON value=1",
+			func(sink *MockSink) {
+				sink.On("Write", mock.Anything, mock.Anything).Return(errors.New("write error"))
+			},
+		},
+		{
+			"ParseErrorDoesNotWrite",
+			"invalid",
+			func(sink *MockSink) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := NewMockSink(t)
+			tt.setupMock(sink)
+
+			ingester := NewIngester(sink, logrus.New())
+			ingester.handleMessage(nil, testMessage{topic: "garden/data/light", payload: []byte(tt.payload)})
+			// Stop waits for the message to be written
+			ingester.Stop()
+		})
+	}
+}
+
+func TestHandleMessageDoesNotWaitForSink(t *testing.T) {
+	writing := make(chan struct{})
+	unblock := make(chan struct{})
+	sink := NewMockSink(t)
+	sink.On("Write", mock.Anything, mock.Anything).Return(nil).Once().Run(func(mock.Arguments) {
+		close(writing)
+		<-unblock
+	})
+	sink.On("Write", mock.Anything, mock.Anything).Return(nil).Once()
+
+	ingester := newIngester(sink, logrus.New(), 1)
+	message := testMessage{topic: "garden/data/light", payload: []byte("light,state=ON value=1")}
+
+	// The first message is being written, so the second is buffered and the third is dropped instead of waiting
+	ingester.handleMessage(nil, message)
+	<-writing
+	handled := make(chan struct{})
+	go func() {
+		ingester.handleMessage(nil, message)
+		ingester.handleMessage(nil, message)
+		close(handled)
+	}()
+	select {
+	case <-handled:
+	case <-time.After(time.Second):
+		t.Error("timed out waiting for messages to be handled")
+	}
+
+	close(unblock)
+	ingester.Stop()
+
+	// Messages are dropped after stopping
+	ingester.handleMessage(nil, message)
+}
+
+func TestTopicHandlers(t *testing.T) {
+	ingester := NewIngester(NewMockSink(t), logrus.New())
+	defer ingester.Stop()
+
+	handlers := ingester.TopicHandlers(Config{})
+	assert.Len(t, handlers, len(DefaultTopics))
+	assert.Equal(t, "+/data/water", handlers[0].Topic)
+
+	handlers = ingester.TopicHandlers(Config{Topics: []string{"garden/data/#"}})
+	assert.Len(t, handlers, 1)
+	assert.Equal(t, "garden/data/#", handlers[0].Topic)
+}
+
+func TestNewSink(t *testing.T) {
+	sink, err := NewSink(Config{}, nil, influxdb.Config{}, logrus.New())
+	assert.NoError(t, err)
+	assert.IsType(t, &InfluxDBSink{}, sink)
+
+	sink, err = NewSink(Config{Sink: "log"}, nil, influxdb.Config{}, logrus.New())
+	assert.NoError(t, err)
+	assert.IsType(t, &LogSink{}, sink)
+	assert.NoError(t, sink.Write(context.Background(), []Point{{Measurement: "water"}}))
+
+	_, err = NewSink(Config{Sink: "file"}, nil, influxdb.Config{}, logrus.New())
+	assert.Error(t, err)
+	assert.Equal(t, `invalid ingest sink: "file"`, err.Error())
+}
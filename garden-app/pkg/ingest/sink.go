@@ -0,0 +1,62 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/influxdb"
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+	"github.com/influxdata/influxdb-client-go/v2/api/write"
+	"github.com/sirupsen/logrus"
+)
+
+// NewSink creates the Sink for the configured driver
+func NewSink(config Config, influxdbClient influxdb2.Client, influxdbConfig influxdb.Config, logger *logrus.Logger) (Sink, error) {
+	switch config.Sink {
+	case "", "influxdb":
+		return &InfluxDBSink{influxdbClient, influxdbConfig.Org, influxdbConfig.Bucket}, nil
+	case "log":
+		return &LogSink{logger.WithField("component", "ingest_sink")}, nil
+	default:
+		return nil, fmt.Errorf("invalid ingest sink: %q", config.Sink)
+	}
+}
+
+// InfluxDBSink writes Points to the InfluxDB bucket that is used for querying sensor data
+type InfluxDBSink struct {
+	client influxdb2.Client
+	org    string
+	bucket string
+}
+
+// Write uses the blocking write API so errors can be logged by the Ingester
+func (s *InfluxDBSink) Write(ctx context.Context, points []Point) error {
+	writePoints := make([]*write.Point, 0, len(points))
+	for _, p := range points {
+		writePoints = append(writePoints, write.NewPoint(p.Measurement, p.Tags, p.Fields, p.Time))
+	}
+
+	err := s.client.WriteAPIBlocking(s.org, s.bucket).WritePoint(ctx, writePoints...)
+	if err != nil {
+		return fmt.Errorf("error writing to InfluxDB: %w", err)
+	}
+	return nil
+}
+
+// LogSink logs Points instead of storing them. It is useful for debugging a controller's data
+type LogSink struct {
+	logger *logrus.Entry
+}
+
+// Write logs each Point
+func (s *LogSink) Write(_ context.Context, points []Point) error {
+	for _, p := range points {
+		s.logger.WithFields(logrus.Fields{
+			"measurement": p.Measurement,
+			"tags":        p.Tags,
+			"fields":      p.Fields,
+			"timestamp":   p.Time,
+		}).Info("ingested point")
+	}
+	return nil
+}
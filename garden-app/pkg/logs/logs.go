@@ -0,0 +1,144 @@
+package logs
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/ingest"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultMaxEntries is the number of log entries kept for each Garden
+	DefaultMaxEntries = 500
+	// DefaultRetention is how long log entries are kept
+	DefaultRetention = 7 * 24 * time.Hour
+	// DefaultLevel is used when a garden-controller does not include a level with its log message
+	DefaultLevel = "info"
+
+	measurement  = "logs"
+	messageField = "message"
+	levelKey     = "level"
+)
+
+// Config enables storing log messages published by garden-controllers on the "data/logs" topic. Each Garden keeps
+// up to MaxEntries that are newer than Retention
+type Config struct {
+	Enabled    bool          `mapstructure:"enabled"`
+	MaxEntries int           `mapstructure:"max_entries"`
+	Retention  time.Duration `mapstructure:"retention"`
+}
+
+func (c Config) maxEntries() int {
+	if c.MaxEntries <= 0 {
+		return DefaultMaxEntries
+	}
+	return c.MaxEntries
+}
+
+func (c Config) retention() time.Duration {
+	if c.Retention <= 0 {
+		return DefaultRetention
+	}
+	return c.Retention
+}
+
+// Entry is a single log message from a garden-controller
+type Entry struct {
+	Time    time.Time `json:"time"`
+	Level   string    `json:"level"`
+	Message string    `json:"message"`
+}
+
+// ParseEntry parses a log message in line protocol, like `logs message="garden-controller setup complete"`. The level
+// is read from an optional "level" tag or field
+func ParseEntry(payload []byte, now time.Time) (Entry, error) {
+	points, err := ingest.ParseLineProtocol("", payload, now)
+	if err != nil {
+		return Entry{}, err
+	}
+	point := points[0]
+	if point.Measurement != measurement {
+		return Entry{}, fmt.Errorf("unexpected measurement %q", point.Measurement)
+	}
+
+	message, ok := point.Fields[messageField].(string)
+	if !ok {
+		return Entry{}, fmt.Errorf("missing or invalid field %q", messageField)
+	}
+
+	level := DefaultLevel
+	if l, ok := point.Fields[levelKey].(string); ok {
+		level = l
+	}
+	if l, ok := point.Tags[levelKey]; ok {
+		level = l
+	}
+	if _, err := logrus.ParseLevel(level); err != nil {
+		return Entry{}, fmt.Errorf("invalid level %q", level)
+	}
+
+	return Entry{Time: point.Time, Level: level, Message: message}, nil
+}
+
+// Append adds an Entry to the end of the entries and removes the oldest entries to stay within the configured
+// maximum and retention
+func (c Config) Append(entries []Entry, entry Entry, now time.Time) []Entry {
+	return c.Trim(append(entries, entry), now)
+}
+
+// Trim removes the oldest entries to stay within the configured maximum and retention
+func (c Config) Trim(entries []Entry, now time.Time) []Entry {
+	cutoff := now.Add(-c.retention())
+	start := 0
+	for start < len(entries) && entries[start].Time.Before(cutoff) {
+		start++
+	}
+	if len(entries)-start > c.maxEntries() {
+		start = len(entries) - c.maxEntries()
+	}
+	return entries[start:]
+}
+
+// Filter selects log entries at or above a level and after a time
+type Filter struct {
+	Level logrus.Level
+	Since time.Time
+}
+
+// NewFilter creates a Filter from a minimum level and a time range ending now. An empty level includes all levels and
+// a zero time range includes all times
+func NewFilter(level string, timeRange time.Duration, now time.Time) (Filter, error) {
+	filter := Filter{Level: logrus.TraceLevel}
+	if level != "" {
+		var err error
+		filter.Level, err = logrus.ParseLevel(level)
+		if err != nil {
+			return Filter{}, err
+		}
+	}
+	if timeRange > 0 {
+		filter.Since = now.Add(-timeRange)
+	}
+	return filter, nil
+}
+
+// Match returns true if the Entry is selected by the Filter
+func (f Filter) Match(entry Entry) bool {
+	level, err := logrus.ParseLevel(entry.Level)
+	if err != nil || level > f.Level {
+		return false
+	}
+	return !entry.Time.Before(f.Since)
+}
+
+// Apply returns the entries that are selected by the Filter
+func (f Filter) Apply(entries []Entry) []Entry {
+	result := []Entry{}
+	for _, entry := range entries {
+		if f.Match(entry) {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
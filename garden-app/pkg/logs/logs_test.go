@@ -0,0 +1,160 @@
+package logs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEntry(t *testing.T) {
+	now := time.Date(2023, time.August, 23, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		payload       string
+		expected      Entry
+		expectedError string
+	}{
+		{
+			"DefaultLevel",
+			`logs message="garden-controller setup complete"`,
+			Entry{Time: now, Level: "info", Message: "garden-controller setup complete"},
+			"",
+		},
+		{
+			"LevelTag",
+			`logs,level=error message="unable to read sensor"`,
+			Entry{Time: now, Level: "error", Message: "unable to read sensor"},
+			"",
+		},
+		{
+			"LevelField",
+			`logs message="low memory",level="warn"`,
+			Entry{Time: now, Level: "warn", Message: "low memory"},
+			"",
+		},
+		{
+			"InvalidLevel",
+			`logs,level=loud message="hello"`,
+			Entry{},
+			`invalid level "loud"`,
+		},
+		{
+			"WrongMeasurement",
+			`water,zone=1 millis=1000`,
+			Entry{},
+			`unexpected measurement "water"`,
+		},
+		{
+			"MissingMessage",
+			`logs value=1`,
+			Entry{},
+			`missing or invalid field "message"`,
+		},
+		{
+			"InvalidLineProtocol",
+			`not line protocol`,
+			Entry{},
+			"invalid line protocol: metric parse error: expected field at 1:9: \"not line protocol\"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := ParseEntry([]byte(tt.payload), now)
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, entry)
+		})
+	}
+}
+
+func TestAppend(t *testing.T) {
+	now := time.Date(2023, time.August, 23, 10, 0, 0, 0, time.UTC)
+	entryAt := func(d time.Duration) Entry {
+		return Entry{Time: now.Add(-d), Level: "info", Message: d.String()}
+	}
+
+	tests := []struct {
+		name     string
+		config   Config
+		entries  []Entry
+		expected []Entry
+	}{
+		{
+			"Empty",
+			Config{},
+			nil,
+			[]Entry{entryAt(0)},
+		},
+		{
+			"MaxEntries",
+			Config{MaxEntries: 2},
+			[]Entry{entryAt(3 * time.Minute), entryAt(2 * time.Minute), entryAt(time.Minute)},
+			[]Entry{entryAt(time.Minute), entryAt(0)},
+		},
+		{
+			"Retention",
+			Config{Retention: time.Hour},
+			[]Entry{entryAt(2 * time.Hour), entryAt(time.Minute)},
+			[]Entry{entryAt(time.Minute), entryAt(0)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.config.Append(tt.entries, entryAt(0), now))
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	now := time.Date(2023, time.August, 23, 10, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		{Time: now.Add(-2 * time.Hour), Level: "error", Message: "old error"},
+		{Time: now.Add(-time.Minute), Level: "debug", Message: "debug"},
+		{Time: now.Add(-time.Minute), Level: "warn", Message: "warning"},
+	}
+
+	tests := []struct {
+		name      string
+		level     string
+		timeRange time.Duration
+		expected  []string
+	}{
+		{"All", "", 0, []string{"old error", "debug", "warning"}},
+		{"Level", "warn", 0, []string{"old error", "warning"}},
+		{"TimeRange", "", time.Hour, []string{"debug", "warning"}},
+		{"LevelAndTimeRange", "error", time.Hour, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := NewFilter(tt.level, tt.timeRange, now)
+			assert.NoError(t, err)
+
+			messages := []string{}
+			for _, entry := range filter.Apply(entries) {
+				messages = append(messages, entry.Message)
+			}
+			assert.Equal(t, tt.expected, messages)
+		})
+	}
+
+	t.Run("InvalidLevel", func(t *testing.T) {
+		_, err := NewFilter("loud", 0, now)
+		assert.Error(t, err)
+	})
+
+	t.Run("DefaultIncludesTrace", func(t *testing.T) {
+		filter, err := NewFilter("", 0, now)
+		assert.NoError(t, err)
+		assert.Equal(t, logrus.TraceLevel, filter.Level)
+	})
+}
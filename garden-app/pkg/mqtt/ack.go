@@ -0,0 +1,281 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultAckTopicTemplate is the topic template used by garden-controllers to acknowledge commands
+	DefaultAckTopicTemplate = "{{.Garden}}/command/ack"
+	// DefaultAckTimeout is how long to wait for a command to be acknowledged before retrying
+	DefaultAckTimeout = 5 * time.Second
+	// DefaultMaxAttempts is how many times a command is published before it times out
+	DefaultMaxAttempts = 3
+	// DefaultAckRetention is how long commands are tracked after they are created
+	DefaultAckRetention = time.Hour
+)
+
+// CommandStatus is the state of a command that is reported by the garden-controller
+type CommandStatus string
+
+const (
+	// CommandPending means the command was published but not acknowledged
+	CommandPending CommandStatus = "pending"
+	// CommandAccepted means the garden-controller received the command and will execute it
+	CommandAccepted CommandStatus = "accepted"
+	// CommandStarted means the garden-controller started executing the command
+	CommandStarted CommandStatus = "started"
+	// CommandFinished means the garden-controller is done executing the command
+	CommandFinished CommandStatus = "finished"
+	// CommandRejected means the garden-controller will not execute the command, for example if its queue is full
+	CommandRejected CommandStatus = "rejected"
+	// CommandTimedOut means the command was not acknowledged after all attempts. This is only set by the garden-app
+	CommandTimedOut CommandStatus = "timed_out"
+)
+
+// statusOrder is used to ignore acknowledgements that would move a command back to an earlier status, like when
+// a duplicate command from a retry is accepted after the original is finished
+var statusOrder = map[CommandStatus]int{
+	CommandPending:  0,
+	CommandTimedOut: 0,
+	CommandAccepted: 1,
+	CommandStarted:  2,
+	CommandFinished: 3,
+	CommandRejected: 3,
+}
+
+// AckConfig enables tracking the status of commands using acknowledgements published by the garden-controller.
+// Commands that are not acknowledged before the Timeout are published again with the same correlation ID until
+// MaxAttempts is reached
+type AckConfig struct {
+	Enabled     bool          `mapstructure:"enabled"`
+	Topic       string        `mapstructure:"topic"`
+	Timeout     time.Duration `mapstructure:"timeout"`
+	MaxAttempts int           `mapstructure:"max_attempts"`
+	Retention   time.Duration `mapstructure:"retention"`
+}
+
+func (c AckConfig) timeout() time.Duration {
+	if c.Timeout <= 0 {
+		return DefaultAckTimeout
+	}
+	return c.Timeout
+}
+
+func (c AckConfig) maxAttempts() int {
+	if c.MaxAttempts <= 0 {
+		return DefaultMaxAttempts
+	}
+	return c.MaxAttempts
+}
+
+func (c AckConfig) retention() time.Duration {
+	if c.Retention <= 0 {
+		return DefaultAckRetention
+	}
+	return c.Retention
+}
+
+// AckTopic returns the topic string for acknowledging commands
+func (c *Config) AckTopic(topicPrefix string) (string, error) {
+	templates, err := c.templates()
+	if err != nil {
+		return "", err
+	}
+	return executeTopicTemplate(templates.ack, TopicData{Garden: topicPrefix})
+}
+
+// CommandAck is published by the garden-controller to report the status of a command
+type CommandAck struct {
+	CorrelationID string        `json:"correlation_id"`
+	Status        CommandStatus `json:"status"`
+	Reason        string        `json:"reason,omitempty"`
+}
+
+// TrackedCommand is a command that was published by the garden-app and its latest status
+type TrackedCommand struct {
+	ID        string        `json:"id"`
+	Type      CommandType   `json:"type"`
+	Topic     string        `json:"topic"`
+	Status    CommandStatus `json:"status"`
+	Reason    string        `json:"reason,omitempty"`
+	Attempts  int           `json:"attempts"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+// trackedCommand holds the data needed to publish the command again
+type trackedCommand struct {
+	TrackedCommand
+	topicPrefix string
+	ackTopic    string
+	message     []byte
+	qos         byte
+	retained    bool
+	timer       *time.Timer
+}
+
+// commandTracker keeps the status of published commands and publishes them again if they are not acknowledged
+type commandTracker struct {
+	mu       sync.Mutex
+	config   AckConfig
+	commands map[string]*trackedCommand
+	publish  func(topicPrefix, topic string, message []byte, qos byte, retained bool) error
+	logger   *logrus.Entry
+}
+
+func newCommandTracker(config AckConfig, publish func(string, string, []byte, byte, bool) error) *commandTracker {
+	return &commandTracker{
+		config:   config,
+		commands: map[string]*trackedCommand{},
+		publish:  publish,
+		logger:   logrus.WithField("source", "command_tracker"),
+	}
+}
+
+// track starts tracking a command after it is published for the first time. Only acknowledgements published to the
+// ackTopic of the Garden that the command was sent to will update it
+func (t *commandTracker) track(id string, commandType CommandType, topicPrefix, topic, ackTopic string, message []byte, qos byte, retained bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	t.removeExpired(now)
+
+	cmd := &trackedCommand{
+		TrackedCommand: TrackedCommand{
+			ID:        id,
+			Type:      commandType,
+			Topic:     topic,
+			Status:    CommandPending,
+			Attempts:  1,
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+		topicPrefix: topicPrefix,
+		ackTopic:    ackTopic,
+		message:     message,
+		qos:         qos,
+		retained:    retained,
+	}
+	cmd.timer = time.AfterFunc(t.config.timeout(), func() { t.retry(id) })
+	t.commands[id] = cmd
+}
+
+// untrack stops tracking a command if it could not be published
+func (t *commandTracker) untrack(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if cmd, ok := t.commands[id]; ok {
+		cmd.timer.Stop()
+		delete(t.commands, id)
+	}
+}
+
+// retry publishes the command again if it is still pending, or times out the command after all attempts
+func (t *commandTracker) retry(id string) {
+	t.mu.Lock()
+	cmd, ok := t.commands[id]
+	if !ok || cmd.Status != CommandPending {
+		t.mu.Unlock()
+		return
+	}
+
+	logger := t.logger.WithFields(logrus.Fields{"correlation_id": id, "topic": cmd.Topic})
+	cmd.UpdatedAt = time.Now()
+	if cmd.Attempts >= t.config.maxAttempts() {
+		cmd.Status = CommandTimedOut
+		t.mu.Unlock()
+		logger.Warn("command was not acknowledged")
+		return
+	}
+	cmd.Attempts++
+	logger = logger.WithField("attempt", cmd.Attempts)
+	cmd.timer = time.AfterFunc(t.config.timeout(), func() { t.retry(id) })
+	t.mu.Unlock()
+
+	logger.Info("publishing unacknowledged command again")
+	err := t.publish(cmd.topicPrefix, cmd.Topic, cmd.message, cmd.qos, cmd.retained)
+	if err != nil {
+		logger.WithError(err).Error("unable to publish command again")
+	}
+}
+
+// handleAck is the MessageHandler for acknowledgements from garden-controllers
+func (t *commandTracker) handleAck(_ mqtt.Client, msg mqtt.Message) {
+	var ack CommandAck
+	err := json.Unmarshal(msg.Payload(), &ack)
+	if err != nil {
+		t.logger.WithError(err).WithField("topic", msg.Topic()).Error("unable to unmarshal command ack")
+		return
+	}
+
+	err = t.update(msg.Topic(), ack)
+	if err != nil {
+		t.logger.WithError(err).WithField("topic", msg.Topic()).Warn("unable to update command status")
+	}
+}
+
+// update sets the command's status from an acknowledgement that was published to the topic
+func (t *commandTracker) update(topic string, ack CommandAck) error {
+	switch ack.Status {
+	case CommandAccepted, CommandStarted, CommandFinished, CommandRejected:
+	default:
+		return fmt.Errorf("invalid command status %q", ack.Status)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	cmd, ok := t.commands[ack.CorrelationID]
+	if !ok {
+		return fmt.Errorf("unknown command %q", ack.CorrelationID)
+	}
+	if topic != cmd.ackTopic {
+		return fmt.Errorf("command %q was sent to a different garden, expected ack on %q", ack.CorrelationID, cmd.ackTopic)
+	}
+
+	cmd.timer.Stop()
+	if statusOrder[ack.Status] <= statusOrder[cmd.Status] {
+		return nil
+	}
+	cmd.Status = ack.Status
+	cmd.Reason = ack.Reason
+	cmd.UpdatedAt = time.Now()
+	return nil
+}
+
+// removeExpired removes commands that are older than the retention period
+func (t *commandTracker) removeExpired(now time.Time) {
+	for id, cmd := range t.commands {
+		if now.Sub(cmd.CreatedAt) > t.config.retention() {
+			cmd.timer.Stop()
+			delete(t.commands, id)
+		}
+	}
+}
+
+// list returns all tracked commands with the newest first
+func (t *commandTracker) list() []TrackedCommand {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.removeExpired(time.Now())
+
+	result := make([]TrackedCommand, 0, len(t.commands))
+	for _, cmd := range t.commands {
+		result = append(result, cmd.TrackedCommand)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+	return result
+}
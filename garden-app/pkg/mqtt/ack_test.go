@@ -0,0 +1,183 @@
+package mqtt
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/stretchr/testify/assert"
+)
+
+// testMessage implements paho.Message for testing handlers
+type testMessage struct {
+	paho.Message
+	topic   string
+	payload []byte
+}
+
+func (m testMessage) Topic() string   { return m.topic }
+func (m testMessage) Payload() []byte { return m.payload }
+
+// publishRecorder records published messages instead of sending them to a broker
+type publishRecorder struct {
+	sync.Mutex
+	topics []string
+}
+
+func (p *publishRecorder) publish(_, topic string, _ []byte, _ byte, _ bool) error {
+	p.Lock()
+	defer p.Unlock()
+	p.topics = append(p.topics, topic)
+	return nil
+}
+
+func (p *publishRecorder) count() int {
+	p.Lock()
+	defer p.Unlock()
+	return len(p.topics)
+}
+
+func TestAckTopic(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		expected string
+	}{
+		{"Default", Config{}, "garden/command/ack"},
+		{"Custom", Config{Ack: AckConfig{Topic: "{{.Garden}}/ack"}}, "garden/ack"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			topic, err := tt.config.AckTopic("garden")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, topic)
+		})
+	}
+}
+
+func TestCommandTrackerRetries(t *testing.T) {
+	recorder := &publishRecorder{}
+	tracker := newCommandTracker(AckConfig{Timeout: 10 * time.Millisecond, MaxAttempts: 2}, recorder.publish)
+
+	tracker.track("id", WaterCommand, "garden", "garden/command/water", "garden/command/ack", []byte(`{}`), 1, false)
+
+	assert.Eventually(t, func() bool {
+		commands := tracker.list()
+		return len(commands) == 1 && commands[0].Status == CommandTimedOut
+	}, time.Second, 5*time.Millisecond)
+
+	// The initial publish is done by the client, so only the retry is recorded
+	assert.Equal(t, 1, recorder.count())
+	assert.Equal(t, 2, tracker.list()[0].Attempts)
+}
+
+func TestCommandTrackerAcks(t *testing.T) {
+	tests := []struct {
+		name           string
+		acks           []string
+		expectedStatus CommandStatus
+		expectedReason string
+	}{
+		{
+			"Accepted",
+			[]string{`{"correlation_id":"id","status":"accepted"}`},
+			CommandAccepted,
+			"",
+		},
+		{
+			"Finished",
+			[]string{
+				`{"correlation_id":"id","status":"accepted"}`,
+				`{"correlation_id":"id","status":"started"}`,
+				`{"correlation_id":"id","status":"finished"}`,
+			},
+			CommandFinished,
+			"",
+		},
+		{
+			"Rejected",
+			[]string{`{"correlation_id":"id","status":"rejected","reason":"queue full"}`},
+			CommandRejected,
+			"queue full",
+		},
+		{
+			"DuplicateAcceptedAfterFinishedIsIgnored",
+			[]string{
+				`{"correlation_id":"id","status":"finished"}`,
+				`{"correlation_id":"id","status":"accepted"}`,
+			},
+			CommandFinished,
+			"",
+		},
+		{
+			"InvalidStatusIgnored",
+			[]string{`{"correlation_id":"id","status":"done"}`},
+			CommandPending,
+			"",
+		},
+		{
+			"UnknownCommandIgnored",
+			[]string{`{"correlation_id":"other","status":"accepted"}`},
+			CommandPending,
+			"",
+		},
+		{
+			"InvalidJSONIgnored",
+			[]string{`not json`},
+			CommandPending,
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &publishRecorder{}
+			tracker := newCommandTracker(AckConfig{Timeout: time.Minute}, recorder.publish)
+			tracker.track("id", LightCommand, "garden", "garden/command/light", "garden/command/ack", []byte(`{}`), 1, false)
+
+			for _, ack := range tt.acks {
+				tracker.handleAck(nil, testMessage{topic: "garden/command/ack", payload: []byte(ack)})
+			}
+
+			commands := tracker.list()
+			assert.Len(t, commands, 1)
+			assert.Equal(t, "id", commands[0].ID)
+			assert.Equal(t, LightCommand, commands[0].Type)
+			assert.Equal(t, tt.expectedStatus, commands[0].Status)
+			assert.Equal(t, tt.expectedReason, commands[0].Reason)
+			assert.Equal(t, 0, recorder.count())
+		})
+	}
+}
+
+func TestCommandTrackerAckFromOtherGarden(t *testing.T) {
+	tracker := newCommandTracker(AckConfig{Timeout: time.Minute}, (&publishRecorder{}).publish)
+	tracker.track("id", LightCommand, "garden", "garden/command/light", "garden/command/ack", []byte(`{}`), 1, false)
+
+	err := tracker.update("other-garden/command/ack", CommandAck{CorrelationID: "id", Status: CommandAccepted})
+	assert.Error(t, err)
+	assert.Equal(t, CommandPending, tracker.list()[0].Status)
+
+	err = tracker.update("garden/command/ack", CommandAck{CorrelationID: "id", Status: CommandAccepted})
+	assert.NoError(t, err)
+	assert.Equal(t, CommandAccepted, tracker.list()[0].Status)
+}
+
+func TestCommandTrackerRetention(t *testing.T) {
+	tracker := newCommandTracker(AckConfig{Retention: time.Minute}, (&publishRecorder{}).publish)
+	tracker.track("old", StopCommand, "garden", "garden/command/stop", "garden/command/ack", nil, 1, false)
+	tracker.track("new", StopCommand, "garden", "garden/command/stop", "garden/command/ack", nil, 1, false)
+
+	tracker.mu.Lock()
+	tracker.commands["old"].CreatedAt = time.Now().Add(-2 * time.Minute)
+	tracker.mu.Unlock()
+
+	commands := tracker.list()
+	assert.Len(t, commands, 1)
+	assert.Equal(t, "new", commands[0].ID)
+
+	tracker.untrack("new")
+	assert.Empty(t, tracker.list())
+}
@@ -0,0 +1,118 @@
+package broker
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/rs/zerolog"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultPort is the standard MQTT port that is used if a port is not configured
+const DefaultPort = 1883
+
+// Config is used to run an in-process MQTT broker. If no Users are configured, anonymous clients are allowed.
+// CertFile and KeyFile enable TLS for the TCP and websocket listeners
+type Config struct {
+	Enabled       bool   `mapstructure:"enabled"`
+	Port          int    `mapstructure:"port"`
+	WebsocketPort int    `mapstructure:"websocket_port"`
+	Users         []User `mapstructure:"users"`
+	CertFile      string `mapstructure:"cert_file"`
+	KeyFile       string `mapstructure:"key_file"`
+}
+
+// User is a username and password that is allowed to connect to the broker
+type User struct {
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+}
+
+// Broker wraps the MQTT server so it can be started and stopped with the garden-app server
+type Broker struct {
+	server *mochi.Server
+	port   int
+}
+
+// New creates a Broker with the configured listeners and authentication. It is not started until Start is called
+func New(config Config, logger *logrus.Logger) (*Broker, error) {
+	if config.Port == 0 {
+		config.Port = DefaultPort
+	}
+
+	// mochi uses zerolog, so only warnings and errors are written to the garden-app's logger
+	zl := zerolog.New(logger.WithField("source", "mqtt_broker").WriterLevel(logrus.InfoLevel)).Level(zerolog.WarnLevel)
+	server := mochi.New(&mochi.Options{Logger: &zl})
+
+	err := addAuthHook(server, config.Users)
+	if err != nil {
+		return nil, err
+	}
+
+	var listenerConfig *listeners.Config
+	if config.CertFile != "" || config.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error loading broker certificate: %w", err)
+		}
+		listenerConfig = &listeners.Config{
+			TLSConfig: &tls.Config{MinVersion: tls.VersionTLS12, Certificates: []tls.Certificate{cert}},
+		}
+	}
+
+	err = server.AddListener(listeners.NewTCP("tcp", fmt.Sprintf(":%d", config.Port), listenerConfig))
+	if err != nil {
+		return nil, fmt.Errorf("error adding TCP listener: %w", err)
+	}
+	if config.WebsocketPort != 0 {
+		err = server.AddListener(listeners.NewWebsocket("ws", fmt.Sprintf(":%d", config.WebsocketPort), listenerConfig))
+		if err != nil {
+			return nil, fmt.Errorf("error adding websocket listener: %w", err)
+		}
+	}
+
+	return &Broker{server, config.Port}, nil
+}
+
+// addAuthHook allows anonymous access if there are no Users. Otherwise, only the Users are allowed to connect
+func addAuthHook(server *mochi.Server, users []User) error {
+	if len(users) == 0 {
+		return server.AddHook(new(auth.AllowHook), nil)
+	}
+
+	rules := auth.AuthRules{}
+	for _, user := range users {
+		if user.Username == "" || user.Password == "" {
+			return fmt.Errorf("username and password are required for broker users")
+		}
+		rules = append(rules, auth.AuthRule{
+			Username: auth.RString(user.Username),
+			Password: auth.RString(user.Password),
+			Allow:    true,
+		})
+	}
+
+	err := server.AddHook(new(auth.Hook), &auth.Options{Ledger: &auth.Ledger{Auth: rules}})
+	if err != nil {
+		return fmt.Errorf("error adding auth hook: %w", err)
+	}
+	return nil
+}
+
+// Port returns the port of the TCP listener so a local client can connect to it
+func (b *Broker) Port() int {
+	return b.port
+}
+
+// Start begins listening for connections. It does not block
+func (b *Broker) Start() error {
+	return b.server.Serve()
+}
+
+// Stop closes all listeners and client connections
+func (b *Broker) Stop() error {
+	return b.server.Close()
+}
@@ -0,0 +1,129 @@
+package broker
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func freePort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func newTestClient(port int, username, password string) paho.Client {
+	opts := paho.NewClientOptions().AddBroker(fmt.Sprintf("tcp://localhost:%d", port))
+	opts.ClientID = "test-client"
+	opts.Username = username
+	opts.Password = password
+	return paho.NewClient(opts)
+}
+
+func TestBroker(t *testing.T) {
+	tests := []struct {
+		name          string
+		users         []User
+		username      string
+		password      string
+		expectedError string
+	}{
+		{
+			"Anonymous",
+			nil,
+			"",
+			"",
+			"",
+		},
+		{
+			"SuccessfulAuth",
+			[]User{{Username: "garden", Password: "password"}},
+			"garden",
+			"password",
+			"",
+		},
+		{
+			"InvalidPassword",
+			[]User{{Username: "garden", Password: "password"}},
+			"garden",
+			"wrong",
+			"not Authorized",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			port := freePort(t)
+			b, err := New(Config{Enabled: true, Port: port, Users: tt.users}, logrus.New())
+			require.NoError(t, err)
+			require.NoError(t, b.Start())
+			defer b.Stop()
+
+			assert.Equal(t, port, b.Port())
+
+			client := newTestClient(port, tt.username, tt.password)
+			token := client.Connect()
+			token.WaitTimeout(time.Second)
+			if tt.expectedError != "" {
+				assert.Error(t, token.Error())
+				assert.Equal(t, tt.expectedError, token.Error().Error())
+				return
+			}
+			require.NoError(t, token.Error())
+			defer client.Disconnect(0)
+
+			received := make(chan string, 1)
+			token = client.Subscribe("garden/data/water", 1, func(_ paho.Client, msg paho.Message) {
+				received <- string(msg.Payload())
+			})
+			token.WaitTimeout(time.Second)
+			require.NoError(t, token.Error())
+
+			token = client.Publish("garden/data/water", 1, false, "water,zone=0 millis=1000")
+			token.WaitTimeout(time.Second)
+			require.NoError(t, token.Error())
+
+			select {
+			case msg := <-received:
+				assert.Equal(t, "water,zone=0 millis=1000", msg)
+			case <-time.After(time.Second):
+				t.Error("timed out waiting for message")
+			}
+		})
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        Config
+		expectedError string
+	}{
+		{
+			"MissingPassword",
+			Config{Users: []User{{Username: "garden"}}},
+			"username and password are required for broker users",
+		},
+		{
+			"MissingCertificate",
+			Config{CertFile: "missing.pem", KeyFile: "missing-key.pem"},
+			"error loading broker certificate: open missing.pem: no such file or directory",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.config, logrus.New())
+			assert.Error(t, err)
+			assert.Equal(t, tt.expectedError, err.Error())
+		})
+	}
+}
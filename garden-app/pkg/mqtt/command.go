@@ -0,0 +1,105 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/rs/xid"
+)
+
+// CommandType is the type of command sent to a garden-controller. It is used to configure publishing options
+type CommandType string
+
+const (
+	// WaterCommand is used to water a Zone
+	WaterCommand CommandType = "water"
+	// StopCommand is used to stop the currently-watering Zone
+	StopCommand CommandType = "stop"
+	// StopAllCommand is used to stop watering and clear the watering queue
+	StopAllCommand CommandType = "stop_all"
+	// LightCommand is used to change the state of a Garden's light
+	LightCommand CommandType = "light"
+)
+
+// DefaultQoS is the QoS used when it is not configured
+const DefaultQoS byte = 1
+
+// PublishOptions configures how a type of command is published and subscribed to. Since the MQTT client uses
+// MQTT v3.1.1, which does not have message properties, the MessageExpiry is added to the command's payload as an
+// expiration timestamp and the garden-controller is responsible for dropping expired commands
+type PublishOptions struct {
+	QoS           *byte         `mapstructure:"qos"`
+	Retain        bool          `mapstructure:"retain"`
+	MessageExpiry time.Duration `mapstructure:"message_expiry"`
+}
+
+// qos returns the configured QoS or the default
+func (o PublishOptions) qos() byte {
+	if o.QoS == nil {
+		return DefaultQoS
+	}
+	return *o.QoS
+}
+
+// CommandMetadata is added to the JSON payload of every command
+type CommandMetadata struct {
+	CorrelationID string `json:"correlation_id"`
+	ExpiresAt     int64  `json:"expires_at,omitempty"`
+}
+
+// Expired returns true if the command has an expiration and it is before the current time
+func (m CommandMetadata) Expired(now time.Time) bool {
+	return m.ExpiresAt > 0 && now.Unix() > m.ExpiresAt
+}
+
+// CommandOptions returns the configured PublishOptions for the CommandType
+func (c *Config) CommandOptions(commandType CommandType) PublishOptions {
+	return c.Commands[commandType]
+}
+
+// validateCommands makes sure all configured command options are valid
+func (c *Config) validateCommands() error {
+	for commandType, opts := range c.Commands {
+		switch commandType {
+		case WaterCommand, StopCommand, StopAllCommand, LightCommand:
+		default:
+			return fmt.Errorf("invalid command type %q, must be one of: %s, %s, %s, %s", commandType, WaterCommand, StopCommand, StopAllCommand, LightCommand)
+		}
+		if opts.qos() > 2 {
+			return fmt.Errorf("invalid qos %d for %s commands, must be 0, 1, or 2", opts.qos(), commandType)
+		}
+		if opts.MessageExpiry < 0 {
+			return fmt.Errorf("invalid message_expiry for %s commands, must not be negative", commandType)
+		}
+	}
+	return nil
+}
+
+// addCommandMetadata sets a new correlation ID and the expiration in the command's JSON payload. The correlation ID is
+// returned so the command can be tracked
+func addCommandMetadata(message []byte, opts PublishOptions, now time.Time) ([]byte, string, error) {
+	metadata := CommandMetadata{CorrelationID: xid.New().String()}
+	if opts.MessageExpiry > 0 {
+		metadata.ExpiresAt = now.Add(opts.MessageExpiry).Unix()
+	}
+
+	payload := map[string]json.RawMessage{}
+	if len(message) > 0 {
+		err := json.Unmarshal(message, &payload)
+		if err != nil {
+			return nil, "", fmt.Errorf("command message must be a JSON object: %w", err)
+		}
+	}
+
+	payload["correlation_id"], _ = json.Marshal(metadata.CorrelationID)
+	if metadata.ExpiresAt > 0 {
+		payload["expires_at"], _ = json.Marshal(metadata.ExpiresAt)
+	}
+
+	result, err := json.Marshal(payload)
+	if err != nil {
+		return nil, "", fmt.Errorf("error marshaling command: %w", err)
+	}
+	return result, metadata.CorrelationID, nil
+}
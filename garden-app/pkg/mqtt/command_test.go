@@ -0,0 +1,119 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func bytePointer(n byte) *byte {
+	return &n
+}
+
+func TestAddCommandMetadata(t *testing.T) {
+	now := time.Date(2023, time.August, 23, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		message       []byte
+		opts          PublishOptions
+		expected      map[string]interface{}
+		expectedError string
+	}{
+		{
+			"WaterMessageNoExpiry",
+			[]byte(`{"duration":1000,"id":"c5cvhpcbcv45e8bp16dg","position":0}`),
+			PublishOptions{},
+			map[string]interface{}{"duration": float64(1000), "id": "c5cvhpcbcv45e8bp16dg", "position": float64(0)},
+			"",
+		},
+		{
+			"EmptyMessageWithExpiry",
+			nil,
+			PublishOptions{MessageExpiry: 5 * time.Minute},
+			map[string]interface{}{"expires_at": float64(now.Add(5 * time.Minute).Unix())},
+			"",
+		},
+		{
+			"InvalidMessage",
+			[]byte("no message"),
+			PublishOptions{},
+			nil,
+			"command message must be a JSON object: invalid character 'o' in literal null (expecting 'u')",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, id, err := addCommandMetadata(tt.message, tt.opts, now)
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				return
+			}
+			assert.NoError(t, err)
+
+			var payload map[string]interface{}
+			assert.NoError(t, json.Unmarshal(result, &payload))
+			assert.Equal(t, id, payload["correlation_id"])
+			assert.Len(t, payload["correlation_id"], 20)
+			delete(payload, "correlation_id")
+			assert.Equal(t, tt.expected, payload)
+		})
+	}
+}
+
+func TestCommandMetadataExpired(t *testing.T) {
+	now := time.Now()
+	assert.False(t, CommandMetadata{}.Expired(now))
+	assert.False(t, CommandMetadata{ExpiresAt: now.Add(time.Minute).Unix()}.Expired(now))
+	assert.True(t, CommandMetadata{ExpiresAt: now.Add(-time.Minute).Unix()}.Expired(now))
+}
+
+func TestValidateCommands(t *testing.T) {
+	tests := []struct {
+		name          string
+		commands      map[CommandType]PublishOptions
+		expectedError string
+	}{
+		{
+			"Successful",
+			map[CommandType]PublishOptions{
+				WaterCommand: {QoS: bytePointer(2), MessageExpiry: time.Hour},
+				LightCommand: {QoS: bytePointer(0), Retain: true},
+			},
+			"",
+		},
+		{
+			"InvalidCommandType",
+			map[CommandType]PublishOptions{"fertilize": {}},
+			`invalid command type "fertilize", must be one of: water, stop, stop_all, light`,
+		},
+		{
+			"InvalidQoS",
+			map[CommandType]PublishOptions{StopCommand: {QoS: bytePointer(3)}},
+			"invalid qos 3 for stop commands, must be 0, 1, or 2",
+		},
+		{
+			"NegativeExpiry",
+			map[CommandType]PublishOptions{WaterCommand: {MessageExpiry: -time.Minute}},
+			"invalid message_expiry for water commands, must not be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := Config{Commands: tt.commands}
+			err := config.validateCommands()
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, DefaultQoS, config.CommandOptions(StopCommand).qos())
+		})
+	}
+}
@@ -9,6 +9,22 @@ type MockClient struct {
 	mock.Mock
 }
 
+// Commands provides a mock function with given fields:
+func (_m *MockClient) Commands() []TrackedCommand {
+	ret := _m.Called()
+
+	var r0 []TrackedCommand
+	if rf, ok := ret.Get(0).(func() []TrackedCommand); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]TrackedCommand)
+		}
+	}
+
+	return r0
+}
+
 // Connect provides a mock function with given fields:
 func (_m *MockClient) Connect() error {
 	ret := _m.Called()
@@ -29,21 +45,21 @@ func (_m *MockClient) Disconnect(_a0 uint) {
 }
 
 // LightTopic provides a mock function with given fields: _a0
-func (_m *MockClient) LightTopic(_a0 string) (string, error) {
+func (_m *MockClient) LightTopic(_a0 TopicData) (string, error) {
 	ret := _m.Called(_a0)
 
 	var r0 string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
+	if rf, ok := ret.Get(0).(func(TopicData) (string, error)); ok {
 		return rf(_a0)
 	}
-	if rf, ok := ret.Get(0).(func(string) string); ok {
+	if rf, ok := ret.Get(0).(func(TopicData) string); ok {
 		r0 = rf(_a0)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
+	if rf, ok := ret.Get(1).(func(TopicData) error); ok {
 		r1 = rf(_a0)
 	} else {
 		r1 = ret.Error(1)
@@ -66,22 +82,60 @@ func (_m *MockClient) Publish(_a0 string, _a1 []byte) error {
 	return r0
 }
 
+// PublishCommand provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *MockClient) PublishCommand(_a0 CommandType, _a1 string, _a2 string, _a3 []byte) (string, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(CommandType, string, string, []byte) (string, error)); ok {
+		return rf(_a0, _a1, _a2, _a3)
+	}
+	if rf, ok := ret.Get(0).(func(CommandType, string, string, []byte) string); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(CommandType, string, string, []byte) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PublishRetained provides a mock function with given fields: _a0, _a1
+func (_m *MockClient) PublishRetained(_a0 string, _a1 []byte) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []byte) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // StopAllTopic provides a mock function with given fields: _a0
-func (_m *MockClient) StopAllTopic(_a0 string) (string, error) {
+func (_m *MockClient) StopAllTopic(_a0 TopicData) (string, error) {
 	ret := _m.Called(_a0)
 
 	var r0 string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
+	if rf, ok := ret.Get(0).(func(TopicData) (string, error)); ok {
 		return rf(_a0)
 	}
-	if rf, ok := ret.Get(0).(func(string) string); ok {
+	if rf, ok := ret.Get(0).(func(TopicData) string); ok {
 		r0 = rf(_a0)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
+	if rf, ok := ret.Get(1).(func(TopicData) error); ok {
 		r1 = rf(_a0)
 	} else {
 		r1 = ret.Error(1)
@@ -91,21 +145,21 @@ func (_m *MockClient) StopAllTopic(_a0 string) (string, error) {
 }
 
 // StopTopic provides a mock function with given fields: _a0
-func (_m *MockClient) StopTopic(_a0 string) (string, error) {
+func (_m *MockClient) StopTopic(_a0 TopicData) (string, error) {
 	ret := _m.Called(_a0)
 
 	var r0 string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
+	if rf, ok := ret.Get(0).(func(TopicData) (string, error)); ok {
 		return rf(_a0)
 	}
-	if rf, ok := ret.Get(0).(func(string) string); ok {
+	if rf, ok := ret.Get(0).(func(TopicData) string); ok {
 		r0 = rf(_a0)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
+	if rf, ok := ret.Get(1).(func(TopicData) error); ok {
 		r1 = rf(_a0)
 	} else {
 		r1 = ret.Error(1)
@@ -114,22 +168,36 @@ func (_m *MockClient) StopTopic(_a0 string) (string, error) {
 	return r0, r1
 }
 
+// Subscribe provides a mock function with given fields: _a0
+func (_m *MockClient) Subscribe(_a0 TopicHandler) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(TopicHandler) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // WaterTopic provides a mock function with given fields: _a0
-func (_m *MockClient) WaterTopic(_a0 string) (string, error) {
+func (_m *MockClient) WaterTopic(_a0 TopicData) (string, error) {
 	ret := _m.Called(_a0)
 
 	var r0 string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
+	if rf, ok := ret.Get(0).(func(TopicData) (string, error)); ok {
 		return rf(_a0)
 	}
-	if rf, ok := ret.Get(0).(func(string) string); ok {
+	if rf, ok := ret.Get(0).(func(TopicData) string); ok {
 		r0 = rf(_a0)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
+	if rf, ok := ret.Get(1).(func(TopicData) error); ok {
 		r1 = rf(_a0)
 	} else {
 		r1 = ret.Error(1)
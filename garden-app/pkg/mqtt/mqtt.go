@@ -1,14 +1,14 @@
 package mqtt
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
-	"html/template"
 	"sync"
+	"time"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
 )
 
 var mqttClientSummary = prometheus.NewSummaryVec(prometheus.SummaryOpts{
@@ -19,23 +19,38 @@ var mqttClientSummary = prometheus.NewSummaryVec(prometheus.SummaryOpts{
 
 // Config is used to read the necessary configuration values from a YAML file
 type Config struct {
-	ClientID string `mapstructure:"client_id"`
-	Broker   string `mapstructure:"broker"`
-	Port     int    `mapstructure:"port"`
+	ClientID string    `mapstructure:"client_id"`
+	Broker   string    `mapstructure:"broker"`
+	Port     int       `mapstructure:"port"`
+	Scheme   string    `mapstructure:"scheme"`
+	Username string    `mapstructure:"username"`
+	Password string    `mapstructure:"password"`
+	TLS      TLSConfig `mapstructure:"tls"`
+
+	Commands map[CommandType]PublishOptions `mapstructure:"commands"`
+	Ack      AckConfig                      `mapstructure:"ack"`
+	Signing  SigningConfig                  `mapstructure:"signing"`
 
 	WaterTopicTemplate   string `mapstructure:"water_topic"`
 	StopTopicTemplate    string `mapstructure:"stop_topic"`
 	StopAllTopicTemplate string `mapstructure:"stop_all_topic"`
 	LightTopicTemplate   string `mapstructure:"light_topic"`
+
+	// topicTemplates are set by ParseTopicTemplates so they are only parsed once
+	topicTemplates *topicTemplates
 }
 
 // Client is an interface that allows access to MQTT functionality within the garden-app
 type Client interface {
 	Publish(string, []byte) error
-	WaterTopic(string) (string, error)
-	StopTopic(string) (string, error)
-	StopAllTopic(string) (string, error)
-	LightTopic(string) (string, error)
+	PublishRetained(string, []byte) error
+	PublishCommand(CommandType, string, string, []byte) (string, error)
+	Subscribe(TopicHandler) error
+	Commands() []TrackedCommand
+	WaterTopic(TopicData) (string, error)
+	StopTopic(TopicData) (string, error)
+	StopAllTopic(TopicData) (string, error)
+	LightTopic(TopicData) (string, error)
 	Connect() error
 	Disconnect(uint)
 }
@@ -45,40 +60,157 @@ type client struct {
 	mu sync.Mutex
 	mqtt.Client
 	Config
+
+	// handlersMu protects handlers, which are subscribed every time the client connects
+	handlersMu sync.Mutex
+	handlers   []TopicHandler
+
+	// tracker is only used if command acknowledgements are enabled
+	tracker *commandTracker
+
+	logger *logrus.Entry
 }
 
-// TopicHandler is a struct that contains a topic string and MessageHandler for instructing the client how to handle topics
+// TopicHandler is a struct that contains a topic string and MessageHandler for instructing the client how to handle topics.
+// If the topic is for commands, the CommandType is used to get the QoS for subscribing
 type TopicHandler struct {
-	Topic   string
-	Handler mqtt.MessageHandler
+	Topic       string
+	Handler     mqtt.MessageHandler
+	CommandType CommandType
 }
 
 // NewClient is used to create and return a MQTTClient. The handlers argument enables the subscriber
 // using the supplied functions to handle incoming messages. It really should be used with only one function,
 // but I wanted to make it an optional argument, which required using the variadic function argument
 func NewClient(config Config, defaultHandler mqtt.MessageHandler, handlers ...TopicHandler) (Client, error) {
-	opts := mqtt.NewClientOptions().AddBroker(fmt.Sprintf("tcp://%s:%d", config.Broker, config.Port))
+	brokerURL, err := config.BrokerURL()
+	if err != nil {
+		return nil, err
+	}
+	err = config.validateCommands()
+	if err != nil {
+		return nil, err
+	}
+	err = config.ParseTopicTemplates()
+	if err != nil {
+		return nil, err
+	}
+	opts := mqtt.NewClientOptions().AddBroker(brokerURL)
 	opts.ClientID = config.ClientID
+	opts.Username = config.Username
+	opts.Password = config.Password
+	if config.TLS.Enabled() || config.isSecure() {
+		opts.TLSConfig, err = config.TLS.Load()
+		if err != nil {
+			return nil, fmt.Errorf("error loading TLS config: %w", err)
+		}
+	}
 	opts.AutoReconnect = true
 	opts.CleanSession = false
-	if len(handlers) > 0 {
-		opts.OnConnect = func(c mqtt.Client) {
-			for _, handler := range handlers {
-				if token := c.Subscribe(handler.Topic, byte(1), handler.Handler); token.Wait() && token.Error() != nil {
-					// TODO: can I return an error instead of panicking (recover maybe?)
-					panic(token.Error())
-				}
-			}
+
+	err = config.validateSigning()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &client{Config: config, handlers: handlers, logger: logrus.WithField("source", "mqtt_client")}
+	if config.Ack.Enabled {
+		ackTopic, err := config.AckTopic("+")
+		if err != nil {
+			return nil, fmt.Errorf("unable to fill ack topic template: %w", err)
 		}
+		c.tracker = newCommandTracker(config.Ack, c.publishCommand)
+		c.handlers = append(c.handlers, TopicHandler{Topic: ackTopic, Handler: c.tracker.handleAck})
 	}
+	opts.OnConnect = c.subscribeAll
 	opts.DefaultPublishHandler = defaultHandler
 
-	err := prometheus.Register(mqttClientSummary)
+	err = prometheus.Register(mqttClientSummary)
 	if err != nil && errors.Is(err, prometheus.AlreadyRegisteredError{}) {
 		return nil, err
 	}
 
-	return &client{Client: mqtt.NewClient(opts), Config: config}, nil
+	c.Client = mqtt.NewClient(opts)
+	return c, nil
+}
+
+// subscribeAll is used when connecting to subscribe to all of the client's topics. It runs on the MQTT client's
+// goroutine, so errors are logged and the other topics are still subscribed
+func (c *client) subscribeAll(mqttClient mqtt.Client) {
+	c.handlersMu.Lock()
+	topics := []string{}
+	seen := map[string]bool{}
+	for _, handler := range c.handlers {
+		if !seen[handler.Topic] {
+			seen[handler.Topic] = true
+			topics = append(topics, handler.Topic)
+		}
+	}
+	c.handlersMu.Unlock()
+
+	for _, topic := range topics {
+		if err := c.subscribe(mqttClient, topic); err != nil {
+			c.logger.WithError(err).WithField("topic", topic).Error("unable to subscribe to topic")
+		}
+	}
+}
+
+// subscribe subscribes to the topic once using the highest QoS of its handlers. The MQTT client only keeps one
+// MessageHandler for each topic, so messages are passed to every TopicHandler that uses the same topic
+func (c *client) subscribe(mqttClient mqtt.Client, topic string) error {
+	c.handlersMu.Lock()
+	var qos byte
+	for _, handler := range c.handlers {
+		if handler.Topic != topic {
+			continue
+		}
+		if handlerQoS := c.CommandOptions(handler.CommandType).qos(); handlerQoS > qos {
+			qos = handlerQoS
+		}
+	}
+	c.handlersMu.Unlock()
+
+	messageHandler := func(mqttClient mqtt.Client, msg mqtt.Message) {
+		c.handleMessage(topic, mqttClient, msg)
+	}
+	if token := mqttClient.Subscribe(topic, qos, messageHandler); token.Wait() && token.Error() != nil {
+		return token.Error()
+	}
+	return nil
+}
+
+// handleMessage passes a message received on the subscribed topic to all of the topic's handlers
+func (c *client) handleMessage(topic string, mqttClient mqtt.Client, msg mqtt.Message) {
+	c.handlersMu.Lock()
+	handlers := []mqtt.MessageHandler{}
+	for _, handler := range c.handlers {
+		if handler.Topic == topic && handler.Handler != nil {
+			handlers = append(handlers, handler.Handler)
+		}
+	}
+	c.handlersMu.Unlock()
+
+	for _, handler := range handlers {
+		handler(mqttClient, msg)
+	}
+}
+
+// Subscribe adds a TopicHandler after the client is created. The client will connect if it is not already connected,
+// and the subscription is restored when reconnecting. Multiple TopicHandlers can use the same topic and each will
+// receive all of its messages
+func (c *client) Subscribe(handler TopicHandler) error {
+	timer := prometheus.NewTimer(mqttClientSummary.WithLabelValues("Subscribe", handler.Topic))
+	defer timer.ObserveDuration()
+
+	c.handlersMu.Lock()
+	c.handlers = append(c.handlers, handler)
+	c.handlersMu.Unlock()
+
+	// Connecting will subscribe to all topics, including the new one
+	if !c.Client.IsConnected() {
+		return c.Connect()
+	}
+	return c.subscribe(c.Client, handler.Topic)
 }
 
 // Connect uses the MQTT Client's Connect function but returns the error instead of Token
@@ -99,6 +231,75 @@ func (c *client) Publish(topic string, message []byte) error {
 	timer := prometheus.NewTimer(mqttClientSummary.WithLabelValues("Publish", topic))
 	defer timer.ObserveDuration()
 
+	return c.publish(topic, message, DefaultQoS, false)
+}
+
+// PublishRetained will send the message to the specified MQTT topic and the broker will keep it for new subscribers
+func (c *client) PublishRetained(topic string, message []byte) error {
+	timer := prometheus.NewTimer(mqttClientSummary.WithLabelValues("PublishRetained", topic))
+	defer timer.ObserveDuration()
+
+	return c.publish(topic, message, DefaultQoS, true)
+}
+
+// PublishCommand will add a correlation ID and expiration to the command and send it to the specified MQTT topic using
+// the QoS and retain options configured for the CommandType. The command is signed if signing is enabled. The topic
+// prefix is the Garden that the command is sent to. The correlation ID is returned so the command's status can be found
+func (c *client) PublishCommand(commandType CommandType, topicPrefix, topic string, message []byte) (string, error) {
+	timer := prometheus.NewTimer(mqttClientSummary.WithLabelValues("PublishCommand", topic))
+	defer timer.ObserveDuration()
+
+	opts := c.CommandOptions(commandType)
+	message, id, err := addCommandMetadata(message, opts, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	// Start tracking before publishing so acknowledgements are not missed
+	if c.tracker != nil {
+		ackTopic, err := c.AckTopic(topicPrefix)
+		if err != nil {
+			return "", fmt.Errorf("unable to fill ack topic template: %w", err)
+		}
+		c.tracker.track(id, commandType, topicPrefix, topic, ackTopic, message, opts.qos(), opts.Retain)
+	}
+
+	err = c.publishCommand(topicPrefix, topic, message, opts.qos(), opts.Retain)
+	if err != nil {
+		if c.tracker != nil {
+			c.tracker.untrack(id)
+		}
+		return "", err
+	}
+	return id, nil
+}
+
+// publishCommand signs the command with the secret for the topic prefix if signing is enabled and publishes it.
+// Commands are signed every time they are published so a retried command has a new timestamp and nonce
+func (c *client) publishCommand(topicPrefix, topic string, message []byte, qos byte, retained bool) error {
+	if c.Signing.Enabled {
+		secret, ok := c.Signing.Secret(topicPrefix)
+		if !ok {
+			return fmt.Errorf("no signing secret for topic prefix %q", topicPrefix)
+		}
+		var err error
+		message, err = SignCommand(message, secret, time.Now())
+		if err != nil {
+			return fmt.Errorf("unable to sign command: %w", err)
+		}
+	}
+	return c.publish(topic, message, qos, retained)
+}
+
+// Commands returns the status of recently-published commands. It is empty if command acknowledgements are not enabled
+func (c *client) Commands() []TrackedCommand {
+	if c.tracker == nil {
+		return nil
+	}
+	return c.tracker.list()
+}
+
+func (c *client) publish(topic string, message []byte, qos byte, retained bool) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	if len(topic) == 0 {
@@ -107,37 +308,8 @@ func (c *client) Publish(topic string, message []byte) error {
 	if err := c.Connect(); err != nil {
 		return fmt.Errorf("unable to connect to MQTT broker: %v", err)
 	}
-	if token := c.Client.Publish(topic, byte(1), false, message); token.Wait() && token.Error() != nil {
+	if token := c.Client.Publish(topic, qos, retained, message); token.Wait() && token.Error() != nil {
 		return fmt.Errorf("unable to publish MQTT message: %v", token.Error())
 	}
 	return nil
 }
-
-// WaterTopic returns the topic string for watering a plant
-func (c *Config) WaterTopic(topicPrefix string) (string, error) {
-	return c.executeTopicTemplate(c.WaterTopicTemplate, topicPrefix)
-}
-
-// StopTopic returns the topic string for stopping watering a single plant
-func (c *Config) StopTopic(topicPrefix string) (string, error) {
-	return c.executeTopicTemplate(c.StopTopicTemplate, topicPrefix)
-}
-
-// StopAllTopic returns the topic string for stopping watering all plants in a garden
-func (c *Config) StopAllTopic(topicPrefix string) (string, error) {
-	return c.executeTopicTemplate(c.StopAllTopicTemplate, topicPrefix)
-}
-
-// LightTopic returns the topic string for changing the light state in a Garden
-func (c *Config) LightTopic(topicPrefix string) (string, error) {
-	return c.executeTopicTemplate(c.LightTopicTemplate, topicPrefix)
-}
-
-// executeTopicTemplate is a helper function used by all the exported topic evaluation functions
-func (c *Config) executeTopicTemplate(templateString string, topicPrefix string) (string, error) {
-	t := template.Must(template.New("topic").Parse(templateString))
-	var result bytes.Buffer
-	data := map[string]string{"Garden": topicPrefix}
-	err := t.Execute(&result, data)
-	return result.String(), err
-}
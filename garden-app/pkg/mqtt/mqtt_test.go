@@ -0,0 +1,148 @@
+package mqtt
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/mqtt/broker"
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// messageRecorder records the payloads received by a MessageHandler
+type messageRecorder struct {
+	sync.Mutex
+	payloads []string
+}
+
+func (r *messageRecorder) handle(_ paho.Client, msg paho.Message) {
+	r.Lock()
+	defer r.Unlock()
+	r.payloads = append(r.payloads, string(msg.Payload()))
+}
+
+func (r *messageRecorder) received() []string {
+	r.Lock()
+	defer r.Unlock()
+	return append([]string{}, r.payloads...)
+}
+
+func TestSubscribeSameTopic(t *testing.T) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	require.NoError(t, listener.Close())
+
+	b, err := broker.New(broker.Config{Enabled: true, Port: port}, logrus.New())
+	require.NoError(t, err)
+	require.NoError(t, b.Start())
+	defer b.Stop()
+
+	// Handlers from NewClient and Subscribe use the same topic, like data ingestion and state tracking
+	fromNewClient, fromSubscribe, otherTopic := &messageRecorder{}, &messageRecorder{}, &messageRecorder{}
+	client, err := NewClient(
+		Config{ClientID: "test", Broker: "localhost", Port: port},
+		nil,
+		TopicHandler{Topic: "+/data/light", Handler: fromNewClient.handle},
+		TopicHandler{Topic: "+/data/water", Handler: otherTopic.handle},
+	)
+	require.NoError(t, err)
+	require.NoError(t, client.Connect())
+	defer client.Disconnect(100)
+
+	require.NoError(t, client.Subscribe(TopicHandler{Topic: "+/data/light", Handler: fromSubscribe.handle}))
+
+	require.NoError(t, client.Publish("garden/data/light", []byte("light,garden=garden state=1")))
+
+	for _, recorder := range []*messageRecorder{fromNewClient, fromSubscribe} {
+		assert.Eventually(t, func() bool { return len(recorder.received()) == 1 }, time.Second, 5*time.Millisecond)
+		assert.Equal(t, []string{"light,garden=garden state=1"}, recorder.received())
+	}
+	assert.Empty(t, otherTopic.received())
+}
+
+func TestPublishCommandSigned(t *testing.T) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	require.NoError(t, listener.Close())
+
+	b, err := broker.New(broker.Config{Enabled: true, Port: port}, logrus.New())
+	require.NoError(t, err)
+	require.NoError(t, b.Start())
+	defer b.Stop()
+
+	recorder := &messageRecorder{}
+	signing := SigningConfig{Enabled: true, Secrets: []GardenSecret{{TopicPrefix: "garden", Secret: "secret"}}}
+	client, err := NewClient(Config{ClientID: "test", Broker: "localhost", Port: port, Signing: signing}, nil)
+	require.NoError(t, err)
+	require.NoError(t, client.Connect())
+	defer client.Disconnect(100)
+
+	// Subscribing after connecting waits for the subscription, so the command can't be published before it
+	require.NoError(t, client.Subscribe(TopicHandler{Topic: "garden/custom/water", Handler: recorder.handle}))
+
+	// The secret is found using the topic prefix, so the topic doesn't need to come from the topic templates
+	_, err = client.PublishCommand(WaterCommand, "garden", "garden/custom/water", []byte(`{"duration":1000}`))
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool { return len(recorder.received()) == 1 }, time.Second, 5*time.Millisecond)
+	if received := recorder.received(); len(received) == 1 {
+		_, err = signing.VerifyCommand([]byte(received[0]), "secret", time.Now())
+		assert.NoError(t, err)
+	}
+
+	_, err = client.PublishCommand(WaterCommand, "other-garden", "other-garden/command/water", []byte(`{"duration":1000}`))
+	assert.Error(t, err)
+	assert.Equal(t, `no signing secret for topic prefix "other-garden"`, err.Error())
+}
+
+// errorToken is a completed paho.Token with an error
+type errorToken struct {
+	paho.Token
+	err error
+}
+
+func (t errorToken) Wait() bool   { return true }
+func (t errorToken) Error() error { return t.err }
+func (t errorToken) Done() <-chan struct{} {
+	done := make(chan struct{})
+	close(done)
+	return done
+}
+
+// subscribeRecorder is a paho.Client that records subscriptions and rejects some topics
+type subscribeRecorder struct {
+	paho.Client
+	rejected   string
+	subscribed []string
+}
+
+func (c *subscribeRecorder) Subscribe(topic string, _ byte, _ paho.MessageHandler) paho.Token {
+	if topic == c.rejected {
+		return errorToken{err: errors.New("not authorized")}
+	}
+	c.subscribed = append(c.subscribed, topic)
+	return errorToken{}
+}
+
+func TestSubscribeAllContinuesAfterError(t *testing.T) {
+	recorder := &messageRecorder{}
+	c, err := NewClient(
+		Config{},
+		nil,
+		TopicHandler{Topic: "+/data/logs", Handler: recorder.handle},
+		TopicHandler{Topic: "+/data/light", Handler: recorder.handle},
+	)
+	require.NoError(t, err)
+
+	// A rejected subscription does not panic on the MQTT client's goroutine or stop the other subscriptions
+	mqttClient := &subscribeRecorder{rejected: "+/data/logs"}
+	assert.NotPanics(t, func() { c.(*client).subscribeAll(mqttClient) })
+	assert.Equal(t, []string{"+/data/light"}, mqttClient.subscribed)
+}
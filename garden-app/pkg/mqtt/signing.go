@@ -0,0 +1,156 @@
+package mqtt
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultSignatureMaxAge is how old a signed command can be before it is rejected by the garden-controller
+	DefaultSignatureMaxAge = time.Minute
+
+	secretSize = 32
+	nonceSize  = 16
+	// signaturePrefix starts the signature field, which is always the last field in a signed command. The signed
+	// bytes are the command without this field so they can be verified without re-encoding the JSON
+	signaturePrefix = `,"signature":"`
+	signatureSuffix = `"}`
+	signatureLength = len(signaturePrefix) + 2*sha256.Size + len(signatureSuffix)
+)
+
+// SigningConfig enables signing commands with an HMAC using a secret for each Garden. A timestamp and nonce are
+// added to each command so the garden-controller can reject old and replayed commands
+type SigningConfig struct {
+	Enabled bool           `mapstructure:"enabled"`
+	Secrets []GardenSecret `mapstructure:"secrets"`
+	MaxAge  time.Duration  `mapstructure:"max_age"`
+}
+
+// GardenSecret is the secret used to sign commands for the Garden using the topic prefix
+type GardenSecret struct {
+	TopicPrefix string `mapstructure:"topic_prefix"`
+	Secret      string `mapstructure:"secret"`
+}
+
+// SignatureMaxAge returns the configured MaxAge or the default
+func (c SigningConfig) SignatureMaxAge() time.Duration {
+	if c.MaxAge <= 0 {
+		return DefaultSignatureMaxAge
+	}
+	return c.MaxAge
+}
+
+// Secret returns the secret for the Garden using the topic prefix
+func (c SigningConfig) Secret(topicPrefix string) (string, bool) {
+	for _, s := range c.Secrets {
+		if s.TopicPrefix == topicPrefix {
+			return s.Secret, true
+		}
+	}
+	return "", false
+}
+
+// validateSigning checks that every configured Garden has a secret when signing is enabled
+func (c *Config) validateSigning() error {
+	if !c.Signing.Enabled {
+		return nil
+	}
+
+	for _, s := range c.Signing.Secrets {
+		if s.Secret == "" {
+			return fmt.Errorf("missing signing secret for topic prefix %q", s.TopicPrefix)
+		}
+	}
+	return nil
+}
+
+// CommandSignature has the fields added to a command when it is signed
+type CommandSignature struct {
+	Timestamp int64  `json:"timestamp"`
+	Nonce     string `json:"nonce"`
+}
+
+// GenerateSecret creates a random secret for signing commands
+func GenerateSecret() (string, error) {
+	return randomHex(secretSize)
+}
+
+// SignCommand adds a timestamp and nonce to the command's JSON payload and then adds the HMAC-SHA256 signature of
+// the result as the last field
+func SignCommand(message []byte, secret string, now time.Time) ([]byte, error) {
+	nonce, err := randomHex(nonceSize)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create nonce: %w", err)
+	}
+
+	payload := map[string]json.RawMessage{}
+	err = json.Unmarshal(message, &payload)
+	if err != nil {
+		return nil, fmt.Errorf("command message must be a JSON object: %w", err)
+	}
+	payload["timestamp"], _ = json.Marshal(now.Unix())
+	payload["nonce"], _ = json.Marshal(nonce)
+
+	signed, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling command: %w", err)
+	}
+
+	result := append([]byte{}, signed[:len(signed)-1]...)
+	result = append(result, signaturePrefix...)
+	result = append(result, computeSignature(signed, secret)...)
+	result = append(result, signatureSuffix...)
+	return result, nil
+}
+
+// VerifyCommand is used by the garden-controller to check that a command has a valid signature from its secret and
+// was signed within the max age. It returns the command's nonce so the controller can reject replayed commands
+func (c SigningConfig) VerifyCommand(message []byte, secret string, now time.Time) (string, error) {
+	if len(message) < signatureLength ||
+		!bytes.HasSuffix(message, []byte(signatureSuffix)) ||
+		!bytes.HasPrefix(message[len(message)-signatureLength:], []byte(signaturePrefix)) {
+		return "", errors.New("missing signature")
+	}
+
+	signed := append(append([]byte{}, message[:len(message)-signatureLength]...), '}')
+	signature := message[len(message)-signatureLength+len(signaturePrefix) : len(message)-len(signatureSuffix)]
+	if !hmac.Equal(signature, computeSignature(signed, secret)) {
+		return "", errors.New("invalid signature")
+	}
+
+	var cs CommandSignature
+	err := json.Unmarshal(signed, &cs)
+	if err != nil {
+		return "", fmt.Errorf("invalid signed command: %w", err)
+	}
+	if cs.Nonce == "" {
+		return "", errors.New("missing nonce")
+	}
+	age := now.Sub(time.Unix(cs.Timestamp, 0))
+	if age > c.SignatureMaxAge() || age < -c.SignatureMaxAge() {
+		return "", errors.New("signature timestamp is outside of the max age")
+	}
+	return cs.Nonce, nil
+}
+
+func computeSignature(message []byte, secret string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(message)
+	return []byte(hex.EncodeToString(mac.Sum(nil)))
+}
+
+func randomHex(size int) (string, error) {
+	b := make([]byte, size)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,113 @@
+package mqtt
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignCommand(t *testing.T) {
+	now := time.Date(2023, time.August, 23, 10, 0, 0, 0, time.UTC)
+	config := SigningConfig{Enabled: true}
+	message := []byte(`{"correlation_id":"cjjqnk8dqc7ug3ge2dcg","duration":1000,"position":0}`)
+
+	signed, err := SignCommand(message, "secret", now)
+	assert.NoError(t, err)
+
+	var payload map[string]interface{}
+	assert.NoError(t, json.Unmarshal(signed, &payload))
+	assert.Equal(t, "cjjqnk8dqc7ug3ge2dcg", payload["correlation_id"])
+	assert.Equal(t, float64(now.Unix()), payload["timestamp"])
+	assert.Len(t, payload["nonce"], 2*nonceSize)
+	assert.Len(t, payload["signature"], 64)
+
+	tamper := func(from, to string) []byte {
+		return bytes.Replace(signed, []byte(from), []byte(to), 1)
+	}
+
+	tests := []struct {
+		name          string
+		message       []byte
+		secret        string
+		now           time.Time
+		expectedError string
+	}{
+		{"Valid", signed, "secret", now, ""},
+		{"ValidWithinMaxAge", signed, "secret", now.Add(30 * time.Second), ""},
+		{"WrongSecret", signed, "other-secret", now, "invalid signature"},
+		{"ModifiedPayload", tamper(`"duration":1000`, `"duration":9000`), "secret", now, "invalid signature"},
+		{"Unsigned", message, "secret", now, "missing signature"},
+		{"TooOld", signed, "secret", now.Add(2 * time.Minute), "signature timestamp is outside of the max age"},
+		{"FromFuture", signed, "secret", now.Add(-2 * time.Minute), "signature timestamp is outside of the max age"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nonce, err := config.VerifyCommand(tt.message, tt.secret, tt.now)
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, payload["nonce"], nonce)
+		})
+	}
+
+	t.Run("NewNonceEachTime", func(t *testing.T) {
+		signedAgain, err := SignCommand(message, "secret", now)
+		assert.NoError(t, err)
+		assert.NotEqual(t, signed, signedAgain)
+	})
+}
+
+func TestValidateSigning(t *testing.T) {
+	config := Config{
+		WaterTopicTemplate:   "{{.Garden}}/command/water",
+		StopTopicTemplate:    "{{.Garden}}/command/stop",
+		StopAllTopicTemplate: "{{.Garden}}/command/stop_all",
+		LightTopicTemplate:   "{{.Garden}}/command/light",
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		assert.NoError(t, config.validateSigning())
+	})
+
+	t.Run("Enabled", func(t *testing.T) {
+		config.Signing = SigningConfig{Enabled: true, Secrets: []GardenSecret{{TopicPrefix: "garden", Secret: "secret"}}}
+		assert.NoError(t, config.validateSigning())
+
+		secret, ok := config.Signing.Secret("garden")
+		assert.True(t, ok)
+		assert.Equal(t, "secret", secret)
+		_, ok = config.Signing.Secret("other-garden")
+		assert.False(t, ok)
+	})
+
+	t.Run("MissingSecret", func(t *testing.T) {
+		config.Signing = SigningConfig{Enabled: true, Secrets: []GardenSecret{{TopicPrefix: "garden"}}}
+		err := config.validateSigning()
+		assert.Error(t, err)
+		assert.Equal(t, `missing signing secret for topic prefix "garden"`, err.Error())
+	})
+
+	t.Run("TopicUsesZone", func(t *testing.T) {
+		zoneConfig := config
+		zoneConfig.WaterTopicTemplate = "{{.Garden}}/zone/{{.ZonePosition}}/water"
+		zoneConfig.Signing = SigningConfig{Enabled: true, Secrets: []GardenSecret{{TopicPrefix: "garden", Secret: "secret"}}}
+		assert.NoError(t, zoneConfig.validateSigning())
+	})
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	assert.NoError(t, err)
+	assert.Len(t, secret, 2*secretSize)
+
+	other, err := GenerateSecret()
+	assert.NoError(t, err)
+	assert.NotEqual(t, secret, other)
+}
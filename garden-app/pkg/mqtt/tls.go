@@ -0,0 +1,94 @@
+package mqtt
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Supported schemes for connecting to the MQTT broker
+const (
+	SchemeTCP       = "tcp"
+	SchemeSSL       = "ssl"
+	SchemeWebSocket = "ws"
+	SchemeWSS       = "wss"
+)
+
+// TLSConfig is used to configure TLS when connecting to the MQTT broker. A CA bundle is only required if the
+// broker's certificate is not signed by a trusted CA. The client certificate and key are used for mutual TLS
+type TLSConfig struct {
+	CAFile             string `mapstructure:"ca_file"`
+	CertFile           string `mapstructure:"cert_file"`
+	KeyFile            string `mapstructure:"key_file"`
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"`
+}
+
+// Enabled returns true if any TLS options are configured
+func (c TLSConfig) Enabled() bool {
+	return c.CAFile != "" || c.CertFile != "" || c.KeyFile != "" || c.InsecureSkipVerify
+}
+
+// Load reads the configured files and creates a *tls.Config
+func (c TLSConfig) Load() (*tls.Config, error) {
+	// nolint:gosec
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	if c.CAFile != "" {
+		caCert, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no certificates found in CA file %q", c.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return nil, errors.New("cert_file and key_file must both be provided")
+	}
+	if c.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error loading client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// BrokerURL returns the URL used to connect to the broker. If the scheme is not configured, it is "ssl" when TLS
+// is configured and "tcp" otherwise
+func (c *Config) BrokerURL() (string, error) {
+	scheme := c.Scheme
+	if scheme == "" {
+		scheme = SchemeTCP
+		if c.TLS.Enabled() {
+			scheme = SchemeSSL
+		}
+	}
+
+	switch scheme {
+	case SchemeTCP, SchemeWebSocket:
+		if c.TLS.Enabled() {
+			return "", fmt.Errorf("tls is configured but scheme %q is not encrypted", scheme)
+		}
+	case SchemeSSL, SchemeWSS:
+	default:
+		return "", fmt.Errorf("invalid scheme %q, must be one of: %s, %s, %s, %s", scheme, SchemeTCP, SchemeSSL, SchemeWebSocket, SchemeWSS)
+	}
+
+	return fmt.Sprintf("%s://%s:%d", scheme, c.Broker, c.Port), nil
+}
+
+// isSecure returns true if the configured scheme uses TLS
+func (c *Config) isSecure() bool {
+	return c.Scheme == SchemeSSL || c.Scheme == SchemeWSS
+}
@@ -0,0 +1,155 @@
+package mqtt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBrokerURL(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        Config
+		expected      string
+		expectedError string
+	}{
+		{
+			"DefaultTCP",
+			Config{Broker: "localhost", Port: 1883},
+			"tcp://localhost:1883",
+			"",
+		},
+		{
+			"DefaultSSLWithTLS",
+			Config{Broker: "localhost", Port: 8883, TLS: TLSConfig{CAFile: "ca.pem"}},
+			"ssl://localhost:8883",
+			"",
+		},
+		{
+			"WSS",
+			Config{Broker: "localhost", Port: 443, Scheme: "wss"},
+			"wss://localhost:443",
+			"",
+		},
+		{
+			"InvalidScheme",
+			Config{Broker: "localhost", Port: 1883, Scheme: "http"},
+			"",
+			`invalid scheme "http", must be one of: tcp, ssl, ws, wss`,
+		},
+		{
+			"TLSWithUnencryptedScheme",
+			Config{Broker: "localhost", Port: 1883, Scheme: "tcp", TLS: TLSConfig{InsecureSkipVerify: true}},
+			"",
+			`tls is configured but scheme "tcp" is not encrypted`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url, err := tt.config.BrokerURL()
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, url)
+		})
+	}
+}
+
+func TestTLSConfigLoad(t *testing.T) {
+	certFile, keyFile := createTestCertificate(t)
+
+	invalidCAFile := filepath.Join(t.TempDir(), "invalid.pem")
+	assert.NoError(t, os.WriteFile(invalidCAFile, []byte("not a certificate"), 0o600))
+
+	tests := []struct {
+		name          string
+		config        TLSConfig
+		expectedError string
+	}{
+		{
+			"SuccessfulCAOnly",
+			TLSConfig{CAFile: certFile},
+			"",
+		},
+		{
+			"SuccessfulMutualTLS",
+			TLSConfig{CAFile: certFile, CertFile: certFile, KeyFile: keyFile},
+			"",
+		},
+		{
+			"InvalidCAFile",
+			TLSConfig{CAFile: invalidCAFile},
+			`no certificates found in CA file "` + invalidCAFile + `"`,
+		},
+		{
+			"MissingKeyFile",
+			TLSConfig{CertFile: certFile},
+			"cert_file and key_file must both be provided",
+		},
+		{
+			"MismatchedKeyPair",
+			TLSConfig{CertFile: keyFile, KeyFile: keyFile},
+			"error loading client certificate: tls: failed to find certificate PEM data in certificate input, but did find a private key; PEM inputs may have been switched",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tlsConfig, err := tt.config.Load()
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, tlsConfig.RootCAs)
+			if tt.config.CertFile != "" {
+				assert.Len(t, tlsConfig.Certificates, 1)
+			}
+		})
+	}
+}
+
+// createTestCertificate writes a self-signed certificate and its key to a temporary directory
+func createTestCertificate(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "garden-test"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	assert.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	assert.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+
+	return certFile, keyFile
+}
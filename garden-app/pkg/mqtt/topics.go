@@ -0,0 +1,152 @@
+package mqtt
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/rs/xid"
+)
+
+// TopicData is used to fill topic templates. Garden is the Garden's topic prefix. The Zone fields are only set for
+// water commands, which allows publishing to a topic for each Zone like "{{.Garden}}/zone/{{.ZonePosition}}/water"
+type TopicData struct {
+	Garden       string
+	GardenID     xid.ID
+	ZoneID       xid.ID
+	ZonePosition uint
+	Command      CommandType
+}
+
+// topicTemplateFuncs are the helper functions available in topic templates. String functions accept any value so
+// they can be used with IDs and the command type, and they take the value last so they can be used in pipelines
+var topicTemplateFuncs = template.FuncMap{
+	"lower": func(s any) string {
+		return strings.ToLower(fmt.Sprint(s))
+	},
+	"upper": func(s any) string {
+		return strings.ToUpper(fmt.Sprint(s))
+	},
+	"replace": func(old, replacement string, s any) string {
+		return strings.ReplaceAll(fmt.Sprint(s), old, replacement)
+	},
+	"trimPrefix": func(prefix string, s any) string {
+		return strings.TrimPrefix(fmt.Sprint(s), prefix)
+	},
+	"trimSuffix": func(suffix string, s any) string {
+		return strings.TrimSuffix(fmt.Sprint(s), suffix)
+	},
+	// add is used for devices that number relays starting at 1, like "{{ add .ZonePosition 1 }}"
+	"add": func(a, b uint) uint {
+		return a + b
+	},
+}
+
+// topicTemplates are the parsed templates for each command topic and the ack topic
+type topicTemplates struct {
+	commands map[CommandType]*template.Template
+	ack      *template.Template
+}
+
+// ParseTopicTemplates parses and validates the topic templates so they are not parsed every time a topic is created.
+// NewClient uses this, so it only needs to be used when a Config's topics are used without a Client
+func (c *Config) ParseTopicTemplates() error {
+	templates, err := c.parseTopicTemplates()
+	if err != nil {
+		return err
+	}
+	c.topicTemplates = templates
+	return nil
+}
+
+func (c *Config) parseTopicTemplates() (*topicTemplates, error) {
+	result := &topicTemplates{commands: map[CommandType]*template.Template{}}
+	for _, topic := range []struct {
+		commandType    CommandType
+		name           string
+		templateString string
+	}{
+		{WaterCommand, "water_topic", c.WaterTopicTemplate},
+		{StopCommand, "stop_topic", c.StopTopicTemplate},
+		{StopAllCommand, "stop_all_topic", c.StopAllTopicTemplate},
+		{LightCommand, "light_topic", c.LightTopicTemplate},
+	} {
+		t, err := parseTopicTemplate(topic.name, topic.templateString, topic.commandType)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s template: %w", topic.name, err)
+		}
+		result.commands[topic.commandType] = t
+	}
+
+	ackTemplate := c.Ack.Topic
+	if ackTemplate == "" {
+		ackTemplate = DefaultAckTopicTemplate
+	}
+	var err error
+	result.ack, err = parseTopicTemplate("ack_topic", ackTemplate, "")
+	if err != nil {
+		return nil, fmt.Errorf("invalid ack topic template: %w", err)
+	}
+
+	return result, nil
+}
+
+// parseTopicTemplate parses the template and executes it with example data so errors, like using a field that does
+// not exist, are found when the Config is loaded instead of when publishing
+func parseTopicTemplate(name, templateString string, commandType CommandType) (*template.Template, error) {
+	t, err := template.New(name).Funcs(topicTemplateFuncs).Parse(templateString)
+	if err != nil {
+		return nil, err
+	}
+	_, err = executeTopicTemplate(t, TopicData{Garden: "garden", Command: commandType})
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// templates returns the parsed topic templates. If ParseTopicTemplates was not used, the templates are parsed every
+// time
+func (c *Config) templates() (*topicTemplates, error) {
+	if c.topicTemplates != nil {
+		return c.topicTemplates, nil
+	}
+	return c.parseTopicTemplates()
+}
+
+// WaterTopic returns the topic string for watering a Zone
+func (c *Config) WaterTopic(data TopicData) (string, error) {
+	return c.commandTopic(WaterCommand, data)
+}
+
+// StopTopic returns the topic string for stopping watering a single Zone
+func (c *Config) StopTopic(data TopicData) (string, error) {
+	return c.commandTopic(StopCommand, data)
+}
+
+// StopAllTopic returns the topic string for stopping watering all Zones in a Garden
+func (c *Config) StopAllTopic(data TopicData) (string, error) {
+	return c.commandTopic(StopAllCommand, data)
+}
+
+// LightTopic returns the topic string for changing the light state in a Garden
+func (c *Config) LightTopic(data TopicData) (string, error) {
+	return c.commandTopic(LightCommand, data)
+}
+
+// commandTopic is a helper function used by all the exported command topic functions
+func (c *Config) commandTopic(commandType CommandType, data TopicData) (string, error) {
+	templates, err := c.templates()
+	if err != nil {
+		return "", err
+	}
+	data.Command = commandType
+	return executeTopicTemplate(templates.commands[commandType], data)
+}
+
+func executeTopicTemplate(t *template.Template, data TopicData) (string, error) {
+	var result bytes.Buffer
+	err := t.Execute(&result, data)
+	return result.String(), err
+}
@@ -0,0 +1,78 @@
+package mqtt
+
+import (
+	"testing"
+
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopicTemplates(t *testing.T) {
+	gardenID, _ := xid.FromString("c5cvhpcbcv45e8bp16dg")
+	zoneID, _ := xid.FromString("chkodpg3lcj13q82mq40")
+	data := TopicData{Garden: "Garden", GardenID: gardenID, ZoneID: zoneID, ZonePosition: 2}
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{"TopicPrefix", "{{.Garden}}/command/water", "Garden/command/water"},
+		{"ZonePosition", "{{.Garden}}/zone/{{.ZonePosition}}/water", "Garden/zone/2/water"},
+		{"ZonePositionFromOne", "cmnd/{{ lower .Garden }}/POWER{{ add .ZonePosition 1 }}", "cmnd/garden/POWER3"},
+		{"IDs", "gardens/{{.GardenID}}/zones/{{.ZoneID}}", "gardens/c5cvhpcbcv45e8bp16dg/zones/chkodpg3lcj13q82mq40"},
+		{"Command", "{{.Garden}}/{{.Command}}", "Garden/water"},
+		{"Upper", "{{ upper .Command }}", "WATER"},
+		{"Replace", `{{ .Garden | replace "rd" "-" }}`, "Ga-en"},
+		{"TrimPrefixAndSuffix", `{{ trimPrefix "Gar" .Garden }}/{{ trimSuffix "en" .Garden }}`, "den/Gard"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := Config{WaterTopicTemplate: tt.template}
+			err := config.ParseTopicTemplates()
+			assert.NoError(t, err)
+
+			topic, err := config.WaterTopic(data)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, topic)
+		})
+	}
+}
+
+func TestParseTopicTemplatesErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        Config
+		expectedError string
+	}{
+		{
+			"ParseError",
+			Config{LightTopicTemplate: "{{.Garden"},
+			`invalid light_topic template: template: light_topic:1: unclosed action`,
+		},
+		{
+			"UnknownField",
+			Config{WaterTopicTemplate: "{{.Zone}}/water"},
+			`invalid water_topic template: template: water_topic:1:2: executing "water_topic" at <.Zone>: can't evaluate field Zone in type mqtt.TopicData`,
+		},
+		{
+			"UnknownFunction",
+			Config{StopTopicTemplate: "{{ title .Garden }}"},
+			`invalid stop_topic template: template: stop_topic:1: function "title" not defined`,
+		},
+		{
+			"AckTopic",
+			Config{Ack: AckConfig{Topic: "{{.Zone}}"}},
+			`invalid ack topic template: template: ack_topic:1:2: executing "ack_topic" at <.Zone>: can't evaluate field Zone in type mqtt.TopicData`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.ParseTopicTemplates()
+			assert.Error(t, err)
+			assert.Equal(t, tt.expectedError, err.Error())
+		})
+	}
+}
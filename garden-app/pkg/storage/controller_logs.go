@@ -0,0 +1,22 @@
+package storage
+
+import (
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/logs"
+	"github.com/rs/xid"
+)
+
+const controllerLogsPrefix = "ControllerLogs_"
+
+// GetControllerLogs returns the stored log entries published by the Garden's controller
+func (c *Client) GetControllerLogs(gardenID xid.ID) ([]logs.Entry, error) {
+	entries, err := getOne[[]logs.Entry](c, controllerLogsPrefix+gardenID.String())
+	if err != nil || entries == nil {
+		return nil, err
+	}
+	return *entries, nil
+}
+
+// SaveControllerLogs stores the log entries for the Garden's controller
+func (c *Client) SaveControllerLogs(gardenID xid.ID, entries []logs.Entry) error {
+	return save(c, entries, controllerLogsPrefix+gardenID.String())
+}
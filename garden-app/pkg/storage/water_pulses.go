@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg"
+	"github.com/rs/xid"
+)
+
+const waterPulsePrefix = "WaterPulse_"
+
+func waterPulseZonePrefix(zoneID xid.ID) string {
+	return fmt.Sprintf("%s%s_", waterPulsePrefix, zoneID)
+}
+
+func waterPulseKey(pulse *pkg.WaterPulse) string {
+	return fmt.Sprintf("%s%d", waterPulseZonePrefix(pulse.ZoneID), pulse.Time.UnixNano())
+}
+
+// GetWaterPulses returns all recorded closed-loop WaterPulses for a Zone, oldest first
+func (c *Client) GetWaterPulses(zoneID xid.ID) ([]*pkg.WaterPulse, error) {
+	pulses, err := getMultiple[*pkg.WaterPulse](c, true, waterPulseZonePrefix(zoneID))
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(pulses, func(i, j int) bool {
+		return pulses[i].Time.Before(pulses[j].Time)
+	})
+	return pulses, nil
+}
+
+// SaveWaterPulse ...
+func (c *Client) SaveWaterPulse(pulse *pkg.WaterPulse) error {
+	return save[*pkg.WaterPulse](c, pulse, waterPulseKey(pulse))
+}
@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather"
+)
+
+const weatherCachePrefix = "WeatherCache_"
+
+// WeatherCache implements weather.Cache using the storage Client so cached weather data is kept across restarts and
+// can be shared by replicas using the same storage
+type WeatherCache struct {
+	client *Client
+	stop   chan struct{}
+}
+
+// NewWeatherCache creates a weather.Cache that uses this storage Client. If cleanupInterval is positive, expired
+// entries are deleted at that interval so entries that are never read again do not stay in storage
+func (c *Client) NewWeatherCache(cleanupInterval time.Duration) *WeatherCache {
+	wc := &WeatherCache{c, make(chan struct{})}
+	if cleanupInterval > 0 {
+		go wc.cleanup(cleanupInterval)
+	}
+	return wc
+}
+
+// Stop stops deleting expired entries
+func (wc *WeatherCache) Stop() {
+	close(wc.stop)
+}
+
+func (wc *WeatherCache) cleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			_ = wc.DeleteExpired()
+		case <-wc.stop:
+			return
+		}
+	}
+}
+
+// DeleteExpired removes all expired entries
+func (wc *WeatherCache) DeleteExpired() error {
+	_, err := wc.GetAll("")
+	return err
+}
+
+// Get returns the entry for the key, or nil if it does not exist. Expired entries are deleted
+func (wc *WeatherCache) Get(key string) (*weather.CacheEntry, error) {
+	entry, err := getOne[weather.CacheEntry](wc.client, weatherCachePrefix+key)
+	if err != nil || entry == nil {
+		return nil, err
+	}
+
+	if entry.Expired() {
+		return nil, wc.client.db.Delete(weatherCachePrefix + key)
+	}
+
+	return entry, nil
+}
+
+// Set saves the entry using its key
+func (wc *WeatherCache) Set(entry *weather.CacheEntry) error {
+	return save[*weather.CacheEntry](wc.client, entry, weatherCachePrefix+entry.Key)
+}
+
+// GetAll returns all unexpired entries with keys starting with the prefix. Expired entries are deleted
+func (wc *WeatherCache) GetAll(prefix string) ([]*weather.CacheEntry, error) {
+	keys, err := wc.keys(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []*weather.CacheEntry{}
+	for _, key := range keys {
+		entry, err := wc.Get(strings.TrimPrefix(key, weatherCachePrefix))
+		if err != nil {
+			return nil, err
+		}
+		if entry != nil {
+			results = append(results, entry)
+		}
+	}
+	return results, nil
+}
+
+// Delete removes all entries with keys starting with the prefix
+func (wc *WeatherCache) Delete(prefix string) error {
+	keys, err := wc.keys(prefix)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		err = wc.client.db.Delete(key)
+		if err != nil {
+			return fmt.Errorf("error deleting %q: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func (wc *WeatherCache) keys(prefix string) ([]string, error) {
+	keys, err := wc.client.db.Keys()
+	if err != nil {
+		return nil, fmt.Errorf("error getting keys: %w", err)
+	}
+
+	results := []string{}
+	for _, key := range keys {
+		if strings.HasPrefix(key, weatherCachePrefix+prefix) {
+			results = append(results, key)
+		}
+	}
+	return results, nil
+}
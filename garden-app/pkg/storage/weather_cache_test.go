@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWeatherCache(t *testing.T) {
+	client, err := NewClient(Config{Driver: "hashmap"})
+	assert.NoError(t, err)
+	c := client.NewWeatherCache(0)
+
+	expiresAt := time.Now().Add(time.Minute).Truncate(time.Second)
+
+	assert.NoError(t, c.Set(&weather.CacheEntry{Key: "a_1", Value: 1, ExpiresAt: expiresAt}))
+	assert.NoError(t, c.Set(&weather.CacheEntry{Key: "a_2", Value: 2, ExpiresAt: expiresAt}))
+	assert.NoError(t, c.Set(&weather.CacheEntry{Key: "b_1", Value: 3, ExpiresAt: expiresAt}))
+	assert.NoError(t, c.Set(&weather.CacheEntry{Key: "a_expired", Value: 4, ExpiresAt: time.Now().Add(-time.Minute)}))
+
+	t.Run("Get", func(t *testing.T) {
+		entry, err := c.Get("a_1")
+		assert.NoError(t, err)
+		assert.Equal(t, float32(1), entry.Value)
+		assert.True(t, expiresAt.Equal(entry.ExpiresAt))
+
+		entry, err = c.Get("does_not_exist")
+		assert.NoError(t, err)
+		assert.Nil(t, entry)
+	})
+
+	t.Run("ExpiredEntriesAreDeleted", func(t *testing.T) {
+		entry, err := c.Get("a_expired")
+		assert.NoError(t, err)
+		assert.Nil(t, entry)
+
+		keys, err := client.db.Keys()
+		assert.NoError(t, err)
+		assert.NotContains(t, keys, weatherCachePrefix+"a_expired")
+	})
+
+	t.Run("GetAll", func(t *testing.T) {
+		entries, err := c.GetAll("a_")
+		assert.NoError(t, err)
+		assert.Len(t, entries, 2)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		assert.NoError(t, c.Delete("a_"))
+
+		entries, err := c.GetAll("")
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1)
+		assert.Equal(t, "b_1", entries[0].Key)
+	})
+
+	t.Run("SharedAcrossClients", func(t *testing.T) {
+		// A new cache using the same storage has the same data, like after a restart or in another replica
+		entry, err := client.NewWeatherCache(0).Get("b_1")
+		assert.NoError(t, err)
+		assert.Equal(t, float32(3), entry.Value)
+	})
+}
+
+func TestWeatherCacheCleanup(t *testing.T) {
+	client, err := NewClient(Config{Driver: "hashmap"})
+	assert.NoError(t, err)
+	c := client.NewWeatherCache(10 * time.Millisecond)
+	defer c.Stop()
+
+	assert.NoError(t, c.Set(&weather.CacheEntry{Key: "a_1", Value: 1, ExpiresAt: time.Now().Add(time.Minute)}))
+	assert.NoError(t, c.Set(&weather.CacheEntry{Key: "a_expired", Value: 2, ExpiresAt: time.Now().Add(-time.Minute)}))
+
+	// The expired entry is deleted without being read
+	assert.Eventually(t, func() bool {
+		keys, err := client.db.Keys()
+		assert.NoError(t, err)
+		return len(keys) == 1 && keys[0] == weatherCachePrefix+"a_1"
+	}, time.Second, 10*time.Millisecond)
+}
@@ -28,7 +28,7 @@ func (c *Client) GetWeatherClient(id xid.ID) (weather.Client, error) {
 	return weather.NewClient(clientConfig, func(weatherClientOptions map[string]interface{}) error {
 		clientConfig.Options = weatherClientOptions
 		return c.SaveWeatherClientConfig(clientConfig)
-	})
+	}, c.GetWeatherClient)
 }
 
 // GetWeatherClientConfig ...
@@ -60,16 +60,40 @@ func (c *Client) GetWaterSchedulesUsingWeatherClient(id xid.ID) ([]*pkg.WaterSch
 
 	results := []*pkg.WaterSchedule{}
 	for _, ws := range waterSchedules {
-		if ws.HasWeatherControl() {
-			if ws.HasRainControl() {
-				if ws.WeatherControl.Rain.ClientID == id {
-					results = append(results, ws)
-				}
+		if !ws.HasWeatherControl() {
+			continue
+		}
+		for _, clientID := range ws.WeatherControl.ClientIDs() {
+			if clientID == id {
+				results = append(results, ws)
+				break
 			}
-			if ws.HasTemperatureControl() {
-				if ws.WeatherControl.Temperature.ClientID == id {
-					results = append(results, ws)
-				}
+		}
+	}
+
+	return results, nil
+}
+
+// GetWeatherClientsUsingWeatherClient will return all composite WeatherClients that use this WeatherClient
+func (c *Client) GetWeatherClientsUsingWeatherClient(id xid.ID) ([]*weather.Config, error) {
+	weatherClients, err := c.GetWeatherClientConfigs()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get all WeatherClients: %w", err)
+	}
+
+	results := []*weather.Config{}
+	for _, wc := range weatherClients {
+		if wc.Type != weather.CompositeType {
+			continue
+		}
+		compositeConfig, err := weather.NewCompositeConfig(wc.Options)
+		if err != nil {
+			continue
+		}
+		for _, clientID := range compositeConfig.IDs() {
+			if clientID == id {
+				results = append(results, wc)
+				break
 			}
 		}
 	}
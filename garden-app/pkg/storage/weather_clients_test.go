@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetWaterSchedulesUsingWeatherClient(t *testing.T) {
+	client, err := NewClient(Config{Driver: "hashmap"})
+	assert.NoError(t, err)
+
+	weatherClientID := xid.New()
+	threshold := float32(2)
+
+	multipleControls := &pkg.WaterSchedule{
+		ID:       xid.New(),
+		Interval: &pkg.Duration{Duration: 24 * time.Hour},
+		WeatherControl: &weather.Control{
+			Freeze: &weather.ThresholdControl{Threshold: &threshold, ClientID: weatherClientID},
+			Wind:   &weather.ThresholdControl{Threshold: &threshold, ClientID: weatherClientID},
+		},
+	}
+	otherClient := &pkg.WaterSchedule{
+		ID:       xid.New(),
+		Interval: &pkg.Duration{Duration: 24 * time.Hour},
+		WeatherControl: &weather.Control{
+			Freeze: &weather.ThresholdControl{Threshold: &threshold, ClientID: xid.New()},
+		},
+	}
+	assert.NoError(t, client.SaveWaterSchedule(multipleControls))
+	assert.NoError(t, client.SaveWaterSchedule(otherClient))
+
+	result, err := client.GetWaterSchedulesUsingWeatherClient(weatherClientID)
+	assert.NoError(t, err)
+	assert.Len(t, result, 1)
+	assert.Equal(t, multipleControls.ID, result[0].ID)
+}
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather/history"
+	"github.com/rs/xid"
+)
+
+const weatherObservationPrefix = "WeatherObservation_"
+
+// weatherObservationKeyPrefix is the prefix for all of a WeatherClient's observations at a resolution. Using the
+// resolution in the key allows storing multiple resolutions for the same periods
+func weatherObservationKeyPrefix(clientID xid.ID, resolution time.Duration) string {
+	return fmt.Sprintf("%s%s_%d_", weatherObservationPrefix, clientID, int64(resolution.Seconds()))
+}
+
+func weatherObservationKey(clientID xid.ID, resolution time.Duration, t time.Time) string {
+	return fmt.Sprintf("%s%d", weatherObservationKeyPrefix(clientID, resolution), t.Unix())
+}
+
+// GetWeatherObservations returns a WeatherClient's stored observations at the resolution with times between start
+// (inclusive) and end (exclusive), sorted by time
+func (c *Client) GetWeatherObservations(clientID xid.ID, resolution time.Duration, start, end time.Time) ([]*history.Observation, error) {
+	keys, err := c.db.Keys()
+	if err != nil {
+		return nil, fmt.Errorf("error getting keys: %w", err)
+	}
+
+	prefix := weatherObservationKeyPrefix(clientID, resolution)
+	results := []*history.Observation{}
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+
+		observation, err := getOne[history.Observation](c, key)
+		if err != nil {
+			return nil, fmt.Errorf("error getting data: %w", err)
+		}
+		if observation == nil || observation.Time.Before(start) || !observation.Time.Before(end) {
+			continue
+		}
+		results = append(results, observation)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Time.Before(results[j].Time)
+	})
+	return results, nil
+}
+
+// SaveWeatherObservations stores a WeatherClient's observations at the resolution
+func (c *Client) SaveWeatherObservations(clientID xid.ID, resolution time.Duration, observations []*history.Observation) error {
+	for _, o := range observations {
+		err := save[*history.Observation](c, o, weatherObservationKey(clientID, resolution, o.Time))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteWeatherObservations deletes all of a WeatherClient's stored observations
+func (c *Client) DeleteWeatherObservations(clientID xid.ID) error {
+	keys, err := c.db.Keys()
+	if err != nil {
+		return fmt.Errorf("error getting keys: %w", err)
+	}
+
+	prefix := weatherObservationPrefix + clientID.String() + "_"
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		err = c.db.Delete(key)
+		if err != nil {
+			return fmt.Errorf("error deleting %q: %w", key, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather/history"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWeatherObservations(t *testing.T) {
+	client, err := NewClient(Config{Driver: "hashmap"})
+	assert.NoError(t, err)
+
+	id := xid.New()
+	otherID := xid.New()
+	start := time.Date(2023, time.August, 1, 0, 0, 0, 0, time.UTC)
+	rain := float32(1)
+
+	observations := []*history.Observation{}
+	for i := 0; i < 4; i++ {
+		observations = append(observations, &history.Observation{Time: start.Add(time.Duration(i) * time.Hour), RainMM: &rain})
+	}
+	assert.NoError(t, client.SaveWeatherObservations(id, time.Hour, observations))
+	assert.NoError(t, client.SaveWeatherObservations(otherID, time.Hour, observations))
+	assert.NoError(t, client.SaveWeatherObservations(id, 24*time.Hour, observations[:1]))
+
+	t.Run("GetInRange", func(t *testing.T) {
+		result, err := client.GetWeatherObservations(id, time.Hour, start.Add(time.Hour), start.Add(3*time.Hour))
+		assert.NoError(t, err)
+		assert.Len(t, result, 2)
+		assert.True(t, start.Add(time.Hour).Equal(result[0].Time))
+		assert.True(t, start.Add(2*time.Hour).Equal(result[1].Time))
+	})
+
+	t.Run("GetOtherResolution", func(t *testing.T) {
+		result, err := client.GetWeatherObservations(id, 24*time.Hour, start, start.Add(24*time.Hour))
+		assert.NoError(t, err)
+		assert.Len(t, result, 1)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		assert.NoError(t, client.DeleteWeatherObservations(id))
+
+		result, err := client.GetWeatherObservations(id, time.Hour, start, start.Add(4*time.Hour))
+		assert.NoError(t, err)
+		assert.Len(t, result, 0)
+
+		result, err = client.GetWeatherObservations(otherID, time.Hour, start, start.Add(4*time.Hour))
+		assert.NoError(t, err)
+		assert.Len(t, result, 4)
+	})
+}
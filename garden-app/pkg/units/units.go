@@ -0,0 +1,91 @@
+// Package units converts between the metric units used for all stored and measured data and the imperial units that
+// can be used for configuration and responses
+package units
+
+import (
+	"fmt"
+	"strings"
+)
+
+// System is a system of units used for responses
+type System string
+
+const (
+	// Metric uses Celsius and millimeters. This is the default
+	Metric System = "metric"
+	// Imperial uses Fahrenheit and inches
+	Imperial System = "imperial"
+)
+
+// Validate returns an error if the System is not known. An empty System is valid and means the default is used
+func (s System) Validate() error {
+	switch s {
+	case "", Metric, Imperial:
+		return nil
+	default:
+		return fmt.Errorf("invalid unit system %q, must be one of: %s, %s", s, Metric, Imperial)
+	}
+}
+
+// Unit is the unit of a configured value
+type Unit string
+
+// Units for temperature and rain values
+const (
+	Celsius     Unit = "celsius"
+	Fahrenheit  Unit = "fahrenheit"
+	Millimeters Unit = "mm"
+	Inches      Unit = "inches"
+)
+
+// ValidateUnit returns an error if the Unit is not one of the allowed Units. An empty Unit is always valid and means
+// the metric unit is used
+func ValidateUnit(u Unit, allowed ...Unit) error {
+	if u == "" {
+		return nil
+	}
+	for _, a := range allowed {
+		if u == a {
+			return nil
+		}
+	}
+
+	if len(allowed) == 0 {
+		return fmt.Errorf("unit %q is not supported", u)
+	}
+	allowedStrings := make([]string, 0, len(allowed))
+	for _, a := range allowed {
+		allowedStrings = append(allowedStrings, string(a))
+	}
+	return fmt.Errorf("invalid unit %q, must be one of: %s", u, strings.Join(allowedStrings, ", "))
+}
+
+// Float is used so conversions work with float32 weather data and float64 sensor data
+type Float interface {
+	~float32 | ~float64
+}
+
+// CelsiusToFahrenheit converts a temperature
+func CelsiusToFahrenheit[T Float](celsius T) T {
+	return celsius*9/5 + 32
+}
+
+// FahrenheitToCelsius converts a temperature
+func FahrenheitToCelsius[T Float](fahrenheit T) T {
+	return (fahrenheit - 32) * 5 / 9
+}
+
+// FahrenheitDifferenceToCelsius converts a difference between temperatures, like a range, so no offset is used
+func FahrenheitDifferenceToCelsius[T Float](fahrenheit T) T {
+	return fahrenheit * 5 / 9
+}
+
+// MillimetersToInches converts a length
+func MillimetersToInches[T Float](mm T) T {
+	return mm / 25.4
+}
+
+// InchesToMillimeters converts a length
+func InchesToMillimeters[T Float](inches T) T {
+	return inches * 25.4
+}
@@ -0,0 +1,52 @@
+package units
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSystemValidate(t *testing.T) {
+	assert.NoError(t, System("").Validate())
+	assert.NoError(t, Metric.Validate())
+	assert.NoError(t, Imperial.Validate())
+
+	err := System("kelvin").Validate()
+	assert.Error(t, err)
+	assert.Equal(t, `invalid unit system "kelvin", must be one of: metric, imperial`, err.Error())
+}
+
+func TestValidateUnit(t *testing.T) {
+	tests := []struct {
+		name        string
+		unit        Unit
+		allowed     []Unit
+		expectedErr string
+	}{
+		{"Empty", "", []Unit{Celsius}, ""},
+		{"Allowed", Fahrenheit, []Unit{Celsius, Fahrenheit}, ""},
+		{"NotAllowed", Inches, []Unit{Celsius, Fahrenheit}, `invalid unit "inches", must be one of: celsius, fahrenheit`},
+		{"NoneAllowed", Inches, nil, `unit "inches" is not supported`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateUnit(tt.unit, tt.allowed...)
+			if tt.expectedErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Error(t, err)
+			assert.Equal(t, tt.expectedErr, err.Error())
+		})
+	}
+}
+
+func TestConversions(t *testing.T) {
+	assert.Equal(t, float32(212), CelsiusToFahrenheit(float32(100)))
+	assert.Equal(t, float64(-40), CelsiusToFahrenheit(float64(-40)))
+	assert.Equal(t, float32(0), FahrenheitToCelsius(float32(32)))
+	assert.Equal(t, float32(10), FahrenheitDifferenceToCelsius(float32(18)))
+	assert.Equal(t, float32(2), MillimetersToInches(float32(50.8)))
+	assert.Equal(t, float32(25.4), InchesToMillimeters(float32(1)))
+}
@@ -37,7 +37,9 @@ func (ws *WaterSchedule) EndDated() bool {
 // This checks that WeatherControl is defined and has at least one type of control configured
 func (ws *WaterSchedule) HasWeatherControl() bool {
 	return ws != nil &&
-		(ws.HasRainControl() || ws.HasSoilMoistureControl() || ws.HasTemperatureControl())
+		(ws.HasRainControl() || ws.HasSoilMoistureControl() || ws.HasClosedLoopMoistureControl() || ws.HasTemperatureControl() ||
+			ws.HasSensorTemperatureControl() || ws.HasSensorHumidityControl() ||
+			ws.HasFreezeControl() || ws.HasWindControl() || ws.HasHeatControl() || ws.HasRainForecastControl())
 }
 
 // Patch allows modifying the struct in-place with values from a different instance
@@ -87,12 +89,55 @@ func (ws *WaterSchedule) HasSoilMoistureControl() bool {
 		ws.WeatherControl.SoilMoisture.MinimumMoisture != nil
 }
 
+// HasClosedLoopMoistureControl is used to determine if the Zone should be watered in pulses until reaching a target soil moisture
+func (ws *WaterSchedule) HasClosedLoopMoistureControl() bool {
+	return ws.WeatherControl != nil &&
+		ws.WeatherControl.SoilMoisture != nil &&
+		ws.WeatherControl.SoilMoisture.IsClosedLoop()
+}
+
 // HasTemperatureControl is used to determine if configuration is available for environmental scaling
 func (ws *WaterSchedule) HasTemperatureControl() bool {
 	return ws.WeatherControl != nil &&
 		ws.WeatherControl.Temperature != nil
 }
 
+// HasSensorTemperatureControl is used to determine if watering should be scaled using the Garden's temperature sensor
+func (ws *WaterSchedule) HasSensorTemperatureControl() bool {
+	return ws.WeatherControl != nil &&
+		ws.WeatherControl.SensorTemperature != nil
+}
+
+// HasSensorHumidityControl is used to determine if watering should be scaled using the Garden's humidity sensor
+func (ws *WaterSchedule) HasSensorHumidityControl() bool {
+	return ws.WeatherControl != nil &&
+		ws.WeatherControl.SensorHumidity != nil
+}
+
+// HasFreezeControl is used to determine if watering should be skipped when the temperature is below a threshold
+func (ws *WaterSchedule) HasFreezeControl() bool {
+	return ws.WeatherControl != nil &&
+		ws.WeatherControl.Freeze != nil
+}
+
+// HasWindControl is used to determine if watering should be skipped when wind speed is above a threshold
+func (ws *WaterSchedule) HasWindControl() bool {
+	return ws.WeatherControl != nil &&
+		ws.WeatherControl.Wind != nil
+}
+
+// HasHeatControl is used to determine if an extra watering should be added when the temperature is above a threshold
+func (ws *WaterSchedule) HasHeatControl() bool {
+	return ws.WeatherControl != nil &&
+		ws.WeatherControl.Heat != nil
+}
+
+// HasRainForecastControl is used to determine if watering should be skipped or reduced when rain is forecast
+func (ws *WaterSchedule) HasRainForecastControl() bool {
+	return ws.WeatherControl != nil &&
+		ws.WeatherControl.RainForecast != nil
+}
+
 // IsActive determines if the WaterSchedule is currently in it's ActivePeriod. Always true if no ActivePeriod is configured
 func (ws *WaterSchedule) IsActive(now time.Time) bool {
 	if ws.ActivePeriod == nil {
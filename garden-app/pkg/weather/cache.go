@@ -0,0 +1,128 @@
+package weather
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+	"github.com/rs/xid"
+)
+
+const (
+	// DefaultCacheTTL is how long weather data is cached when a client does not configure cache_ttl
+	DefaultCacheTTL = 5 * time.Minute
+	// DefaultCleanupInterval is how often caches delete expired entries
+	DefaultCleanupInterval = time.Minute
+)
+
+// CacheEntry is a single cached value from a weather client
+type CacheEntry struct {
+	Key       string    `json:"key"`
+	Value     float32   `json:"value"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Expired returns true if the entry should no longer be used
+func (e *CacheEntry) Expired() bool {
+	return !time.Now().Before(e.ExpiresAt)
+}
+
+// Cache is used to store responses from all weather clients. Keys are prefixed with the client's ID so entries can be
+// listed and flushed for each client
+type Cache interface {
+	// Get returns the entry for the key, or nil if it does not exist or is expired
+	Get(key string) (*CacheEntry, error)
+	Set(entry *CacheEntry) error
+	// GetAll returns all unexpired entries with keys starting with the prefix
+	GetAll(prefix string) ([]*CacheEntry, error)
+	// Delete removes all entries with keys starting with the prefix
+	Delete(prefix string) error
+}
+
+// SetCache changes the Cache used by all weather clients. This should be called before any clients are used
+func SetCache(c Cache) {
+	responseCache = c
+}
+
+// ResetCache replaces the Cache with a new, empty MemoryCache
+func ResetCache() {
+	responseCache = NewMemoryCache()
+}
+
+// GetCacheEntries returns all cached data for a weather client, sorted by key
+func GetCacheEntries(id xid.ID) ([]*CacheEntry, error) {
+	entries, err := responseCache.GetAll(cacheKeyPrefix(id))
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+	return entries, nil
+}
+
+// FlushCache removes all cached data for a weather client
+func FlushCache(id xid.ID) error {
+	return responseCache.Delete(cacheKeyPrefix(id))
+}
+
+func cacheKeyPrefix(id xid.ID) string {
+	return id.String() + "_"
+}
+
+func cacheKey(id xid.ID, format string, args ...interface{}) string {
+	return cacheKeyPrefix(id) + fmt.Sprintf(format, args...)
+}
+
+// MemoryCache is a process-local Cache. It is the default, but data is lost on restart and not shared by replicas
+type MemoryCache struct {
+	cache *cache.Cache
+}
+
+// NewMemoryCache creates a new, empty MemoryCache
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{cache.New(DefaultCacheTTL, DefaultCleanupInterval)}
+}
+
+// Get ...
+func (c *MemoryCache) Get(key string) (*CacheEntry, error) {
+	entry, found := c.cache.Get(key)
+	if !found {
+		return nil, nil
+	}
+	return entry.(*CacheEntry), nil
+}
+
+// Set ...
+func (c *MemoryCache) Set(entry *CacheEntry) error {
+	ttl := time.Until(entry.ExpiresAt)
+	// go-cache treats zero and negative durations as default and no expiration
+	if ttl <= 0 {
+		return nil
+	}
+	c.cache.Set(entry.Key, entry, ttl)
+	return nil
+}
+
+// GetAll ...
+func (c *MemoryCache) GetAll(prefix string) ([]*CacheEntry, error) {
+	entries := []*CacheEntry{}
+	for key, item := range c.cache.Items() {
+		if strings.HasPrefix(key, prefix) {
+			entries = append(entries, item.Object.(*CacheEntry))
+		}
+	}
+	return entries, nil
+}
+
+// Delete ...
+func (c *MemoryCache) Delete(prefix string) error {
+	for key := range c.cache.Items() {
+		if strings.HasPrefix(key, prefix) {
+			c.cache.Delete(key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package weather
+
+import (
+	"testing"
+	"time"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/duration"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryCache(t *testing.T) {
+	c := NewMemoryCache()
+	expiresAt := time.Now().Add(time.Minute)
+
+	assert.NoError(t, c.Set(&CacheEntry{Key: "a_1", Value: 1, ExpiresAt: expiresAt}))
+	assert.NoError(t, c.Set(&CacheEntry{Key: "a_2", Value: 2, ExpiresAt: expiresAt}))
+	assert.NoError(t, c.Set(&CacheEntry{Key: "b_1", Value: 3, ExpiresAt: expiresAt}))
+	// Entries that are already expired are not stored
+	assert.NoError(t, c.Set(&CacheEntry{Key: "a_expired", Value: 4, ExpiresAt: time.Now().Add(-time.Minute)}))
+
+	entry, err := c.Get("a_1")
+	assert.NoError(t, err)
+	assert.Equal(t, &CacheEntry{Key: "a_1", Value: 1, ExpiresAt: expiresAt}, entry)
+
+	entry, err = c.Get("a_expired")
+	assert.NoError(t, err)
+	assert.Nil(t, entry)
+
+	entries, err := c.GetAll("a_")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	assert.NoError(t, c.Delete("a_"))
+
+	entries, err = c.GetAll("")
+	assert.NoError(t, err)
+	assert.Equal(t, []*CacheEntry{{Key: "b_1", Value: 3, ExpiresAt: expiresAt}}, entries)
+}
+
+func TestCacheEntriesAndFlush(t *testing.T) {
+	defer ResetCache()
+
+	config := &Config{
+		ID:   xid.New(),
+		Type: "fake",
+		Options: map[string]interface{}{
+			"rain_mm":              25.4,
+			"rain_interval":        "24h",
+			"avg_high_temperature": 40,
+		},
+		CacheTTL: &duration.Duration{Duration: time.Hour},
+	}
+	client, err := NewClient(config, func(m map[string]interface{}) error { return nil }, nil)
+	assert.NoError(t, err)
+
+	_, err = client.GetTotalRain(24 * time.Hour)
+	assert.NoError(t, err)
+	_, err = client.GetAverageHighTemperature(72 * time.Hour)
+	assert.NoError(t, err)
+
+	entries, err := GetCacheEntries(config.ID)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, config.ID.String()+"_avg_temp_72h0m0s", entries[0].Key)
+	assert.Equal(t, float32(40), entries[0].Value)
+	assert.Equal(t, config.ID.String()+"_total_rain_24h0m0s", entries[1].Key)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), entries[1].ExpiresAt, time.Second)
+
+	// Other clients are not affected by flushing
+	otherID := xid.New()
+	assert.NoError(t, responseCache.Set(&CacheEntry{Key: cacheKey(otherID, "current_temp"), Value: 1, ExpiresAt: time.Now().Add(time.Minute)}))
+
+	assert.NoError(t, FlushCache(config.ID))
+
+	entries, err = GetCacheEntries(config.ID)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 0)
+
+	entries, err = GetCacheEntries(otherID)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestNewClientInvalidCacheTTL(t *testing.T) {
+	tests := []struct {
+		name     string
+		cacheTTL *duration.Duration
+	}{
+		{"Negative", &duration.Duration{Duration: -time.Minute}},
+		{"Zero", &duration.Duration{Duration: 0}},
+		{"Cron", &duration.Duration{Cron: "0 * * * *"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewClient(&Config{Type: "fake", CacheTTL: tt.cacheTTL}, nil, nil)
+			assert.Error(t, err)
+			assert.Equal(t, "cache_ttl must be a positive duration", err.Error())
+		})
+	}
+}
@@ -1,18 +1,25 @@
 package weather
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/duration"
 	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather/fake"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather/history"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather/influxdb"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather/measurement"
 	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather/netatmo"
-	"github.com/patrickmn/go-cache"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather/openmeteo"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather/replay"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather/wunderground"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/xid"
 )
 
 var (
-	responseCache = cache.New(5*time.Minute, 1*time.Minute)
+	responseCache Cache = NewMemoryCache()
 
 	weatherClientSummary = prometheus.NewSummaryVec(prometheus.SummaryOpts{
 		Namespace: "garden_app",
@@ -29,21 +36,53 @@ func init() {
 type Client interface {
 	GetTotalRain(since time.Duration) (float32, error)
 	GetAverageHighTemperature(since time.Duration) (float32, error)
+	GetCurrentTemperature() (float32, error)
+	GetCurrentWindSpeed() (float32, error)
+	GetForecastRain(within time.Duration) (float32, error)
+	GetForecastRainProbability(within time.Duration) (float32, error)
+	GetForecastLowTemperature(within time.Duration) (float32, error)
+	GetHistory(start, end time.Time, resolution time.Duration) ([]*history.Observation, error)
+	GetMeasurement(q measurement.Query) (float32, error)
 }
 
-// Config is used to identify and configure a client type
+// Config is used to identify and configure a client type. CacheTTL is optional and sets how long responses from this
+// client are cached
 type Config struct {
-	ID      xid.ID                 `json:"id" yaml:"id"`
-	Type    string                 `json:"type" yaml:"type"`
-	Options map[string]interface{} `json:"options" yaml:"options"`
+	ID       xid.ID                 `json:"id" yaml:"id"`
+	Type     string                 `json:"type" yaml:"type"`
+	Options  map[string]interface{} `json:"options" yaml:"options"`
+	CacheTTL *duration.Duration     `json:"cache_ttl,omitempty" yaml:"cache_ttl,omitempty"`
+}
+
+// GetCacheTTL returns the configured CacheTTL or DefaultCacheTTL
+func (c *Config) GetCacheTTL() time.Duration {
+	if c.CacheTTL == nil {
+		return DefaultCacheTTL
+	}
+	return c.CacheTTL.Duration
 }
 
 // NewClient will use the config to create and return the correct type of weather client. If no type is provided, this will
-// return a nil client rather than an error since Weather client is not required
-func NewClient(c *Config, storageCallback func(map[string]interface{}) error) (client Client, err error) {
+// return a nil client rather than an error since Weather client is not required. getClient is used by composite clients
+// to get their sub-clients by ID
+func NewClient(c *Config, storageCallback func(map[string]interface{}) error, getClient func(xid.ID) (Client, error)) (client Client, err error) {
+	if c.CacheTTL != nil && (c.CacheTTL.Cron != "" || c.CacheTTL.Duration <= 0) {
+		return nil, errors.New("cache_ttl must be a positive duration")
+	}
+
 	switch c.Type {
 	case "netatmo":
 		client, err = netatmo.NewClient(c.Options, storageCallback)
+	case "openmeteo":
+		client, err = openmeteo.NewClient(c.Options)
+	case "wunderground":
+		client, err = wunderground.NewClient(c.Options)
+	case "influxdb":
+		client, err = influxdb.NewClient(c.Options)
+	case "replay":
+		client, err = replay.NewClient(c.Options)
+	case CompositeType:
+		client, err = newCompositeClient(c.Options, getClient)
 	case "fake":
 		client, err = fake.NewClient(c.Options)
 	default:
@@ -56,11 +95,22 @@ func NewClient(c *Config, storageCallback func(map[string]interface{}) error) (c
 	return newMetricsWrapperClient(client, c), nil
 }
 
+// AuthStatus returns the status of the client's OAuth tokens. It is nil for clients that do not use OAuth
+func (c *Config) AuthStatus() (*netatmo.AuthStatus, error) {
+	if c.Type != "netatmo" {
+		return nil, nil
+	}
+	return netatmo.GetAuthStatus(c.Options)
+}
+
 // Patch allows modifying an existing Config with fields from a new one
 func (c *Config) Patch(newConfig *Config) {
 	if newConfig.Type != "" {
 		c.Type = newConfig.Type
 	}
+	if newConfig.CacheTTL != nil {
+		c.CacheTTL = newConfig.CacheTTL
+	}
 
 	if c.Options == nil && newConfig.Options != nil {
 		c.Options = map[string]interface{}{}
@@ -88,54 +138,92 @@ func newMetricsWrapperClient(client Client, config *Config) Client {
 	return &clientWrapper{client, config}
 }
 
-// GetTotalRain ...
-func (c *clientWrapper) GetTotalRain(since time.Duration) (float32, error) {
+// cached gets a value from the cache or, if it is not cached, from the wrapped client. Errors from the cache are
+// ignored so an unavailable cache does not prevent getting weather data
+func (c *clientWrapper) cached(function, key string, get func() (float32, error)) (float32, error) {
 	now := time.Now()
 	cached := false
 	defer func() {
-		weatherClientSummary.WithLabelValues("GetTotalRain", fmt.Sprintf("%t", cached)).Observe(time.Since(now).Seconds())
+		weatherClientSummary.WithLabelValues(function, fmt.Sprintf("%t", cached)).Observe(time.Since(now).Seconds())
 	}()
 
-	cacheKey := fmt.Sprintf("total_rain_%d_%s", since, c.Config.ID)
-	cachedData, found := responseCache.Get(cacheKey)
-	if found {
+	cachedData, err := responseCache.Get(key)
+	if err == nil && cachedData != nil {
 		cached = true
-		return cachedData.(float32), nil
+		return cachedData.Value, nil
 	}
 
-	totalRain, err := c.Client.GetTotalRain(since)
+	result, err := get()
 	if err != nil {
 		return 0, err
 	}
-	responseCache.Set(cacheKey, totalRain, cache.DefaultExpiration)
+	_ = responseCache.Set(&CacheEntry{
+		Key:       key,
+		Value:     result,
+		ExpiresAt: time.Now().Add(c.Config.GetCacheTTL()),
+	})
+
+	return result, nil
+}
 
-	return totalRain, nil
+// GetTotalRain ...
+func (c *clientWrapper) GetTotalRain(since time.Duration) (float32, error) {
+	return c.cached("GetTotalRain", cacheKey(c.Config.ID, "total_rain_%s", since), func() (float32, error) {
+		return c.Client.GetTotalRain(since)
+	})
 }
 
 // GetAverageHighTemperature ...
 func (c *clientWrapper) GetAverageHighTemperature(since time.Duration) (float32, error) {
-	now := time.Now()
-	cached := false
-	defer func() {
-		weatherClientSummary.WithLabelValues("GetAverageHighTemperature", fmt.Sprintf("%t", cached)).Observe(time.Since(now).Seconds())
-	}()
+	return c.cached("GetAverageHighTemperature", cacheKey(c.Config.ID, "avg_temp_%s", since), func() (float32, error) {
+		return c.Client.GetAverageHighTemperature(since)
+	})
+}
 
-	cacheKey := fmt.Sprintf("avg_temp_%d_%s", since, c.Config.ID)
-	cachedData, found := responseCache.Get(cacheKey)
-	if found {
-		cached = true
-		return cachedData.(float32), nil
-	}
+// GetCurrentTemperature ...
+func (c *clientWrapper) GetCurrentTemperature() (float32, error) {
+	return c.cached("GetCurrentTemperature", cacheKey(c.Config.ID, "current_temp"), c.Client.GetCurrentTemperature)
+}
 
-	avgTemp, err := c.Client.GetAverageHighTemperature(since)
-	if err != nil {
-		return 0, err
-	}
-	responseCache.Set(cacheKey, avgTemp, cache.DefaultExpiration)
+// GetCurrentWindSpeed ...
+func (c *clientWrapper) GetCurrentWindSpeed() (float32, error) {
+	return c.cached("GetCurrentWindSpeed", cacheKey(c.Config.ID, "wind_speed"), c.Client.GetCurrentWindSpeed)
+}
+
+// GetForecastRain ...
+func (c *clientWrapper) GetForecastRain(within time.Duration) (float32, error) {
+	return c.cached("GetForecastRain", cacheKey(c.Config.ID, "forecast_rain_%s", within), func() (float32, error) {
+		return c.Client.GetForecastRain(within)
+	})
+}
+
+// GetForecastRainProbability ...
+func (c *clientWrapper) GetForecastRainProbability(within time.Duration) (float32, error) {
+	return c.cached("GetForecastRainProbability", cacheKey(c.Config.ID, "forecast_rain_probability_%s", within), func() (float32, error) {
+		return c.Client.GetForecastRainProbability(within)
+	})
+}
+
+// GetForecastLowTemperature ...
+func (c *clientWrapper) GetForecastLowTemperature(within time.Duration) (float32, error) {
+	return c.cached("GetForecastLowTemperature", cacheKey(c.Config.ID, "forecast_low_temp_%s", within), func() (float32, error) {
+		return c.Client.GetForecastLowTemperature(within)
+	})
+}
 
-	return avgTemp, nil
+// GetMeasurement ...
+func (c *clientWrapper) GetMeasurement(q measurement.Query) (float32, error) {
+	return c.cached("GetMeasurement", cacheKey(c.Config.ID, "measurement_%s", q), func() (float32, error) {
+		return c.Client.GetMeasurement(q)
+	})
 }
 
-func ResetCache() {
-	responseCache = cache.New(5*time.Minute, 1*time.Minute)
+// GetHistory is not cached since history is stored separately
+func (c *clientWrapper) GetHistory(start, end time.Time, resolution time.Duration) ([]*history.Observation, error) {
+	now := time.Now()
+	defer func() {
+		weatherClientSummary.WithLabelValues("GetHistory", "false").Observe(time.Since(now).Seconds())
+	}()
+
+	return c.Client.GetHistory(start, end, resolution)
 }
@@ -34,7 +34,7 @@ func TestConfigPatch(t *testing.T) {
 }
 
 func TestNewWeatherClientInvalidType(t *testing.T) {
-	_, err := NewClient(&Config{Type: "DNE"}, func(m map[string]interface{}) error { return nil })
+	_, err := NewClient(&Config{Type: "DNE"}, func(m map[string]interface{}) error { return nil }, nil)
 	assert.Error(t, err)
 	assert.Equal(t, "invalid type 'DNE'", err.Error())
 }
@@ -47,7 +47,7 @@ func TestCachedWeatherClient(t *testing.T) {
 			"rain_interval":        "24h",
 			"avg_high_temperature": 40,
 		},
-	}, func(m map[string]interface{}) error { return nil })
+	}, func(m map[string]interface{}) error { return nil }, nil)
 	assert.NoError(t, err)
 	assert.NotNil(t, client)
 
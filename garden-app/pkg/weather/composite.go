@@ -0,0 +1,214 @@
+package weather
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather/history"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather/measurement"
+	"github.com/mitchellh/mapstructure"
+	"github.com/rs/xid"
+)
+
+// CompositeType is the Config Type for a client that combines other WeatherClients
+const CompositeType = "composite"
+
+// CompositeStrategy determines how results from a composite client's sub-clients are combined
+type CompositeStrategy string
+
+const (
+	// StrategyFirstHealthy uses the result from the first sub-client that does not return an error
+	StrategyFirstHealthy CompositeStrategy = "first_healthy"
+	// StrategyAverage uses the average result from all sub-clients that do not return an error
+	StrategyAverage CompositeStrategy = "average"
+	// StrategyMaxRain uses the highest rain, forecast rain, and rain probability from all sub-clients that do not
+	// return an error. Other values use the first healthy sub-client
+	StrategyMaxRain CompositeStrategy = "max_rain"
+)
+
+// CompositeConfig is specific to the composite client. ClientIDs are the IDs of other WeatherClients, in order of
+// preference. Strategy defaults to StrategyFirstHealthy
+type CompositeConfig struct {
+	ClientIDs []string          `mapstructure:"client_ids"`
+	Strategy  CompositeStrategy `mapstructure:"strategy"`
+	clientIDs []xid.ID
+}
+
+// compositeClient combines results from other WeatherClients so Weather Controls keep working when one of them is
+// unavailable. Sub-clients are looked up for each request so they always use their most recent configuration
+type compositeClient struct {
+	*CompositeConfig
+	getClient func(xid.ID) (Client, error)
+}
+
+// NewCompositeConfig decodes and validates the options for a composite client
+func NewCompositeConfig(options map[string]interface{}) (*CompositeConfig, error) {
+	config := &CompositeConfig{}
+	err := mapstructure.Decode(options, config)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(config.ClientIDs) == 0 {
+		return nil, errors.New("client_ids must be provided")
+	}
+	for _, id := range config.ClientIDs {
+		clientID, err := xid.FromString(id)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client_id %q: %w", id, err)
+		}
+		config.clientIDs = append(config.clientIDs, clientID)
+	}
+
+	switch config.Strategy {
+	case "":
+		config.Strategy = StrategyFirstHealthy
+	case StrategyFirstHealthy, StrategyAverage, StrategyMaxRain:
+	default:
+		return nil, fmt.Errorf("invalid strategy %q, must be one of: first_healthy, average, max_rain", config.Strategy)
+	}
+
+	return config, nil
+}
+
+// IDs returns the parsed IDs of the sub-clients
+func (c *CompositeConfig) IDs() []xid.ID {
+	return c.clientIDs
+}
+
+func newCompositeClient(options map[string]interface{}, getClient func(xid.ID) (Client, error)) (*compositeClient, error) {
+	config, err := NewCompositeConfig(options)
+	if err != nil {
+		return nil, err
+	}
+	return &compositeClient{config, getClient}, nil
+}
+
+// GetTotalRain combines total rain from the sub-clients
+func (c *compositeClient) GetTotalRain(since time.Duration) (float32, error) {
+	return c.combine(true, func(client Client) (float32, error) {
+		return client.GetTotalRain(since)
+	})
+}
+
+// GetAverageHighTemperature combines average high temperatures from the sub-clients
+func (c *compositeClient) GetAverageHighTemperature(since time.Duration) (float32, error) {
+	return c.combine(false, func(client Client) (float32, error) {
+		return client.GetAverageHighTemperature(since)
+	})
+}
+
+// GetCurrentTemperature combines current temperatures from the sub-clients
+func (c *compositeClient) GetCurrentTemperature() (float32, error) {
+	return c.combine(false, Client.GetCurrentTemperature)
+}
+
+// GetCurrentWindSpeed combines current wind speeds from the sub-clients
+func (c *compositeClient) GetCurrentWindSpeed() (float32, error) {
+	return c.combine(false, Client.GetCurrentWindSpeed)
+}
+
+// GetForecastRain combines forecast rain from the sub-clients
+func (c *compositeClient) GetForecastRain(within time.Duration) (float32, error) {
+	return c.combine(true, func(client Client) (float32, error) {
+		return client.GetForecastRain(within)
+	})
+}
+
+// GetForecastRainProbability combines forecast rain probabilities from the sub-clients
+func (c *compositeClient) GetForecastRainProbability(within time.Duration) (float32, error) {
+	return c.combine(true, func(client Client) (float32, error) {
+		return client.GetForecastRainProbability(within)
+	})
+}
+
+// GetForecastLowTemperature combines forecast low temperatures from the sub-clients
+func (c *compositeClient) GetForecastLowTemperature(within time.Duration) (float32, error) {
+	return c.combine(false, func(client Client) (float32, error) {
+		return client.GetForecastLowTemperature(within)
+	})
+}
+
+// GetMeasurement combines measurements from the sub-clients. The max_rain strategy only applies to rain measurements
+func (c *compositeClient) GetMeasurement(q measurement.Query) (float32, error) {
+	return c.combine(q.Type == measurement.Rain, func(client Client) (float32, error) {
+		return client.GetMeasurement(q)
+	})
+}
+
+// GetHistory uses the first healthy sub-client for all strategies since histories can't be combined
+func (c *compositeClient) GetHistory(start, end time.Time, resolution time.Duration) ([]*history.Observation, error) {
+	var errs []string
+	for _, id := range c.clientIDs {
+		if c.getClient == nil {
+			return nil, errors.New("unable to get weather client")
+		}
+		client, err := c.getClient(id)
+		if err == nil {
+			var result []*history.Observation
+			result, err = client.GetHistory(start, end, resolution)
+			if err == nil {
+				return result, nil
+			}
+		}
+		errs = append(errs, fmt.Sprintf("%s: %v", id, err))
+	}
+	return nil, fmt.Errorf("all weather clients failed: %s", strings.Join(errs, "; "))
+}
+
+// combine gets a value from the sub-clients using the configured strategy. isRain determines if StrategyMaxRain uses
+// the highest value or the first healthy one
+func (c *compositeClient) combine(isRain bool, get func(Client) (float32, error)) (float32, error) {
+	strategy := c.Strategy
+	if strategy == StrategyMaxRain && !isRain {
+		strategy = StrategyFirstHealthy
+	}
+
+	var results []float32
+	var errs []string
+	for _, id := range c.clientIDs {
+		result, err := c.get(id, get)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", id, err))
+			continue
+		}
+		if strategy == StrategyFirstHealthy {
+			return result, nil
+		}
+		results = append(results, result)
+	}
+
+	if len(results) == 0 {
+		return 0, fmt.Errorf("all weather clients failed: %s", strings.Join(errs, "; "))
+	}
+
+	combined := results[0]
+	switch strategy {
+	case StrategyAverage:
+		total := float32(0)
+		for _, r := range results {
+			total += r
+		}
+		combined = total / float32(len(results))
+	case StrategyMaxRain:
+		for _, r := range results {
+			if r > combined {
+				combined = r
+			}
+		}
+	}
+	return combined, nil
+}
+
+func (c *compositeClient) get(id xid.ID, get func(Client) (float32, error)) (float32, error) {
+	if c.getClient == nil {
+		return 0, errors.New("unable to get weather client")
+	}
+	client, err := c.getClient(id)
+	if err != nil {
+		return 0, err
+	}
+	return get(client)
+}
@@ -0,0 +1,172 @@
+package weather
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather/measurement"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCompositeConfig(t *testing.T) {
+	id := xid.New()
+	tests := []struct {
+		name             string
+		options          map[string]interface{}
+		expectedStrategy CompositeStrategy
+		expectedError    string
+	}{
+		{
+			"SuccessfulDefaultStrategy",
+			map[string]interface{}{"client_ids": []string{id.String()}},
+			StrategyFirstHealthy,
+			"",
+		},
+		{
+			"SuccessfulAverage",
+			map[string]interface{}{"client_ids": []interface{}{id.String()}, "strategy": "average"},
+			StrategyAverage,
+			"",
+		},
+		{
+			"MissingClientIDs",
+			map[string]interface{}{"strategy": "average"},
+			"",
+			"client_ids must be provided",
+		},
+		{
+			"InvalidClientID",
+			map[string]interface{}{"client_ids": []string{"abc"}},
+			"",
+			`invalid client_id "abc": xid: invalid ID`,
+		},
+		{
+			"InvalidStrategy",
+			map[string]interface{}{"client_ids": []string{id.String()}, "strategy": "min_rain"},
+			"",
+			`invalid strategy "min_rain", must be one of: first_healthy, average, max_rain`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := NewCompositeConfig(tt.options)
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStrategy, config.Strategy)
+			assert.Equal(t, []xid.ID{id}, config.IDs())
+		})
+	}
+}
+
+func TestCompositeClient(t *testing.T) {
+	failingID, lowID, highID, missingID := xid.New(), xid.New(), xid.New(), xid.New()
+
+	humidityQuery := measurement.Query{Type: measurement.Humidity, Aggregation: measurement.Average, Window: time.Hour}
+
+	newClients := func(t *testing.T) map[xid.ID]Client {
+		failing := NewMockClient(t)
+		failing.On("GetTotalRain", 24*time.Hour).Return(float32(0), errors.New("api is down")).Maybe()
+		failing.On("GetCurrentTemperature").Return(float32(0), errors.New("api is down")).Maybe()
+		failing.On("GetMeasurement", humidityQuery).Return(float32(0), errors.New("api is down")).Maybe()
+
+		low := NewMockClient(t)
+		low.On("GetTotalRain", 24*time.Hour).Return(float32(2), nil).Maybe()
+		low.On("GetCurrentTemperature").Return(float32(20), nil).Maybe()
+		low.On("GetMeasurement", humidityQuery).Return(float32(40), nil).Maybe()
+
+		high := NewMockClient(t)
+		high.On("GetTotalRain", 24*time.Hour).Return(float32(6), nil).Maybe()
+		high.On("GetCurrentTemperature").Return(float32(30), nil).Maybe()
+		high.On("GetMeasurement", humidityQuery).Return(float32(60), nil).Maybe()
+
+		return map[xid.ID]Client{failingID: failing, lowID: low, highID: high}
+	}
+
+	tests := []struct {
+		name                string
+		strategy            CompositeStrategy
+		clientIDs           []xid.ID
+		expectedRain        float32
+		expectedTemperature float32
+		expectedHumidity    float32
+		expectedError       string
+	}{
+		{
+			"FirstHealthy",
+			StrategyFirstHealthy,
+			[]xid.ID{failingID, highID, lowID},
+			6,
+			30,
+			60,
+			"",
+		},
+		{
+			"Average",
+			StrategyAverage,
+			[]xid.ID{failingID, highID, lowID},
+			4,
+			25,
+			50,
+			"",
+		},
+		{
+			"MaxRainUsesFirstHealthyForOtherValues",
+			StrategyMaxRain,
+			[]xid.ID{failingID, lowID, highID},
+			6,
+			20,
+			40,
+			"",
+		},
+		{
+			"AllFailed",
+			StrategyAverage,
+			[]xid.ID{failingID, missingID},
+			0,
+			0,
+			0,
+			fmt.Sprintf("all weather clients failed: %s: api is down; %s: weather client config not found", failingID, missingID),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clients := newClients(t)
+			client := &compositeClient{
+				&CompositeConfig{Strategy: tt.strategy, clientIDs: tt.clientIDs},
+				func(id xid.ID) (Client, error) {
+					client, ok := clients[id]
+					if !ok {
+						return nil, errors.New("weather client config not found")
+					}
+					return client, nil
+				},
+			}
+
+			rain, err := client.GetTotalRain(24 * time.Hour)
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedRain, rain)
+
+			temperature, err := client.GetCurrentTemperature()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedTemperature, temperature)
+
+			humidity, err := client.GetMeasurement(humidityQuery)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedHumidity, humidity)
+		})
+	}
+}
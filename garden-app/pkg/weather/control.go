@@ -1,12 +1,28 @@
 package weather
 
-import "github.com/rs/xid"
+import (
+	"time"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/duration"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/influxdb"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/units"
+	"github.com/rs/xid"
+)
 
 // Control defines certain parameters and behaviors to influence watering patterns based off weather data
+// SensorTemperature and SensorHumidity use data from the Garden's own temperature and humidity sensor instead of a
+// weather Client, so their ClientID is not used
+// Freeze, Wind, and Heat are threshold rules that can skip watering or add an extra watering instead of scaling
 type Control struct {
-	Rain         *ScaleControl        `json:"rain_control,omitempty"`
-	SoilMoisture *SoilMoistureControl `json:"moisture_control,omitempty"`
-	Temperature  *ScaleControl        `json:"temperature_control,omitempty"`
+	Rain              *ScaleControl        `json:"rain_control,omitempty"`
+	SoilMoisture      *SoilMoistureControl `json:"moisture_control,omitempty"`
+	Temperature       *ScaleControl        `json:"temperature_control,omitempty"`
+	SensorTemperature *ScaleControl        `json:"sensor_temperature_control,omitempty"`
+	SensorHumidity    *ScaleControl        `json:"sensor_humidity_control,omitempty"`
+	Freeze            *ThresholdControl    `json:"freeze_control,omitempty"`
+	Wind              *ThresholdControl    `json:"wind_control,omitempty"`
+	Heat              *HeatControl         `json:"heat_control,omitempty"`
+	RainForecast      *RainForecastControl `json:"rain_forecast_control,omitempty"`
 }
 
 // Patch allows modifying the struct in-place with values from a different instance
@@ -21,9 +37,7 @@ func (wc *Control) Patch(new *Control) {
 		if wc.SoilMoisture == nil {
 			wc.SoilMoisture = &SoilMoistureControl{}
 		}
-		if new.SoilMoisture.MinimumMoisture != nil {
-			wc.SoilMoisture.MinimumMoisture = new.SoilMoisture.MinimumMoisture
-		}
+		wc.SoilMoisture.Patch(new.SoilMoisture)
 	}
 	if new.Temperature != nil {
 		if wc.Temperature == nil {
@@ -31,13 +45,296 @@ func (wc *Control) Patch(new *Control) {
 		}
 		wc.Temperature.Patch(new.Temperature)
 	}
+	if new.SensorTemperature != nil {
+		if wc.SensorTemperature == nil {
+			wc.SensorTemperature = &ScaleControl{}
+		}
+		wc.SensorTemperature.Patch(new.SensorTemperature)
+	}
+	if new.SensorHumidity != nil {
+		if wc.SensorHumidity == nil {
+			wc.SensorHumidity = &ScaleControl{}
+		}
+		wc.SensorHumidity.Patch(new.SensorHumidity)
+	}
+	if new.Freeze != nil {
+		if wc.Freeze == nil {
+			wc.Freeze = &ThresholdControl{}
+		}
+		wc.Freeze.Patch(new.Freeze)
+	}
+	if new.Wind != nil {
+		if wc.Wind == nil {
+			wc.Wind = &ThresholdControl{}
+		}
+		wc.Wind.Patch(new.Wind)
+	}
+	if new.Heat != nil {
+		if wc.Heat == nil {
+			wc.Heat = &HeatControl{}
+		}
+		wc.Heat.Patch(new.Heat)
+	}
+	if new.RainForecast != nil {
+		if wc.RainForecast == nil {
+			wc.RainForecast = &RainForecastControl{}
+		}
+		wc.RainForecast.Patch(new.RainForecast)
+	}
+}
+
+// ClientIDs returns the ID of the weather Client used by each of the Control's rules. An ID is included once for
+// each rule that uses it
+func (wc *Control) ClientIDs() []xid.ID {
+	ids := []xid.ID{}
+	add := func(id xid.ID) {
+		if !id.IsNil() {
+			ids = append(ids, id)
+		}
+	}
+
+	if wc.Rain != nil {
+		add(wc.Rain.ClientID)
+	}
+	if wc.Temperature != nil {
+		add(wc.Temperature.ClientID)
+	}
+	if wc.Freeze != nil {
+		add(wc.Freeze.ClientID)
+	}
+	if wc.Wind != nil {
+		add(wc.Wind.ClientID)
+	}
+	if wc.Heat != nil {
+		add(wc.Heat.ClientID)
+	}
+	if wc.RainForecast != nil {
+		add(wc.RainForecast.ClientID)
+	}
+	return ids
 }
 
 // SoilMoistureControl defines parameters for delaying watering based on soil moisture data. This will skip watering if the
 // soil moisture is below the minimum
-// soil moisture value is currently hard-coded as the average value over the last 15 minutes
+// soil moisture value is read using the Aggregation of data in the Window, which default to the average value over
+// the last 15 minutes
+//
+// When TargetMoisture is set, watering is done in a closed loop: the Zone is watered in pulses of PulseDuration, then waits
+// for SoakDuration before reading the moisture again. This continues until TargetMoisture is reached or the total watering
+// time reaches MaxDuration. If MaxDuration is not set, the WaterSchedule's (scaled) Duration is used as the maximum
 type SoilMoistureControl struct {
-	MinimumMoisture *int `json:"minimum_moisture,omitempty"`
+	MinimumMoisture *int               `json:"minimum_moisture,omitempty"`
+	TargetMoisture  *int               `json:"target_moisture,omitempty"`
+	PulseDuration   *duration.Duration `json:"pulse_duration,omitempty"`
+	SoakDuration    *duration.Duration `json:"soak_duration,omitempty"`
+	MaxDuration     *duration.Duration `json:"max_duration,omitempty"`
+
+	Window      *duration.Duration   `json:"window,omitempty"`
+	Aggregation influxdb.Aggregation `json:"aggregation,omitempty"`
+}
+
+// Patch allows modifying the struct in-place with values from a different instance
+func (smc *SoilMoistureControl) Patch(new *SoilMoistureControl) {
+	if new.MinimumMoisture != nil {
+		smc.MinimumMoisture = new.MinimumMoisture
+	}
+	if new.TargetMoisture != nil {
+		smc.TargetMoisture = new.TargetMoisture
+	}
+	if new.PulseDuration != nil {
+		smc.PulseDuration = new.PulseDuration
+	}
+	if new.SoakDuration != nil {
+		smc.SoakDuration = new.SoakDuration
+	}
+	if new.MaxDuration != nil {
+		smc.MaxDuration = new.MaxDuration
+	}
+	if new.Window != nil {
+		smc.Window = new.Window
+	}
+	if new.Aggregation != "" {
+		smc.Aggregation = new.Aggregation
+	}
+}
+
+// QueryWindow returns the configured time window for reading moisture data. If it is not configured, zero is
+// returned so the InfluxDB client will use its default
+func (smc *SoilMoistureControl) QueryWindow() time.Duration {
+	if smc.Window == nil {
+		return 0
+	}
+	return smc.Window.Duration
+}
+
+// IsClosedLoop returns true if the control is configured to water in pulses until reaching the TargetMoisture
+func (smc *SoilMoistureControl) IsClosedLoop() bool {
+	return smc.TargetMoisture != nil && smc.PulseDuration != nil
+}
+
+// DefaultFreezeForecastWindow is how far ahead Freeze checks the forecast low temperature if Window is not set
+const DefaultFreezeForecastWindow = 24 * time.Hour
+
+// ThresholdControl is used to skip watering when a weather value crosses the Threshold. The direction depends on
+// the rule: Freeze skips when the lower of the current and forecast low temperature (Celsius) within Window is below
+// the Threshold and Wind skips when current wind speed (km/h) is above it. Window is only used by Freeze. Unit is
+// optional and allows Freeze to use a Threshold in Fahrenheit
+type ThresholdControl struct {
+	Threshold *float32           `json:"threshold"`
+	Window    *duration.Duration `json:"window,omitempty"`
+	Unit      units.Unit         `json:"unit,omitempty"`
+	ClientID  xid.ID             `json:"client_id"`
+}
+
+// Patch allows modifying the struct in-place with values from a different instance
+func (tc *ThresholdControl) Patch(new *ThresholdControl) {
+	if new.Threshold != nil {
+		tc.Threshold = new.Threshold
+	}
+	if new.Window != nil {
+		tc.Window = new.Window
+	}
+	if new.Unit != "" {
+		tc.Unit = new.Unit
+	}
+	if !new.ClientID.IsNil() {
+		tc.ClientID = new.ClientID
+	}
+}
+
+// ForecastWindow returns the configured Window or DefaultFreezeForecastWindow
+func (tc *ThresholdControl) ForecastWindow() time.Duration {
+	if tc.Window == nil {
+		return DefaultFreezeForecastWindow
+	}
+	return tc.Window.Duration
+}
+
+// LowestTemperature returns the lower of the Client's current temperature and its forecast low temperature within
+// the period. If the Client does not provide a forecast, the current temperature is used
+func LowestTemperature(client Client, within time.Duration) (float32, error) {
+	current, err := client.GetCurrentTemperature()
+	if err != nil {
+		return 0, err
+	}
+
+	forecastLow, err := client.GetForecastLowTemperature(within)
+	if err != nil || forecastLow > current {
+		return current, nil
+	}
+	return forecastLow, nil
+}
+
+// MetricThreshold returns the Threshold converted from the Unit to the metric unit used by weather data
+func (tc *ThresholdControl) MetricThreshold() float32 {
+	return toMetric(*tc.Threshold, tc.Unit)
+}
+
+// IsBelow returns true if the value is below the Threshold
+func (tc *ThresholdControl) IsBelow(actualValue float32) bool {
+	return actualValue < tc.MetricThreshold()
+}
+
+// IsAbove returns true if the value is above the Threshold
+func (tc *ThresholdControl) IsAbove(actualValue float32) bool {
+	return actualValue > tc.MetricThreshold()
+}
+
+// HeatControl is used to add an emergency extra watering when the current temperature (Celsius) is above the
+// Threshold. The temperature is checked periodically between regular waterings and at most one extra watering
+// happens per WaterSchedule interval. If Duration is not set, the WaterSchedule's Duration is used. Unit is optional
+// and allows using a Threshold in Fahrenheit
+type HeatControl struct {
+	Threshold *float32           `json:"threshold"`
+	Duration  *duration.Duration `json:"duration,omitempty"`
+	Unit      units.Unit         `json:"unit,omitempty"`
+	ClientID  xid.ID             `json:"client_id"`
+}
+
+// Patch allows modifying the struct in-place with values from a different instance
+func (hc *HeatControl) Patch(new *HeatControl) {
+	if new.Threshold != nil {
+		hc.Threshold = new.Threshold
+	}
+	if new.Duration != nil {
+		hc.Duration = new.Duration
+	}
+	if new.Unit != "" {
+		hc.Unit = new.Unit
+	}
+	if !new.ClientID.IsNil() {
+		hc.ClientID = new.ClientID
+	}
+}
+
+// MetricThreshold returns the Threshold converted from the Unit to Celsius
+func (hc *HeatControl) MetricThreshold() float32 {
+	return toMetric(*hc.Threshold, hc.Unit)
+}
+
+// IsAbove returns true if the value is above the Threshold
+func (hc *HeatControl) IsAbove(actualValue float32) bool {
+	return actualValue > hc.MetricThreshold()
+}
+
+// RainForecastControl is used to skip or reduce watering when rain is forecast in the next Window. The forecast is
+// ignored if the probability of rain is less than MinimumProbability (percent). If the forecast rain (mm) is at least
+// SkipThreshold, watering is skipped. Otherwise, if Factor and Range are set, watering is scaled down proportionally
+// to forecast rain, the same way as rain_control with a baseline of zero. Unit is optional and allows using
+// SkipThreshold and Range in inches
+type RainForecastControl struct {
+	Window             *duration.Duration `json:"window"`
+	MinimumProbability *float32           `json:"minimum_probability,omitempty"`
+	SkipThreshold      *float32           `json:"skip_threshold,omitempty"`
+	Factor             *float32           `json:"factor,omitempty"`
+	Range              *float32           `json:"range,omitempty"`
+	Unit               units.Unit         `json:"unit,omitempty"`
+	ClientID           xid.ID             `json:"client_id"`
+}
+
+// Patch allows modifying the struct in-place with values from a different instance
+func (rfc *RainForecastControl) Patch(new *RainForecastControl) {
+	if new.Window != nil {
+		rfc.Window = new.Window
+	}
+	if new.MinimumProbability != nil {
+		rfc.MinimumProbability = new.MinimumProbability
+	}
+	if new.SkipThreshold != nil {
+		rfc.SkipThreshold = new.SkipThreshold
+	}
+	if new.Factor != nil {
+		rfc.Factor = new.Factor
+	}
+	if new.Range != nil {
+		rfc.Range = new.Range
+	}
+	if new.Unit != "" {
+		rfc.Unit = new.Unit
+	}
+	if !new.ClientID.IsNil() {
+		rfc.ClientID = new.ClientID
+	}
+}
+
+// IsLikely returns true if the probability of rain is high enough for the forecast to be used
+func (rfc *RainForecastControl) IsLikely(probability float32) bool {
+	return rfc.MinimumProbability == nil || probability >= *rfc.MinimumProbability
+}
+
+// ShouldSkip returns true if the forecast rain is at least the SkipThreshold
+func (rfc *RainForecastControl) ShouldSkip(rainMM float32) bool {
+	return rfc.SkipThreshold != nil && rainMM >= toMetric(*rfc.SkipThreshold, rfc.Unit)
+}
+
+// Scale returns the multiplier for the forecast rain. If Factor or Range are not set, watering is not scaled
+func (rfc *RainForecastControl) Scale(rainMM float32) float32 {
+	if rfc.Factor == nil || rfc.Range == nil {
+		return 1
+	}
+	baseline := float32(0)
+	sc := &ScaleControl{BaselineValue: &baseline, Factor: rfc.Factor, Range: rfc.Range, Unit: rfc.Unit}
+	return sc.InvertedScaleDownOnly(rainMM)
 }
 
 // ScaleControl is a generic struct that enables scaling
@@ -56,14 +353,18 @@ type SoilMoistureControl struct {
 //   - Input  60 degrees: ( 60 - 90)/30 * 0.5 + 1 = 0.5 => water 15m
 //   - Input  50 degrees: ( 50 - 90)/30 * 0.5 + 1 = 0.3333333333 => less than factor of 0.5, so we round up to 0.5
 //
+// Unit is optional and is the unit of BaselineValue and Range. Weather data is always in Celsius or millimeters, so
+// values in Fahrenheit or inches are converted before scaling
+//
 // Basically, a Factor of 0.5 means that if watering is set at 30m, I want to water at most 45 min and at least 15 min
 // This way, the control doesn't need to know anything about the durations and can just return a multiplier that
 // makes this happen
 type ScaleControl struct {
-	BaselineValue *float32 `json:"baseline_value"`
-	Factor        *float32 `json:"factor"`
-	Range         *float32 `json:"range"`
-	ClientID      xid.ID   `json:"client_id"`
+	BaselineValue *float32   `json:"baseline_value"`
+	Factor        *float32   `json:"factor"`
+	Range         *float32   `json:"range"`
+	Unit          units.Unit `json:"unit,omitempty"`
+	ClientID      xid.ID     `json:"client_id"`
 }
 
 // Patch allows modifying the struct in-place with values from a different instance
@@ -77,15 +378,42 @@ func (sc *ScaleControl) Patch(new *ScaleControl) {
 	if new.Range != nil {
 		sc.Range = new.Range
 	}
+	if new.Unit != "" {
+		sc.Unit = new.Unit
+	}
 	if !new.ClientID.IsNil() {
 		sc.ClientID = new.ClientID
 	}
 }
 
+// metricValues returns the BaselineValue and Range converted from the Unit to the metric unit used by weather data
+func (sc *ScaleControl) metricValues() (baseline, r float32) {
+	switch sc.Unit {
+	case units.Fahrenheit:
+		return units.FahrenheitToCelsius(*sc.BaselineValue), units.FahrenheitDifferenceToCelsius(*sc.Range)
+	case units.Inches:
+		return units.InchesToMillimeters(*sc.BaselineValue), units.InchesToMillimeters(*sc.Range)
+	default:
+		return *sc.BaselineValue, *sc.Range
+	}
+}
+
+// toMetric converts a threshold value from the Unit to the metric unit used by weather data
+func toMetric(value float32, unit units.Unit) float32 {
+	switch unit {
+	case units.Fahrenheit:
+		return units.FahrenheitToCelsius(value)
+	case units.Inches:
+		return units.InchesToMillimeters(value)
+	default:
+		return value
+	}
+}
+
 // Scale calculates and returns the multiplier based on the input value
 func (sc *ScaleControl) Scale(actualValue float32) float32 {
-	diff := actualValue - *sc.BaselineValue
-	r := *sc.Range
+	baseline, r := sc.metricValues()
+	diff := actualValue - baseline
 	if diff > r {
 		diff = r
 	}
@@ -95,16 +423,24 @@ func (sc *ScaleControl) Scale(actualValue float32) float32 {
 	return (diff/r)*(*sc.Factor) + 1
 }
 
+// InvertedScale calculates and returns the multiplier based on the input value, but is inverted so higher input
+// values cause scaling < 1 and lower input values cause scaling > 1. This is useful for humidity, where drier air
+// should result in more watering
+func (sc *ScaleControl) InvertedScale(actualValue float32) float32 {
+	return 2 - sc.Scale(actualValue)
+}
+
 // InvertedScaleDownOnly calculates and returns the multiplier based on the input value, but is inverted
 // so higher input values cause scaling < 1. Also it will only scale in this direction
 func (sc *ScaleControl) InvertedScaleDownOnly(actualValue float32) float32 {
+	baseline, r := sc.metricValues()
+
 	// If the baseline is not reached, just scale 1
-	if actualValue < *sc.BaselineValue {
+	if actualValue < baseline {
 		return 1
 	}
 
-	diff := actualValue - *sc.BaselineValue
-	r := *sc.Range
+	diff := actualValue - baseline
 	if diff > r {
 		diff = r
 	}
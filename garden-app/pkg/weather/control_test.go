@@ -4,6 +4,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/duration"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/units"
 	"github.com/rs/xid"
 	"github.com/stretchr/testify/assert"
 )
@@ -54,6 +56,71 @@ func TestPatch(t *testing.T) {
 				},
 			},
 		},
+		{
+			"PatchSensorTemperature.BaselineValue",
+			&Control{
+				SensorTemperature: &ScaleControl{
+					BaselineValue: float32Pointer(25.4),
+				},
+			},
+		},
+		{
+			"PatchSensorHumidity.Range",
+			&Control{
+				SensorHumidity: &ScaleControl{
+					Range: float32Pointer(40),
+				},
+			},
+		},
+		{
+			"PatchFreeze.Threshold",
+			&Control{
+				Freeze: &ThresholdControl{
+					Threshold: float32Pointer(0),
+				},
+			},
+		},
+		{
+			"PatchFreeze.Unit",
+			&Control{
+				Freeze: &ThresholdControl{
+					Unit: units.Fahrenheit,
+				},
+			},
+		},
+		{
+			"PatchWind.ClientID",
+			&Control{
+				Wind: &ThresholdControl{
+					ClientID: xid.New(),
+				},
+			},
+		},
+		{
+			"PatchHeat",
+			&Control{
+				Heat: &HeatControl{
+					Threshold: float32Pointer(40),
+					Duration:  &duration.Duration{Duration: 10 * time.Minute},
+					Unit:      units.Fahrenheit,
+					ClientID:  xid.New(),
+				},
+			},
+		},
+		{
+			"PatchRainForecast",
+			&Control{
+				RainForecast: &RainForecastControl{
+					Window:             &duration.Duration{Duration: 6 * time.Hour},
+					MinimumProbability: float32Pointer(50),
+					SkipThreshold:      float32Pointer(5),
+					Factor:             float32Pointer(0),
+					Range:              float32Pointer(10),
+					Unit:               units.Inches,
+					ClientID:           xid.New(),
+				},
+			},
+		},
 		{
 			"PatchSoilMoisture.MinimumMoisture",
 			&Control{
@@ -62,6 +129,17 @@ func TestPatch(t *testing.T) {
 				},
 			},
 		},
+		{
+			"PatchSoilMoisture.ClosedLoop",
+			&Control{
+				SoilMoisture: &SoilMoistureControl{
+					TargetMoisture: &fifty,
+					PulseDuration:  &duration.Duration{Duration: time.Minute},
+					SoakDuration:   &duration.Duration{Duration: 10 * time.Minute},
+					MaxDuration:    &duration.Duration{Duration: time.Hour},
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -80,10 +158,34 @@ func TestPatch(t *testing.T) {
 			if tt.newControl.SoilMoisture == nil {
 				tt.newControl.SoilMoisture = &SoilMoistureControl{}
 			}
+			if tt.newControl.SensorTemperature == nil {
+				tt.newControl.SensorTemperature = &ScaleControl{}
+			}
+			if tt.newControl.SensorHumidity == nil {
+				tt.newControl.SensorHumidity = &ScaleControl{}
+			}
+			if tt.newControl.Freeze == nil {
+				tt.newControl.Freeze = &ThresholdControl{}
+			}
+			if tt.newControl.Wind == nil {
+				tt.newControl.Wind = &ThresholdControl{}
+			}
+			if tt.newControl.Heat == nil {
+				tt.newControl.Heat = &HeatControl{}
+			}
+			if tt.newControl.RainForecast == nil {
+				tt.newControl.RainForecast = &RainForecastControl{}
+			}
 			c := &Control{
-				Rain:         &ScaleControl{},
-				Temperature:  &ScaleControl{},
-				SoilMoisture: &SoilMoistureControl{},
+				Rain:              &ScaleControl{},
+				Temperature:       &ScaleControl{},
+				SoilMoisture:      &SoilMoistureControl{},
+				SensorTemperature: &ScaleControl{},
+				SensorHumidity:    &ScaleControl{},
+				Freeze:            &ThresholdControl{},
+				Wind:              &ThresholdControl{},
+				Heat:              &HeatControl{},
+				RainForecast:      &RainForecastControl{},
 			}
 			c.Patch(tt.newControl)
 			assert.Equal(t, tt.newControl, c)
@@ -91,6 +193,128 @@ func TestPatch(t *testing.T) {
 	}
 }
 
+func TestClientIDs(t *testing.T) {
+	rainClientID := xid.New()
+	tempClientID := xid.New()
+
+	c := &Control{
+		Rain:         &ScaleControl{ClientID: rainClientID},
+		Temperature:  &ScaleControl{ClientID: tempClientID},
+		Freeze:       &ThresholdControl{ClientID: tempClientID},
+		SoilMoisture: &SoilMoistureControl{},
+	}
+	assert.Equal(t, []xid.ID{rainClientID, tempClientID, tempClientID}, c.ClientIDs())
+	assert.Equal(t, []xid.ID{}, (&Control{}).ClientIDs())
+}
+
+func TestRainForecastControl(t *testing.T) {
+	tests := []struct {
+		name           string
+		rfc            RainForecastControl
+		probability    float32
+		rainMM         float32
+		expectedLikely bool
+		expectedSkip   bool
+		expectedScale  float32
+	}{
+		{
+			"NoMinimumProbabilityIsAlwaysLikely",
+			RainForecastControl{SkipThreshold: float32Pointer(5)},
+			0,
+			2,
+			true,
+			false,
+			1,
+		},
+		{
+			"BelowMinimumProbability",
+			RainForecastControl{MinimumProbability: float32Pointer(50), SkipThreshold: float32Pointer(5)},
+			40,
+			10,
+			false,
+			true,
+			1,
+		},
+		{
+			"SkipAtThreshold",
+			RainForecastControl{MinimumProbability: float32Pointer(50), SkipThreshold: float32Pointer(5)},
+			50,
+			5,
+			true,
+			true,
+			1,
+		},
+		{
+			"ScaleDownHalfRange",
+			RainForecastControl{Factor: float32Pointer(0), Range: float32Pointer(10)},
+			100,
+			5,
+			true,
+			false,
+			0.5,
+		},
+		{
+			"ScaleDownBeyondRange",
+			RainForecastControl{Factor: float32Pointer(0.5), Range: float32Pointer(10)},
+			100,
+			20,
+			true,
+			false,
+			0.5,
+		},
+		{
+			"SkipThresholdInInches",
+			RainForecastControl{SkipThreshold: float32Pointer(0.5), Unit: units.Inches},
+			100,
+			12.7,
+			true,
+			true,
+			1,
+		},
+		{
+			"BelowSkipThresholdInInches",
+			RainForecastControl{SkipThreshold: float32Pointer(0.5), Unit: units.Inches},
+			100,
+			10,
+			true,
+			false,
+			1,
+		},
+		{
+			"ScaleDownRangeInInches",
+			RainForecastControl{Factor: float32Pointer(0), Range: float32Pointer(1), Unit: units.Inches},
+			100,
+			12.7,
+			true,
+			false,
+			0.5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedLikely, tt.rfc.IsLikely(tt.probability))
+			assert.Equal(t, tt.expectedSkip, tt.rfc.ShouldSkip(tt.rainMM))
+			assert.Equal(t, tt.expectedScale, tt.rfc.Scale(tt.rainMM))
+		})
+	}
+}
+
+func TestThresholdWithUnit(t *testing.T) {
+	freeze := &ThresholdControl{Threshold: float32Pointer(32), Unit: units.Fahrenheit}
+	assert.InDelta(t, float32(0), freeze.MetricThreshold(), 0.0001)
+	assert.True(t, freeze.IsBelow(-1))
+	assert.False(t, freeze.IsBelow(1))
+
+	heat := &HeatControl{Threshold: float32Pointer(104), Unit: units.Fahrenheit}
+	assert.InDelta(t, float32(40), heat.MetricThreshold(), 0.0001)
+	assert.True(t, heat.IsAbove(41))
+	assert.False(t, heat.IsAbove(39))
+
+	metric := &ThresholdControl{Threshold: float32Pointer(32)}
+	assert.Equal(t, float32(32), metric.MetricThreshold())
+}
+
 func TestScale(t *testing.T) {
 	baseline := float32(90)
 	factor := float32(0.5)
@@ -156,6 +380,119 @@ func TestScale(t *testing.T) {
 	}
 }
 
+func TestScaleWithUnit(t *testing.T) {
+	tests := []struct {
+		name           string
+		unit           units.Unit
+		baseline       float32
+		r              float32
+		input          float32
+		expectedFactor float32
+	}{
+		{
+			"FahrenheitAtBaseline",
+			units.Fahrenheit,
+			86,
+			18,
+			30,
+			1,
+		},
+		{
+			"FahrenheitMaxScaleUp",
+			units.Fahrenheit,
+			86,
+			18,
+			40,
+			1.5,
+		},
+		{
+			"FahrenheitHalfScaleDown",
+			units.Fahrenheit,
+			86,
+			18,
+			25,
+			0.75,
+		},
+		{
+			"Inches",
+			units.Inches,
+			1,
+			1,
+			50.8,
+			1.5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			factor := float32(0.5)
+			sc := ScaleControl{
+				BaselineValue: &tt.baseline,
+				Factor:        &factor,
+				Range:         &tt.r,
+				Unit:          tt.unit,
+			}
+			assert.InDelta(t, tt.expectedFactor, sc.Scale(tt.input), 0.0001)
+		})
+	}
+}
+
+func TestInvertedScale(t *testing.T) {
+	sc := ScaleControl{
+		BaselineValue: float32Pointer(50),
+		Factor:        float32Pointer(0.5),
+		Range:         float32Pointer(40),
+	}
+
+	tests := []struct {
+		name             string
+		input            float32
+		expectedFactor   float32
+		expectedDuration time.Duration
+	}{
+		{
+			"BaselineNoChange",
+			50,
+			1,
+			30 * time.Minute,
+		},
+		{
+			"LowerValueScalesUp",
+			30,
+			1.25,
+			37*time.Minute + 30*time.Second,
+		},
+		{
+			"BeyondMaxScaleUp",
+			0,
+			1.5,
+			45 * time.Minute,
+		},
+		{
+			"HigherValueScalesDown",
+			70,
+			0.75,
+			22*time.Minute + 30*time.Second,
+		},
+		{
+			"BeyondMaxScaleDown",
+			100,
+			0.5,
+			15 * time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scale := sc.InvertedScale(tt.input)
+			assert.Equal(t, tt.expectedFactor, scale)
+			baseDuration := time.Minute * 30
+			scaledDuration := time.Duration(int64(float32(baseDuration) * scale)).Round(time.Second)
+			assert.Equal(t, tt.expectedDuration, scaledDuration)
+		})
+	}
+}
+
 func TestInvertedScaleDownOnly(t *testing.T) {
 	sc := ScaleControl{
 		BaselineValue: float32Pointer(25.4),
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"time"
 
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather/history"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather/measurement"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -14,6 +16,15 @@ type Config struct {
 	rainInterval time.Duration
 
 	AverageHighTemperature float32 `mapstructure:"avg_high_temperature"`
+	CurrentTemperature     float32 `mapstructure:"current_temperature"`
+	WindSpeed              float32 `mapstructure:"wind_speed"`
+	Humidity               float32 `mapstructure:"humidity"`
+	SolarRadiation         float32 `mapstructure:"solar_radiation"`
+
+	ForecastRainMM          float32 `mapstructure:"forecast_rain_mm"`
+	ForecastRainProbability float32 `mapstructure:"forecast_rain_probability"`
+	// ForecastLowTemperature defaults to CurrentTemperature if it is not configured
+	ForecastLowTemperature *float32 `mapstructure:"forecast_low_temperature"`
 
 	Error string `mapstructure:"error"`
 }
@@ -60,3 +71,106 @@ func (c *Client) GetAverageHighTemperature(_ time.Duration) (float32, error) {
 
 	return c.AverageHighTemperature, nil
 }
+
+// GetCurrentTemperature returns the configured value
+func (c *Client) GetCurrentTemperature() (float32, error) {
+	if c.Error != "" {
+		return 0, errors.New(c.Error)
+	}
+
+	return c.CurrentTemperature, nil
+}
+
+// GetCurrentWindSpeed returns the configured value
+func (c *Client) GetCurrentWindSpeed() (float32, error) {
+	if c.Error != "" {
+		return 0, errors.New(c.Error)
+	}
+
+	return c.WindSpeed, nil
+}
+
+// GetForecastRain returns the configured forecast rain, regardless of the time period
+func (c *Client) GetForecastRain(_ time.Duration) (float32, error) {
+	if c.Error != "" {
+		return 0, errors.New(c.Error)
+	}
+
+	return c.ForecastRainMM, nil
+}
+
+// GetForecastRainProbability returns the configured forecast rain probability, regardless of the time period
+func (c *Client) GetForecastRainProbability(_ time.Duration) (float32, error) {
+	if c.Error != "" {
+		return 0, errors.New(c.Error)
+	}
+
+	return c.ForecastRainProbability, nil
+}
+
+// GetForecastLowTemperature returns the configured forecast low temperature, regardless of the time period. If it is
+// not configured, the current temperature is used
+func (c *Client) GetForecastLowTemperature(_ time.Duration) (float32, error) {
+	if c.Error != "" {
+		return 0, errors.New(c.Error)
+	}
+
+	if c.ForecastLowTemperature == nil {
+		return c.CurrentTemperature, nil
+	}
+	return *c.ForecastLowTemperature, nil
+}
+
+// GetHistory returns the configured rain, scaled to the resolution, and the configured average high temperature for
+// each period
+func (c *Client) GetHistory(start, end time.Time, resolution time.Duration) ([]*history.Observation, error) {
+	if c.Error != "" {
+		return nil, errors.New(c.Error)
+	}
+
+	rainMM := float32(resolution.Hours()/c.rainInterval.Hours()) * c.RainMM
+
+	b := history.NewBuilder(start, end, resolution)
+	for t := start; t.Before(end); t = t.Add(resolution) {
+		b.AddRain(t, rainMM)
+		b.AddTemperature(t, c.AverageHighTemperature)
+	}
+	return b.Observations(), nil
+}
+
+// GetMeasurement treats the configured values as constant hourly readings, so a sum is the value for each hour in the
+// window and every other aggregation is the value itself. Rain is the configured amount spread over the rain interval
+// and the max temperature is the configured average high temperature
+func (c *Client) GetMeasurement(q measurement.Query) (float32, error) {
+	if c.Error != "" {
+		return 0, errors.New(c.Error)
+	}
+	if err := q.Validate(); err != nil {
+		return 0, err
+	}
+
+	var value float32
+	switch q.Type {
+	case measurement.Rain:
+		if q.Aggregation == measurement.Sum {
+			return c.GetTotalRain(q.Window)
+		}
+		return c.GetTotalRain(time.Hour)
+	case measurement.Temperature:
+		if q.Aggregation == measurement.Max {
+			return c.AverageHighTemperature, nil
+		}
+		value = c.CurrentTemperature
+	case measurement.Humidity:
+		value = c.Humidity
+	case measurement.WindSpeed:
+		value = c.WindSpeed
+	case measurement.SolarRadiation:
+		value = c.SolarRadiation
+	}
+
+	if q.Aggregation == measurement.Sum {
+		return value * float32(q.Window.Hours()), nil
+	}
+	return value, nil
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather/measurement"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -92,3 +93,120 @@ func TestGetTotalRain(t *testing.T) {
 		})
 	}
 }
+
+func TestGetCurrentConditions(t *testing.T) {
+	client, err := NewClient(map[string]interface{}{
+		"rain_interval":       "24h",
+		"current_temperature": -2,
+		"wind_speed":          30,
+	})
+	assert.NoError(t, err)
+
+	temp, err := client.GetCurrentTemperature()
+	assert.NoError(t, err)
+	assert.Equal(t, float32(-2), temp)
+
+	windSpeed, err := client.GetCurrentWindSpeed()
+	assert.NoError(t, err)
+	assert.Equal(t, float32(30), windSpeed)
+}
+
+func TestGetForecast(t *testing.T) {
+	client, err := NewClient(map[string]interface{}{
+		"rain_interval":             "24h",
+		"forecast_rain_mm":          12.5,
+		"forecast_rain_probability": 80,
+	})
+	assert.NoError(t, err)
+
+	forecastRain, err := client.GetForecastRain(6 * time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, float32(12.5), forecastRain)
+
+	probability, err := client.GetForecastRainProbability(6 * time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, float32(80), probability)
+}
+
+func TestGetForecastLowTemperature(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  map[string]interface{}
+		expected float32
+	}{
+		{"Configured", map[string]interface{}{"rain_interval": "24h", "current_temperature": 5, "forecast_low_temperature": -3}, -3},
+		{"DefaultsToCurrentTemperature", map[string]interface{}{"rain_interval": "24h", "current_temperature": 5}, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient(tt.options)
+			assert.NoError(t, err)
+
+			temperature, err := client.GetForecastLowTemperature(12 * time.Hour)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, temperature)
+		})
+	}
+}
+
+func TestGetHistory(t *testing.T) {
+	client, err := NewClient(map[string]interface{}{
+		"rain_mm":              24,
+		"rain_interval":        "24h",
+		"avg_high_temperature": 30,
+	})
+	assert.NoError(t, err)
+
+	start := time.Date(2023, time.August, 1, 0, 0, 0, 0, time.UTC)
+	observations, err := client.GetHistory(start, start.Add(3*time.Hour), time.Hour)
+	assert.NoError(t, err)
+	assert.Len(t, observations, 3)
+
+	for i, o := range observations {
+		assert.Equal(t, start.Add(time.Duration(i)*time.Hour), o.Time)
+		assert.Equal(t, float32(1), *o.RainMM)
+		assert.Equal(t, float32(30), *o.HighTemperature)
+	}
+}
+
+func TestGetMeasurement(t *testing.T) {
+	client, err := NewClient(map[string]interface{}{
+		"rain_mm":              24,
+		"rain_interval":        "24h",
+		"avg_high_temperature": 30,
+		"current_temperature":  20,
+		"humidity":             45,
+		"wind_speed":           10,
+		"solar_radiation":      500,
+	})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		query    measurement.Query
+		expected float32
+	}{
+		{"RainSum", measurement.Query{Type: measurement.Rain, Aggregation: measurement.Sum, Window: 48 * time.Hour}, 48},
+		{"RainMax", measurement.Query{Type: measurement.Rain, Aggregation: measurement.Max, Window: 48 * time.Hour}, 1},
+		{"TemperatureMax", measurement.Query{Type: measurement.Temperature, Aggregation: measurement.Max, Window: 24 * time.Hour}, 30},
+		{"TemperatureLast", measurement.Query{Type: measurement.Temperature, Aggregation: measurement.Last, Window: time.Hour}, 20},
+		{"HumidityAverage", measurement.Query{Type: measurement.Humidity, Aggregation: measurement.Average, Window: 24 * time.Hour}, 45},
+		{"WindSpeedMax", measurement.Query{Type: measurement.WindSpeed, Aggregation: measurement.Max, Window: time.Hour}, 10},
+		{"SolarRadiationSum", measurement.Query{Type: measurement.SolarRadiation, Aggregation: measurement.Sum, Window: 2 * time.Hour}, 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := client.GetMeasurement(tt.query)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+
+	t.Run("InvalidQuery", func(t *testing.T) {
+		_, err := client.GetMeasurement(measurement.Query{Type: "pressure", Aggregation: measurement.Max, Window: time.Hour})
+		assert.Error(t, err)
+		assert.Equal(t, `invalid measurement type "pressure"`, err.Error())
+	})
+}
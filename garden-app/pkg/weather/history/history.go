@@ -0,0 +1,68 @@
+package history
+
+import (
+	"time"
+)
+
+// Observation is the weather for one period of a history. Time is the start of the period. RainMM is the total rain
+// and HighTemperature is the highest temperature (Celsius) in the period. Values are nil if there is no data
+type Observation struct {
+	Time            time.Time `json:"time"`
+	RainMM          *float32  `json:"rain_mm,omitempty"`
+	HighTemperature *float32  `json:"high_temperature,omitempty"`
+}
+
+// Builder is used by weather clients to group measurements into evenly-sized periods
+type Builder struct {
+	start        time.Time
+	resolution   time.Duration
+	observations []*Observation
+}
+
+// NewBuilder creates a Builder with a period for each resolution between start (inclusive) and end (exclusive)
+func NewBuilder(start, end time.Time, resolution time.Duration) *Builder {
+	b := &Builder{start: start, resolution: resolution}
+	for t := start; t.Before(end); t = t.Add(resolution) {
+		b.observations = append(b.observations, &Observation{Time: t})
+	}
+	return b
+}
+
+// AddRain adds rain to the period containing the time. Measurements outside of the range are ignored
+func (b *Builder) AddRain(t time.Time, mm float32) {
+	o := b.period(t)
+	if o == nil {
+		return
+	}
+	if o.RainMM == nil {
+		o.RainMM = new(float32)
+	}
+	*o.RainMM += mm
+}
+
+// AddTemperature sets the period's high temperature if this is higher. Measurements outside of the range are ignored
+func (b *Builder) AddTemperature(t time.Time, celsius float32) {
+	o := b.period(t)
+	if o == nil {
+		return
+	}
+	if o.HighTemperature == nil || celsius > *o.HighTemperature {
+		o.HighTemperature = &celsius
+	}
+}
+
+// Observations returns all periods in order, including periods without data
+func (b *Builder) Observations() []*Observation {
+	return b.observations
+}
+
+func (b *Builder) period(t time.Time) *Observation {
+	if t.Before(b.start) {
+		return nil
+	}
+	i := int(t.Sub(b.start) / b.resolution)
+	if i >= len(b.observations) {
+		return nil
+	}
+	return b.observations[i]
+}
@@ -0,0 +1,33 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func float32Pointer(f float32) *float32 {
+	return &f
+}
+
+func TestBuilder(t *testing.T) {
+	start := time.Date(2023, time.August, 20, 0, 0, 0, 0, time.UTC)
+	b := NewBuilder(start, start.Add(72*time.Hour), 24*time.Hour)
+
+	b.AddRain(start.Add(2*time.Hour), 1)
+	b.AddRain(start.Add(20*time.Hour), 2.5)
+	b.AddRain(start.Add(-time.Hour), 100)
+	b.AddRain(start.Add(72*time.Hour), 100)
+
+	b.AddTemperature(start.Add(12*time.Hour), 30)
+	b.AddTemperature(start.Add(15*time.Hour), 35)
+	b.AddTemperature(start.Add(16*time.Hour), 32)
+	b.AddTemperature(start.Add(60*time.Hour), -2)
+
+	assert.Equal(t, []*Observation{
+		{Time: start, RainMM: float32Pointer(3.5), HighTemperature: float32Pointer(35)},
+		{Time: start.Add(24 * time.Hour)},
+		{Time: start.Add(48 * time.Hour), HighTemperature: float32Pointer(-2)},
+	}, b.Observations())
+}
@@ -0,0 +1,195 @@
+package influxdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/influxdb"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather/history"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather/measurement"
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	// queryTimeout is longer than influxdb.QueryTimeout since weather queries can cover multiple days of data
+	queryTimeout = 10 * time.Second
+
+	minTemperatureInterval = 72 * time.Hour
+	currentWindow          = time.Hour
+)
+
+// errForecastNotSupported is returned for forecast data because InfluxDB only has measurements from local sensors
+var errForecastNotSupported = errors.New("influxdb does not provide forecast data")
+
+// Config is specific to the InfluxDB weather client. InfluxDB uses the same options as the garden-app's influxdb
+// configuration, so it can be copied. Each weather measurement is configured with its own Source and only the ones
+// that are used by Weather Controls are required
+type Config struct {
+	InfluxDB    influxdb.Config `json:"influxdb" yaml:"influxdb" mapstructure:"influxdb"`
+	Rain        *Source         `json:"rain,omitempty" yaml:"rain,omitempty" mapstructure:"rain,omitempty"`
+	Temperature *Source         `json:"temperature,omitempty" yaml:"temperature,omitempty" mapstructure:"temperature,omitempty"`
+	Wind        *Source         `json:"wind,omitempty" yaml:"wind,omitempty" mapstructure:"wind,omitempty"`
+}
+
+// Source identifies the data for a weather measurement using the measurement name, field, and tags. Values are
+// expected to be metric: rain in millimeters per report, temperature in degrees Celsius, and wind speed in km/h
+type Source struct {
+	Measurement string            `json:"measurement" yaml:"measurement" mapstructure:"measurement"`
+	Field       string            `json:"field" yaml:"field" mapstructure:"field"`
+	Tags        map[string]string `json:"tags,omitempty" yaml:"tags,omitempty" mapstructure:"tags,omitempty"`
+}
+
+// Client is used to get weather data from local sensors that report to InfluxDB, such as a rain gauge or outdoor
+// thermometer using Telegraf
+type Client struct {
+	*Config
+	influxdb.Client
+}
+
+// NewClient creates a new InfluxDB weather client from configuration
+func NewClient(options map[string]interface{}) (*Client, error) {
+	client := &Client{}
+
+	err := mapstructure.Decode(options, &client.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	if client.InfluxDB.Address == "" {
+		return nil, errors.New("influxdb.address must be provided")
+	}
+	if client.InfluxDB.Bucket == "" {
+		return nil, errors.New("influxdb.bucket must be provided")
+	}
+	if client.Rain == nil && client.Temperature == nil && client.Wind == nil {
+		return nil, errors.New("at least one of rain, temperature, or wind must be provided")
+	}
+	for name, source := range map[string]*Source{"rain": client.Rain, "temperature": client.Temperature, "wind": client.Wind} {
+		if source == nil {
+			continue
+		}
+		err = source.validate()
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+
+	client.Client = influxdb.NewClient(client.InfluxDB)
+
+	return client, nil
+}
+
+// validate makes sure the Source has the required fields and that values can be safely used in Flux queries
+func (s *Source) validate() error {
+	if s.Measurement == "" {
+		return errors.New("measurement must be provided")
+	}
+	if s.Field == "" {
+		return errors.New("field must be provided")
+	}
+
+	values := []string{s.Measurement, s.Field}
+	for k, v := range s.Tags {
+		values = append(values, k, v)
+	}
+	for _, v := range values {
+		if strings.ContainsAny(v, `"\`) {
+			return fmt.Errorf("%q must not contain quotes or backslashes", v)
+		}
+	}
+	return nil
+}
+
+// GetTotalRain returns the sum of all rainfall in millimeters in the given period. Each value is expected to be the
+// amount of rain since the previous report
+func (c *Client) GetTotalRain(since time.Duration) (float32, error) {
+	if c.Rain == nil {
+		return 0, errors.New("rain is not configured")
+	}
+
+	result, err := c.query(c.Rain, since, false, "", influxdb.AggregationSum)
+	if errors.Is(err, influxdb.ErrNoData) {
+		// Rain gauges might only report when it is raining
+		return 0, nil
+	}
+	return result, err
+}
+
+// GetAverageHighTemperature returns the average daily high temperature between the given time and the end of
+// yesterday (since daily high can be misleading if queried mid-day)
+func (c *Client) GetAverageHighTemperature(since time.Duration) (float32, error) {
+	if c.Temperature == nil {
+		return 0, errors.New("temperature is not configured")
+	}
+
+	// Time to check since must always be at least 3 days
+	if since < minTemperatureInterval {
+		since = minTemperatureInterval
+	}
+
+	return c.query(c.Temperature, since, true, influxdb.AggregationMax, influxdb.AggregationMean)
+}
+
+// GetCurrentTemperature returns the most recent temperature in degrees Celsius from the last hour
+func (c *Client) GetCurrentTemperature() (float32, error) {
+	if c.Temperature == nil {
+		return 0, errors.New("temperature is not configured")
+	}
+	return c.query(c.Temperature, currentWindow, false, "", influxdb.AggregationLast)
+}
+
+// GetCurrentWindSpeed returns the most recent wind speed in km/h from the last hour
+func (c *Client) GetCurrentWindSpeed() (float32, error) {
+	if c.Wind == nil {
+		return 0, errors.New("wind is not configured")
+	}
+	return c.query(c.Wind, currentWindow, false, "", influxdb.AggregationLast)
+}
+
+// GetForecastRain is not supported by InfluxDB and always returns an error
+func (c *Client) GetForecastRain(_ time.Duration) (float32, error) {
+	return 0, errForecastNotSupported
+}
+
+// GetForecastRainProbability is not supported by InfluxDB and always returns an error
+func (c *Client) GetForecastRainProbability(_ time.Duration) (float32, error) {
+	return 0, errForecastNotSupported
+}
+
+// GetForecastLowTemperature is not supported by InfluxDB and always returns an error
+func (c *Client) GetForecastLowTemperature(_ time.Duration) (float32, error) {
+	return 0, errForecastNotSupported
+}
+
+// GetHistory is not supported since the data is already in InfluxDB and can be charted directly, so it always returns
+// an error
+func (c *Client) GetHistory(_, _ time.Time, _ time.Duration) ([]*history.Observation, error) {
+	return nil, errors.New("influxdb history is not supported, use InfluxDB or Grafana to chart this data")
+}
+
+func (c *Client) query(source *Source, window time.Duration, excludeToday bool, dailyAggregation, aggregation influxdb.Aggregation) (float32, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+	defer cancel()
+
+	result, err := c.Client.GetMeasurement(ctx, influxdb.MeasurementQuery{
+		Measurement:      source.Measurement,
+		Field:            source.Field,
+		Tags:             source.Tags,
+		Window:           window,
+		ExcludeToday:     excludeToday,
+		DailyAggregation: dailyAggregation,
+		Aggregation:      aggregation,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error querying %s.%s: %w", source.Measurement, source.Field, err)
+	}
+	return float32(result), nil
+}
+
+// GetMeasurement is not implemented for this client and always returns an error
+func (c *Client) GetMeasurement(q measurement.Query) (float32, error) {
+	return 0, measurement.NotSupportedError("influxdb", q)
+}
@@ -0,0 +1,218 @@
+package influxdb
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/influxdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestClient(t *testing.T) (*Client, *influxdb.MockClient) {
+	client, err := NewClient(map[string]interface{}{
+		"influxdb": map[string]interface{}{
+			"address": "http://localhost:8086",
+			"bucket":  "telegraf",
+		},
+		"rain": map[string]interface{}{
+			"measurement": "rain_gauge",
+			"field":       "mm",
+			"tags":        map[string]interface{}{"station": "backyard"},
+		},
+		"temperature": map[string]interface{}{
+			"measurement": "outdoor",
+			"field":       "temperature",
+		},
+	})
+	assert.NoError(t, err)
+
+	influxdbClient := influxdb.NewMockClient(t)
+	client.Client = influxdbClient
+	return client, influxdbClient
+}
+
+func TestNewClient(t *testing.T) {
+	influxdbOptions := map[string]interface{}{"address": "http://localhost:8086", "bucket": "telegraf"}
+	tests := []struct {
+		name          string
+		options       map[string]interface{}
+		expectedError string
+	}{
+		{
+			"Successful",
+			map[string]interface{}{
+				"influxdb": influxdbOptions,
+				"wind":     map[string]interface{}{"measurement": "anemometer", "field": "speed"},
+			},
+			"",
+		},
+		{
+			"MissingAddress",
+			map[string]interface{}{
+				"influxdb": map[string]interface{}{"bucket": "telegraf"},
+			},
+			"influxdb.address must be provided",
+		},
+		{
+			"MissingBucket",
+			map[string]interface{}{
+				"influxdb": map[string]interface{}{"address": "http://localhost:8086"},
+			},
+			"influxdb.bucket must be provided",
+		},
+		{
+			"MissingSources",
+			map[string]interface{}{"influxdb": influxdbOptions},
+			"at least one of rain, temperature, or wind must be provided",
+		},
+		{
+			"MissingMeasurement",
+			map[string]interface{}{
+				"influxdb": influxdbOptions,
+				"rain":     map[string]interface{}{"field": "mm"},
+			},
+			"invalid rain: measurement must be provided",
+		},
+		{
+			"MissingField",
+			map[string]interface{}{
+				"influxdb":    influxdbOptions,
+				"temperature": map[string]interface{}{"measurement": "outdoor"},
+			},
+			"invalid temperature: field must be provided",
+		},
+		{
+			"InvalidTag",
+			map[string]interface{}{
+				"influxdb": influxdbOptions,
+				"rain": map[string]interface{}{
+					"measurement": "rain_gauge",
+					"field":       "mm",
+					"tags":        map[string]interface{}{"station": `backyard") or (r`},
+				},
+			},
+			`invalid rain: "backyard\") or (r" must not contain quotes or backslashes`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewClient(tt.options)
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestGetTotalRain(t *testing.T) {
+	tests := []struct {
+		name          string
+		result        float64
+		err           error
+		expected      float32
+		expectedError string
+	}{
+		{
+			"Successful",
+			12.5,
+			nil,
+			12.5,
+			"",
+		},
+		{
+			"NoDataIsZero",
+			0,
+			influxdb.ErrNoData,
+			0,
+			"",
+		},
+		{
+			"Error",
+			0,
+			errors.New("influxdb error"),
+			0,
+			"error querying rain_gauge.mm: influxdb error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, influxdbClient := newTestClient(t)
+			influxdbClient.On("GetMeasurement", mock.Anything, influxdb.MeasurementQuery{
+				Measurement: "rain_gauge",
+				Field:       "mm",
+				Tags:        map[string]string{"station": "backyard"},
+				Window:      24 * time.Hour,
+				Aggregation: influxdb.AggregationSum,
+			}).Return(tt.result, tt.err)
+
+			rain, err := client.GetTotalRain(24 * time.Hour)
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, rain)
+		})
+	}
+}
+
+func TestGetAverageHighTemperature(t *testing.T) {
+	client, influxdbClient := newTestClient(t)
+	// minimum of 3 days is used
+	influxdbClient.On("GetMeasurement", mock.Anything, influxdb.MeasurementQuery{
+		Measurement:      "outdoor",
+		Field:            "temperature",
+		Window:           72 * time.Hour,
+		ExcludeToday:     true,
+		DailyAggregation: influxdb.AggregationMax,
+		Aggregation:      influxdb.AggregationMean,
+	}).Return(33.0, nil)
+
+	temperature, err := client.GetAverageHighTemperature(24 * time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, float32(33), temperature)
+}
+
+func TestGetAverageHighTemperatureNoData(t *testing.T) {
+	client, influxdbClient := newTestClient(t)
+	influxdbClient.On("GetMeasurement", mock.Anything, mock.Anything).Return(0.0, influxdb.ErrNoData)
+
+	_, err := client.GetAverageHighTemperature(72 * time.Hour)
+	assert.ErrorIs(t, err, influxdb.ErrNoData)
+}
+
+func TestGetCurrentTemperature(t *testing.T) {
+	client, influxdbClient := newTestClient(t)
+	influxdbClient.On("GetMeasurement", mock.Anything, influxdb.MeasurementQuery{
+		Measurement: "outdoor",
+		Field:       "temperature",
+		Window:      time.Hour,
+		Aggregation: influxdb.AggregationLast,
+	}).Return(-1.5, nil)
+
+	temperature, err := client.GetCurrentTemperature()
+	assert.NoError(t, err)
+	assert.Equal(t, float32(-1.5), temperature)
+}
+
+func TestNotConfigured(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	_, err := client.GetCurrentWindSpeed()
+	assert.Error(t, err)
+	assert.Equal(t, "wind is not configured", err.Error())
+
+	_, err = client.GetForecastRain(24 * time.Hour)
+	assert.ErrorIs(t, err, errForecastNotSupported)
+
+	_, err = client.GetForecastRainProbability(24 * time.Hour)
+	assert.ErrorIs(t, err, errForecastNotSupported)
+}
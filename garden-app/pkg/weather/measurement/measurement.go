@@ -0,0 +1,139 @@
+// Package measurement defines a generic query for weather data so clients can expose any measurement they support
+// without a new method for each one. It is separate from the weather package so clients can use it without an
+// import cycle
+package measurement
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Type is the kind of data to query
+type Type string
+
+const (
+	// Rain is precipitation in millimeters
+	Rain Type = "rain"
+	// Temperature is air temperature in degrees Celsius
+	Temperature Type = "temperature"
+	// Humidity is relative humidity as a percentage
+	Humidity Type = "humidity"
+	// WindSpeed is wind speed in km/h
+	WindSpeed Type = "wind_speed"
+	// SolarRadiation is solar radiation in W/m²
+	SolarRadiation Type = "solar_radiation"
+)
+
+// Aggregation is how values in the window are combined into one
+type Aggregation string
+
+const (
+	// Sum adds all values in the window
+	Sum Aggregation = "sum"
+	// Average is the mean of all values in the window
+	Average Aggregation = "avg"
+	// Min is the lowest value in the window
+	Min Aggregation = "min"
+	// Max is the highest value in the window
+	Max Aggregation = "max"
+	// Last is the most recent value in the window
+	Last Aggregation = "last"
+)
+
+var (
+	validTypes = map[Type]bool{
+		Rain:           true,
+		Temperature:    true,
+		Humidity:       true,
+		WindSpeed:      true,
+		SolarRadiation: true,
+	}
+	validAggregations = map[Aggregation]bool{
+		Sum:     true,
+		Average: true,
+		Min:     true,
+		Max:     true,
+		Last:    true,
+	}
+)
+
+// Query selects a Type of data from the Window before now and combines it with the Aggregation
+type Query struct {
+	Type        Type
+	Aggregation Aggregation
+	Window      time.Duration
+}
+
+// Validate returns an error if the Query has an unknown Type or Aggregation or a non-positive Window
+func (q Query) Validate() error {
+	if !validTypes[q.Type] {
+		return fmt.Errorf("invalid measurement type %q", q.Type)
+	}
+	if !validAggregations[q.Aggregation] {
+		return fmt.Errorf("invalid aggregation %q", q.Aggregation)
+	}
+	if q.Window <= 0 {
+		return errors.New("window must be a positive duration")
+	}
+	return nil
+}
+
+// String is used to identify a Query, for example in cache keys
+func (q Query) String() string {
+	return fmt.Sprintf("%s_%s_%s", q.Type, q.Aggregation, q.Window)
+}
+
+// NotSupportedError is returned by clients that do not have data for the Query
+func NotSupportedError(clientType string, q Query) error {
+	return fmt.Errorf("%s measurement with %s aggregation is not supported by %s", q.Type, q.Aggregation, clientType)
+}
+
+// Point is a single value at a time
+type Point struct {
+	Time  time.Time
+	Value float32
+}
+
+// Aggregate combines the points using the Aggregation. It returns an error if there are no points
+func Aggregate(points []Point, aggregation Aggregation) (float32, error) {
+	if len(points) == 0 {
+		return 0, errors.New("no data found")
+	}
+
+	result := points[0].Value
+	switch aggregation {
+	case Sum, Average:
+		total := float32(0)
+		for _, p := range points {
+			total += p.Value
+		}
+		if aggregation == Sum {
+			return total, nil
+		}
+		return total / float32(len(points)), nil
+	case Min:
+		for _, p := range points[1:] {
+			if p.Value < result {
+				result = p.Value
+			}
+		}
+	case Max:
+		for _, p := range points[1:] {
+			if p.Value > result {
+				result = p.Value
+			}
+		}
+	case Last:
+		latest := points[0].Time
+		for _, p := range points[1:] {
+			if p.Time.After(latest) {
+				latest = p.Time
+				result = p.Value
+			}
+		}
+	default:
+		return 0, fmt.Errorf("invalid aggregation %q", aggregation)
+	}
+	return result, nil
+}
@@ -0,0 +1,83 @@
+package measurement
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       Query
+		expectedErr string
+	}{
+		{
+			"Valid",
+			Query{Humidity, Average, time.Hour},
+			"",
+		},
+		{
+			"InvalidType",
+			Query{"pressure", Average, time.Hour},
+			`invalid measurement type "pressure"`,
+		},
+		{
+			"InvalidAggregation",
+			Query{Humidity, "median", time.Hour},
+			`invalid aggregation "median"`,
+		},
+		{
+			"InvalidWindow",
+			Query{Humidity, Average, 0},
+			"window must be a positive duration",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.query.Validate()
+			if tt.expectedErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Error(t, err)
+			assert.Equal(t, tt.expectedErr, err.Error())
+		})
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	now := time.Now()
+	points := []Point{
+		{now.Add(-2 * time.Hour), 2},
+		{now, 4},
+		{now.Add(-time.Hour), 9},
+	}
+
+	tests := []struct {
+		aggregation Aggregation
+		expected    float32
+	}{
+		{Sum, 15},
+		{Average, 5},
+		{Min, 2},
+		{Max, 9},
+		{Last, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.aggregation), func(t *testing.T) {
+			result, err := Aggregate(points, tt.aggregation)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+
+	t.Run("NoData", func(t *testing.T) {
+		_, err := Aggregate(nil, Sum)
+		assert.Error(t, err)
+		assert.Equal(t, "no data found", err.Error())
+	})
+}
@@ -3,6 +3,9 @@
 package weather
 
 import (
+	history "github.com/calvinmclean/automated-garden/garden-app/pkg/weather/history"
+	measurement "github.com/calvinmclean/automated-garden/garden-app/pkg/weather/measurement"
+
 	time "time"
 
 	mock "github.com/stretchr/testify/mock"
@@ -37,6 +40,176 @@ func (_m *MockClient) GetAverageHighTemperature(since time.Duration) (float32, e
 	return r0, r1
 }
 
+// GetCurrentTemperature provides a mock function with given fields:
+func (_m *MockClient) GetCurrentTemperature() (float32, error) {
+	ret := _m.Called()
+
+	var r0 float32
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (float32, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() float32); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(float32)
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCurrentWindSpeed provides a mock function with given fields:
+func (_m *MockClient) GetCurrentWindSpeed() (float32, error) {
+	ret := _m.Called()
+
+	var r0 float32
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (float32, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() float32); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(float32)
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetForecastLowTemperature provides a mock function with given fields: within
+func (_m *MockClient) GetForecastLowTemperature(within time.Duration) (float32, error) {
+	ret := _m.Called(within)
+
+	var r0 float32
+	var r1 error
+	if rf, ok := ret.Get(0).(func(time.Duration) (float32, error)); ok {
+		return rf(within)
+	}
+	if rf, ok := ret.Get(0).(func(time.Duration) float32); ok {
+		r0 = rf(within)
+	} else {
+		r0 = ret.Get(0).(float32)
+	}
+
+	if rf, ok := ret.Get(1).(func(time.Duration) error); ok {
+		r1 = rf(within)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetForecastRain provides a mock function with given fields: within
+func (_m *MockClient) GetForecastRain(within time.Duration) (float32, error) {
+	ret := _m.Called(within)
+
+	var r0 float32
+	var r1 error
+	if rf, ok := ret.Get(0).(func(time.Duration) (float32, error)); ok {
+		return rf(within)
+	}
+	if rf, ok := ret.Get(0).(func(time.Duration) float32); ok {
+		r0 = rf(within)
+	} else {
+		r0 = ret.Get(0).(float32)
+	}
+
+	if rf, ok := ret.Get(1).(func(time.Duration) error); ok {
+		r1 = rf(within)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetForecastRainProbability provides a mock function with given fields: within
+func (_m *MockClient) GetForecastRainProbability(within time.Duration) (float32, error) {
+	ret := _m.Called(within)
+
+	var r0 float32
+	var r1 error
+	if rf, ok := ret.Get(0).(func(time.Duration) (float32, error)); ok {
+		return rf(within)
+	}
+	if rf, ok := ret.Get(0).(func(time.Duration) float32); ok {
+		r0 = rf(within)
+	} else {
+		r0 = ret.Get(0).(float32)
+	}
+
+	if rf, ok := ret.Get(1).(func(time.Duration) error); ok {
+		r1 = rf(within)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetHistory provides a mock function with given fields: start, end, resolution
+func (_m *MockClient) GetHistory(start time.Time, end time.Time, resolution time.Duration) ([]*history.Observation, error) {
+	ret := _m.Called(start, end, resolution)
+
+	var r0 []*history.Observation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(time.Time, time.Time, time.Duration) ([]*history.Observation, error)); ok {
+		return rf(start, end, resolution)
+	}
+	if rf, ok := ret.Get(0).(func(time.Time, time.Time, time.Duration) []*history.Observation); ok {
+		r0 = rf(start, end, resolution)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*history.Observation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(time.Time, time.Time, time.Duration) error); ok {
+		r1 = rf(start, end, resolution)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMeasurement provides a mock function with given fields: q
+func (_m *MockClient) GetMeasurement(q measurement.Query) (float32, error) {
+	ret := _m.Called(q)
+
+	var r0 float32
+	var r1 error
+	if rf, ok := ret.Get(0).(func(measurement.Query) (float32, error)); ok {
+		return rf(q)
+	}
+	if rf, ok := ret.Get(0).(func(measurement.Query) float32); ok {
+		r0 = rf(q)
+	} else {
+		r0 = ret.Get(0).(float32)
+	}
+
+	if rf, ok := ret.Get(1).(func(measurement.Query) error); ok {
+		r1 = rf(q)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetTotalRain provides a mock function with given fields: since
 func (_m *MockClient) GetTotalRain(since time.Duration) (float32, error) {
 	ret := _m.Called(since)
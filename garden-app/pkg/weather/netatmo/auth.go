@@ -0,0 +1,260 @@
+package netatmo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// tokenRefreshMargin is how long before expiration that tokens are refreshed so requests do not use a token that
+	// expires while they are in progress
+	tokenRefreshMargin = 5 * time.Minute
+
+	// rotatedTokenTTL is how long to remember replaced refresh tokens. Clients are created for each request, so this only
+	// needs to be long enough for other Clients that read the old token to finish
+	rotatedTokenTTL = time.Hour
+
+	authorizationScope = "read_station"
+)
+
+var (
+	// refreshLock serializes token refreshes. Netatmo invalidates a refresh token once it is used, so concurrent
+	// refreshes with the same token would fail and store an invalid token
+	refreshLock sync.Mutex
+
+	// rotatedTokens maps refresh tokens that were already used to the tokens that replaced them. This allows Clients
+	// created with old tokens to use the new tokens instead of refreshing again
+	rotatedTokens = map[string]rotatedToken{}
+)
+
+type rotatedToken struct {
+	*TokenData
+	rotatedAt time.Time
+}
+
+// AuthStatus shows if a Client's tokens are valid and the result of the most recent refresh
+type AuthStatus struct {
+	Valid            bool       `json:"valid"`
+	ValidUntil       time.Time  `json:"valid_until"`
+	LastRefresh      *time.Time `json:"last_refresh,omitempty"`
+	LastRefreshError string     `json:"last_refresh_error,omitempty"`
+}
+
+// GetAuthStatus reads the AuthStatus from a Client's options without creating the Client, since that might use the API
+func GetAuthStatus(options map[string]interface{}) (*AuthStatus, error) {
+	config, err := decodeConfig(options)
+	if err != nil {
+		return nil, err
+	}
+	if config.Authentication == nil {
+		return &AuthStatus{}, nil
+	}
+
+	status := &AuthStatus{
+		Valid:            time.Now().Before(config.Authentication.ExpirationDate),
+		ValidUntil:       config.Authentication.ExpirationDate,
+		LastRefreshError: config.Authentication.LastRefreshError,
+	}
+	if !config.Authentication.LastRefresh.IsZero() {
+		status.LastRefresh = &config.Authentication.LastRefresh
+	}
+	return status, nil
+}
+
+// AuthorizationURL returns the URL to start Netatmo's OAuth authorization code flow. After the user allows access,
+// Netatmo redirects to redirectURI with the state and a code that is used with ExchangeAuthorizationCode
+func AuthorizationURL(options map[string]interface{}, redirectURI, state string) (string, error) {
+	config, err := decodeConfig(options)
+	if err != nil {
+		return "", err
+	}
+	if config.ClientID == "" {
+		return "", errors.New("client_id must be provided")
+	}
+
+	authURL, err := url.Parse(baseURI)
+	if err != nil {
+		return "", err
+	}
+	authURL.Path = "/oauth2/authorize"
+	authURL.RawQuery = url.Values{
+		"client_id":    {config.ClientID},
+		"redirect_uri": {redirectURI},
+		"scope":        {authorizationScope},
+		"state":        {state},
+	}.Encode()
+
+	return authURL.String(), nil
+}
+
+// ExchangeAuthorizationCode gets tokens for the code from the OAuth authorization code flow and returns the options
+// updated with the new tokens
+func ExchangeAuthorizationCode(options map[string]interface{}, code, redirectURI string) (map[string]interface{}, error) {
+	config, err := decodeConfig(options)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &Client{Config: config, Client: http.DefaultClient}
+	client.baseURL, err = url.Parse(baseURI)
+	if err != nil {
+		return nil, err
+	}
+
+	err = client.exchangeAuthorizationCode(code, redirectURI)
+	if err != nil {
+		return nil, err
+	}
+	return client.options(), nil
+}
+
+func (c *Client) exchangeAuthorizationCode(code, redirectURI string) error {
+	if c.ClientID == "" || c.ClientSecret == "" {
+		return errors.New("client_id and client_secret must be provided")
+	}
+
+	c.Authentication = &TokenData{}
+	err := c.requestToken(url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {c.ClientID},
+		"client_secret": {c.ClientSecret},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"scope":         {authorizationScope},
+	})
+	if err != nil {
+		return fmt.Errorf("error exchanging authorization code: %w", err)
+	}
+	c.Authentication.LastRefresh = time.Now()
+
+	return nil
+}
+
+// ensureValidToken refreshes the access token if it expires soon. If another Client already refreshed the same token,
+// its new token is used instead
+func (c *Client) ensureValidToken() error {
+	if c.Authentication == nil {
+		return errors.New("authentication must be provided")
+	}
+	if time.Now().Add(tokenRefreshMargin).Before(c.Authentication.ExpirationDate) {
+		return nil
+	}
+
+	refreshLock.Lock()
+	defer refreshLock.Unlock()
+
+	for token, rotated := range rotatedTokens {
+		if time.Since(rotated.rotatedAt) > rotatedTokenTTL {
+			delete(rotatedTokens, token)
+		}
+	}
+
+	if rotated, ok := rotatedTokens[c.Authentication.RefreshToken]; ok {
+		newTokens := *rotated.TokenData
+		c.Authentication = &newTokens
+		return nil
+	}
+
+	return c.refreshToken()
+}
+
+// refreshToken gets a new access token and stores the result, including any error, with the storage callback
+func (c *Client) refreshToken() error {
+	oldRefreshToken := c.Authentication.RefreshToken
+	refreshErr := c.requestToken(url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {c.Authentication.RefreshToken},
+		"client_id":     {c.ClientID},
+		"client_secret": {c.ClientSecret},
+	})
+
+	c.Authentication.LastRefresh = time.Now()
+	c.Authentication.LastRefreshError = ""
+	if refreshErr != nil {
+		c.Authentication.LastRefreshError = refreshErr.Error()
+	} else {
+		newTokens := *c.Authentication
+		rotatedTokens[oldRefreshToken] = rotatedToken{&newTokens, time.Now()}
+	}
+
+	// Use storage callback to save new authentication details
+	err := c.storageCallback(c.options())
+	if err != nil {
+		return fmt.Errorf("error executing storage callback to store new tokens: %w", err)
+	}
+
+	if refreshErr != nil {
+		return fmt.Errorf("error refreshing token: %w", refreshErr)
+	}
+	return nil
+}
+
+// requestToken uses the form data to get new tokens and sets them on the Client
+func (c *Client) requestToken(formData url.Values) error {
+	tokenURL := *c.baseURL
+	tokenURL.Path = "/oauth2/token"
+
+	req, err := http.NewRequest(http.MethodPost, tokenURL.String(), strings.NewReader(formData.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response body with status %d: %v", resp.StatusCode, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("received unexpected status %d with body: %s", resp.StatusCode, string(respBody))
+	}
+
+	var tokens TokenData
+	err = json.Unmarshal(respBody, &tokens)
+	if err != nil {
+		return fmt.Errorf("unable to unmarshal refresh token response body: %w", err)
+	}
+
+	c.Authentication.AccessToken = tokens.AccessToken
+	c.Authentication.RefreshToken = tokens.RefreshToken
+	c.Authentication.ExpiresIn = tokens.ExpiresIn
+	c.Authentication.ExpirationDate = time.Now().Add(time.Duration(tokens.ExpiresIn) * time.Second)
+
+	return nil
+}
+
+// options returns the Client's Config as options to save with the storage callback
+func (c *Client) options() map[string]interface{} {
+	return map[string]interface{}{
+		"station_id":          c.Config.StationID,
+		"station_name":        c.Config.StationName,
+		"rain_module_id":      c.Config.RainModuleID,
+		"rain_module_name":    c.Config.RainModuleName,
+		"outdoor_module_id":   c.Config.OutdoorModuleID,
+		"outdoor_module_name": c.Config.OutdoorModuleName,
+		"wind_module_id":      c.Config.WindModuleID,
+		"wind_module_name":    c.Config.WindModuleName,
+		"authentication": map[string]interface{}{
+			"access_token":       c.Config.Authentication.AccessToken,
+			"refresh_token":      c.Config.Authentication.RefreshToken,
+			"expires_in":         c.Config.Authentication.ExpiresIn,
+			"expiration_date":    c.Config.Authentication.ExpirationDate,
+			"last_refresh":       c.Config.Authentication.LastRefresh,
+			"last_refresh_error": c.Config.Authentication.LastRefreshError,
+		},
+		"client_id":     c.Config.ClientID,
+		"client_secret": c.Config.ClientSecret,
+	}
+}
@@ -0,0 +1,136 @@
+package netatmo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc, auth *TokenData, storageCallback func(map[string]interface{}) error) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	baseURL, err := url.Parse(server.URL)
+	assert.NoError(t, err)
+
+	return &Client{
+		Config: &Config{
+			ClientID:       "client_id",
+			ClientSecret:   "client_secret",
+			Authentication: auth,
+		},
+		Client:          http.DefaultClient,
+		baseURL:         baseURL,
+		storageCallback: storageCallback,
+	}
+}
+
+func TestEnsureValidToken(t *testing.T) {
+	t.Run("NotExpiringDoesNotRefresh", func(t *testing.T) {
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			t.Error("unexpected request to refresh token")
+		}, &TokenData{RefreshToken: "not_expiring", ExpirationDate: time.Now().Add(time.Hour)}, nil)
+
+		assert.NoError(t, c.ensureValidToken())
+	})
+
+	t.Run("ExpiringRefreshesOnce", func(t *testing.T) {
+		requests := 0
+		handler := func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			assert.Equal(t, "/oauth2/token", r.URL.Path)
+			assert.NoError(t, r.ParseForm())
+			assert.Equal(t, "refresh_token", r.Form.Get("grant_type"))
+			assert.Equal(t, "old_refresh_token", r.Form.Get("refresh_token"))
+			_, _ = w.Write([]byte(`{"access_token":"new_access_token","refresh_token":"new_refresh_token","expires_in":10800}`))
+		}
+
+		var stored map[string]interface{}
+		storageCallback := func(options map[string]interface{}) error {
+			stored = options
+			return nil
+		}
+
+		c := newTestClient(t, handler, &TokenData{RefreshToken: "old_refresh_token", ExpirationDate: time.Now().Add(time.Minute)}, storageCallback)
+		assert.NoError(t, c.ensureValidToken())
+		assert.Equal(t, 1, requests)
+		assert.Equal(t, "new_access_token", c.Authentication.AccessToken)
+		assert.Equal(t, "new_refresh_token", stored["authentication"].(map[string]interface{})["refresh_token"])
+		assert.WithinDuration(t, time.Now().Add(3*time.Hour), c.Authentication.ExpirationDate, time.Minute)
+
+		// A Client created from the old config uses the rotated tokens instead of refreshing with an invalid token
+		other := newTestClient(t, handler, &TokenData{RefreshToken: "old_refresh_token"}, storageCallback)
+		assert.NoError(t, other.ensureValidToken())
+		assert.Equal(t, 1, requests)
+		assert.Equal(t, "new_access_token", other.Authentication.AccessToken)
+	})
+
+	t.Run("RefreshErrorIsStored", func(t *testing.T) {
+		var stored map[string]interface{}
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+		}, &TokenData{RefreshToken: "invalid_refresh_token"}, func(options map[string]interface{}) error {
+			stored = options
+			return nil
+		})
+
+		err := c.ensureValidToken()
+		assert.Error(t, err)
+		assert.Equal(t, `error refreshing token: received unexpected status 400 with body: {"error":"invalid_grant"}`, err.Error())
+
+		status, err := GetAuthStatus(stored)
+		assert.NoError(t, err)
+		assert.False(t, status.Valid)
+		assert.NotNil(t, status.LastRefresh)
+		assert.Equal(t, `received unexpected status 400 with body: {"error":"invalid_grant"}`, status.LastRefreshError)
+	})
+
+	t.Run("MissingAuthentication", func(t *testing.T) {
+		c := &Client{Config: &Config{}}
+		err := c.ensureValidToken()
+		assert.Error(t, err)
+		assert.Equal(t, "authentication must be provided", err.Error())
+	})
+}
+
+func TestGetAuthStatus(t *testing.T) {
+	validUntil := time.Now().Add(time.Hour).Truncate(time.Second).UTC()
+	status, err := GetAuthStatus(map[string]interface{}{
+		"authentication": map[string]interface{}{
+			"access_token":    "access_token",
+			"expiration_date": validUntil.Format(time.RFC3339Nano),
+			"last_refresh":    "0001-01-01T00:00:00Z",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &AuthStatus{Valid: true, ValidUntil: validUntil}, status)
+}
+
+func TestAuthorizationURL(t *testing.T) {
+	authURL, err := AuthorizationURL(map[string]interface{}{"client_id": "client_id"}, "http://localhost/callback", "state")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://api.netatmo.com/oauth2/authorize?client_id=client_id&redirect_uri=http%3A%2F%2Flocalhost%2Fcallback&scope=read_station&state=state", authURL)
+
+	_, err = AuthorizationURL(map[string]interface{}{}, "http://localhost/callback", "state")
+	assert.Error(t, err)
+	assert.Equal(t, "client_id must be provided", err.Error())
+}
+
+func TestExchangeAuthorizationCode(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "authorization_code", r.Form.Get("grant_type"))
+		assert.Equal(t, "code", r.Form.Get("code"))
+		assert.Equal(t, "http://localhost/callback", r.Form.Get("redirect_uri"))
+		_, _ = w.Write([]byte(`{"access_token":"access_token","refresh_token":"refresh_token","expires_in":10800}`))
+	}, nil, nil)
+
+	assert.NoError(t, c.exchangeAuthorizationCode("code", "http://localhost/callback"))
+	assert.Equal(t, "access_token", c.Authentication.AccessToken)
+	assert.Equal(t, "refresh_token", c.Authentication.RefreshToken)
+}
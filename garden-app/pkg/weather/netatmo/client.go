@@ -7,7 +7,6 @@ import (
 	"io"
 	"net/http"
 	"net/url"
-	"strings"
 	"time"
 
 	"github.com/mitchellh/mapstructure"
@@ -20,6 +19,7 @@ const (
 // Config is specific to the Netatmo API and holds all of the necessary fields for interacting with the API.
 // If StationID is not provided, StationName is used to get it from the API
 // If RainModuleID is not provided, RainModuleName is used to get it from the API
+// The wind module is optional and is only required for wind data
 // For Authentication, AccessToken, RefreshToken, ClientID and ClientSecret are required
 type Config struct {
 	StationID   string `json:"station_id,omitempty" yaml:"station_id,omitempty" mapstructure:"station_id,omitempty"`
@@ -31,6 +31,9 @@ type Config struct {
 	OutdoorModuleID   string `json:"outdoor_module_id,omitempty" yaml:"outdoor_module_id,omitempty" mapstructure:"outdoor_module_id,omitempty"`
 	OutdoorModuleName string `json:"outdoor_module_name,omitempty" yaml:"outdoor_module_name,omitempty" mapstructure:"outdoor_module_name,omitempty"`
 
+	WindModuleID   string `json:"wind_module_id,omitempty" yaml:"wind_module_id,omitempty" mapstructure:"wind_module_id,omitempty"`
+	WindModuleName string `json:"wind_module_name,omitempty" yaml:"wind_module_name,omitempty" mapstructure:"wind_module_name,omitempty"`
+
 	Authentication *TokenData `json:"authentication,omitempty" yaml:"authentication,omitempty" mapstructure:"authentication,omitempty"`
 	ClientID       string     `json:"client_id,omitempty" yaml:"client_id,omitempty" mapstructure:"client_id,omitempty"`
 	ClientSecret   string     `json:"client_secret,omitempty" yaml:"client_secret,omitempty" mapstructure:"client_secret,omitempty"`
@@ -42,6 +45,9 @@ type TokenData struct {
 	RefreshToken   string    `json:"refresh_token,omitempty" yaml:"refresh_token,omitempty" mapstructure:"refresh_token,omitempty"`
 	ExpiresIn      int       `json:"expires_in,omitempty" yaml:"expires_in,omitempty" mapstructure:"expires_in,omitempty"`
 	ExpirationDate time.Time `json:"expiration_date,omitempty" yaml:"expiration_date,omitempty" mapstructure:"expiration_date,omitempty"`
+
+	LastRefresh      time.Time `json:"last_refresh,omitempty" yaml:"last_refresh,omitempty" mapstructure:"last_refresh,omitempty"`
+	LastRefreshError string    `json:"last_refresh_error,omitempty" yaml:"last_refresh_error,omitempty" mapstructure:"last_refresh_error,omitempty"`
 }
 
 // Client is used to interact with Netatmo API
@@ -57,19 +63,19 @@ type Client struct {
 // If RainModuleID is not provided, RainModuleName is used to get it from the API
 // For Authentication, AccessToken, RefreshToken, ClientID and ClientSecret are required
 func NewClient(options map[string]interface{}, storageCallback func(map[string]interface{}) error) (*Client, error) {
-	client := &Client{Client: http.DefaultClient, storageCallback: storageCallback}
-
-	err := mapstructure.Decode(options, &client.Config)
+	config, err := decodeConfig(options)
 	if err != nil {
 		return nil, err
 	}
+	client := &Client{Config: config, Client: http.DefaultClient, storageCallback: storageCallback}
 
 	client.baseURL, err = url.Parse(baseURI)
 	if err != nil {
 		return nil, err
 	}
 
-	if client.StationID == "" || client.RainModuleID == "" || client.OutdoorModuleID == "" {
+	missingWindModuleID := client.WindModuleID == "" && client.WindModuleName != ""
+	if client.StationID == "" || client.RainModuleID == "" || client.OutdoorModuleID == "" || missingWindModuleID {
 		err = client.setDeviceIDs()
 		if err != nil {
 			return nil, err
@@ -79,6 +85,24 @@ func NewClient(options map[string]interface{}, storageCallback func(map[string]i
 	return client, nil
 }
 
+// decodeConfig decodes options into a Config. Times are decoded from strings since that is how they are stored
+func decodeConfig(options map[string]interface{}) (*Config, error) {
+	var config Config
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.StringToTimeHookFunc(time.RFC3339Nano),
+		Result:     &config,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = decoder.Decode(options)
+	if err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
 type stationDataResponse struct {
 	Body struct {
 		Devices []station `json:"devices"`
@@ -89,14 +113,17 @@ type station struct {
 	ID         string `json:"_id"`
 	Name       string `json:"station_name"`
 	ModuleName string `json:"module_name"`
-	Modules    []struct {
+	Place      struct {
+		Timezone string `json:"timezone"`
+	} `json:"place"`
+	Modules []struct {
 		ID   string `json:"_id"`
 		Name string `json:"module_name"`
 	} `json:"modules"`
 }
 
 func (c *Client) getStationData() (stationDataResponse, error) {
-	err := c.refreshToken()
+	err := c.ensureValidToken()
 	if err != nil {
 		return stationDataResponse{}, err
 	}
@@ -169,7 +196,7 @@ func (c *Client) setDeviceIDs() error {
 	c.StationID = targetStation.ID
 
 	// Find module ID if not provided
-	if c.RainModuleID == "" || c.OutdoorModuleID == "" {
+	if c.RainModuleID == "" || c.OutdoorModuleID == "" || c.WindModuleID == "" {
 		for _, m := range targetStation.Modules {
 			if c.RainModuleID == "" && m.Name == c.RainModuleName {
 				c.RainModuleID = m.ID
@@ -177,6 +204,9 @@ func (c *Client) setDeviceIDs() error {
 			if c.OutdoorModuleID == "" && m.Name == c.OutdoorModuleName {
 				c.OutdoorModuleID = m.ID
 			}
+			if c.WindModuleID == "" && c.WindModuleName != "" && m.Name == c.WindModuleName {
+				c.WindModuleID = m.ID
+			}
 		}
 	}
 	if c.RainModuleID == "" {
@@ -185,63 +215,8 @@ func (c *Client) setDeviceIDs() error {
 	if c.OutdoorModuleID == "" {
 		return fmt.Errorf("no outdoor module found with name %q", c.OutdoorModuleName)
 	}
-
-	return nil
-}
-
-func (c *Client) refreshToken() error {
-	formData := url.Values{
-		"grant_type":    {"refresh_token"},
-		"refresh_token": {c.Authentication.RefreshToken},
-		"client_id":     {c.ClientID},
-		"client_secret": {c.ClientSecret},
-	}
-
-	req, err := http.NewRequest("POST", "https://api.netatmo.com/oauth2/token", strings.NewReader(formData.Encode()))
-	if err != nil {
-		return err
-	}
-	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-	resp, err := c.Client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("error reading response body with status %d: %v", resp.StatusCode, err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("received unexpected status %d with body: %s", resp.StatusCode, string(respBody))
-	}
-
-	err = json.Unmarshal(respBody, c.Authentication)
-	if err != nil {
-		return fmt.Errorf("unable to unmarshal refresh token response body: %w", err)
-	}
-	c.Authentication.ExpirationDate = time.Now().Add(time.Duration(c.Authentication.ExpiresIn) * time.Second)
-
-	// Use storage callback to save new authentication details
-	err = c.storageCallback(map[string]interface{}{
-		"station_id":          c.Config.StationID,
-		"station_name":        c.Config.StationName,
-		"rain_module_id":      c.Config.RainModuleID,
-		"rain_module_name":    c.Config.RainModuleName,
-		"outdoor_module_id":   c.Config.OutdoorModuleID,
-		"outdoor_module_name": c.Config.OutdoorModuleName,
-		"authentication": map[string]interface{}{
-			"access_token":    c.Config.Authentication.AccessToken,
-			"refresh_token":   c.Config.Authentication.RefreshToken,
-			"expires_in":      c.Config.Authentication.ExpiresIn,
-			"expiration_date": c.Config.Authentication.ExpirationDate,
-		},
-		"client_id":     c.Config.ClientID,
-		"client_secret": c.Config.ClientSecret,
-	})
-	if err != nil {
-		return fmt.Errorf("error executing storage callback to store new tokens: %w", err)
+	if c.WindModuleID == "" && c.WindModuleName != "" {
+		return fmt.Errorf("no wind module found with name %q", c.WindModuleName)
 	}
 
 	return nil
@@ -0,0 +1,34 @@
+package netatmo
+
+import (
+	"errors"
+	"time"
+)
+
+// currentDataInterval is how far back to look for the most recent measurement. Netatmo modules report about every
+// 5 minutes, so this leaves room for missed reports
+const currentDataInterval = time.Hour
+
+// GetCurrentTemperature returns the most recent temperature in degrees Celsius from the outdoor module
+func (c *Client) GetCurrentTemperature() (float32, error) {
+	return c.getLatestMeasure("temperature")
+}
+
+// GetCurrentWindSpeed returns the most recent wind speed in km/h from the wind module
+func (c *Client) GetCurrentWindSpeed() (float32, error) {
+	if c.WindModuleID == "" {
+		return 0, errors.New("wind_module_id or wind_module_name must be provided to get wind data")
+	}
+	return c.getLatestMeasure("windstrength")
+}
+
+func (c *Client) getLatestMeasure(dataType string) (float32, error) {
+	data, err := c.getMeasure(dataType, "max", time.Now().Add(-currentDataInterval), nil)
+	if err != nil {
+		return 0, err
+	}
+	if len(*data) == 0 {
+		return 0, errors.New("no data found in the last hour")
+	}
+	return data.Latest(), nil
+}
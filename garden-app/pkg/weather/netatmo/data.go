@@ -54,8 +54,21 @@ func (d *weatherData) Average() float32 {
 	return d.Total() / float32(len(*d))
 }
 
+// Latest returns the most recent value
+func (d *weatherData) Latest() float32 {
+	var latestTime time.Time
+	latest := float32(0)
+	for t, data := range *d {
+		if t.After(latestTime) {
+			latestTime = t
+			latest = data
+		}
+	}
+	return latest
+}
+
 func (c *Client) getMeasure(dataType, scale string, beginDate time.Time, endDate *time.Time) (*weatherData, error) {
-	err := c.refreshToken()
+	err := c.ensureValidToken()
 	if err != nil {
 		return nil, err
 	}
@@ -64,8 +77,11 @@ func (c *Client) getMeasure(dataType, scale string, beginDate time.Time, endDate
 	measureURL.Path = "/api/getmeasure"
 
 	moduleID := c.OutdoorModuleID
-	if strings.Contains(dataType, "rain") {
+	switch {
+	case strings.Contains(dataType, "rain"):
 		moduleID = c.RainModuleID
+	case strings.Contains(dataType, "wind"), strings.Contains(dataType, "gust"):
+		moduleID = c.WindModuleID
 	}
 
 	values := measureURL.Query()
@@ -43,3 +43,12 @@ func TestWeatherDataTotal(t *testing.T) {
 	}
 	assert.Equal(t, float32(10.4), data.Total())
 }
+
+func TestWeatherDataLatest(t *testing.T) {
+	data := weatherData{
+		time.Unix(1662058800, 0): 7.9,
+		time.Unix(1662231600, 0): 3.2,
+		time.Unix(1662145200, 0): 2.5,
+	}
+	assert.Equal(t, float32(3.2), data.Latest())
+}
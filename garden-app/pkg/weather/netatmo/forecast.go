@@ -0,0 +1,24 @@
+package netatmo
+
+import (
+	"errors"
+	"time"
+)
+
+// errForecastNotSupported is returned for forecast data because Netatmo only provides measurements from the station
+var errForecastNotSupported = errors.New("netatmo does not provide forecast data")
+
+// GetForecastRain is not supported by Netatmo and always returns an error
+func (c *Client) GetForecastRain(_ time.Duration) (float32, error) {
+	return 0, errForecastNotSupported
+}
+
+// GetForecastRainProbability is not supported by Netatmo and always returns an error
+func (c *Client) GetForecastRainProbability(_ time.Duration) (float32, error) {
+	return 0, errForecastNotSupported
+}
+
+// GetForecastLowTemperature is not supported by Netatmo and always returns an error
+func (c *Client) GetForecastLowTemperature(_ time.Duration) (float32, error) {
+	return 0, errForecastNotSupported
+}
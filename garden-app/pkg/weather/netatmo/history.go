@@ -0,0 +1,81 @@
+package netatmo
+
+import (
+	"fmt"
+	"time"
+	// Time zone data is embedded since station time zones are needed and the container image does not include it
+	_ "time/tzdata"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather/history"
+)
+
+// measureScales maps history resolutions to the scales supported by the Netatmo getmeasure API
+var measureScales = map[time.Duration]string{
+	30 * time.Minute:   "30min",
+	time.Hour:          "1hour",
+	3 * time.Hour:      "3hours",
+	24 * time.Hour:     "1day",
+	7 * 24 * time.Hour: "1week",
+}
+
+// GetHistory returns the total rain and high temperature for each period. The resolution must be one of the scales
+// supported by Netatmo. Netatmo groups daily and weekly data by the station's local midnight, so those periods start
+// at the station's midnight on or before start and end
+func (c *Client) GetHistory(start, end time.Time, resolution time.Duration) ([]*history.Observation, error) {
+	scale, ok := measureScales[resolution]
+	if !ok {
+		return nil, fmt.Errorf("unsupported resolution %s for netatmo, must be one of: 30m, 1h, 3h, 24h, 168h", resolution)
+	}
+
+	// Aggregated types are only used for scales of a day or longer. Shorter scales use the values for each period
+	rainType, temperatureType := "rain", "temperature"
+	if resolution >= 24*time.Hour {
+		rainType, temperatureType = "sum_rain", "max_temp"
+
+		loc, err := c.stationLocation()
+		if err != nil {
+			return nil, fmt.Errorf("unable to get station time zone: %w", err)
+		}
+		start = startOfDay(start, loc)
+		end = startOfDay(end, loc)
+	}
+
+	rainData, err := c.getMeasure(rainType, scale, start, &end)
+	if err != nil {
+		return nil, err
+	}
+
+	temperatureData, err := c.getMeasure(temperatureType, scale, start, &end)
+	if err != nil {
+		return nil, err
+	}
+
+	b := history.NewBuilder(start, end, resolution)
+	for t, mm := range *rainData {
+		b.AddRain(t, mm)
+	}
+	for t, celsius := range *temperatureData {
+		b.AddTemperature(t, celsius)
+	}
+	return b.Observations(), nil
+}
+
+// stationLocation gets the station's time zone from the API
+func (c *Client) stationLocation() (*time.Location, error) {
+	stationData, err := c.getStationData()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range stationData.Body.Devices {
+		if s.ID == c.StationID {
+			return time.LoadLocation(s.Place.Timezone)
+		}
+	}
+	return nil, fmt.Errorf("no station found with ID %q", c.StationID)
+}
+
+func startOfDay(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
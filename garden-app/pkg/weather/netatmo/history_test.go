@@ -0,0 +1,88 @@
+package netatmo
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather/history"
+	"github.com/stretchr/testify/assert"
+)
+
+func float32Pointer(f float32) *float32 {
+	return &f
+}
+
+func TestGetHistoryUnsupportedResolution(t *testing.T) {
+	_, err := (&Client{}).GetHistory(time.Now().Add(-24*time.Hour), time.Now(), 2*time.Hour)
+	assert.Error(t, err)
+	assert.Equal(t, "unsupported resolution 2h0m0s for netatmo, must be one of: 30m, 1h, 3h, 24h, 168h", err.Error())
+}
+
+func TestGetHistory(t *testing.T) {
+	phoenix, err := time.LoadLocation("America/Phoenix")
+	assert.NoError(t, err)
+
+	// Periods are requested starting at UTC midnight
+	start := time.Date(2023, time.August, 20, 0, 0, 0, 0, time.UTC)
+	localStart := time.Date(2023, time.August, 19, 0, 0, 0, 0, phoenix)
+
+	tests := []struct {
+		name          string
+		end           time.Time
+		resolution    time.Duration
+		expectedStart time.Time
+		expectedTypes []string
+	}{
+		{
+			"DailyUsesStationTimeZone",
+			start.Add(48 * time.Hour),
+			24 * time.Hour,
+			localStart,
+			[]string{"sum_rain", "max_temp"},
+		},
+		{
+			"HourlyUsesRawTypes",
+			start.Add(2 * time.Hour),
+			time.Hour,
+			start,
+			[]string{"rain", "temperature"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requestedTypes := []string{}
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/api/getstationsdata":
+					fmt.Fprint(w, `{"body":{"devices":[{"_id":"station","place":{"timezone":"America/Phoenix"}}]}}`)
+				case "/api/getmeasure":
+					dataType := r.URL.Query().Get("type")
+					requestedTypes = append(requestedTypes, dataType)
+					assert.Equal(t, fmt.Sprint(tt.expectedStart.Unix()), r.URL.Query().Get("date_begin"))
+
+					value := 1.5
+					if dataType == "max_temp" || dataType == "temperature" {
+						value = 35
+					}
+					fmt.Fprintf(w, `{"body":{"%d":[%g],"%d":[%g]}}`,
+						tt.expectedStart.Unix(), value, tt.expectedStart.Add(tt.resolution).Unix(), value,
+					)
+				default:
+					t.Errorf("unexpected request to %s", r.URL.Path)
+				}
+			}, &TokenData{AccessToken: "token", ExpirationDate: time.Now().Add(time.Hour)}, nil)
+			c.StationID = "station"
+
+			observations, err := c.GetHistory(start, tt.end, tt.resolution)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedTypes, requestedTypes)
+			assert.Equal(t, []*history.Observation{
+				{Time: tt.expectedStart, RainMM: float32Pointer(1.5), HighTemperature: float32Pointer(35)},
+				{Time: tt.expectedStart.Add(tt.resolution), RainMM: float32Pointer(1.5), HighTemperature: float32Pointer(35)},
+			}, observations)
+		})
+	}
+}
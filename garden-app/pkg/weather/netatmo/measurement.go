@@ -0,0 +1,84 @@
+package netatmo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather/measurement"
+)
+
+// Netatmo returns at most 1024 values for each request, so longer windows use a larger scale. Raw data is reported
+// about every 5 minutes
+const (
+	maxRawMeasureWindow    = 72 * time.Hour
+	maxHourlyMeasureWindow = 1024 * time.Hour
+)
+
+// rawMeasureTypes are used with the "max" scale, which returns every reported value
+var rawMeasureTypes = map[measurement.Type]string{
+	measurement.Rain:        "rain",
+	measurement.Temperature: "temperature",
+	measurement.Humidity:    "humidity",
+	measurement.WindSpeed:   "windstrength",
+}
+
+// GetMeasurement gets data for the Query from the module with that type of sensor. Netatmo does not measure solar
+// radiation. Windows longer than 72 hours use hourly or daily values, where wind speed is the average for each period
+func (c *Client) GetMeasurement(q measurement.Query) (float32, error) {
+	if err := q.Validate(); err != nil {
+		return 0, err
+	}
+
+	scale := "max"
+	switch {
+	case q.Window > maxHourlyMeasureWindow:
+		scale = "1day"
+	case q.Window > maxRawMeasureWindow:
+		scale = "1hour"
+	}
+
+	dataType, err := c.measureType(q, scale)
+	if err != nil {
+		return 0, err
+	}
+
+	data, err := c.getMeasure(dataType, scale, time.Now().Add(-q.Window), nil)
+	if err != nil {
+		return 0, err
+	}
+
+	points := make([]measurement.Point, 0, len(*data))
+	for t, v := range *data {
+		points = append(points, measurement.Point{Time: t, Value: v})
+	}
+	return measurement.Aggregate(points, q.Aggregation)
+}
+
+// measureType returns the Netatmo type for the Query at the scale. Larger scales already aggregate each period, so
+// they use the matching min, max, or sum type when Netatmo has one
+func (c *Client) measureType(q measurement.Query, scale string) (string, error) {
+	dataType, ok := rawMeasureTypes[q.Type]
+	if !ok {
+		return "", measurement.NotSupportedError("netatmo", q)
+	}
+	if q.Type == measurement.WindSpeed && c.WindModuleID == "" {
+		return "", errors.New("wind_module_id or wind_module_name must be provided to get wind data")
+	}
+	if scale == "max" {
+		return dataType, nil
+	}
+
+	switch {
+	case q.Type == measurement.Rain:
+		return "sum_rain", nil
+	case q.Type == measurement.Temperature && q.Aggregation == measurement.Min:
+		return "min_temp", nil
+	case q.Type == measurement.Temperature && q.Aggregation == measurement.Max:
+		return "max_temp", nil
+	case q.Type == measurement.Humidity && q.Aggregation == measurement.Min:
+		return "min_hum", nil
+	case q.Type == measurement.Humidity && q.Aggregation == measurement.Max:
+		return "max_hum", nil
+	}
+	return dataType, nil
+}
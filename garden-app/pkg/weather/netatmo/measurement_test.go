@@ -0,0 +1,76 @@
+package netatmo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather/measurement"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMeasureType(t *testing.T) {
+	tests := []struct {
+		name         string
+		query        measurement.Query
+		scale        string
+		expectedType string
+		expectedErr  string
+	}{
+		{
+			"RawHumidity",
+			measurement.Query{Type: measurement.Humidity, Aggregation: measurement.Max, Window: time.Hour},
+			"max",
+			"humidity",
+			"",
+		},
+		{
+			"HourlyRain",
+			measurement.Query{Type: measurement.Rain, Aggregation: measurement.Sum, Window: 168 * time.Hour},
+			"1hour",
+			"sum_rain",
+			"",
+		},
+		{
+			"HourlyMinTemperature",
+			measurement.Query{Type: measurement.Temperature, Aggregation: measurement.Min, Window: 168 * time.Hour},
+			"1hour",
+			"min_temp",
+			"",
+		},
+		{
+			"DailyAverageHumidity",
+			measurement.Query{Type: measurement.Humidity, Aggregation: measurement.Average, Window: 2000 * time.Hour},
+			"1day",
+			"humidity",
+			"",
+		},
+		{
+			"WindWithoutModule",
+			measurement.Query{Type: measurement.WindSpeed, Aggregation: measurement.Max, Window: time.Hour},
+			"max",
+			"",
+			"wind_module_id or wind_module_name must be provided to get wind data",
+		},
+		{
+			"SolarRadiationNotSupported",
+			measurement.Query{Type: measurement.SolarRadiation, Aggregation: measurement.Sum, Window: time.Hour},
+			"max",
+			"",
+			"solar_radiation measurement with sum aggregation is not supported by netatmo",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{Config: &Config{}}
+			dataType, err := c.measureType(tt.query, tt.scale)
+			if tt.expectedErr != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedErr, err.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedType, dataType)
+		})
+	}
+}
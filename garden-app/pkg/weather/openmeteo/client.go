@@ -0,0 +1,363 @@
+package openmeteo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather/history"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather/measurement"
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	baseURI = "https://api.open-meteo.com"
+
+	// maxPastDays and maxForecastDays are the limits of the Open-Meteo forecast API
+	maxPastDays     = 92
+	maxForecastDays = 16
+
+	minTemperatureInterval = 72 * time.Hour
+)
+
+// Config is specific to the Open-Meteo API and holds all of the necessary fields for interacting with the API.
+// Latitude and Longitude are required. BaseURL is optional and defaults to the public Open-Meteo API
+type Config struct {
+	Latitude  *float64 `json:"latitude,omitempty" yaml:"latitude,omitempty" mapstructure:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty" yaml:"longitude,omitempty" mapstructure:"longitude,omitempty"`
+	BaseURL   string   `json:"base_url,omitempty" yaml:"base_url,omitempty" mapstructure:"base_url,omitempty"`
+}
+
+// Client is used to interact with the Open-Meteo API. It uses the forecast API for recent historical data with the
+// past_days parameter, since the separate historical archive API is delayed by a few days. This limits historical
+// data to the last 92 days, and longer periods return an error
+type Client struct {
+	*Config
+	*http.Client
+	baseURL *url.URL
+	now     func() time.Time
+}
+
+// NewClient creates a new Open-Meteo API client from configuration
+func NewClient(options map[string]interface{}) (*Client, error) {
+	client := &Client{Client: http.DefaultClient, now: time.Now}
+
+	err := mapstructure.Decode(options, &client.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	if client.Latitude == nil {
+		return nil, errors.New("latitude must be provided")
+	}
+	if *client.Latitude < -90 || *client.Latitude > 90 {
+		return nil, errors.New("latitude must be between -90 and 90")
+	}
+	if client.Longitude == nil {
+		return nil, errors.New("longitude must be provided")
+	}
+	if *client.Longitude < -180 || *client.Longitude > 180 {
+		return nil, errors.New("longitude must be between -180 and 180")
+	}
+
+	baseURL := client.BaseURL
+	if baseURL == "" {
+		baseURL = baseURI
+	}
+	client.baseURL, err = url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// forecastResponse is the subset of the Open-Meteo forecast API response used by this client. Times are unix timestamps
+type forecastResponse struct {
+	Current struct {
+		Temperature float32 `json:"temperature_2m"`
+		WindSpeed   float32 `json:"wind_speed_10m"`
+	} `json:"current"`
+	Hourly struct {
+		Time                     []int64   `json:"time"`
+		Temperature              []float32 `json:"temperature_2m"`
+		Precipitation            []float32 `json:"precipitation"`
+		PrecipitationProbability []float32 `json:"precipitation_probability"`
+	} `json:"hourly"`
+	Daily struct {
+		Time           []int64   `json:"time"`
+		TemperatureMax []float32 `json:"temperature_2m_max"`
+	} `json:"daily"`
+}
+
+// GetTotalRain returns the sum of all rainfall in millimeters in the given period
+func (c *Client) GetTotalRain(since time.Duration) (float32, error) {
+	pastDays, err := getPastDays(since)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := c.getForecast(url.Values{
+		"hourly":        {"precipitation"},
+		"past_days":     {fmt.Sprint(pastDays)},
+		"forecast_days": {"1"},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	now := c.now()
+	return sumInRange(resp.Hourly.Time, resp.Hourly.Precipitation, now.Add(-since), now), nil
+}
+
+// GetAverageHighTemperature returns the average daily high temperature between the given time and the end of
+// yesterday (since daily high can be misleading if queried mid-day)
+func (c *Client) GetAverageHighTemperature(since time.Duration) (float32, error) {
+	// Time to check since must always be at least 3 days
+	if since < minTemperatureInterval {
+		since = minTemperatureInterval
+	}
+
+	pastDays, err := getPastDays(since)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := c.getForecast(url.Values{
+		"daily":         {"temperature_2m_max"},
+		"past_days":     {fmt.Sprint(pastDays)},
+		"forecast_days": {"1"},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// The last value is today, which is excluded
+	temperatures := resp.Daily.TemperatureMax
+	if len(temperatures) < 2 {
+		return 0, errors.New("no daily temperature data found")
+	}
+	temperatures = temperatures[:len(temperatures)-1]
+
+	total := float32(0)
+	for _, t := range temperatures {
+		total += t
+	}
+	return total / float32(len(temperatures)), nil
+}
+
+// GetCurrentTemperature returns the current temperature in degrees Celsius
+func (c *Client) GetCurrentTemperature() (float32, error) {
+	resp, err := c.getForecast(url.Values{
+		"current": {"temperature_2m"},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return resp.Current.Temperature, nil
+}
+
+// GetCurrentWindSpeed returns the current wind speed in km/h
+func (c *Client) GetCurrentWindSpeed() (float32, error) {
+	resp, err := c.getForecast(url.Values{
+		"current": {"wind_speed_10m"},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return resp.Current.WindSpeed, nil
+}
+
+// GetForecastRain returns the sum of forecast rainfall in millimeters between now and the end of the given period
+func (c *Client) GetForecastRain(within time.Duration) (float32, error) {
+	resp, err := c.getForecast(url.Values{
+		"hourly":        {"precipitation"},
+		"forecast_days": {fmt.Sprint(days(within, maxForecastDays-1) + 1)},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	now := c.now()
+	return sumInRange(resp.Hourly.Time, resp.Hourly.Precipitation, now, now.Add(within)), nil
+}
+
+// GetForecastRainProbability returns the highest hourly probability of rain (percent) between now and the end of the
+// given period
+func (c *Client) GetForecastRainProbability(within time.Duration) (float32, error) {
+	resp, err := c.getForecast(url.Values{
+		"hourly":        {"precipitation_probability"},
+		"forecast_days": {fmt.Sprint(days(within, maxForecastDays-1) + 1)},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	now := c.now()
+	start, end := now.Truncate(time.Hour), now.Add(within)
+	maxProbability := float32(0)
+	for i, t := range resp.Hourly.Time {
+		if i >= len(resp.Hourly.PrecipitationProbability) {
+			break
+		}
+		hour := time.Unix(t, 0)
+		if hour.Before(start) || hour.After(end) {
+			continue
+		}
+		if p := resp.Hourly.PrecipitationProbability[i]; p > maxProbability {
+			maxProbability = p
+		}
+	}
+	return maxProbability, nil
+}
+
+// GetForecastLowTemperature returns the lowest hourly forecast temperature in degrees Celsius between now and the end
+// of the given period
+func (c *Client) GetForecastLowTemperature(within time.Duration) (float32, error) {
+	resp, err := c.getForecast(url.Values{
+		"hourly":        {"temperature_2m"},
+		"forecast_days": {fmt.Sprint(days(within, maxForecastDays-1) + 1)},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	now := c.now()
+	start, end := now.Truncate(time.Hour), now.Add(within)
+	var low *float32
+	for i, t := range resp.Hourly.Time {
+		if i >= len(resp.Hourly.Temperature) {
+			break
+		}
+		hour := time.Unix(t, 0)
+		if hour.Before(start) || hour.After(end) {
+			continue
+		}
+		if low == nil || resp.Hourly.Temperature[i] < *low {
+			low = &resp.Hourly.Temperature[i]
+		}
+	}
+	if low == nil {
+		return 0, errors.New("no hourly temperature data found")
+	}
+	return *low, nil
+}
+
+// GetHistory returns the total rain and highest hourly temperature for each period
+func (c *Client) GetHistory(start, end time.Time, resolution time.Duration) ([]*history.Observation, error) {
+	pastDays, err := getPastDays(c.now().Sub(start))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.getForecast(url.Values{
+		"hourly":        {"precipitation,temperature_2m"},
+		"past_days":     {fmt.Sprint(pastDays)},
+		"forecast_days": {"1"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	b := history.NewBuilder(start, end, resolution)
+	for i, t := range resp.Hourly.Time {
+		hour := time.Unix(t, 0)
+		// Precipitation is the sum for the preceding hour
+		if i < len(resp.Hourly.Precipitation) {
+			b.AddRain(hour.Add(-time.Hour), resp.Hourly.Precipitation[i])
+		}
+		if i < len(resp.Hourly.Temperature) {
+			b.AddTemperature(hour, resp.Hourly.Temperature[i])
+		}
+	}
+	return b.Observations(), nil
+}
+
+func (c *Client) getForecast(values url.Values) (*forecastResponse, error) {
+	forecastURL := *c.baseURL
+	forecastURL.Path = strings.TrimSuffix(forecastURL.Path, "/") + "/v1/forecast"
+
+	values.Add("latitude", fmt.Sprint(*c.Latitude))
+	values.Add("longitude", fmt.Sprint(*c.Longitude))
+	values.Add("timeformat", "unixtime")
+	values.Add("timezone", "auto")
+	forecastURL.RawQuery = values.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, forecastURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Accept", "application/json")
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body with status %d: %v", resp.StatusCode, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received unexpected status %d with body: %s", resp.StatusCode, string(respBody))
+	}
+
+	var respData forecastResponse
+	err = json.Unmarshal(respBody, &respData)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read response body '%s': %v", string(respBody), err)
+	}
+
+	return &respData, nil
+}
+
+// days returns the number of days needed to cover the duration, between 1 and max
+func days(d time.Duration, max int) int {
+	result := int(math.Ceil(d.Hours() / 24))
+	if result < 1 {
+		return 1
+	}
+	if result > max {
+		return max
+	}
+	return result
+}
+
+// getPastDays returns the number of past days needed to cover the duration. The forecast API only provides maxPastDays
+// of data, so an error is returned for longer durations instead of silently using less data
+func getPastDays(d time.Duration) (int, error) {
+	if d > maxPastDays*24*time.Hour {
+		return 0, fmt.Errorf("open-meteo only provides data for the last %d days, but %s was requested", maxPastDays, d)
+	}
+	return days(d, maxPastDays), nil
+}
+
+// sumInRange adds up hourly values for hours that end in the time range. Open-Meteo precipitation is the sum for the
+// preceding hour, so an hour is included if its timestamp is after start and not after end
+func sumInRange(times []int64, values []float32, start, end time.Time) float32 {
+	total := float32(0)
+	for i, t := range times {
+		if i >= len(values) {
+			break
+		}
+		hour := time.Unix(t, 0)
+		if !hour.After(start) || hour.After(end) {
+			continue
+		}
+		total += values[i]
+	}
+	return total
+}
+
+// GetMeasurement is not implemented for this client and always returns an error
+func (c *Client) GetMeasurement(q measurement.Query) (float32, error) {
+	return 0, measurement.NotSupportedError("open-meteo", q)
+}
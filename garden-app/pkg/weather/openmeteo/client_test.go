@@ -0,0 +1,206 @@
+package openmeteo
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var now = time.Date(2023, time.August, 23, 12, 30, 0, 0, time.UTC)
+
+func hour(offset int) int64 {
+	return now.Truncate(time.Hour).Add(time.Duration(offset) * time.Hour).Unix()
+}
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(map[string]interface{}{
+		"latitude":  33.4484,
+		"longitude": -112.074,
+		"base_url":  server.URL,
+	})
+	assert.NoError(t, err)
+	client.now = func() time.Time { return now }
+	return client
+}
+
+func TestNewClient(t *testing.T) {
+	tests := []struct {
+		name          string
+		options       map[string]interface{}
+		expectedError string
+	}{
+		{
+			"Successful",
+			map[string]interface{}{"latitude": 33.4484, "longitude": -112.074},
+			"",
+		},
+		{
+			"MissingLatitude",
+			map[string]interface{}{"longitude": -112.074},
+			"latitude must be provided",
+		},
+		{
+			"MissingLongitude",
+			map[string]interface{}{"latitude": 33.4484},
+			"longitude must be provided",
+		},
+		{
+			"InvalidLatitude",
+			map[string]interface{}{"latitude": 100, "longitude": -112.074},
+			"latitude must be between -90 and 90",
+		},
+		{
+			"InvalidLongitude",
+			map[string]interface{}{"latitude": 33.4484, "longitude": 200},
+			"longitude must be between -180 and 180",
+		},
+		{
+			"InvalidOptionType",
+			map[string]interface{}{"latitude": "north"},
+			"1 error(s) decoding:\n\n* 'latitude' expected type 'float64', got unconvertible type 'string', value: 'north'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient(tt.options)
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, baseURI, client.baseURL.String())
+		})
+	}
+}
+
+func TestGetTotalRain(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/forecast", r.URL.Path)
+		assert.Equal(t, "precipitation", r.URL.Query().Get("hourly"))
+		assert.Equal(t, "1", r.URL.Query().Get("past_days"))
+		assert.Equal(t, "33.4484", r.URL.Query().Get("latitude"))
+		assert.Equal(t, "-112.074", r.URL.Query().Get("longitude"))
+		assert.Equal(t, "unixtime", r.URL.Query().Get("timeformat"))
+
+		// Hours before the range and in the future are not included
+		fmt.Fprintf(w, `{"hourly":{"time":[%d,%d,%d,%d],"precipitation":[5,1.5,2,10]}}`, hour(-30), hour(-12), hour(0), hour(1))
+	})
+
+	rain, err := client.GetTotalRain(24 * time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, float32(3.5), rain)
+}
+
+func TestPastDataLimit(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("no request should be made for a period beyond the limit")
+	})
+	expectedError := "open-meteo only provides data for the last 92 days, but 2232h0m0s was requested"
+
+	_, err := client.GetTotalRain(93 * 24 * time.Hour)
+	assert.Error(t, err)
+	assert.Equal(t, expectedError, err.Error())
+
+	_, err = client.GetAverageHighTemperature(93 * 24 * time.Hour)
+	assert.Error(t, err)
+	assert.Equal(t, expectedError, err.Error())
+
+	now := client.now()
+	_, err = client.GetHistory(now.Add(-93*24*time.Hour), now, time.Hour)
+	assert.Error(t, err)
+	assert.Equal(t, expectedError, err.Error())
+}
+
+func TestGetAverageHighTemperature(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "temperature_2m_max", r.URL.Query().Get("daily"))
+		// minimum of 3 days is used
+		assert.Equal(t, "3", r.URL.Query().Get("past_days"))
+
+		// The last value is today and is not included
+		fmt.Fprintf(w, `{"daily":{"time":[%d,%d,%d,%d],"temperature_2m_max":[30,33,36,45]}}`, hour(-84), hour(-60), hour(-36), hour(-12))
+	})
+
+	temperature, err := client.GetAverageHighTemperature(24 * time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, float32(33), temperature)
+}
+
+func TestGetAverageHighTemperatureNoData(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"daily":{"time":[],"temperature_2m_max":[]}}`)
+	})
+
+	_, err := client.GetAverageHighTemperature(72 * time.Hour)
+	assert.Error(t, err)
+	assert.Equal(t, "no daily temperature data found", err.Error())
+}
+
+func TestGetCurrentConditions(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"current":{"time":1692793800,"temperature_2m":-1.5,"wind_speed_10m":22.3}}`)
+	})
+
+	temperature, err := client.GetCurrentTemperature()
+	assert.NoError(t, err)
+	assert.Equal(t, float32(-1.5), temperature)
+
+	windSpeed, err := client.GetCurrentWindSpeed()
+	assert.NoError(t, err)
+	assert.Equal(t, float32(22.3), windSpeed)
+}
+
+func TestGetForecast(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "2", r.URL.Query().Get("forecast_days"))
+
+		fmt.Fprintf(w,
+			`{"hourly":{"time":[%d,%d,%d,%d,%d],"precipitation":[4,0,1,2.5,8],"precipitation_probability":[90,10,40,70,95]}}`,
+			hour(-1), hour(0), hour(1), hour(6), hour(7),
+		)
+	})
+
+	rain, err := client.GetForecastRain(6 * time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, float32(3.5), rain)
+
+	probability, err := client.GetForecastRainProbability(6 * time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, float32(70), probability)
+}
+
+func TestGetForecastLowTemperature(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "temperature_2m", r.URL.Query().Get("hourly"))
+		assert.Equal(t, "2", r.URL.Query().Get("forecast_days"))
+
+		fmt.Fprintf(w,
+			`{"hourly":{"time":[%d,%d,%d,%d,%d],"temperature_2m":[-5,4,1.5,-1,-8]}}`,
+			hour(-1), hour(0), hour(1), hour(6), hour(7),
+		)
+	})
+
+	temperature, err := client.GetForecastLowTemperature(6 * time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, float32(-1), temperature)
+}
+
+func TestUnexpectedStatus(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":true,"reason":"Latitude must be in range of -90 to 90°."}`)
+	})
+
+	_, err := client.GetCurrentTemperature()
+	assert.Error(t, err)
+	assert.Equal(t, `received unexpected status 400 with body: {"error":true,"reason":"Latitude must be in range of -90 to 90°."}`, err.Error())
+}
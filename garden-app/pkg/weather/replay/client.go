@@ -0,0 +1,331 @@
+package replay
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather/history"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather/measurement"
+	"github.com/mitchellh/mapstructure"
+)
+
+const minTemperatureInterval = 72 * time.Hour
+
+// timeLayouts are the accepted formats for record times. Times without a zone are UTC
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// Config is specific to the replay client and holds all of the necessary fields for reading and replaying weather
+// data from a file. File is required. CurrentTime and TimeOffset are optional and mutually exclusive ways to choose
+// which point in the data is treated as now
+type Config struct {
+	File string `mapstructure:"file"`
+	// Format is "csv" or "json". If it is not provided, it is determined from the file extension
+	Format string `mapstructure:"format"`
+	// CurrentTime is a fixed RFC3339 time that is always used as the current time
+	CurrentTime string `mapstructure:"current_time"`
+	// TimeOffset is added to the real current time, so "-8760h" replays the same time last year
+	TimeOffset string `mapstructure:"time_offset"`
+}
+
+// Record is a single weather observation. RainMM is the amount of rain since the previous record. Fields are pointers
+// so records can leave out values that were not measured
+type Record struct {
+	Time        time.Time `json:"time"`
+	RainMM      *float32  `json:"rain_mm,omitempty"`
+	Temperature *float32  `json:"temperature,omitempty"`
+	WindSpeed   *float32  `json:"wind_speed,omitempty"`
+}
+
+// Client replays historical weather data from a file. All queries are answered relative to the configured current
+// time. Since the data also has the "future", forecasts are answered with the actual recorded values.
+// This is intended for tuning Weather Controls in integration tests or a staging environment
+type Client struct {
+	*Config
+	records []Record
+	now     func() time.Time
+}
+
+// NewClient creates a new replay client by reading the configured file
+func NewClient(options map[string]interface{}) (*Client, error) {
+	client := &Client{now: time.Now}
+
+	err := mapstructure.Decode(options, &client.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	if client.File == "" {
+		return nil, errors.New("file must be provided")
+	}
+
+	if client.CurrentTime != "" && client.TimeOffset != "" {
+		return nil, errors.New("only one of current_time and time_offset can be provided")
+	}
+	if client.CurrentTime != "" {
+		currentTime, err := time.Parse(time.RFC3339, client.CurrentTime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid current_time: %w", err)
+		}
+		client.now = func() time.Time { return currentTime }
+	}
+	if client.TimeOffset != "" {
+		offset, err := time.ParseDuration(client.TimeOffset)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time_offset: %w", err)
+		}
+		client.now = func() time.Time { return time.Now().Add(offset) }
+	}
+
+	client.records, err = readFile(client.File, client.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// GetTotalRain returns the sum of recorded rain in the given period
+func (c *Client) GetTotalRain(since time.Duration) (float32, error) {
+	now := c.now()
+	return c.sumRain(now.Add(-since), now), nil
+}
+
+// GetAverageHighTemperature returns the average of the highest recorded temperature for each day between the given time
+// and the end of yesterday (since daily high can be misleading if queried mid-day)
+func (c *Client) GetAverageHighTemperature(since time.Duration) (float32, error) {
+	// Time to check since must always be at least 3 days
+	if since < minTemperatureInterval {
+		since = minTemperatureInterval
+	}
+
+	now := c.now()
+	start := now.Add(-since)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	highs := map[string]float32{}
+	for _, r := range c.records {
+		if r.Temperature == nil || r.Time.Before(start) || !r.Time.Before(today) {
+			continue
+		}
+		day := r.Time.In(now.Location()).Format("2006-01-02")
+		if high, ok := highs[day]; !ok || *r.Temperature > high {
+			highs[day] = *r.Temperature
+		}
+	}
+
+	if len(highs) == 0 {
+		return 0, errors.New("no daily temperature data found")
+	}
+
+	total := float32(0)
+	for _, high := range highs {
+		total += high
+	}
+	return total / float32(len(highs)), nil
+}
+
+// GetCurrentTemperature returns the most recent recorded temperature
+func (c *Client) GetCurrentTemperature() (float32, error) {
+	return c.latest("temperature", func(r Record) *float32 { return r.Temperature })
+}
+
+// GetCurrentWindSpeed returns the most recent recorded wind speed
+func (c *Client) GetCurrentWindSpeed() (float32, error) {
+	return c.latest("wind speed", func(r Record) *float32 { return r.WindSpeed })
+}
+
+// GetForecastRain returns the sum of recorded rain between now and the end of the given period
+func (c *Client) GetForecastRain(within time.Duration) (float32, error) {
+	now := c.now()
+	return c.sumRain(now, now.Add(within)), nil
+}
+
+// GetForecastRainProbability returns 100 if any rain is recorded between now and the end of the given period, otherwise 0
+func (c *Client) GetForecastRainProbability(within time.Duration) (float32, error) {
+	rain, err := c.GetForecastRain(within)
+	if err != nil || rain <= 0 {
+		return 0, err
+	}
+	return 100, nil
+}
+
+// GetForecastLowTemperature returns the lowest recorded temperature between now and the end of the given period
+func (c *Client) GetForecastLowTemperature(within time.Duration) (float32, error) {
+	now := c.now()
+	end := now.Add(within)
+
+	var low *float32
+	for _, r := range c.records {
+		if r.Temperature == nil || r.Time.Before(now) || r.Time.After(end) {
+			continue
+		}
+		if low == nil || *r.Temperature < *low {
+			low = r.Temperature
+		}
+	}
+	if low == nil {
+		return 0, fmt.Errorf("no temperature data found between %s and %s", now.Format(time.RFC3339), end.Format(time.RFC3339))
+	}
+	return *low, nil
+}
+
+// GetHistory returns the recorded rain and high temperature for each period
+func (c *Client) GetHistory(start, end time.Time, resolution time.Duration) ([]*history.Observation, error) {
+	b := history.NewBuilder(start, end, resolution)
+	for _, r := range c.records {
+		if r.RainMM != nil {
+			b.AddRain(r.Time, *r.RainMM)
+		}
+		if r.Temperature != nil {
+			b.AddTemperature(r.Time, *r.Temperature)
+		}
+	}
+	return b.Observations(), nil
+}
+
+// sumRain adds up rain for records after start and not after end
+func (c *Client) sumRain(start, end time.Time) float32 {
+	total := float32(0)
+	for _, r := range c.records {
+		if r.RainMM == nil || !r.Time.After(start) || r.Time.After(end) {
+			continue
+		}
+		total += *r.RainMM
+	}
+	return total
+}
+
+// latest returns the most recent value that is not after the current time
+func (c *Client) latest(name string, value func(Record) *float32) (float32, error) {
+	now := c.now()
+	for i := len(c.records) - 1; i >= 0; i-- {
+		r := c.records[i]
+		if r.Time.After(now) || value(r) == nil {
+			continue
+		}
+		return *value(r), nil
+	}
+	return 0, fmt.Errorf("no %s data found before %s", name, now.Format(time.RFC3339))
+}
+
+// readFile reads and sorts records from a CSV or JSON file
+func readFile(filename, format string) ([]Record, error) {
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(filename)), ".")
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []Record
+	switch format {
+	case "csv":
+		records, err = readCSV(f)
+	case "json":
+		err = json.NewDecoder(f).Decode(&records)
+	default:
+		return nil, fmt.Errorf("invalid format %q, must be one of: csv, json", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", filename, err)
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("no records found in %s", filename)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Time.Before(records[j].Time)
+	})
+	return records, nil
+}
+
+// readCSV reads records from CSV with a header row. The time column is required and rain_mm, temperature, and
+// wind_speed are optional. Empty values are treated as missing
+func readCSV(r io.Reader) ([]Record, error) {
+	csvReader := csv.NewReader(r)
+	csvReader.TrimLeadingSpace = true
+
+	header, err := csvReader.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	timeColumn, ok := columns["time"]
+	if !ok {
+		return nil, errors.New("missing time column")
+	}
+
+	records := []Record{}
+	for {
+		row, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		record := Record{}
+		record.Time, err = parseTime(row[timeColumn])
+		if err != nil {
+			return nil, err
+		}
+
+		for name, field := range map[string]**float32{
+			"rain_mm":     &record.RainMM,
+			"temperature": &record.Temperature,
+			"wind_speed":  &record.WindSpeed,
+		} {
+			i, ok := columns[name]
+			if !ok || strings.TrimSpace(row[i]) == "" {
+				continue
+			}
+			value, err := strconv.ParseFloat(strings.TrimSpace(row[i]), 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s at %s: %w", name, row[timeColumn], err)
+			}
+			v := float32(value)
+			*field = &v
+		}
+
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func parseTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range timeLayouts {
+		t, err := time.Parse(layout, value)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, must be RFC3339 or YYYY-MM-DD HH:MM:SS", value)
+}
+
+// GetMeasurement is not implemented for this client and always returns an error
+func (c *Client) GetMeasurement(q measurement.Query) (float32, error) {
+	return 0, measurement.NotSupportedError("replay", q)
+}
@@ -0,0 +1,195 @@
+package replay
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var now = time.Date(2023, time.July, 10, 12, 0, 0, 0, time.UTC)
+
+func newTestClient(t *testing.T, file string) *Client {
+	client, err := NewClient(map[string]interface{}{"file": file})
+	assert.NoError(t, err)
+	client.now = func() time.Time { return now }
+	return client
+}
+
+func TestNewClient(t *testing.T) {
+	tests := []struct {
+		name          string
+		options       map[string]interface{}
+		expectedError string
+	}{
+		{
+			"SuccessfulCSV",
+			map[string]interface{}{"file": "testdata/weather.csv"},
+			"",
+		},
+		{
+			"SuccessfulJSONWithCurrentTime",
+			map[string]interface{}{"file": "testdata/weather.json", "current_time": "2023-07-10T12:00:00Z"},
+			"",
+		},
+		{
+			"SuccessfulWithTimeOffset",
+			map[string]interface{}{"file": "testdata/weather.csv", "time_offset": "-8760h"},
+			"",
+		},
+		{
+			"MissingFile",
+			map[string]interface{}{},
+			"file must be provided",
+		},
+		{
+			"FileDoesNotExist",
+			map[string]interface{}{"file": "testdata/missing.csv"},
+			"open testdata/missing.csv: no such file or directory",
+		},
+		{
+			"InvalidFormat",
+			map[string]interface{}{"file": "testdata/weather.csv", "format": "xml"},
+			`invalid format "xml", must be one of: csv, json`,
+		},
+		{
+			"InvalidValue",
+			map[string]interface{}{"file": "testdata/invalid.csv"},
+			`error reading testdata/invalid.csv: invalid rain_mm at 2023-07-10 06:00: strconv.ParseFloat: parsing "lots": invalid syntax`,
+		},
+		{
+			"CurrentTimeAndTimeOffset",
+			map[string]interface{}{"file": "testdata/weather.csv", "current_time": "2023-07-10T12:00:00Z", "time_offset": "-8760h"},
+			"only one of current_time and time_offset can be provided",
+		},
+		{
+			"InvalidCurrentTime",
+			map[string]interface{}{"file": "testdata/weather.csv", "current_time": "yesterday"},
+			`invalid current_time: parsing time "yesterday" as "2006-01-02T15:04:05Z07:00": cannot parse "yesterday" as "2006"`,
+		},
+		{
+			"InvalidTimeOffset",
+			map[string]interface{}{"file": "testdata/weather.csv", "time_offset": "last year"},
+			`invalid time_offset: time: invalid duration "last year"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewClient(tt.options)
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestCurrentTime(t *testing.T) {
+	client, err := NewClient(map[string]interface{}{
+		"file":         "testdata/weather.json",
+		"current_time": "2023-07-10T12:00:00Z",
+	})
+	assert.NoError(t, err)
+
+	// JSON records are sorted, so the latest record before the current time is used
+	temperature, err := client.GetCurrentTemperature()
+	assert.NoError(t, err)
+	assert.Equal(t, float32(30), temperature)
+}
+
+func TestGetTotalRain(t *testing.T) {
+	tests := []struct {
+		name     string
+		since    time.Duration
+		expected float32
+	}{
+		{"24h", 24 * time.Hour, 2},
+		{"72h", 72 * time.Hour, 2},
+		{"96h", 96 * time.Hour, 4},
+	}
+
+	client := newTestClient(t, "testdata/weather.csv")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rain, err := client.GetTotalRain(tt.since)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, rain)
+		})
+	}
+}
+
+func TestGetAverageHighTemperature(t *testing.T) {
+	client := newTestClient(t, "testdata/weather.csv")
+
+	// minimum of 3 days is used and today is not included
+	temperature, err := client.GetAverageHighTemperature(24 * time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, float32(38), temperature)
+}
+
+func TestGetAverageHighTemperatureNoData(t *testing.T) {
+	client := newTestClient(t, "testdata/weather.csv")
+	client.now = func() time.Time { return now.AddDate(0, 1, 0) }
+
+	_, err := client.GetAverageHighTemperature(72 * time.Hour)
+	assert.Error(t, err)
+	assert.Equal(t, "no daily temperature data found", err.Error())
+}
+
+func TestGetCurrentConditions(t *testing.T) {
+	client := newTestClient(t, "testdata/weather.csv")
+
+	temperature, err := client.GetCurrentTemperature()
+	assert.NoError(t, err)
+	assert.Equal(t, float32(32), temperature)
+
+	// The most recent record does not have wind speed, so the previous one is used
+	windSpeed, err := client.GetCurrentWindSpeed()
+	assert.NoError(t, err)
+	assert.Equal(t, float32(8), windSpeed)
+
+	client.now = func() time.Time { return now.AddDate(0, -1, 0) }
+	_, err = client.GetCurrentWindSpeed()
+	assert.Error(t, err)
+	assert.Equal(t, "no wind speed data found before 2023-06-10T12:00:00Z", err.Error())
+}
+
+func TestGetForecastLowTemperature(t *testing.T) {
+	client := newTestClient(t, "testdata/weather.csv")
+
+	temperature, err := client.GetForecastLowTemperature(24 * time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, float32(28), temperature)
+
+	_, err = client.GetForecastLowTemperature(3 * time.Hour)
+	assert.Error(t, err)
+	assert.Equal(t, "no temperature data found between 2023-07-10T12:00:00Z and 2023-07-10T15:00:00Z", err.Error())
+}
+
+func TestGetForecast(t *testing.T) {
+	tests := []struct {
+		name                string
+		within              time.Duration
+		expectedRain        float32
+		expectedProbability float32
+	}{
+		{"NoRain", 3 * time.Hour, 0, 0},
+		{"Rain", 24 * time.Hour, 5, 100},
+	}
+
+	client := newTestClient(t, "testdata/weather.csv")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rain, err := client.GetForecastRain(tt.within)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedRain, rain)
+
+			probability, err := client.GetForecastRainProbability(tt.within)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedProbability, probability)
+		})
+	}
+}
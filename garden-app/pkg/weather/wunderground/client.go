@@ -0,0 +1,296 @@
+package wunderground
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather/history"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather/measurement"
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	baseURI = "https://api.weather.com"
+
+	minTemperatureInterval = 72 * time.Hour
+)
+
+// errForecastNotSupported is returned for forecast data because the personal weather station API only provides
+// measurements from the station
+var errForecastNotSupported = errors.New("weather underground personal stations do not provide forecast data")
+
+// Config is specific to the Weather Underground personal weather station (PWS) API and holds all of the necessary
+// fields for interacting with the API. StationID and APIKey are required. BaseURL is optional and defaults to the
+// public API
+type Config struct {
+	StationID string `json:"station_id,omitempty" yaml:"station_id,omitempty" mapstructure:"station_id,omitempty"`
+	APIKey    string `json:"api_key,omitempty" yaml:"api_key,omitempty" mapstructure:"api_key,omitempty"`
+	BaseURL   string `json:"base_url,omitempty" yaml:"base_url,omitempty" mapstructure:"base_url,omitempty"`
+}
+
+// Client is used to interact with the Weather Underground PWS API. This works for any personal station that uploads
+// to Weather Underground, including Ambient Weather and Ecowitt stations
+type Client struct {
+	*Config
+	*http.Client
+	baseURL *url.URL
+	now     func() time.Time
+}
+
+// NewClient creates a new Weather Underground API client from configuration
+func NewClient(options map[string]interface{}) (*Client, error) {
+	client := &Client{Client: http.DefaultClient, now: time.Now}
+
+	err := mapstructure.Decode(options, &client.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	if client.StationID == "" {
+		return nil, errors.New("station_id must be provided")
+	}
+	if client.APIKey == "" {
+		return nil, errors.New("api_key must be provided")
+	}
+
+	baseURL := client.BaseURL
+	if baseURL == "" {
+		baseURL = baseURI
+	}
+	client.baseURL, err = url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// metricData holds the measurements used by this client. Values are pointers since the API uses null for
+// measurements that the station does not report
+type metricData struct {
+	Temperature *float32 `json:"temp"`
+	TempHigh    *float32 `json:"tempHigh"`
+	WindSpeed   *float32 `json:"windSpeed"`
+	PrecipTotal *float32 `json:"precipTotal"`
+}
+
+// observation is a single current observation or daily summary from the station. Epoch is the unix timestamp of the
+// last observation included in it and TZ is the station's time zone
+type observation struct {
+	Epoch  int64      `json:"epoch"`
+	TZ     string     `json:"tz"`
+	Metric metricData `json:"metric"`
+}
+
+type currentResponse struct {
+	Observations []observation `json:"observations"`
+}
+
+type dailySummaryResponse struct {
+	Summaries []observation `json:"summaries"`
+}
+
+// GetTotalRain returns the sum of all rainfall in millimeters in the given period. Only daily totals are available,
+// so the total for a day that is partially in the period is prorated by how much of the day is in it. The API only
+// provides the last 7 days, including today
+func (c *Client) GetTotalRain(since time.Duration) (float32, error) {
+	summaries, err := c.getDailySummaries()
+	if err != nil {
+		return 0, err
+	}
+
+	now := c.now()
+	start := now.Add(-since)
+	total := float32(0)
+	for _, s := range summaries {
+		if s.Metric.PrecipTotal == nil {
+			continue
+		}
+
+		dayStart, dayEnd := c.day(s)
+		// Today's total only includes rain until now
+		if dayEnd.After(now) {
+			dayEnd = now
+		}
+		if !dayEnd.After(start) || !dayEnd.After(dayStart) {
+			continue
+		}
+
+		rain := *s.Metric.PrecipTotal
+		if dayStart.Before(start) {
+			rain *= float32(dayEnd.Sub(start)) / float32(dayEnd.Sub(dayStart))
+		}
+		total += rain
+	}
+	return total, nil
+}
+
+// GetAverageHighTemperature returns the average daily high temperature between the given time and the end of
+// yesterday (since daily high can be misleading if queried mid-day)
+func (c *Client) GetAverageHighTemperature(since time.Duration) (float32, error) {
+	// Time to check since must always be at least 3 days
+	if since < minTemperatureInterval {
+		since = minTemperatureInterval
+	}
+
+	summaries, err := c.getDailySummaries()
+	if err != nil {
+		return 0, err
+	}
+
+	now := c.now()
+	start := now.Add(-since)
+	total := float32(0)
+	count := 0
+	for _, s := range summaries {
+		if time.Unix(s.Epoch, 0).Before(start) || s.Metric.TempHigh == nil {
+			continue
+		}
+		// Today is excluded, even if the station has not reported yet today and it is not the last summary
+		if _, dayEnd := c.day(s); dayEnd.After(now) {
+			continue
+		}
+		total += *s.Metric.TempHigh
+		count++
+	}
+	if count == 0 {
+		return 0, errors.New("no daily temperature data found")
+	}
+	return total / float32(count), nil
+}
+
+// GetCurrentTemperature returns the current temperature in degrees Celsius
+func (c *Client) GetCurrentTemperature() (float32, error) {
+	current, err := c.getCurrentObservation()
+	if err != nil {
+		return 0, err
+	}
+	if current.Metric.Temperature == nil {
+		return 0, errors.New("station did not report temperature")
+	}
+	return *current.Metric.Temperature, nil
+}
+
+// GetCurrentWindSpeed returns the current wind speed in km/h
+func (c *Client) GetCurrentWindSpeed() (float32, error) {
+	current, err := c.getCurrentObservation()
+	if err != nil {
+		return 0, err
+	}
+	if current.Metric.WindSpeed == nil {
+		return 0, errors.New("station did not report wind speed")
+	}
+	return *current.Metric.WindSpeed, nil
+}
+
+// GetForecastRain is not supported by personal weather stations and always returns an error
+func (c *Client) GetForecastRain(_ time.Duration) (float32, error) {
+	return 0, errForecastNotSupported
+}
+
+// GetForecastRainProbability is not supported by personal weather stations and always returns an error
+func (c *Client) GetForecastRainProbability(_ time.Duration) (float32, error) {
+	return 0, errForecastNotSupported
+}
+
+// GetForecastLowTemperature is not supported by personal weather stations and always returns an error
+func (c *Client) GetForecastLowTemperature(_ time.Duration) (float32, error) {
+	return 0, errForecastNotSupported
+}
+
+// GetHistory is not supported because the API only provides daily summaries in the station's local time and always
+// returns an error
+func (c *Client) GetHistory(_, _ time.Time, _ time.Duration) ([]*history.Observation, error) {
+	return nil, errors.New("weather underground history is not supported")
+}
+
+// day returns the start and end of the day that a daily summary covers, in the station's time zone. If the time zone
+// is unknown, the local time zone is used
+func (c *Client) day(s observation) (time.Time, time.Time) {
+	loc := c.now().Location()
+	if s.TZ != "" {
+		if stationLoc, err := time.LoadLocation(s.TZ); err == nil {
+			loc = stationLoc
+		}
+	}
+
+	t := time.Unix(s.Epoch, 0).In(loc)
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	return start, start.AddDate(0, 0, 1)
+}
+
+func (c *Client) getCurrentObservation() (*observation, error) {
+	var resp currentResponse
+	err := c.get("/v2/pws/observations/current", &resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Observations) == 0 {
+		return nil, errors.New("no current observations found")
+	}
+	return &resp.Observations[0], nil
+}
+
+// getDailySummaries returns the daily summaries for the last 7 days, ordered from oldest to newest
+func (c *Client) getDailySummaries() ([]observation, error) {
+	var resp dailySummaryResponse
+	err := c.get("/v2/pws/dailysummary/7day", &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Summaries, nil
+}
+
+func (c *Client) get(path string, result interface{}) error {
+	requestURL := *c.baseURL
+	requestURL.Path = strings.TrimSuffix(requestURL.Path, "/") + path
+	requestURL.RawQuery = url.Values{
+		"stationId": {c.StationID},
+		"apiKey":    {c.APIKey},
+		"format":    {"json"},
+		"units":     {"m"},
+	}.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, requestURL.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Accept", "application/json")
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response body with status %d: %v", resp.StatusCode, err)
+	}
+
+	// The API responds with 204 No Content when the station has not reported recently
+	if resp.StatusCode == http.StatusNoContent {
+		return fmt.Errorf("no data available for station %q", c.StationID)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("received unexpected status %d with body: %s", resp.StatusCode, string(respBody))
+	}
+
+	err = json.Unmarshal(respBody, result)
+	if err != nil {
+		return fmt.Errorf("unable to read response body '%s': %v", string(respBody), err)
+	}
+
+	return nil
+}
+
+// GetMeasurement is not implemented for this client and always returns an error
+func (c *Client) GetMeasurement(q measurement.Query) (float32, error) {
+	return 0, measurement.NotSupportedError("weather underground", q)
+}
@@ -0,0 +1,224 @@
+package wunderground
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var now = time.Date(2023, time.August, 23, 12, 30, 0, 0, time.UTC)
+
+// endOfDay returns the epoch of the last observation for the day at the offset from today
+func endOfDay(offset int) int64 {
+	return now.Truncate(24*time.Hour).AddDate(0, 0, offset+1).Add(-time.Minute).Unix()
+}
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(map[string]interface{}{
+		"station_id": "KAZPHOEN123",
+		"api_key":    "api-key",
+		"base_url":   server.URL,
+	})
+	assert.NoError(t, err)
+	client.now = func() time.Time { return now }
+	return client
+}
+
+func TestNewClient(t *testing.T) {
+	tests := []struct {
+		name          string
+		options       map[string]interface{}
+		expectedError string
+	}{
+		{
+			"Successful",
+			map[string]interface{}{"station_id": "KAZPHOEN123", "api_key": "api-key"},
+			"",
+		},
+		{
+			"MissingStationID",
+			map[string]interface{}{"api_key": "api-key"},
+			"station_id must be provided",
+		},
+		{
+			"MissingAPIKey",
+			map[string]interface{}{"station_id": "KAZPHOEN123"},
+			"api_key must be provided",
+		},
+		{
+			"InvalidOptionType",
+			map[string]interface{}{"station_id": []string{"KAZPHOEN123"}},
+			"1 error(s) decoding:\n\n* 'station_id' expected type 'string', got unconvertible type '[]string', value: '[KAZPHOEN123]'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient(tt.options)
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, baseURI, client.baseURL.String())
+		})
+	}
+}
+
+func TestGetTotalRain(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/pws/dailysummary/7day", r.URL.Path)
+		assert.Equal(t, "KAZPHOEN123", r.URL.Query().Get("stationId"))
+		assert.Equal(t, "api-key", r.URL.Query().Get("apiKey"))
+		assert.Equal(t, "m", r.URL.Query().Get("units"))
+
+		// Days before the range are not included, null values are ignored, and yesterday is prorated since only the
+		// last 11.5 hours of it are in the range
+		fmt.Fprintf(w,
+			`{"summaries":[{"epoch":%d,"metric":{"precipTotal":5}},{"epoch":%d,"metric":{"precipTotal":null}},{"epoch":%d,"metric":{"precipTotal":4.8}},{"epoch":%d,"metric":{"precipTotal":2}}]}`,
+			endOfDay(-3), endOfDay(-2), endOfDay(-1), now.Add(-time.Minute).Unix(),
+		)
+	})
+
+	rain, err := client.GetTotalRain(24 * time.Hour)
+	assert.NoError(t, err)
+	assert.InDelta(t, float32(4.3), rain, 0.0001)
+}
+
+func TestGetTotalRainStationTimeZone(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		// In Phoenix (UTC-7), it is 05:30 and today's total only covers 5.5 hours. Half of it is in the range
+		fmt.Fprintf(w,
+			`{"summaries":[{"epoch":%d,"tz":"America/Phoenix","metric":{"precipTotal":10}},{"epoch":%d,"tz":"America/Phoenix","metric":{"precipTotal":2}}]}`,
+			now.Add(-6*time.Hour).Unix(), now.Add(-time.Minute).Unix(),
+		)
+	})
+
+	rain, err := client.GetTotalRain(165 * time.Minute)
+	assert.NoError(t, err)
+	assert.InDelta(t, float32(1), rain, 0.0001)
+}
+
+func TestGetAverageHighTemperature(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/pws/dailysummary/7day", r.URL.Path)
+
+		// minimum of 3 days is used and the last value is today, which is not included
+		fmt.Fprintf(w,
+			`{"summaries":[{"epoch":%d,"metric":{"tempHigh":20}},{"epoch":%d,"metric":{"tempHigh":30}},{"epoch":%d,"metric":{"tempHigh":33}},{"epoch":%d,"metric":{"tempHigh":36}},{"epoch":%d,"metric":{"tempHigh":45}}]}`,
+			endOfDay(-4), endOfDay(-3), endOfDay(-2), endOfDay(-1), endOfDay(0),
+		)
+	})
+
+	temperature, err := client.GetAverageHighTemperature(24 * time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, float32(33), temperature)
+}
+
+func TestGetAverageHighTemperatureWithoutToday(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		// The station has not reported today, so the last summary is yesterday and is included
+		fmt.Fprintf(w,
+			`{"summaries":[{"epoch":%d,"metric":{"tempHigh":30}},{"epoch":%d,"metric":{"tempHigh":33}},{"epoch":%d,"metric":{"tempHigh":39}}]}`,
+			endOfDay(-3), endOfDay(-2), endOfDay(-1),
+		)
+	})
+
+	temperature, err := client.GetAverageHighTemperature(72 * time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, float32(34), temperature)
+}
+
+func TestGetAverageHighTemperatureNoData(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"summaries":[{"epoch":%d,"metric":{"tempHigh":45}}]}`, endOfDay(0))
+	})
+
+	_, err := client.GetAverageHighTemperature(72 * time.Hour)
+	assert.Error(t, err)
+	assert.Equal(t, "no daily temperature data found", err.Error())
+}
+
+func TestGetCurrentConditions(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/pws/observations/current", r.URL.Path)
+		fmt.Fprint(w, `{"observations":[{"epoch":1692793800,"metric":{"temp":-1.5,"windSpeed":22.3}}]}`)
+	})
+
+	temperature, err := client.GetCurrentTemperature()
+	assert.NoError(t, err)
+	assert.Equal(t, float32(-1.5), temperature)
+
+	windSpeed, err := client.GetCurrentWindSpeed()
+	assert.NoError(t, err)
+	assert.Equal(t, float32(22.3), windSpeed)
+}
+
+func TestGetCurrentConditionsNotReported(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"observations":[{"epoch":1692793800,"metric":{"temp":null,"windSpeed":null}}]}`)
+	})
+
+	_, err := client.GetCurrentTemperature()
+	assert.Error(t, err)
+	assert.Equal(t, "station did not report temperature", err.Error())
+
+	_, err = client.GetCurrentWindSpeed()
+	assert.Error(t, err)
+	assert.Equal(t, "station did not report wind speed", err.Error())
+}
+
+func TestGetForecast(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("unexpected request for forecast data")
+	})
+
+	_, err := client.GetForecastRain(24 * time.Hour)
+	assert.ErrorIs(t, err, errForecastNotSupported)
+
+	_, err = client.GetForecastRainProbability(24 * time.Hour)
+	assert.ErrorIs(t, err, errForecastNotSupported)
+}
+
+func TestUnexpectedStatus(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		body          string
+		expectedError string
+	}{
+		{
+			"Unauthorized",
+			http.StatusUnauthorized,
+			`{"success":false,"errors":[{"error":{"code":"CDN-0001","message":"Invalid apiKey."}}]}`,
+			`received unexpected status 401 with body: {"success":false,"errors":[{"error":{"code":"CDN-0001","message":"Invalid apiKey."}}]}`,
+		},
+		{
+			"NoContent",
+			http.StatusNoContent,
+			"",
+			`no data available for station "KAZPHOEN123"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			})
+
+			_, err := client.GetCurrentTemperature()
+			assert.Error(t, err)
+			assert.Equal(t, tt.expectedError, err.Error())
+		})
+	}
+}
@@ -93,6 +93,20 @@ type WaterHistory struct {
 	RecordTime time.Time `json:"record_time"`
 }
 
+// WaterPulse records a single pulse of closed-loop moisture watering and the soil moisture that was read before it
+type WaterPulse struct {
+	ZoneID          xid.ID    `json:"zone_id" yaml:"zone_id"`
+	WaterScheduleID xid.ID    `json:"water_schedule_id" yaml:"water_schedule_id"`
+	Duration        *Duration `json:"duration" yaml:"duration"`
+	Moisture        float64   `json:"moisture" yaml:"moisture"`
+	Time            time.Time `json:"time" yaml:"time"`
+}
+
+// EndDated allows this to satisfy an interface even though the resources does not have end-dates
+func (wp *WaterPulse) EndDated() bool {
+	return false
+}
+
 // ZoneAndGarden allows grouping the Zone and Garden it belongs too and is useful in some cases
 // where both are needed in a return value
 type ZoneAndGarden struct {
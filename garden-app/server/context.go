@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/calvinmclean/automated-garden/garden-app/pkg"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/units"
 	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather"
 	"github.com/sirupsen/logrus"
 )
@@ -17,6 +18,7 @@ const (
 	zoneCtxKey
 	weatherClientCtxKey
 	waterScheduleCtxKey
+	unitSystemCtxKey
 )
 
 func newContextWithLogger(ctx context.Context, logger *logrus.Entry) context.Context {
@@ -70,3 +72,19 @@ func newContextWithWaterSchedule(ctx context.Context, ws *pkg.WaterSchedule) con
 func getWaterScheduleFromContext(ctx context.Context) *pkg.WaterSchedule {
 	return ctx.Value(waterScheduleCtxKey).(*pkg.WaterSchedule)
 }
+
+func newContextWithUnitSystem(ctx context.Context, system units.System) context.Context {
+	return context.WithValue(ctx, unitSystemCtxKey, system)
+}
+
+// getUnitSystem returns the unit system requested with the units query parameter. If it was not requested, the
+// Garden's UnitSystem is used. The Garden is nil for resources that do not belong to a Garden
+func getUnitSystem(ctx context.Context, garden *pkg.Garden) units.System {
+	if system, ok := ctx.Value(unitSystemCtxKey).(units.System); ok && system != "" {
+		return system
+	}
+	if garden != nil && garden.UnitSystem != "" {
+		return garden.UnitSystem
+	}
+	return units.Metric
+}
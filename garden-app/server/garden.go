@@ -17,6 +17,7 @@ import (
 const (
 	gardenBasePath   = "/gardens"
 	gardenPathParam  = "gardenID"
+	commandPathParam = "commandID"
 	gardenIDLogField = "garden_id"
 )
 
@@ -128,6 +129,8 @@ func (gr GardensResource) createGarden(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	gr.worker.UpdateHomeAssistantDiscovery(garden)
+
 	render.Status(r, http.StatusCreated)
 	if err := render.Render(w, r, gr.NewGardenResponse(r.Context(), garden)); err != nil {
 		logger.WithError(err).Error("unable to render GardenResponse")
@@ -220,6 +223,8 @@ func (gr GardensResource) endDateGarden(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	gr.worker.UpdateHomeAssistantDiscovery(garden)
+
 	if err := render.Render(w, r, gr.NewGardenResponse(r.Context(), garden)); err != nil {
 		logger.WithError(err).Error("unable to render GardenResponse")
 		render.Render(w, r, ErrRender(err))
@@ -282,6 +287,8 @@ func (gr GardensResource) updateGarden(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	gr.worker.UpdateHomeAssistantDiscovery(garden)
+
 	if err := render.Render(w, r, gr.NewGardenResponse(r.Context(), garden)); err != nil {
 		logger.WithError(err).Error("unable to render GardenResponse")
 		render.Render(w, r, ErrRender(err))
@@ -305,12 +312,65 @@ func (gr GardensResource) gardenAction(w http.ResponseWriter, r *http.Request) {
 	}
 	logger.Debugf("garden action: %+v", action)
 
-	if err := gr.worker.ExecuteGardenAction(garden, action.GardenAction); err != nil {
+	commandIDs, err := gr.worker.ExecuteGardenAction(garden, action.GardenAction)
+	if err != nil {
 		logger.WithError(err).Error("unable to execute GardenAction")
 		render.Render(w, r, InternalServerError(err))
 		return
 	}
 
-	render.Status(r, http.StatusAccepted)
-	render.DefaultResponder(w, r, nil)
+	if err := render.Render(w, r, &ActionResponse{commandIDs}); err != nil {
+		logger.WithError(err).Error("unable to render ActionResponse")
+		render.Render(w, r, ErrRender(err))
+	}
+}
+
+// getGardenCommands responds with the status of recent commands sent to the Garden
+func (gr GardensResource) getGardenCommands(w http.ResponseWriter, r *http.Request) {
+	logger := getLoggerFromContext(r.Context())
+	logger.Info("received request to get Garden commands")
+
+	garden := getGardenFromContext(r.Context())
+
+	commands, err := gr.worker.GardenCommands(garden)
+	if err != nil {
+		logger.WithError(err).Error("unable to get Garden commands")
+		render.Render(w, r, InternalServerError(err))
+		return
+	}
+
+	if err := render.Render(w, r, &GardenCommandsResponse{commands}); err != nil {
+		logger.WithError(err).Error("unable to render GardenCommandsResponse")
+		render.Render(w, r, ErrRender(err))
+	}
+}
+
+// getGardenCommand responds with the status of a single command sent to the Garden
+func (gr GardensResource) getGardenCommand(w http.ResponseWriter, r *http.Request) {
+	commandID := chi.URLParam(r, commandPathParam)
+	logger := getLoggerFromContext(r.Context()).WithField("command_id", commandID)
+	logger.Info("received request to get Garden command")
+
+	garden := getGardenFromContext(r.Context())
+
+	commands, err := gr.worker.GardenCommands(garden)
+	if err != nil {
+		logger.WithError(err).Error("unable to get Garden commands")
+		render.Render(w, r, InternalServerError(err))
+		return
+	}
+
+	for _, cmd := range commands {
+		if cmd.ID != commandID {
+			continue
+		}
+		if err := render.Render(w, r, &GardenCommandResponse{cmd}); err != nil {
+			logger.WithError(err).Error("unable to render GardenCommandResponse")
+			render.Render(w, r, ErrRender(err))
+		}
+		return
+	}
+
+	logger.Info("command not found")
+	render.Render(w, r, ErrNotFoundResponse)
 }
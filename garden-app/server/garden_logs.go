@@ -0,0 +1,132 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/logs"
+	"github.com/go-chi/render"
+)
+
+const eventStreamContentType = "text/event-stream"
+
+// isEventStream returns true if the client requested a stream of server-sent events
+func isEventStream(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), eventStreamContentType)
+}
+
+// getGardenLogs responds with the log messages published by the Garden's controller. The "level" query parameter
+// sets the minimum level and "range" limits the results to recent logs. If the request accepts an event stream,
+// the matching logs are followed by new logs as they are received
+func (gr GardensResource) getGardenLogs(w http.ResponseWriter, r *http.Request) {
+	logger := getLoggerFromContext(r.Context())
+	logger.Info("received request to get Garden logs")
+
+	if !gr.worker.ControllerLogsEnabled() {
+		logger.Error("controller logs are not enabled")
+		render.Render(w, r, ErrInvalidRequest(errors.New("controller logs are not enabled")))
+		return
+	}
+
+	garden := getGardenFromContext(r.Context())
+
+	var timeRange time.Duration
+	if timeRangeString := r.URL.Query().Get("range"); timeRangeString != "" {
+		var err error
+		timeRange, err = time.ParseDuration(timeRangeString)
+		if err != nil {
+			logger.WithError(err).Error("unable to parse time range")
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
+	}
+	filter, err := logs.NewFilter(r.URL.Query().Get("level"), timeRange, time.Now())
+	if err != nil {
+		logger.WithError(err).Error("unable to parse level")
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	if isEventStream(r) {
+		gr.tailGardenLogs(w, r, garden, filter)
+		return
+	}
+
+	entries, err := gr.worker.ControllerLogs(garden, filter)
+	if err != nil {
+		logger.WithError(err).Error("unable to get Garden logs")
+		render.Render(w, r, InternalServerError(err))
+		return
+	}
+
+	if err := render.Render(w, r, &GardenLogsResponse{entries}); err != nil {
+		logger.WithError(err).Error("unable to render GardenLogsResponse")
+		render.Render(w, r, ErrRender(err))
+	}
+}
+
+// tailGardenLogs writes the Garden's stored logs and then new logs as server-sent events until the client disconnects
+func (gr GardensResource) tailGardenLogs(w http.ResponseWriter, r *http.Request, garden *pkg.Garden, filter logs.Filter) {
+	logger := getLoggerFromContext(r.Context())
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		logger.Error("unable to stream Garden logs")
+		render.Render(w, r, InternalServerError(errors.New("streaming is not supported")))
+		return
+	}
+
+	// Start the tail before getting stored logs so new logs are not missed
+	tail, stop := gr.worker.TailControllerLogs(garden)
+	defer stop()
+
+	entries, err := gr.worker.ControllerLogs(garden, filter)
+	if err != nil {
+		logger.WithError(err).Error("unable to get Garden logs")
+		render.Render(w, r, InternalServerError(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", eventStreamContentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	writeEntry := func(entry logs.Entry) error {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "data: %s\n\n", data)
+		return err
+	}
+
+	for _, entry := range entries {
+		if err := writeEntry(entry); err != nil {
+			logger.WithError(err).Error("unable to write Garden log")
+			return
+		}
+	}
+	flusher.Flush()
+
+	logger.Info("following Garden logs")
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case entry := <-tail:
+			if !filter.Match(entry) {
+				continue
+			}
+			if err := writeEntry(entry); err != nil {
+				logger.WithError(err).Error("unable to write Garden log")
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/logs"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/mqtt"
+	"github.com/calvinmclean/automated-garden/garden-app/worker"
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// logMessage implements paho.Message for sending logs to the worker's handler
+type logMessage struct {
+	paho.Message
+	payload string
+}
+
+func (m logMessage) Topic() string   { return "test-garden/data/logs" }
+func (m logMessage) Payload() []byte { return []byte(m.payload) }
+
+// setupGardenLogs creates a router for the logs endpoint and returns the handler subscribed to controller logs
+func setupGardenLogs(t *testing.T, enabled bool) (*chi.Mux, *mqtt.TopicHandler) {
+	t.Helper()
+
+	storageClient := setupZonePlantGardenStorage(t)
+	assert.NoError(t, storageClient.SaveControllerLogs(id, []logs.Entry{
+		{Time: time.Date(2023, time.August, 23, 10, 0, 0, 0, time.UTC), Level: "info", Message: "setup complete"},
+		{Time: time.Date(2023, time.August, 23, 10, 1, 0, 0, time.UTC), Level: "error", Message: "unable to read sensor"},
+	}))
+
+	handler := &mqtt.TopicHandler{}
+	mqttClient := mqtt.NewMockClient(t)
+	w := worker.NewWorker(storageClient, nil, mqttClient, logrus.New())
+	if enabled {
+		mqttClient.On("Subscribe", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+			*handler = args.Get(0).(mqtt.TopicHandler)
+		})
+		// Retention is long enough to keep the example entries
+		assert.NoError(t, w.StartControllerLogs(logs.Config{Retention: 100 * 365 * 24 * time.Hour}))
+	}
+
+	gr := GardensResource{
+		storageClient: storageClient,
+		worker:        w,
+	}
+
+	router := chi.NewRouter()
+	router.Route(fmt.Sprintf("/gardens/{%s}", gardenPathParam), func(r chi.Router) {
+		r.Use(gr.gardenContextMiddleware)
+		r.Get("/logs", gr.getGardenLogs)
+	})
+	return router, handler
+}
+
+func TestGetGardenLogs(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  bool
+		path     string
+		expected string
+		code     int
+	}{
+		{
+			"Successful",
+			true,
+			"/gardens/c5cvhpcbcv45e8bp16dg/logs",
+			`{"logs":[{"time":"2023-08-23T10:00:00Z","level":"info","message":"setup complete"},{"time":"2023-08-23T10:01:00Z","level":"error","message":"unable to read sensor"}]}`,
+			http.StatusOK,
+		},
+		{
+			"SuccessfulFilterLevel",
+			true,
+			"/gardens/c5cvhpcbcv45e8bp16dg/logs?level=warn",
+			`{"logs":[{"time":"2023-08-23T10:01:00Z","level":"error","message":"unable to read sensor"}]}`,
+			http.StatusOK,
+		},
+		{
+			"SuccessfulFilterRange",
+			true,
+			"/gardens/c5cvhpcbcv45e8bp16dg/logs?range=1h",
+			`{"logs":[]}`,
+			http.StatusOK,
+		},
+		{
+			"InvalidLevel",
+			true,
+			"/gardens/c5cvhpcbcv45e8bp16dg/logs?level=loud",
+			`{"status":"Invalid request.","error":"not a valid logrus Level: \"loud\""}`,
+			http.StatusBadRequest,
+		},
+		{
+			"InvalidRange",
+			true,
+			"/gardens/c5cvhpcbcv45e8bp16dg/logs?range=forever",
+			`{"status":"Invalid request.","error":"time: invalid duration \"forever\""}`,
+			http.StatusBadRequest,
+		},
+		{
+			"NotEnabled",
+			false,
+			"/gardens/c5cvhpcbcv45e8bp16dg/logs",
+			`{"status":"Invalid request.","error":"controller logs are not enabled"}`,
+			http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, _ := setupGardenLogs(t, tt.enabled)
+
+			r := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.code, w.Code)
+			assert.Equal(t, tt.expected, strings.TrimSpace(w.Body.String()))
+		})
+	}
+}
+
+func TestGetGardenLogsEventStream(t *testing.T) {
+	router, handler := setupGardenLogs(t, true)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", server.URL+"/gardens/c5cvhpcbcv45e8bp16dg/logs?level=warn", nil)
+	assert.NoError(t, err)
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	readEvent := func() string {
+		line, err := reader.ReadString('\n')
+		assert.NoError(t, err)
+		_, err = reader.ReadString('\n')
+		assert.NoError(t, err)
+		return strings.TrimSpace(line)
+	}
+
+	assert.Equal(t, `data: {"time":"2023-08-23T10:01:00Z","level":"error","message":"unable to read sensor"}`, readEvent())
+
+	// Logs below the level are not sent
+	handler.Handler(nil, logMessage{payload: `logs message="ignored"`})
+	handler.Handler(nil, logMessage{payload: `logs,level=warn message="new warning" 1692784800000000000`})
+
+	assert.Equal(t, `data: {"time":"2023-08-23T10:00:00Z","level":"warn","message":"new warning"}`, readEvent())
+}
@@ -62,10 +62,44 @@ func (g *GardenRequest) Bind(_ *http.Request) error {
 			return fmt.Errorf("invalid time format for light_schedule.start_time: %s", g.LightSchedule.StartTime)
 		}
 	}
+	if g.HealthConfig != nil {
+		err := ValidateHealthConfig(g.HealthConfig)
+		if err != nil {
+			return fmt.Errorf("error validating health_config: %w", err)
+		}
+	}
+	if g.SensorConfig != nil {
+		err := ValidateSensorConfig(g.SensorConfig)
+		if err != nil {
+			return fmt.Errorf("error validating sensor_config: %w", err)
+		}
+	}
+	if err := g.UnitSystem.Validate(); err != nil {
+		return err
+	}
 
 	return nil
 }
 
+// ValidateHealthConfig validates input for a Garden's HealthConfig
+func ValidateHealthConfig(hc *pkg.HealthConfig) error {
+	if hc.Window != nil && hc.Window.Duration <= 0 {
+		return errors.New("window must be a positive duration")
+	}
+	if hc.UpThreshold != nil && hc.UpThreshold.Duration <= 0 {
+		return errors.New("up_threshold must be a positive duration")
+	}
+	return nil
+}
+
+// ValidateSensorConfig validates input for a Garden's SensorConfig
+func ValidateSensorConfig(sc *pkg.SensorConfig) error {
+	if sc.Window != nil && sc.Window.Duration <= 0 {
+		return errors.New("window must be a positive duration")
+	}
+	return sc.Aggregation.Validate()
+}
+
 // UpdateGardenRequest wraps a GardenRequest to change how validation occurs
 type UpdateGardenRequest struct {
 	*pkg.Garden
@@ -106,6 +140,21 @@ func (g *UpdateGardenRequest) Bind(_ *http.Request) error {
 			}
 		}
 	}
+	if g.HealthConfig != nil {
+		err := ValidateHealthConfig(g.HealthConfig)
+		if err != nil {
+			return fmt.Errorf("error validating health_config: %w", err)
+		}
+	}
+	if g.SensorConfig != nil {
+		err := ValidateSensorConfig(g.SensorConfig)
+		if err != nil {
+			return fmt.Errorf("error validating sensor_config: %w", err)
+		}
+	}
+	if err := g.UnitSystem.Validate(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -276,6 +276,30 @@ func TestGardenRequest(t *testing.T) {
 			},
 			"invalid time format for light_schedule.start_time: NOT A TIME",
 		},
+		{
+			"InvalidSensorConfigAggregationError",
+			&GardenRequest{
+				Garden: &pkg.Garden{
+					Name:         "garden",
+					TopicPrefix:  "garden",
+					MaxZones:     &one,
+					SensorConfig: &pkg.SensorConfig{Aggregation: "max"},
+				},
+			},
+			"error validating sensor_config: invalid aggregation \"max\", must be one of: mean, min, median, last",
+		},
+		{
+			"InvalidUnitSystemError",
+			&GardenRequest{
+				Garden: &pkg.Garden{
+					Name:        "garden",
+					TopicPrefix: "garden",
+					MaxZones:    &one,
+					UnitSystem:  "kelvin",
+				},
+			},
+			"invalid unit system \"kelvin\", must be one of: metric, imperial",
+		},
 	}
 
 	t.Run("Successful", func(t *testing.T) {
@@ -412,6 +436,15 @@ func TestUpdateGardenRequest(t *testing.T) {
 			},
 			"invalid time format for light_schedule.start_time: NOT A TIME",
 		},
+		{
+			"InvalidHealthConfigUpThresholdError",
+			&UpdateGardenRequest{
+				Garden: &pkg.Garden{
+					HealthConfig: &pkg.HealthConfig{UpThreshold: &pkg.Duration{Duration: -time.Minute}},
+				},
+			},
+			"error validating health_config: up_threshold must be a positive duration",
+		},
 		{
 			"EndDateError",
 			&UpdateGardenRequest{
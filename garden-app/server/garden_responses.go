@@ -7,6 +7,10 @@ import (
 	"time"
 
 	"github.com/calvinmclean/automated-garden/garden-app/pkg"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/logs"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/mqtt"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/units"
+	"github.com/calvinmclean/automated-garden/garden-app/worker"
 )
 
 // GardenResponse is used to represent a Garden in the response body with the additional Moisture data
@@ -14,6 +18,7 @@ import (
 type GardenResponse struct {
 	*pkg.Garden
 	NextLightAction         *NextLightAction         `json:"next_light_action,omitempty"`
+	State                   *worker.GardenState      `json:"state,omitempty"`
 	Health                  *pkg.GardenHealth        `json:"health,omitempty"`
 	TemperatureHumidityData *TemperatureHumidityData `json:"temperature_humidity_data,omitempty"`
 	NumPlants               uint                     `json:"num_plants"`
@@ -29,10 +34,12 @@ type NextLightAction struct {
 	State pkg.LightState `json:"state"`
 }
 
-// TemperatureHumidityData has the temperature and humidity of the Garden
+// TemperatureHumidityData has the temperature and humidity of the Garden. Temperature is in Celsius or Fahrenheit
+// depending on the unit system
 type TemperatureHumidityData struct {
-	TemperatureCelsius float64 `json:"temperature_celsius"`
-	HumidityPercentage float64 `json:"humidity_percentage"`
+	TemperatureCelsius    *float64 `json:"temperature_celsius,omitempty"`
+	TemperatureFahrenheit *float64 `json:"temperature_fahrenheit,omitempty"`
+	HumidityPercentage    float64  `json:"humidity_percentage"`
 }
 
 // NewGardenResponse creates a self-referencing GardenResponse
@@ -73,6 +80,7 @@ func (gr GardensResource) NewGardenResponse(ctx context.Context, garden *pkg.Gar
 	)
 
 	response.Health = garden.Health(ctx, gr.influxdbClient)
+	response.State = gr.worker.GardenState(garden)
 
 	if garden.LightSchedule != nil {
 		nextOnTime := gr.worker.GetNextLightTime(garden, pkg.LightStateOn)
@@ -104,16 +112,21 @@ func (gr GardensResource) NewGardenResponse(ctx context.Context, garden *pkg.Gar
 	}
 
 	if garden.HasTemperatureHumiditySensor() {
-		t, h, err := gr.influxdbClient.GetTemperatureAndHumidity(ctx, garden.TopicPrefix)
+		t, h, err := gr.influxdbClient.GetTemperatureAndHumidity(ctx, garden.TopicPrefix, garden.SensorWindow(), garden.SensorAggregation())
 		if err != nil {
 			logger := getLoggerFromContext(ctx).WithField(gardenIDLogField, garden.ID.String())
 			logger.WithError(err).Error("error getting temperature and humidity data: %w", err)
 			return response
 		}
 		response.TemperatureHumidityData = &TemperatureHumidityData{
-			TemperatureCelsius: t,
+			TemperatureCelsius: &t,
 			HumidityPercentage: h,
 		}
+		if getUnitSystem(ctx, garden) == units.Imperial {
+			fahrenheit := units.CelsiusToFahrenheit(t)
+			response.TemperatureHumidityData.TemperatureCelsius = nil
+			response.TemperatureHumidityData.TemperatureFahrenheit = &fahrenheit
+		}
 	}
 
 	return response
@@ -144,3 +157,36 @@ func (gr GardensResource) NewAllGardensResponse(ctx context.Context, gardens []*
 func (pr *AllGardensResponse) Render(_ http.ResponseWriter, _ *http.Request) error {
 	return nil
 }
+
+// GardenCommandsResponse is used to return the status of recent commands sent to a Garden
+type GardenCommandsResponse struct {
+	Commands []mqtt.TrackedCommand `json:"commands"`
+}
+
+// Render is used to make this struct compatible with the go-chi webserver for writing
+// the JSON response
+func (resp *GardenCommandsResponse) Render(_ http.ResponseWriter, _ *http.Request) error {
+	return nil
+}
+
+// GardenLogsResponse is used to return the log messages published by a Garden's controller
+type GardenLogsResponse struct {
+	Logs []logs.Entry `json:"logs"`
+}
+
+// Render is used to make this struct compatible with the go-chi webserver for writing
+// the JSON response
+func (resp *GardenLogsResponse) Render(_ http.ResponseWriter, _ *http.Request) error {
+	return nil
+}
+
+// GardenCommandResponse is used to return the status of a single command sent to a Garden
+type GardenCommandResponse struct {
+	mqtt.TrackedCommand
+}
+
+// Render is used to make this struct compatible with the go-chi webserver for writing
+// the JSON response
+func (resp *GardenCommandResponse) Render(_ http.ResponseWriter, _ *http.Request) error {
+	return nil
+}
@@ -73,7 +73,7 @@ func TestGetGarden(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			influxdbClient := new(influxdb.MockClient)
-			influxdbClient.On("GetLastContact", mock.Anything, "test-garden").Return(time.Now(), nil)
+			influxdbClient.On("GetLastContact", mock.Anything, "test-garden", influxdb.DefaultWindow).Return(time.Now(), nil)
 			storageClient := setupZonePlantGardenStorage(t)
 			gr := GardensResource{
 				storageClient:  storageClient,
@@ -185,6 +185,13 @@ func TestCreateGarden(t *testing.T) {
 			`{"name":"test-garden","topic_prefix":"test-garden","id":"[0-9a-v]{20}","max_zones":2,"created_at":"\d{4}-\d{2}-\d\dT\d\d:\d\d:\d\d\.\d+(-07:00|Z)","temperature_humidity_sensor":true,"health":{"status":"UP","details":"last contact from Garden was \d+(s|ms) ago","last_contact":"\d{4}-\d{2}-\d\dT\d\d:\d\d:\d\d\.\d+(-07:00|Z)"},"temperature_humidity_data":{"temperature_celsius":50,"humidity_percentage":50},"num_plants":0,"num_zones":0,"plants":{"rel":"collection","href":"/gardens/[0-9a-v]{20}/plants"},"zones":{"rel":"collection","href":"/gardens/[0-9a-v]{20}/zones"},"links":\[{"rel":"self","href":"/gardens/[0-9a-v]{20}"},{"rel":"plants","href":"/gardens/[0-9a-v]{20}/plants"},{"rel":"zones","href":"/gardens/[0-9a-v]{20}/zones"},{"rel":"action","href":"/gardens/[0-9a-v]{20}/action"}\]}`,
 			http.StatusCreated,
 		},
+		{
+			"SuccessfulWithImperialUnitSystem",
+			`{"name": "test-garden", "topic_prefix": "test-garden", "max_zones": 2, "temperature_humidity_sensor": true, "unit_system": "imperial"}`,
+			false,
+			`{"name":"test-garden","topic_prefix":"test-garden","id":"[0-9a-v]{20}","max_zones":2,"created_at":"\d{4}-\d{2}-\d\dT\d\d:\d\d:\d\d\.\d+(-07:00|Z)","temperature_humidity_sensor":true,"unit_system":"imperial","health":{"status":"UP","details":"last contact from Garden was \d+(s|ms) ago","last_contact":"\d{4}-\d{2}-\d\dT\d\d:\d\d:\d\d\.\d+(-07:00|Z)"},"temperature_humidity_data":{"temperature_fahrenheit":122,"humidity_percentage":50},"num_plants":0,"num_zones":0,"plants":{"rel":"collection","href":"/gardens/[0-9a-v]{20}/plants"},"zones":{"rel":"collection","href":"/gardens/[0-9a-v]{20}/zones"},"links":\[{"rel":"self","href":"/gardens/[0-9a-v]{20}"},{"rel":"plants","href":"/gardens/[0-9a-v]{20}/plants"},{"rel":"zones","href":"/gardens/[0-9a-v]{20}/zones"},{"rel":"action","href":"/gardens/[0-9a-v]{20}/action"}\]}`,
+			http.StatusCreated,
+		},
 		{
 			"SuccessfulButErrorGettingTemperatureAndHumidity",
 			`{"name": "test-garden", "topic_prefix": "test-garden", "max_zones": 2, "temperature_humidity_sensor": true}`,
@@ -223,11 +230,11 @@ func TestCreateGarden(t *testing.T) {
 			assert.NoError(t, err)
 
 			influxdbClient := new(influxdb.MockClient)
-			influxdbClient.On("GetLastContact", mock.Anything, "test-garden").Return(time.Now(), nil)
+			influxdbClient.On("GetLastContact", mock.Anything, "test-garden", influxdb.DefaultWindow).Return(time.Now(), nil)
 			if tt.temperatureHumidityError {
-				influxdbClient.On("GetTemperatureAndHumidity", mock.Anything, "test-garden").Return(0.0, 0.0, errors.New("influxdb error"))
+				influxdbClient.On("GetTemperatureAndHumidity", mock.Anything, "test-garden", time.Duration(0), influxdb.Aggregation("")).Return(0.0, 0.0, errors.New("influxdb error"))
 			} else {
-				influxdbClient.On("GetTemperatureAndHumidity", mock.Anything, "test-garden").Return(50.0, 50.0, nil)
+				influxdbClient.On("GetTemperatureAndHumidity", mock.Anything, "test-garden", time.Duration(0), influxdb.Aggregation("")).Return(50.0, 50.0, nil)
 			}
 			gr := GardensResource{
 				storageClient:  storageClient,
@@ -294,7 +301,7 @@ func TestGetAllGardens(t *testing.T) {
 			}
 
 			influxdbClient := new(influxdb.MockClient)
-			influxdbClient.On("GetLastContact", mock.Anything, "test-garden").Return(time.Now(), nil)
+			influxdbClient.On("GetLastContact", mock.Anything, "test-garden", influxdb.DefaultWindow).Return(time.Now(), nil)
 			gr := GardensResource{
 				storageClient:  storageClient,
 				influxdbClient: influxdbClient,
@@ -443,7 +450,7 @@ func TestUpdateGarden(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			influxdbClient := new(influxdb.MockClient)
-			influxdbClient.On("GetLastContact", mock.Anything, "test-garden").Return(time.Now(), nil)
+			influxdbClient.On("GetLastContact", mock.Anything, "test-garden", influxdb.DefaultWindow).Return(time.Now(), nil)
 			storageClient := setupZonePlantGardenStorage(t)
 			gr := GardensResource{
 				storageClient:  storageClient,
@@ -493,17 +500,17 @@ func TestGardenAction(t *testing.T) {
 		{
 			"SuccessfulLightAction",
 			func(mqttClient *mqtt.MockClient) {
-				mqttClient.On("LightTopic", "test-garden").Return("garden/action/light", nil)
-				mqttClient.On("Publish", "garden/action/light", mock.Anything).Return(nil)
+				mqttClient.On("LightTopic", mqtt.TopicData{Garden: "test-garden", GardenID: id}).Return("garden/action/light", nil)
+				mqttClient.On("PublishCommand", mqtt.LightCommand, "test-garden", "garden/action/light", mock.Anything).Return("id", nil)
 			},
 			`{"light":{"state":"on"}}`,
-			"null",
+			`{"command_ids":["id"]}`,
 			http.StatusAccepted,
 		},
 		{
 			"ExecuteErrorForLightAction",
 			func(mqttClient *mqtt.MockClient) {
-				mqttClient.On("LightTopic", "test-garden").Return("", errors.New("template error"))
+				mqttClient.On("LightTopic", mqtt.TopicData{Garden: "test-garden", GardenID: id}).Return("", errors.New("template error"))
 			},
 			`{"light":{"state":"on"}}`,
 			`{"status":"Server Error.","error":"unable to execute LightAction: unable to fill MQTT topic template: template error"}`,
@@ -550,3 +557,124 @@ func TestGardenAction(t *testing.T) {
 		})
 	}
 }
+
+func TestGardenCommands(t *testing.T) {
+	createdAt, _ := time.Parse(time.RFC3339, "2023-08-23T10:00:00Z")
+	commands := []mqtt.TrackedCommand{
+		{
+			ID:        "cjjqnk8dqc7ug3ge2dcg",
+			Type:      mqtt.WaterCommand,
+			Topic:     "test-garden/command/water",
+			Status:    mqtt.CommandFinished,
+			Attempts:  1,
+			CreatedAt: createdAt,
+			UpdatedAt: createdAt,
+		},
+		{
+			ID:        "cjjqnk8dqc7ug3ge2dd0",
+			Type:      mqtt.WaterCommand,
+			Topic:     "other-garden/command/water",
+			Status:    mqtt.CommandPending,
+			Attempts:  1,
+			CreatedAt: createdAt,
+			UpdatedAt: createdAt,
+		},
+		{
+			ID:        "cjjqnk8dqc7ug3ge2ddg",
+			Type:      mqtt.LightCommand,
+			Topic:     "test-garden/command/light",
+			Status:    mqtt.CommandRejected,
+			Reason:    "light is disabled",
+			Attempts:  2,
+			CreatedAt: createdAt,
+			UpdatedAt: createdAt,
+		},
+	}
+
+	tests := []struct {
+		name      string
+		path      string
+		setupMock func(*mqtt.MockClient)
+		expected  string
+		code      int
+	}{
+		{
+			"SuccessfulList",
+			"/gardens/c5cvhpcbcv45e8bp16dg/commands",
+			func(mqttClient *mqtt.MockClient) {
+				mqttClient.On("Commands").Return(commands)
+			},
+			`{"commands":[{"id":"cjjqnk8dqc7ug3ge2dcg","type":"water","topic":"test-garden/command/water","status":"finished","attempts":1,"created_at":"2023-08-23T10:00:00Z","updated_at":"2023-08-23T10:00:00Z"},{"id":"cjjqnk8dqc7ug3ge2ddg","type":"light","topic":"test-garden/command/light","status":"rejected","reason":"light is disabled","attempts":2,"created_at":"2023-08-23T10:00:00Z","updated_at":"2023-08-23T10:00:00Z"}]}`,
+			http.StatusOK,
+		},
+		{
+			"SuccessfulEmptyList",
+			"/gardens/c5cvhpcbcv45e8bp16dg/commands",
+			func(mqttClient *mqtt.MockClient) {
+				mqttClient.On("Commands").Return(nil)
+			},
+			`{"commands":[]}`,
+			http.StatusOK,
+		},
+		{
+			"SuccessfulGetCommand",
+			"/gardens/c5cvhpcbcv45e8bp16dg/commands/cjjqnk8dqc7ug3ge2ddg",
+			func(mqttClient *mqtt.MockClient) {
+				mqttClient.On("Commands").Return(commands)
+			},
+			`{"id":"cjjqnk8dqc7ug3ge2ddg","type":"light","topic":"test-garden/command/light","status":"rejected","reason":"light is disabled","attempts":2,"created_at":"2023-08-23T10:00:00Z","updated_at":"2023-08-23T10:00:00Z"}`,
+			http.StatusOK,
+		},
+		{
+			"CommandForOtherGardenNotFound",
+			"/gardens/c5cvhpcbcv45e8bp16dg/commands/cjjqnk8dqc7ug3ge2dd0",
+			func(mqttClient *mqtt.MockClient) {
+				mqttClient.On("Commands").Return(commands)
+			},
+			`{"status":"Resource not found."}`,
+			http.StatusNotFound,
+		},
+		{
+			"ErrorFillingTopic",
+			"/gardens/c5cvhpcbcv45e8bp16dg/commands",
+			func(mqttClient *mqtt.MockClient) {
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "test-garden", GardenID: id, ZoneID: id}).Unset()
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "test-garden", GardenID: id, ZoneID: id}).Return("", errors.New("template error"))
+			},
+			`{"status":"Server Error.","error":"unable to fill MQTT topic template: template error"}`,
+			http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mqttClient := mqtt.NewMockClient(t)
+			mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "test-garden", GardenID: id, ZoneID: id}).Return("test-garden/command/water", nil).Maybe()
+			mqttClient.On("StopTopic", mqtt.TopicData{Garden: "test-garden", GardenID: id}).Return("test-garden/command/stop", nil).Maybe()
+			mqttClient.On("StopAllTopic", mqtt.TopicData{Garden: "test-garden", GardenID: id}).Return("test-garden/command/stop_all", nil).Maybe()
+			mqttClient.On("LightTopic", mqtt.TopicData{Garden: "test-garden", GardenID: id}).Return("test-garden/command/light", nil).Maybe()
+			tt.setupMock(mqttClient)
+
+			storageClient := setupZonePlantGardenStorage(t)
+			gr := GardensResource{
+				storageClient: storageClient,
+				worker:        worker.NewWorker(storageClient, nil, mqttClient, logrus.New()),
+			}
+
+			router := chi.NewRouter()
+			router.Route(fmt.Sprintf("/gardens/{%s}", gardenPathParam), func(r chi.Router) {
+				r.Use(gr.gardenContextMiddleware)
+				r.Get("/commands", gr.getGardenCommands)
+				r.Get(fmt.Sprintf("/commands/{%s}", commandPathParam), gr.getGardenCommand)
+			})
+
+			r := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.code, w.Code)
+			assert.Equal(t, tt.expected, strings.TrimSpace(w.Body.String()))
+		})
+	}
+}
@@ -55,6 +55,19 @@ func InternalServerError(err error) render.Renderer {
 	}
 }
 
+// ActionResponse is used to return the correlation IDs of the commands published for an action so their status can
+// be found using the Garden's commands
+type ActionResponse struct {
+	CommandIDs []string `json:"command_ids"`
+}
+
+// Render is used to make this struct compatible with the go-chi webserver for writing
+// the JSON response
+func (resp *ActionResponse) Render(_ http.ResponseWriter, r *http.Request) error {
+	render.Status(r, http.StatusAccepted)
+	return nil
+}
+
 // Link is used for HATEOAS-style REST hypermedia
 type Link struct {
 	Rel  string `json:"rel,omitempty"`
@@ -13,9 +13,14 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/homeassistant"
 	"github.com/calvinmclean/automated-garden/garden-app/pkg/influxdb"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/ingest"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/logs"
 	"github.com/calvinmclean/automated-garden/garden-app/pkg/mqtt"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/mqtt/broker"
 	"github.com/calvinmclean/automated-garden/garden-app/pkg/storage"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather"
 	"github.com/calvinmclean/automated-garden/garden-app/worker"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -28,6 +33,9 @@ import (
 	"github.com/slok/go-http-metrics/middleware/std"
 )
 
+// requestTimeout is how long requests can take before they are cancelled
+const requestTimeout = 3 * time.Second
+
 //go:embed dist/*
 var dist embed.FS
 
@@ -38,6 +46,21 @@ type Config struct {
 	MQTTConfig     mqtt.Config     `mapstructure:"mqtt"`
 	StorageConfig  storage.Config  `mapstructure:"storage"`
 	LogConfig      LogConfig       `mapstructure:"log"`
+
+	WeatherCacheConfig WeatherCacheConfig `mapstructure:"weather_cache"`
+	IngestConfig       ingest.Config      `mapstructure:"ingest"`
+	MQTTBrokerConfig   broker.Config      `mapstructure:"mqtt_broker"`
+
+	HomeAssistantConfig homeassistant.Config `mapstructure:"home_assistant"`
+	StateConfig         worker.StateConfig   `mapstructure:"controller_state"`
+	ControllerLogConfig logs.Config          `mapstructure:"controller_logs"`
+}
+
+// WeatherCacheConfig is used to choose where responses from WeatherClients are cached. Driver is "memory" (default)
+// or "storage". Storage is optional and allows using a different storage for the cache than for other resources
+type WeatherCacheConfig struct {
+	Driver  string          `mapstructure:"driver"`
+	Storage *storage.Config `mapstructure:"storage"`
 }
 
 // WebConfig is used to allow reading the "web_server" section into the main Config struct
@@ -53,10 +76,13 @@ type Server struct {
 	logger          *logrus.Entry
 	gardensResource GardensResource
 	worker          *worker.Worker
+	mqttBroker      *broker.Broker
+	weatherCache    weather.Cache
+	ingester        *ingest.Ingester
 }
 
 // NewServer creates and initializes all server resources based on config
-func NewServer(cfg Config, validateData bool) (*Server, error) {
+func NewServer(cfg Config, validateData bool) (_ *Server, err error) {
 	baseLogger := logrus.New()
 	baseLogger.SetFormatter(cfg.LogConfig.GetFormatter())
 	baseLogger.SetLevel(cfg.LogConfig.GetLogLevel())
@@ -69,7 +95,7 @@ func NewServer(cfg Config, validateData bool) (*Server, error) {
 	r.Use(loggerMiddleware(logger))
 	r.Use(middleware.Recoverer)
 	r.Use(render.SetContentType(render.ContentTypeJSON))
-	r.Use(middleware.Timeout(3 * time.Second))
+	r.Use(unitSystemMiddleware)
 
 	if cfg.EnableCors {
 		r.Use(cors.Handler(cors.Options{
@@ -86,7 +112,10 @@ func NewServer(cfg Config, validateData bool) (*Server, error) {
 	r.Use(std.HandlerProvider("", metrics_middleware.New(metrics_middleware.Config{
 		Recorder: prommetrics.NewRecorder(prommetrics.Config{Prefix: "garden_app"}),
 	})))
-	r.Get("/metrics", promhttp.Handler().ServeHTTP)
+	// Requests time out except for following Garden logs, which stays open until the client disconnects
+	timeout := middleware.Timeout(requestTimeout)
+
+	r.With(timeout).Get("/metrics", promhttp.Handler().ServeHTTP)
 
 	// Initialize Storage Client
 	logger.WithField("driver", cfg.StorageConfig.Driver).Info("initializing storage client")
@@ -95,6 +124,19 @@ func NewServer(cfg Config, validateData bool) (*Server, error) {
 		return nil, fmt.Errorf("unable to initialize storage client: %v", err)
 	}
 
+	// Initialize Weather Cache
+	logger.WithField("driver", cfg.WeatherCacheConfig.Driver).Info("initializing weather cache")
+	weatherCache, err := newWeatherCache(cfg.WeatherCacheConfig, storageClient)
+	if err != nil {
+		return nil, fmt.Errorf("unable to initialize weather cache: %v", err)
+	}
+	defer func() {
+		if err != nil {
+			stopWeatherCache(weatherCache)
+		}
+	}()
+	weather.SetCache(weatherCache)
+
 	if validateData {
 		err = validateAllStoredResources(storageClient)
 		if err != nil {
@@ -102,29 +144,114 @@ func NewServer(cfg Config, validateData bool) (*Server, error) {
 		}
 	}
 
+	// Initialize InfluxDB Client
+	logger.WithFields(logrus.Fields{
+		"address": cfg.InfluxDBConfig.Address,
+		"org":     cfg.InfluxDBConfig.Org,
+		"bucket":  cfg.InfluxDBConfig.Bucket,
+	}).Info("initializing InfluxDB client")
+	influxdbClient := influxdb.NewClient(cfg.InfluxDBConfig)
+
+	// Initialize data ingestion so MQTT subscriptions can be added to the client
+	var ingester *ingest.Ingester
+	var ingestHandlers []mqtt.TopicHandler
+	defer func() {
+		if err != nil && ingester != nil {
+			ingester.Stop()
+		}
+	}()
+	if cfg.IngestConfig.Enabled {
+		logger.WithField("sink", cfg.IngestConfig.Sink).Info("initializing MQTT data ingestion")
+		sink, err := ingest.NewSink(cfg.IngestConfig, influxdbClient, cfg.InfluxDBConfig, baseLogger)
+		if err != nil {
+			return nil, fmt.Errorf("unable to initialize ingest sink: %w", err)
+		}
+		ingester = ingest.NewIngester(sink, baseLogger)
+		ingestHandlers = ingester.TopicHandlers(cfg.IngestConfig)
+	}
+
+	// Start the embedded MQTT broker so the client and controllers can connect to it. The client is disconnected and
+	// the broker is stopped if the rest of the Server cannot be created
+	var mqttBroker *broker.Broker
+	var mqttClient mqtt.Client
+	defer func() {
+		if err == nil {
+			return
+		}
+		if mqttClient != nil {
+			mqttClient.Disconnect(100)
+		}
+		if mqttBroker != nil {
+			if stopErr := mqttBroker.Stop(); stopErr != nil {
+				logger.WithError(stopErr).Error("unable to stop MQTT broker")
+			}
+		}
+	}()
+	if cfg.MQTTBrokerConfig.Enabled {
+		mqttBroker, err = broker.New(cfg.MQTTBrokerConfig, baseLogger)
+		if err != nil {
+			return nil, fmt.Errorf("unable to initialize MQTT broker: %w", err)
+		}
+		logger.WithField("port", mqttBroker.Port()).Info("starting embedded MQTT broker")
+		err = mqttBroker.Start()
+		if err != nil {
+			return nil, fmt.Errorf("unable to start MQTT broker: %w", err)
+		}
+
+		cfg.MQTTConfig.Broker = "localhost"
+		cfg.MQTTConfig.Port = mqttBroker.Port()
+		if cfg.MQTTBrokerConfig.CertFile != "" {
+			cfg.MQTTConfig.Scheme = mqtt.SchemeSSL
+		}
+	}
+
 	// Initialize MQTT Client
 	logger.WithFields(logrus.Fields{
 		"client_id": cfg.MQTTConfig.ClientID,
 		"broker":    cfg.MQTTConfig.Broker,
 		"port":      cfg.MQTTConfig.Port,
 	}).Info("initializing MQTT client")
-	mqttClient, err := mqtt.NewClient(cfg.MQTTConfig, nil)
+	mqttClient, err = mqtt.NewClient(cfg.MQTTConfig, nil, ingestHandlers...)
 	if err != nil {
 		return nil, fmt.Errorf("unable to initialize MQTT client: %v", err)
 	}
-
-	// Initialize InfluxDB Client
-	logger.WithFields(logrus.Fields{
-		"address": cfg.InfluxDBConfig.Address,
-		"org":     cfg.InfluxDBConfig.Org,
-		"bucket":  cfg.InfluxDBConfig.Bucket,
-	}).Info("initializing InfluxDB client")
-	influxdbClient := influxdb.NewClient(cfg.InfluxDBConfig)
+	// The client only connects when publishing unless it needs to subscribe for ingesting data or command acknowledgements
+	if len(ingestHandlers) > 0 || cfg.MQTTConfig.Ack.Enabled {
+		err = mqttClient.Connect()
+		if err != nil {
+			return nil, fmt.Errorf("unable to connect to MQTT broker: %w", err)
+		}
+	}
 
 	// Initialize Scheduler
 	logger.Info("initializing scheduler")
 	worker := worker.NewWorker(storageClient, influxdbClient, mqttClient, baseLogger)
 
+	// The Home Assistant integration uses the controller state to update its water switches
+	if cfg.StateConfig.Enabled || cfg.HomeAssistantConfig.Enabled {
+		logger.Info("starting controller state tracking")
+		err = worker.StartStateTracking()
+		if err != nil {
+			return nil, fmt.Errorf("unable to start controller state tracking: %w", err)
+		}
+	}
+
+	if cfg.HomeAssistantConfig.Enabled {
+		logger.Info("starting Home Assistant integration")
+		err = worker.StartHomeAssistant(cfg.HomeAssistantConfig)
+		if err != nil {
+			return nil, fmt.Errorf("unable to start Home Assistant integration: %w", err)
+		}
+	}
+
+	if cfg.ControllerLogConfig.Enabled {
+		logger.Info("starting controller log storage")
+		err = worker.StartControllerLogs(cfg.ControllerLogConfig)
+		if err != nil {
+			return nil, fmt.Errorf("unable to start controller log storage: %w", err)
+		}
+	}
+
 	// Create API routes/handlers
 	gardenResource, err := NewGardenResource(cfg, storageClient, influxdbClient, worker)
 	if err != nil {
@@ -143,21 +270,30 @@ func NewServer(cfg Config, validateData bool) (*Server, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error setting up static webapp subtree: %w", err)
 	}
-	r.Handle("/*", http.FileServer(http.FS(static)))
+	r.With(timeout).Handle("/*", http.FileServer(http.FS(static)))
 
 	r.Route(gardenBasePath, func(r chi.Router) {
-		r.Post("/", gardenResource.createGarden)
-		r.Get("/", gardenResource.getAllGardens)
+		r.With(timeout).Post("/", gardenResource.createGarden)
+		r.With(timeout).Get("/", gardenResource.getAllGardens)
 
 		r.Route(fmt.Sprintf("/{%s}", gardenPathParam), func(r chi.Router) {
 			r.Use(gardenResource.gardenContextMiddleware)
 
-			r.Get("/", gardenResource.getGarden)
-			r.Patch("/", gardenResource.updateGarden)
-			r.Delete("/", gardenResource.endDateGarden)
+			r.Get("/logs", gardenResource.getGardenLogs)
+
+			r.Group(func(r chi.Router) {
+				r.Use(timeout)
+
+				r.Get("/", gardenResource.getGarden)
+				r.Patch("/", gardenResource.updateGarden)
+				r.Delete("/", gardenResource.endDateGarden)
+				r.Get("/commands", gardenResource.getGardenCommands)
+				r.Get(fmt.Sprintf("/commands/{%s}", commandPathParam), gardenResource.getGardenCommand)
+			})
 
 			// Add new middleware to restrict certain paths to non-end-dated Gardens
 			r.Route("/", func(r chi.Router) {
+				r.Use(timeout)
 				r.Use(restrictEndDatedMiddleware("Garden", gardenCtxKey))
 				r.Post("/action", gardenResource.gardenAction)
 
@@ -191,6 +327,7 @@ func NewServer(cfg Config, validateData bool) (*Server, error) {
 
 							r.Post("/action", zonesResource.zoneAction)
 							r.Get("/history", zonesResource.waterHistory)
+							r.Get("/pulses", zonesResource.waterPulses)
 						})
 					})
 				})
@@ -203,6 +340,7 @@ func NewServer(cfg Config, validateData bool) (*Server, error) {
 		return nil, fmt.Errorf("error initializing '%s' endpoint: %w", weatherClientsBasePath, err)
 	}
 	r.Route(weatherClientsBasePath, func(r chi.Router) {
+		r.Use(timeout)
 		r.Post("/", weatherClientsResource.createWeatherClient)
 		r.Get("/", weatherClientsResource.getAllWeatherClients)
 
@@ -214,6 +352,11 @@ func NewServer(cfg Config, validateData bool) (*Server, error) {
 			r.Delete("/", weatherClientsResource.deleteWeatherClient)
 
 			r.Get("/test", weatherClientsResource.testWeatherClient)
+			r.Get("/cache", weatherClientsResource.getWeatherClientCache)
+			r.Delete("/cache", weatherClientsResource.flushWeatherClientCache)
+			r.Get("/history", weatherClientsResource.getWeatherClientHistory)
+			r.Get("/authorize", weatherClientsResource.authorizeWeatherClient)
+			r.Get("/authorize/callback", weatherClientsResource.authorizeWeatherClientCallback)
 		})
 	})
 
@@ -222,6 +365,7 @@ func NewServer(cfg Config, validateData bool) (*Server, error) {
 		return nil, fmt.Errorf("error initializing '%s' endpoint: %w", waterScheduleBasePath, err)
 	}
 	r.Route(waterScheduleBasePath, func(r chi.Router) {
+		r.Use(timeout)
 		r.Post("/", waterSchedulesResource.createWaterSchedule)
 		r.Get("/", waterSchedulesResource.getAllWaterSchedules)
 
@@ -241,6 +385,9 @@ func NewServer(cfg Config, validateData bool) (*Server, error) {
 		logger,
 		gardenResource,
 		worker,
+		mqttBroker,
+		weatherCache,
+		ingester,
 	}, nil
 }
 
@@ -268,7 +415,18 @@ func (s *Server) Start() {
 		if err != nil {
 			s.logger.WithError(err).Error("unable to shutdown server")
 		}
+		// The worker disconnects the MQTT client, so no more data is received for ingesting
 		s.gardensResource.worker.Stop()
+		if s.ingester != nil {
+			s.ingester.Stop()
+		}
+		stopWeatherCache(s.weatherCache)
+		if s.mqttBroker != nil {
+			err = s.mqttBroker.Stop()
+			if err != nil {
+				s.logger.WithError(err).Error("unable to stop MQTT broker")
+			}
+		}
 
 		wg.Done()
 	}()
@@ -281,6 +439,33 @@ func (s *Server) Stop() {
 	s.quit <- os.Interrupt
 }
 
+// newWeatherCache creates the weather.Cache from config. The storage driver uses the main storageClient unless a
+// separate storage config is provided
+func newWeatherCache(cfg WeatherCacheConfig, storageClient *storage.Client) (weather.Cache, error) {
+	switch cfg.Driver {
+	case "", "memory":
+		return weather.NewMemoryCache(), nil
+	case "storage":
+		if cfg.Storage == nil {
+			return storageClient.NewWeatherCache(weather.DefaultCleanupInterval), nil
+		}
+		cacheStorageClient, err := storage.NewClient(*cfg.Storage)
+		if err != nil {
+			return nil, fmt.Errorf("unable to initialize weather cache storage client: %w", err)
+		}
+		return cacheStorageClient.NewWeatherCache(weather.DefaultCleanupInterval), nil
+	default:
+		return nil, fmt.Errorf("invalid weather cache driver: %q", cfg.Driver)
+	}
+}
+
+// stopWeatherCache stops deleting expired entries if the weather.Cache does it in the background
+func stopWeatherCache(c weather.Cache) {
+	if stopper, ok := c.(interface{ Stop() }); ok {
+		stopper.Stop()
+	}
+}
+
 // validateAllStoredResources will read all resources from storage and make sure they are valid for the types
 func validateAllStoredResources(storageClient *storage.Client) error {
 	gardens, err := storageClient.GetGardens(true)
@@ -1,9 +1,14 @@
 package server
 
 import (
+	"fmt"
+	"net"
 	"testing"
+	"time"
 
 	"github.com/calvinmclean/automated-garden/garden-app/pkg"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/mqtt"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/mqtt/broker"
 	"github.com/calvinmclean/automated-garden/garden-app/pkg/storage"
 	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather"
 	"github.com/stretchr/testify/assert"
@@ -142,3 +147,40 @@ func TestValidateAllStoredResources(t *testing.T) {
 		})
 	}
 }
+
+func TestNewServerStopsBrokerOnError(t *testing.T) {
+	// Get an unused port for the broker
+	listener, err := net.Listen("tcp", ":0")
+	assert.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	assert.NoError(t, listener.Close())
+
+	// The MQTT client fails after the broker is started because of the invalid topic template
+	_, err = NewServer(Config{
+		StorageConfig:    storage.Config{Driver: "hashmap"},
+		MQTTConfig:       mqtt.Config{WaterTopicTemplate: "{{.Garden"},
+		MQTTBrokerConfig: broker.Config{Enabled: true, Port: port},
+	}, false)
+	assert.Error(t, err)
+	assert.Equal(t, "unable to initialize MQTT client: invalid water_topic template: template: water_topic:1: unclosed action", err.Error())
+
+	// The port can be used again since the broker was stopped
+	listener, err = net.Listen("tcp", fmt.Sprintf(":%d", port))
+	assert.NoError(t, err)
+	if listener != nil {
+		assert.NoError(t, listener.Close())
+	}
+}
+
+func TestStopWeatherCache(t *testing.T) {
+	storageClient, err := storage.NewClient(storage.Config{Driver: "hashmap"})
+	assert.NoError(t, err)
+
+	storageCache := storageClient.NewWeatherCache(time.Minute)
+	stopWeatherCache(storageCache)
+	// The cleanup is already stopped, so stopping again closes a closed channel
+	assert.Panics(t, storageCache.Stop)
+
+	// Caches that are not stopped are ignored
+	assert.NotPanics(t, func() { stopWeatherCache(weather.NewMemoryCache()) })
+}
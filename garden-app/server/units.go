@@ -0,0 +1,22 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/units"
+	"github.com/go-chi/render"
+)
+
+// unitSystemMiddleware reads the units query parameter so responses can be converted to the requested unit system
+func unitSystemMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		system := units.System(r.URL.Query().Get("units"))
+		if err := system.Validate(); err != nil {
+			getLoggerFromContext(r.Context()).WithError(err).Error("invalid units query parameter")
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(newContextWithUnitSystem(r.Context(), system)))
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/calvinmclean/automated-garden/garden-app/pkg"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/units"
 	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather"
 	"github.com/rs/xid"
 )
@@ -55,23 +56,209 @@ func ValidateWeatherControl(wc *weather.Control) error {
 		if err != nil {
 			return fmt.Errorf("error validating temperature_control: %w", err)
 		}
+		err = units.ValidateUnit(wc.Temperature.Unit, units.Celsius, units.Fahrenheit)
+		if err != nil {
+			return fmt.Errorf("error validating temperature_control: %w", err)
+		}
 	}
 	if wc.Rain != nil {
 		err := ValidateScaleControl(wc.Rain)
 		if err != nil {
 			return fmt.Errorf("error validating rain_control: %w", err)
 		}
+		err = units.ValidateUnit(wc.Rain.Unit, units.Millimeters, units.Inches)
+		if err != nil {
+			return fmt.Errorf("error validating rain_control: %w", err)
+		}
+	}
+	if wc.SensorTemperature != nil {
+		err := ValidateSensorScaleControl(wc.SensorTemperature)
+		if err != nil {
+			return fmt.Errorf("error validating sensor_temperature_control: %w", err)
+		}
+		err = units.ValidateUnit(wc.SensorTemperature.Unit, units.Celsius, units.Fahrenheit)
+		if err != nil {
+			return fmt.Errorf("error validating sensor_temperature_control: %w", err)
+		}
+	}
+	if wc.SensorHumidity != nil {
+		err := ValidateSensorScaleControl(wc.SensorHumidity)
+		if err != nil {
+			return fmt.Errorf("error validating sensor_humidity_control: %w", err)
+		}
+		err = units.ValidateUnit(wc.SensorHumidity.Unit)
+		if err != nil {
+			return fmt.Errorf("error validating sensor_humidity_control: %w", err)
+		}
+	}
+	if wc.Freeze != nil {
+		err := ValidateThresholdControl(wc.Freeze)
+		if err != nil {
+			return fmt.Errorf("error validating freeze_control: %w", err)
+		}
+		if wc.Freeze.Window != nil && wc.Freeze.Window.Duration <= 0 {
+			return errors.New("error validating freeze_control: window must be a positive duration")
+		}
+		err = units.ValidateUnit(wc.Freeze.Unit, units.Celsius, units.Fahrenheit)
+		if err != nil {
+			return fmt.Errorf("error validating freeze_control: %w", err)
+		}
+	}
+	if wc.Wind != nil {
+		err := ValidateThresholdControl(wc.Wind)
+		if err != nil {
+			return fmt.Errorf("error validating wind_control: %w", err)
+		}
+		if *wc.Wind.Threshold < 0 {
+			return errors.New("error validating wind_control: threshold must not be negative")
+		}
+		if wc.Wind.Window != nil {
+			return errors.New("error validating wind_control: window is only supported by freeze_control")
+		}
+		err = units.ValidateUnit(wc.Wind.Unit)
+		if err != nil {
+			return fmt.Errorf("error validating wind_control: %w", err)
+		}
+	}
+	if wc.Heat != nil {
+		err := ValidateHeatControl(wc.Heat)
+		if err != nil {
+			return fmt.Errorf("error validating heat_control: %w", err)
+		}
+		err = units.ValidateUnit(wc.Heat.Unit, units.Celsius, units.Fahrenheit)
+		if err != nil {
+			return fmt.Errorf("error validating heat_control: %w", err)
+		}
+	}
+	if wc.RainForecast != nil {
+		err := ValidateRainForecastControl(wc.RainForecast)
+		if err != nil {
+			return fmt.Errorf("error validating rain_forecast_control: %w", err)
+		}
+		err = units.ValidateUnit(wc.RainForecast.Unit, units.Millimeters, units.Inches)
+		if err != nil {
+			return fmt.Errorf("error validating rain_forecast_control: %w", err)
+		}
 	}
 	if wc.SoilMoisture != nil {
-		if wc.SoilMoisture.MinimumMoisture == nil {
-			return errors.New("error validating moisture_control: missing required field: minimum_moisture")
+		err := ValidateSoilMoistureControl(wc.SoilMoisture)
+		if err != nil {
+			return fmt.Errorf("error validating moisture_control: %w", err)
 		}
 	}
 	return nil
 }
 
+// ValidateSoilMoistureControl validates input for SoilMoistureControl. Either minimum_moisture or target_moisture is
+// required, and target_moisture requires pulse_duration for closed-loop watering
+func ValidateSoilMoistureControl(smc *weather.SoilMoistureControl) error {
+	errStringFormat := "missing required field: %s"
+	if smc.MinimumMoisture == nil && smc.TargetMoisture == nil {
+		return fmt.Errorf(errStringFormat, "minimum_moisture")
+	}
+	if smc.Window != nil && smc.Window.Duration <= 0 {
+		return errors.New("window must be a positive duration")
+	}
+	if err := smc.Aggregation.Validate(); err != nil {
+		return err
+	}
+	if smc.TargetMoisture == nil {
+		return nil
+	}
+	if smc.PulseDuration == nil {
+		return fmt.Errorf(errStringFormat, "pulse_duration")
+	}
+	if smc.PulseDuration.Duration <= 0 {
+		return errors.New("pulse_duration must be a positive duration")
+	}
+	if smc.MaxDuration != nil && smc.MaxDuration.Duration < smc.PulseDuration.Duration {
+		return errors.New("max_duration must not be less than pulse_duration")
+	}
+	return nil
+}
+
+// ValidateThresholdControl validates input for ThresholdControl
+func ValidateThresholdControl(tc *weather.ThresholdControl) error {
+	errStringFormat := "missing required field: %s"
+	if tc.Threshold == nil {
+		return fmt.Errorf(errStringFormat, "threshold")
+	}
+	if tc.ClientID.IsNil() {
+		return fmt.Errorf(errStringFormat, "client_id")
+	}
+	return nil
+}
+
+// ValidateHeatControl validates input for HeatControl
+func ValidateHeatControl(hc *weather.HeatControl) error {
+	errStringFormat := "missing required field: %s"
+	if hc.Threshold == nil {
+		return fmt.Errorf(errStringFormat, "threshold")
+	}
+	if hc.Duration != nil && hc.Duration.Duration <= 0 {
+		return errors.New("duration must be a positive duration")
+	}
+	if hc.ClientID.IsNil() {
+		return fmt.Errorf(errStringFormat, "client_id")
+	}
+	return nil
+}
+
+// ValidateRainForecastControl validates input for RainForecastControl. At least one of skip_threshold or factor and
+// range is required so the control has an effect
+func ValidateRainForecastControl(rfc *weather.RainForecastControl) error {
+	errStringFormat := "missing required field: %s"
+	if rfc.Window == nil {
+		return fmt.Errorf(errStringFormat, "window")
+	}
+	if rfc.Window.Duration <= 0 {
+		return errors.New("window must be a positive duration")
+	}
+	if rfc.MinimumProbability != nil && (*rfc.MinimumProbability < 0 || *rfc.MinimumProbability > 100) {
+		return errors.New("minimum_probability must be between 0 and 100")
+	}
+	if rfc.SkipThreshold == nil && (rfc.Factor == nil || rfc.Range == nil) {
+		return errors.New("skip_threshold or factor and range are required")
+	}
+	if rfc.Factor != nil && (*rfc.Factor > float32(1) || *rfc.Factor < float32(0)) {
+		return errors.New("factor must be between 0 and 1")
+	}
+	if rfc.Range != nil && *rfc.Range <= float32(0) {
+		return errors.New("range must be a positive number")
+	}
+	if rfc.ClientID.IsNil() {
+		return fmt.Errorf(errStringFormat, "client_id")
+	}
+	return nil
+}
+
 // ValidateScaleControl validates input for ScaleControl
 func ValidateScaleControl(sc *weather.ScaleControl) error {
+	err := validateScaleControlValues(sc)
+	if err != nil {
+		return err
+	}
+	if sc.ClientID.IsNil() {
+		return fmt.Errorf("missing required field: %s", "client_id")
+	}
+	return nil
+}
+
+// ValidateSensorScaleControl validates input for a ScaleControl that uses the Garden's sensor data, so it does
+// not use a weather client
+func ValidateSensorScaleControl(sc *weather.ScaleControl) error {
+	err := validateScaleControlValues(sc)
+	if err != nil {
+		return err
+	}
+	if !sc.ClientID.IsNil() {
+		return errors.New("client_id is not used for sensor controls")
+	}
+	return nil
+}
+
+// validateScaleControlValues validates the scaling values that are required for all ScaleControls
+func validateScaleControlValues(sc *weather.ScaleControl) error {
 	errStringFormat := "missing required field: %s"
 	if sc.BaselineValue == nil {
 		return fmt.Errorf(errStringFormat, "baseline_value")
@@ -88,9 +275,6 @@ func ValidateScaleControl(sc *weather.ScaleControl) error {
 	if *sc.Range < float32(0) {
 		return errors.New("range must be a positive number")
 	}
-	if sc.ClientID.IsNil() {
-		return fmt.Errorf(errStringFormat, "client_id")
-	}
 	return nil
 }
 
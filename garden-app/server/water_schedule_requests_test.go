@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/calvinmclean/automated-garden/garden-app/pkg"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/units"
 	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather"
 	"github.com/rs/xid"
 	"github.com/stretchr/testify/assert"
@@ -73,6 +74,45 @@ func TestWaterScheduleRequest(t *testing.T) {
 			},
 			"error validating weather_control: error validating temperature_control: missing required field: baseline_value",
 		},
+		{
+			"InvalidWeatherControlTemperatureUnit",
+			&WaterScheduleRequest{
+				WaterSchedule: &pkg.WaterSchedule{
+					Interval:  &pkg.Duration{Duration: time.Hour * 24},
+					Duration:  &pkg.Duration{Duration: time.Second},
+					StartTime: &now,
+					WeatherControl: &weather.Control{
+						Temperature: &weather.ScaleControl{
+							BaselineValue: float32Pointer(86),
+							Factor:        float32Pointer(0.5),
+							Range:         float32Pointer(18),
+							Unit:          units.Inches,
+							ClientID:      id,
+						},
+					},
+				},
+			},
+			`error validating weather_control: error validating temperature_control: invalid unit "inches", must be one of: celsius, fahrenheit`,
+		},
+		{
+			"InvalidWeatherControlSensorHumidityUnit",
+			&WaterScheduleRequest{
+				WaterSchedule: &pkg.WaterSchedule{
+					Interval:  &pkg.Duration{Duration: time.Hour * 24},
+					Duration:  &pkg.Duration{Duration: time.Second},
+					StartTime: &now,
+					WeatherControl: &weather.Control{
+						SensorHumidity: &weather.ScaleControl{
+							BaselineValue: float32Pointer(50),
+							Factor:        float32Pointer(0.5),
+							Range:         float32Pointer(20),
+							Unit:          units.Fahrenheit,
+						},
+					},
+				},
+			},
+			`error validating weather_control: error validating sensor_humidity_control: unit "fahrenheit" is not supported`,
+		},
 		{
 			"EmptyWeatherControlFactor",
 			&WaterScheduleRequest{
@@ -125,6 +165,254 @@ func TestWaterScheduleRequest(t *testing.T) {
 			},
 			"error validating weather_control: error validating temperature_control: missing required field: client_id",
 		},
+		{
+			"FreezeControlMissingThreshold",
+			&WaterScheduleRequest{
+				WaterSchedule: &pkg.WaterSchedule{
+					Interval:  &pkg.Duration{Duration: time.Hour * 24},
+					Duration:  &pkg.Duration{Duration: time.Second},
+					StartTime: &now,
+					WeatherControl: &weather.Control{
+						Freeze: &weather.ThresholdControl{
+							ClientID: id,
+						},
+					},
+				},
+			},
+			"error validating weather_control: error validating freeze_control: missing required field: threshold",
+		},
+		{
+			"WindControlNegativeThreshold",
+			&WaterScheduleRequest{
+				WaterSchedule: &pkg.WaterSchedule{
+					Interval:  &pkg.Duration{Duration: time.Hour * 24},
+					Duration:  &pkg.Duration{Duration: time.Second},
+					StartTime: &now,
+					WeatherControl: &weather.Control{
+						Wind: &weather.ThresholdControl{
+							Threshold: float32Pointer(-1),
+							ClientID:  id,
+						},
+					},
+				},
+			},
+			"error validating weather_control: error validating wind_control: threshold must not be negative",
+		},
+		{
+			"FreezeControlInvalidWindow",
+			&WaterScheduleRequest{
+				WaterSchedule: &pkg.WaterSchedule{
+					Interval:  &pkg.Duration{Duration: time.Hour * 24},
+					Duration:  &pkg.Duration{Duration: time.Second},
+					StartTime: &now,
+					WeatherControl: &weather.Control{
+						Freeze: &weather.ThresholdControl{
+							Threshold: float32Pointer(2),
+							Window:    &pkg.Duration{Duration: -time.Hour},
+							ClientID:  id,
+						},
+					},
+				},
+			},
+			"error validating weather_control: error validating freeze_control: window must be a positive duration",
+		},
+		{
+			"WindControlWindow",
+			&WaterScheduleRequest{
+				WaterSchedule: &pkg.WaterSchedule{
+					Interval:  &pkg.Duration{Duration: time.Hour * 24},
+					Duration:  &pkg.Duration{Duration: time.Second},
+					StartTime: &now,
+					WeatherControl: &weather.Control{
+						Wind: &weather.ThresholdControl{
+							Threshold: float32Pointer(30),
+							Window:    &pkg.Duration{Duration: time.Hour},
+							ClientID:  id,
+						},
+					},
+				},
+			},
+			"error validating weather_control: error validating wind_control: window is only supported by freeze_control",
+		},
+		{
+			"InvalidFreezeControlUnit",
+			&WaterScheduleRequest{
+				WaterSchedule: &pkg.WaterSchedule{
+					Interval:  &pkg.Duration{Duration: time.Hour * 24},
+					Duration:  &pkg.Duration{Duration: time.Second},
+					StartTime: &now,
+					WeatherControl: &weather.Control{
+						Freeze: &weather.ThresholdControl{
+							Threshold: float32Pointer(32),
+							Unit:      units.Inches,
+							ClientID:  id,
+						},
+					},
+				},
+			},
+			`error validating weather_control: error validating freeze_control: invalid unit "inches", must be one of: celsius, fahrenheit`,
+		},
+		{
+			"WindControlUnit",
+			&WaterScheduleRequest{
+				WaterSchedule: &pkg.WaterSchedule{
+					Interval:  &pkg.Duration{Duration: time.Hour * 24},
+					Duration:  &pkg.Duration{Duration: time.Second},
+					StartTime: &now,
+					WeatherControl: &weather.Control{
+						Wind: &weather.ThresholdControl{
+							Threshold: float32Pointer(30),
+							Unit:      units.Fahrenheit,
+							ClientID:  id,
+						},
+					},
+				},
+			},
+			`error validating weather_control: error validating wind_control: unit "fahrenheit" is not supported`,
+		},
+		{
+			"InvalidHeatControlUnit",
+			&WaterScheduleRequest{
+				WaterSchedule: &pkg.WaterSchedule{
+					Interval:  &pkg.Duration{Duration: time.Hour * 24},
+					Duration:  &pkg.Duration{Duration: time.Second},
+					StartTime: &now,
+					WeatherControl: &weather.Control{
+						Heat: &weather.HeatControl{
+							Threshold: float32Pointer(104),
+							Unit:      units.Millimeters,
+							ClientID:  id,
+						},
+					},
+				},
+			},
+			`error validating weather_control: error validating heat_control: invalid unit "mm", must be one of: celsius, fahrenheit`,
+		},
+		{
+			"InvalidRainForecastControlUnit",
+			&WaterScheduleRequest{
+				WaterSchedule: &pkg.WaterSchedule{
+					Interval:  &pkg.Duration{Duration: time.Hour * 24},
+					Duration:  &pkg.Duration{Duration: time.Second},
+					StartTime: &now,
+					WeatherControl: &weather.Control{
+						RainForecast: &weather.RainForecastControl{
+							Window:        &pkg.Duration{Duration: 6 * time.Hour},
+							SkipThreshold: float32Pointer(0.5),
+							Unit:          units.Celsius,
+							ClientID:      id,
+						},
+					},
+				},
+			},
+			`error validating weather_control: error validating rain_forecast_control: invalid unit "celsius", must be one of: mm, inches`,
+		},
+		{
+			"HeatControlMissingClientID",
+			&WaterScheduleRequest{
+				WaterSchedule: &pkg.WaterSchedule{
+					Interval:  &pkg.Duration{Duration: time.Hour * 24},
+					Duration:  &pkg.Duration{Duration: time.Second},
+					StartTime: &now,
+					WeatherControl: &weather.Control{
+						Heat: &weather.HeatControl{
+							Threshold: float32Pointer(40),
+						},
+					},
+				},
+			},
+			"error validating weather_control: error validating heat_control: missing required field: client_id",
+		},
+		{
+			"RainForecastControlMissingWindow",
+			&WaterScheduleRequest{
+				WaterSchedule: &pkg.WaterSchedule{
+					Interval:  &pkg.Duration{Duration: time.Hour * 24},
+					Duration:  &pkg.Duration{Duration: time.Second},
+					StartTime: &now,
+					WeatherControl: &weather.Control{
+						RainForecast: &weather.RainForecastControl{
+							SkipThreshold: float32Pointer(5),
+							ClientID:      id,
+						},
+					},
+				},
+			},
+			"error validating weather_control: error validating rain_forecast_control: missing required field: window",
+		},
+		{
+			"RainForecastControlNoEffect",
+			&WaterScheduleRequest{
+				WaterSchedule: &pkg.WaterSchedule{
+					Interval:  &pkg.Duration{Duration: time.Hour * 24},
+					Duration:  &pkg.Duration{Duration: time.Second},
+					StartTime: &now,
+					WeatherControl: &weather.Control{
+						RainForecast: &weather.RainForecastControl{
+							Window:   &pkg.Duration{Duration: 6 * time.Hour},
+							Factor:   float32Pointer(0.5),
+							ClientID: id,
+						},
+					},
+				},
+			},
+			"error validating weather_control: error validating rain_forecast_control: skip_threshold or factor and range are required",
+		},
+		{
+			"RainForecastControlInvalidProbability",
+			&WaterScheduleRequest{
+				WaterSchedule: &pkg.WaterSchedule{
+					Interval:  &pkg.Duration{Duration: time.Hour * 24},
+					Duration:  &pkg.Duration{Duration: time.Second},
+					StartTime: &now,
+					WeatherControl: &weather.Control{
+						RainForecast: &weather.RainForecastControl{
+							Window:             &pkg.Duration{Duration: 6 * time.Hour},
+							MinimumProbability: float32Pointer(120),
+							SkipThreshold:      float32Pointer(5),
+							ClientID:           id,
+						},
+					},
+				},
+			},
+			"error validating weather_control: error validating rain_forecast_control: minimum_probability must be between 0 and 100",
+		},
+		{
+			"SensorTemperatureControlWithClientID",
+			&WaterScheduleRequest{
+				WaterSchedule: &pkg.WaterSchedule{
+					Interval:  &pkg.Duration{Duration: time.Hour * 24},
+					Duration:  &pkg.Duration{Duration: time.Second},
+					StartTime: &now,
+					WeatherControl: &weather.Control{
+						SensorTemperature: &weather.ScaleControl{
+							BaselineValue: float32Pointer(27),
+							Factor:        float32Pointer(0.5),
+							Range:         float32Pointer(10),
+							ClientID:      id,
+						},
+					},
+				},
+			},
+			"error validating weather_control: error validating sensor_temperature_control: client_id is not used for sensor controls",
+		},
+		{
+			"SensorHumidityControlMissingRange",
+			&WaterScheduleRequest{
+				WaterSchedule: &pkg.WaterSchedule{
+					Interval:  &pkg.Duration{Duration: time.Hour * 24},
+					Duration:  &pkg.Duration{Duration: time.Second},
+					StartTime: &now,
+					WeatherControl: &weather.Control{
+						SensorHumidity: &weather.ScaleControl{
+							BaselineValue: float32Pointer(50),
+							Factor:        float32Pointer(0.5),
+						},
+					},
+				},
+			},
+			"error validating weather_control: error validating sensor_humidity_control: missing required field: range",
+		},
 		{
 			"WeatherControlInvalidFactorBig",
 			&WaterScheduleRequest{
@@ -211,6 +499,57 @@ func TestWaterScheduleRequest(t *testing.T) {
 			},
 			"error validating weather_control: error validating moisture_control: missing required field: minimum_moisture",
 		},
+		{
+			"WeatherControlInvalidMoistureAggregation",
+			&WaterScheduleRequest{
+				WaterSchedule: &pkg.WaterSchedule{
+					Interval:  &pkg.Duration{Duration: time.Hour * 24},
+					Duration:  &pkg.Duration{Duration: time.Second},
+					StartTime: &now,
+					WeatherControl: &weather.Control{
+						SoilMoisture: &weather.SoilMoistureControl{
+							MinimumMoisture: intPointer(50),
+							Aggregation:     "sum",
+						},
+					},
+				},
+			},
+			"error validating weather_control: error validating moisture_control: invalid aggregation \"sum\", must be one of: mean, min, median, last",
+		},
+		{
+			"WeatherControlTargetMoistureMissingPulseDuration",
+			&WaterScheduleRequest{
+				WaterSchedule: &pkg.WaterSchedule{
+					Interval:  &pkg.Duration{Duration: time.Hour * 24},
+					Duration:  &pkg.Duration{Duration: time.Second},
+					StartTime: &now,
+					WeatherControl: &weather.Control{
+						SoilMoisture: &weather.SoilMoistureControl{
+							TargetMoisture: intPointer(50),
+						},
+					},
+				},
+			},
+			"error validating weather_control: error validating moisture_control: missing required field: pulse_duration",
+		},
+		{
+			"WeatherControlMaxDurationLessThanPulseDuration",
+			&WaterScheduleRequest{
+				WaterSchedule: &pkg.WaterSchedule{
+					Interval:  &pkg.Duration{Duration: time.Hour * 24},
+					Duration:  &pkg.Duration{Duration: time.Second},
+					StartTime: &now,
+					WeatherControl: &weather.Control{
+						SoilMoisture: &weather.SoilMoistureControl{
+							TargetMoisture: intPointer(50),
+							PulseDuration:  &pkg.Duration{Duration: time.Minute},
+							MaxDuration:    &pkg.Duration{Duration: time.Second},
+						},
+					},
+				},
+			},
+			"error validating weather_control: error validating moisture_control: max_duration must not be less than pulse_duration",
+		},
 		{
 			"ActivePeriodInvalid",
 			&WaterScheduleRequest{
@@ -47,15 +47,16 @@ type NextWaterDetails struct {
 	Message         string     `json:"message,omitempty"`
 }
 
-// GetNextWaterDetails returns the NextWaterDetails for the WaterSchedule
-func GetNextWaterDetails(ws *pkg.WaterSchedule, worker *worker.Worker, excludeWeatherData bool) NextWaterDetails {
+// GetNextWaterDetails returns the NextWaterDetails for the WaterSchedule. The Garden is optional and is used to
+// include scaling from the Garden's own sensor data
+func GetNextWaterDetails(g *pkg.Garden, ws *pkg.WaterSchedule, worker *worker.Worker, excludeWeatherData bool) NextWaterDetails {
 	result := NextWaterDetails{
 		Time:     worker.GetNextWaterTime(ws),
 		Duration: ws.Duration.Duration.String(),
 	}
 
 	if ws.HasWeatherControl() && !excludeWeatherData {
-		wd, hadErr := worker.ScaleWateringDuration(ws)
+		wd, hadErr := worker.ScaleWateringDuration(g, ws)
 		if hadErr {
 			result.Message = "error impacted duration scaling"
 		}
@@ -82,15 +83,37 @@ func (wsr WaterSchedulesResource) NewWaterScheduleResponse(ctx context.Context,
 
 	if ws.HasWeatherControl() && !ws.EndDated() && !excludeWeatherData {
 		response.WeatherData = getWeatherData(ctx, ws, wsr.storageClient)
+		response.WeatherData.convertUnits(getUnitSystem(ctx, nil))
 	}
 
 	if !ws.EndDated() {
-		response.NextWater = GetNextWaterDetails(ws, wsr.worker, excludeWeatherData)
+		var garden *pkg.Garden
+		if !excludeWeatherData && (ws.HasSensorTemperatureControl() || ws.HasSensorHumidityControl()) {
+			garden = wsr.getSensorGarden(ctx, ws)
+		}
+		response.NextWater = GetNextWaterDetails(garden, ws, wsr.worker, excludeWeatherData)
 	}
 
 	return response
 }
 
+// getSensorGarden finds the first Garden with a temperature and humidity sensor that has a Zone using this
+// WaterSchedule. It returns nil if there is no such Garden, so sensor controls are ignored
+func (wsr WaterSchedulesResource) getSensorGarden(ctx context.Context, ws *pkg.WaterSchedule) *pkg.Garden {
+	zonesAndGardens, err := wsr.storageClient.GetZonesUsingWaterSchedule(ws.ID)
+	if err != nil {
+		getLoggerFromContext(ctx).WithError(err).Warn("unable to get Gardens using WaterSchedule")
+		return nil
+	}
+
+	for _, zg := range zonesAndGardens {
+		if zg.Garden.HasTemperatureHumiditySensor() {
+			return zg.Garden
+		}
+	}
+	return nil
+}
+
 // Render is used to make this struct compatible with the go-chi webserver for writing
 // the JSON response
 func (z *WaterScheduleResponse) Render(_ http.ResponseWriter, _ *http.Request) error {
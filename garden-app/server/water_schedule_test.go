@@ -14,6 +14,7 @@ import (
 	"github.com/calvinmclean/automated-garden/garden-app/pkg"
 	"github.com/calvinmclean/automated-garden/garden-app/pkg/influxdb"
 	"github.com/calvinmclean/automated-garden/garden-app/pkg/storage"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/units"
 	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather"
 	"github.com/calvinmclean/automated-garden/garden-app/worker"
 	"github.com/go-chi/chi/v5"
@@ -21,6 +22,7 @@ import (
 	"github.com/rs/xid"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 var createdAt, _ = time.Parse(time.RFC3339Nano, "2021-10-03T11:24:52.891386-07:00")
@@ -115,6 +117,22 @@ func TestGetWaterSchedule(t *testing.T) {
 			},
 			`{"id":"c5cvhpcbcv45e8bp16dg","duration":"1h0m0s","interval":"24h0m0s","start_time":"2021-10-03T11:24:52.891386-07:00","weather_control":{"rain_control":{"baseline_value":0,"factor":0,"range":25.4,"client_id":"c5cvhpcbcv45e8bp16dg"},"temperature_control":{"baseline_value":30,"factor":0.5,"range":10,"client_id":"c5cvhpcbcv45e8bp16dg"}},"next_water":{"time":"\d\d\d\d-\d\d-\d\dT11:24:52.891386-07:00","duration":"1h0m0s"},"links":\[{"rel":"self","href":"/water_schedules/c5cvhpcbcv45e8bp16dg"}\]}`,
 		},
+		{
+			"SuccessfulWithThresholdData",
+			false,
+			&pkg.WaterSchedule{
+				ID:        id,
+				Duration:  &pkg.Duration{Duration: time.Hour},
+				Interval:  &pkg.Duration{Duration: time.Hour * 24},
+				StartTime: &createdAt,
+				WeatherControl: &weather.Control{
+					Freeze: &weather.ThresholdControl{Threshold: float32Pointer(2), ClientID: weatherClientID},
+					Wind:   &weather.ThresholdControl{Threshold: float32Pointer(30), ClientID: weatherClientID},
+					Heat:   &weather.HeatControl{Threshold: float32Pointer(40), ClientID: weatherClientID},
+				},
+			},
+			`{"id":"c5cvhpcbcv45e8bp16dg","duration":"1h0m0s","interval":"24h0m0s","start_time":"2021-10-03T11:24:52.891386-07:00","weather_control":{"freeze_control":{"threshold":2,"client_id":"c5cvhpcbcv45e8bp16dg"},"wind_control":{"threshold":30,"client_id":"c5cvhpcbcv45e8bp16dg"},"heat_control":{"threshold":40,"client_id":"c5cvhpcbcv45e8bp16dg"}},"weather_data":{"freeze":{"celsius":0,"triggered":true},"wind":{"km_per_hour":0,"triggered":false},"heat":{"celsius":0,"triggered":false}},"next_water":{"time":"\d\d\d\d-\d\d-\d\dT11:24:52.891386-07:00","duration":"59m59.99995904s"},"links":\[{"rel":"self","href":"/water_schedules/c5cvhpcbcv45e8bp16dg"}\]}`,
+		},
 		{
 			"ErrorRainWeatherClientDNE",
 			false,
@@ -203,6 +221,161 @@ func TestGetWaterSchedule(t *testing.T) {
 	}
 }
 
+func TestGetWaterScheduleSensorControl(t *testing.T) {
+	ws := createExampleWaterSchedule()
+	ws.WeatherControl = &weather.Control{
+		SensorTemperature: &weather.ScaleControl{
+			BaselineValue: float32Pointer(70),
+			Factor:        float32Pointer(0.5),
+			Range:         float32Pointer(30),
+		},
+	}
+
+	tests := []struct {
+		name              string
+		sensor            bool
+		setupMock         func(*influxdb.MockClient)
+		expectedNextWater string
+	}{
+		{
+			"GardenWithSensor",
+			true,
+			func(influxdbClient *influxdb.MockClient) {
+				influxdbClient.On("GetTemperatureAndHumidity", mock.Anything, "test-garden", time.Hour*24, influxdb.AggregationMean).Return(85.0, 50.0, nil)
+			},
+			`"next_water":{"time":"\d\d\d\d-\d\d-\d\dT11:24:52.891386-07:00","duration":"1.25s"}`,
+		},
+		{
+			"GardenWithoutSensorIgnoresControl",
+			false,
+			func(influxdbClient *influxdb.MockClient) {},
+			`"next_water":{"time":"\d\d\d\d-\d\d-\d\dT11:24:52.891386-07:00","duration":"1s"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			influxdbClient := new(influxdb.MockClient)
+			influxdbClient.On("Close")
+			tt.setupMock(influxdbClient)
+
+			storageClient, err := storage.NewClient(storage.Config{
+				Driver: "hashmap",
+			})
+			assert.NoError(t, err)
+
+			garden := createExampleGarden()
+			garden.TemperatureHumiditySensor = &tt.sensor
+			assert.NoError(t, storageClient.SaveGarden(garden))
+			assert.NoError(t, storageClient.SaveZone(garden.ID, createExampleZone()))
+			assert.NoError(t, storageClient.SaveWaterSchedule(ws))
+
+			wsr, err := NewWaterSchedulesResource(storageClient, worker.NewWorker(storageClient, influxdbClient, nil, logrus.New()))
+			assert.NoError(t, err)
+			wsr.worker.StartAsync()
+
+			router := chi.NewRouter()
+			router.Route(fmt.Sprintf("/water_schedules/{%s}", waterSchedulePathParam), func(r chi.Router) {
+				r.Use(wsr.waterScheduleContextMiddleware)
+				r.Get("/", wsr.getWaterSchedule)
+			})
+
+			r := httptest.NewRequest("GET", fmt.Sprintf("/water_schedules/%s", ws.ID), nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Regexp(t, tt.expectedNextWater, w.Body.String())
+
+			wsr.worker.Stop()
+			influxdbClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetWaterScheduleUnits(t *testing.T) {
+	ws := &pkg.WaterSchedule{
+		ID:        id,
+		Duration:  &pkg.Duration{Duration: time.Hour},
+		Interval:  &pkg.Duration{Duration: time.Hour * 24},
+		StartTime: &createdAt,
+		WeatherControl: &weather.Control{
+			Rain: &weather.ScaleControl{
+				BaselineValue: float32Pointer(0),
+				Factor:        float32Pointer(0),
+				Range:         float32Pointer(1),
+				Unit:          units.Inches,
+				ClientID:      id,
+			},
+			Temperature: &weather.ScaleControl{
+				BaselineValue: float32Pointer(86),
+				Factor:        float32Pointer(0.5),
+				Range:         float32Pointer(18),
+				Unit:          units.Fahrenheit,
+				ClientID:      id,
+			},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedCode   int
+		expectedRegexp string
+	}{
+		{
+			"Metric",
+			"",
+			http.StatusOK,
+			`"weather_data":{"rain":{"mm":25.4,"scale_factor":0},"average_temperature":{"celsius":80,"scale_factor":1.5}}`,
+		},
+		{
+			"Imperial",
+			"?units=imperial",
+			http.StatusOK,
+			`"weather_data":{"rain":{"inches":1,"scale_factor":0},"average_temperature":{"fahrenheit":176,"scale_factor":1.5}}`,
+		},
+		{
+			"InvalidUnits",
+			"?units=kelvin",
+			http.StatusBadRequest,
+			`{"status":"Invalid request.","error":"invalid unit system \\"kelvin\\", must be one of: metric, imperial"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storageClient, err := storage.NewClient(storage.Config{
+				Driver: "hashmap",
+			})
+			assert.NoError(t, err)
+
+			err = storageClient.SaveWaterSchedule(ws)
+			assert.NoError(t, err)
+
+			err = storageClient.SaveWeatherClientConfig(createExampleWeatherClientConfig())
+			assert.NoError(t, err)
+
+			wsr, err := NewWaterSchedulesResource(storageClient, worker.NewWorker(storageClient, nil, nil, logrus.New()))
+			assert.NoError(t, err)
+
+			router := chi.NewRouter()
+			router.Use(unitSystemMiddleware)
+			router.Route(fmt.Sprintf("/water_schedules/{%s}", waterSchedulePathParam), func(r chi.Router) {
+				r.Use(wsr.waterScheduleContextMiddleware)
+				r.Get("/", wsr.getWaterSchedule)
+			})
+
+			r := httptest.NewRequest("GET", fmt.Sprintf("/water_schedules/%s%s", ws.ID, tt.query), nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Regexp(t, tt.expectedRegexp, strings.TrimSpace(w.Body.String()))
+		})
+	}
+}
+
 func TestWaterScheduleContextMiddleware(t *testing.T) {
 	waterSchedule := createExampleWaterSchedule()
 
@@ -1,14 +1,21 @@
 package server
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/calvinmclean/automated-garden/garden-app/pkg/storage"
 	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather/netatmo"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
+	"github.com/patrickmn/go-cache"
 	"github.com/rs/xid"
 )
 
@@ -16,18 +23,33 @@ const (
 	weatherClientsBasePath  = "/weather_clients"
 	weatherClientPathParam  = "clientID"
 	weatherClientIDLogField = "weather_client_id"
+
+	// oauthStateTTL is how long a user has to finish authorizing a WeatherClient
+	oauthStateTTL = 10 * time.Minute
+
+	// maxHistoryPeriods limits the size of history responses. This is the most values Netatmo returns in one request
+	maxHistoryPeriods = 1024
 )
 
 // WeatherClientsResource encapsulates the structs and dependencies necessary for the WeatherClients API
 // to function, including storage and configuring
 type WeatherClientsResource struct {
 	storageClient *storage.Client
+	// oauthStates holds the pendingAuthorization for each state that was sent to an OAuth authorization page
+	oauthStates *cache.Cache
+}
+
+// pendingAuthorization is used to finish the OAuth authorization code flow when the user is redirected back
+type pendingAuthorization struct {
+	clientID    xid.ID
+	redirectURI string
 }
 
 // NewWeatherClientsResource creates a new WeatherClientsResource
 func NewWeatherClientsResource(storageClient *storage.Client) (WeatherClientsResource, error) {
 	wc := WeatherClientsResource{
 		storageClient: storageClient,
+		oauthStates:   cache.New(oauthStateTTL, oauthStateTTL),
 	}
 
 	return wc, nil
@@ -91,6 +113,13 @@ func (wcr WeatherClientsResource) getWeatherClient(w http.ResponseWriter, r *htt
 	logger.Debugf("responding with WeatherClient: %+v", weatherClient)
 
 	gardenResponse := wcr.NewWeatherClientResponse(r.Context(), weatherClient)
+
+	authStatus, err := weatherClient.AuthStatus()
+	if err != nil {
+		logger.WithError(err).Warn("unable to get WeatherClient's auth status")
+	}
+	gardenResponse.AuthStatus = authStatus
+
 	if err := render.Render(w, r, gardenResponse); err != nil {
 		logger.WithError(err).Error("unable to render WeatherClientResponse")
 		render.Render(w, r, ErrRender(err))
@@ -115,6 +144,12 @@ func (wcr WeatherClientsResource) createWeatherClient(w http.ResponseWriter, r *
 	weatherClientConfig.ID = xid.New()
 	logger.Debugf("new WeatherClient ID: %v", weatherClientConfig.ID)
 
+	if err := wcr.validateCompositeClient(weatherClientConfig); err != nil {
+		logger.WithError(err).Error("invalid request to create WeatherClient")
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
 	// Save the WeatherClient
 	logger.Debug("saving WeatherClient")
 	if err := wcr.storageClient.SaveWeatherClientConfig(weatherClientConfig); err != nil {
@@ -147,7 +182,14 @@ func (wcr WeatherClientsResource) updateWeatherClient(w http.ResponseWriter, r *
 	weatherClient.Patch(request.Config)
 
 	// make sure a valid WeatherClient can still be created
-	_, err := weather.NewClient(weatherClient, func(map[string]interface{}) error { return nil })
+	_, err := weather.NewClient(weatherClient, func(map[string]interface{}) error { return nil }, nil)
+	if err != nil {
+		logger.WithError(err).Error("invalid request to update WeatherClient")
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	err = wcr.validateCompositeClient(weatherClient)
 	if err != nil {
 		logger.WithError(err).Error("invalid request to update WeatherClient")
 		render.Render(w, r, ErrInvalidRequest(err))
@@ -162,6 +204,13 @@ func (wcr WeatherClientsResource) updateWeatherClient(w http.ResponseWriter, r *
 		return
 	}
 
+	// Stored observations might be from a different source, so they are removed
+	if err := wcr.storageClient.DeleteWeatherObservations(weatherClient.ID); err != nil {
+		logger.WithError(err).Error("unable to delete WeatherClient's stored observations")
+		render.Render(w, r, InternalServerError(err))
+		return
+	}
+
 	render.Status(r, http.StatusOK)
 	if err := render.Render(w, r, wcr.NewWeatherClientResponse(r.Context(), weatherClient)); err != nil {
 		logger.WithError(err).Error("unable to render WeatherClientResponse")
@@ -190,6 +239,12 @@ func (wcr WeatherClientsResource) deleteWeatherClient(w http.ResponseWriter, r *
 		return
 	}
 
+	if err := wcr.storageClient.DeleteWeatherObservations(weatherClient.ID); err != nil {
+		logger.WithError(err).Error("unable to delete WeatherClient's stored observations")
+		render.Render(w, r, InternalServerError(err))
+		return
+	}
+
 	if err := render.Render(w, r, wcr.NewWeatherClientResponse(r.Context(), weatherClient)); err != nil {
 		logger.WithError(err).Error("unable to render WeatherClientResponse")
 		render.Render(w, r, ErrRender(err))
@@ -206,6 +261,50 @@ func (wcr *WeatherClientsResource) checkIfClientIsBeingUsed(weatherClient *weath
 		return fmt.Errorf("unable to delete WeatherClient used by %d WaterSchedules", len(waterSchedules))
 	}
 
+	weatherClients, err := wcr.storageClient.GetWeatherClientsUsingWeatherClient(weatherClient.ID)
+	if err != nil {
+		return fmt.Errorf("unable to get WeatherClients using WeatherClient %q: %w", weatherClient.ID, err)
+	}
+
+	if len(weatherClients) > 0 {
+		return fmt.Errorf("unable to delete WeatherClient used by %d composite WeatherClients", len(weatherClients))
+	}
+
+	return nil
+}
+
+// validateCompositeClient makes sure a composite WeatherClient only uses existing WeatherClients that are not
+// composite. This prevents loops between composite WeatherClients
+func (wcr *WeatherClientsResource) validateCompositeClient(weatherClient *weather.Config) error {
+	if weatherClient.Type != weather.CompositeType {
+		return nil
+	}
+
+	usedBy, err := wcr.storageClient.GetWeatherClientsUsingWeatherClient(weatherClient.ID)
+	if err != nil {
+		return fmt.Errorf("unable to get WeatherClients using WeatherClient %q: %w", weatherClient.ID, err)
+	}
+	if len(usedBy) > 0 {
+		return errors.New("composite WeatherClients cannot be used by other composite WeatherClients")
+	}
+
+	compositeConfig, err := weather.NewCompositeConfig(weatherClient.Options)
+	if err != nil {
+		return err
+	}
+	for _, id := range compositeConfig.IDs() {
+		subClient, err := wcr.storageClient.GetWeatherClientConfig(id)
+		if err != nil {
+			return fmt.Errorf("unable to get WeatherClient %q: %w", id, err)
+		}
+		if subClient == nil {
+			return fmt.Errorf("WeatherClient %q not found", id)
+		}
+		if subClient.Type == weather.CompositeType {
+			return errors.New("composite WeatherClients cannot be used by other composite WeatherClients")
+		}
+	}
+
 	return nil
 }
 
@@ -222,29 +321,341 @@ func (wcr WeatherClientsResource) testWeatherClient(w http.ResponseWriter, r *ht
 		return
 	}
 
+	resp := &WeatherClientTestResponse{}
+
+	// Sub-clients are tested first so their results are available even if the composite client fails
+	if weatherClient.Type == weather.CompositeType {
+		resp.Clients, err = wcr.testCompositeSubClients(weatherClient)
+		if err != nil {
+			logger.WithError(err).Error("unable to test composite WeatherClient's sub-clients")
+			render.Render(w, r, InternalServerError(err))
+			return
+		}
+	}
+
+	resp.WeatherData, resp.Errors = testClient(wc)
+	if len(resp.Errors) > 0 {
+		logger.WithField("errors", resp.Errors).Error("unable to get weather data from WeatherClient")
+		render.Status(r, http.StatusBadGateway)
+	}
+
+	unitSystem := getUnitSystem(r.Context(), nil)
+	resp.WeatherData.convertUnits(unitSystem)
+	for _, result := range resp.Clients {
+		result.WeatherData.convertUnits(unitSystem)
+	}
+
+	if err := render.Render(w, r, resp); err != nil {
+		logger.WithError(err).Error("unable to render WeatherClientResponse")
+		render.Render(w, r, ErrRender(err))
+	}
+}
+
+// testCompositeSubClients gets the same data as testWeatherClient from each of a composite WeatherClient's sub-clients.
+// Errors from the sub-clients are included in the results instead of being returned
+func (wcr WeatherClientsResource) testCompositeSubClients(weatherClient *weather.Config) ([]*WeatherClientTestResult, error) {
+	compositeConfig, err := weather.NewCompositeConfig(weatherClient.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []*WeatherClientTestResult{}
+	for _, id := range compositeConfig.IDs() {
+		result := &WeatherClientTestResult{ClientID: id}
+		results = append(results, result)
+
+		wc, err := wcr.storageClient.GetWeatherClient(id)
+		if err != nil {
+			result.Errors = append(result.Errors, err.Error())
+			continue
+		}
+
+		result.WeatherData, result.Errors = testClient(wc)
+	}
+	return results, nil
+}
+
+// testClient gets the total rain and average high temperature in the last 72 hours from the client. Errors are
+// returned as messages so they can be included in the response
+func testClient(wc weather.Client) (WeatherData, []string) {
+	data := WeatherData{}
+	var errs []string
+
 	rd, err := wc.GetTotalRain(72 * time.Hour)
 	if err != nil {
-		logger.WithError(err).Error("unable to get total rain in the last 72 hours")
+		errs = append(errs, fmt.Sprintf("unable to get total rain in the last 72 hours: %v", err))
+	} else {
+		data.Rain = &RainData{MM: &rd}
+	}
+
+	td, err := wc.GetAverageHighTemperature(72 * time.Hour)
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("unable to get average high temperature in the last 72 hours: %v", err))
+	} else {
+		data.Temperature = &TemperatureData{Celsius: &td}
+	}
+
+	return data, errs
+}
+
+// getWeatherClientCache responds with all of the WeatherClient's cached data
+func (wcr WeatherClientsResource) getWeatherClientCache(w http.ResponseWriter, r *http.Request) {
+	logger := getLoggerFromContext(r.Context())
+	logger.Info("received request to get WeatherClient cache")
+
+	weatherClient := getWeatherClientFromContext(r.Context())
+
+	entries, err := weather.GetCacheEntries(weatherClient.ID)
+	if err != nil {
+		logger.WithError(err).Error("unable to get WeatherClient cache entries")
 		render.Render(w, r, InternalServerError(err))
 		return
 	}
 
-	td, err := wc.GetAverageHighTemperature(72 * time.Hour)
+	if err := render.Render(w, r, &WeatherClientCacheResponse{entries}); err != nil {
+		logger.WithError(err).Error("unable to render WeatherClientCacheResponse")
+		render.Render(w, r, ErrRender(err))
+	}
+}
+
+// flushWeatherClientCache deletes all of the WeatherClient's cached data and responds with the deleted entries
+func (wcr WeatherClientsResource) flushWeatherClientCache(w http.ResponseWriter, r *http.Request) {
+	logger := getLoggerFromContext(r.Context())
+	logger.Info("received request to flush WeatherClient cache")
+
+	weatherClient := getWeatherClientFromContext(r.Context())
+
+	entries, err := weather.GetCacheEntries(weatherClient.ID)
 	if err != nil {
-		logger.WithError(err).Error("unable to get average high temperature in the last 72 hours")
+		logger.WithError(err).Error("unable to get WeatherClient cache entries")
 		render.Render(w, r, InternalServerError(err))
 		return
 	}
 
-	resp := &WeatherClientTestResponse{WeatherData: WeatherData{
-		Rain: &RainData{
-			MM: rd,
-		},
-		Temperature: &TemperatureData{
-			Celsius: td,
-		},
-	}}
+	err = weather.FlushCache(weatherClient.ID)
+	if err != nil {
+		logger.WithError(err).Error("unable to flush WeatherClient cache")
+		render.Render(w, r, InternalServerError(err))
+		return
+	}
+	logger.Debugf("flushed %d cache entries", len(entries))
+
+	if err := render.Render(w, r, &WeatherClientCacheResponse{entries}); err != nil {
+		logger.WithError(err).Error("unable to render WeatherClientCacheResponse")
+		render.Render(w, r, ErrRender(err))
+	}
+}
 
+// getWeatherClientHistory responds with the WeatherClient's rain and temperature for each period in the range. Only
+// complete periods are included so they can be stored and reused without querying the WeatherClient again
+func (wcr WeatherClientsResource) getWeatherClientHistory(w http.ResponseWriter, r *http.Request) {
+	logger := getLoggerFromContext(r.Context())
+	logger.Info("received request to get WeatherClient history")
+
+	weatherClient := getWeatherClientFromContext(r.Context())
+
+	// Read query parameters and set default values
+	timeRangeString := r.URL.Query().Get("range")
+	if len(timeRangeString) == 0 {
+		timeRangeString = "7d"
+	}
+	resolutionString := r.URL.Query().Get("resolution")
+	if len(resolutionString) == 0 {
+		resolutionString = "1d"
+	}
+	logger.Debugf("using time range %s and resolution %s", timeRangeString, resolutionString)
+
+	timeRange, err := parseHistoryDuration(timeRangeString)
+	if err != nil {
+		logger.WithError(err).Error("unable to parse time range")
+		render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid range: %w", err)))
+		return
+	}
+	resolution, err := parseHistoryDuration(resolutionString)
+	if err != nil {
+		logger.WithError(err).Error("unable to parse resolution")
+		render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid resolution: %w", err)))
+		return
+	}
+	if timeRange < resolution {
+		err = errors.New("range must be at least one resolution")
+		logger.WithError(err).Error("invalid history request")
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	if timeRange/resolution > maxHistoryPeriods {
+		err = fmt.Errorf("range can have at most %d periods of the resolution", maxHistoryPeriods)
+		logger.WithError(err).Error("invalid history request")
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	end := time.Now().Truncate(resolution)
+	start := end.Add(-timeRange).Truncate(resolution)
+	expectedPeriods := int(end.Sub(start) / resolution)
+
+	observations, err := wcr.storageClient.GetWeatherObservations(weatherClient.ID, resolution, start, end)
+	if err != nil {
+		logger.WithError(err).Error("unable to get stored weather observations")
+		render.Render(w, r, InternalServerError(err))
+		return
+	}
+
+	if len(observations) < expectedPeriods {
+		logger.Debugf("found %d of %d stored observations, getting history from WeatherClient", len(observations), expectedPeriods)
+
+		wc, err := wcr.storageClient.GetWeatherClient(weatherClient.ID)
+		if err != nil {
+			logger.WithError(err).Error("unable to get WeatherClient")
+			render.Render(w, r, InternalServerError(err))
+			return
+		}
+
+		observations, err = wc.GetHistory(start, end, resolution)
+		if err != nil {
+			logger.WithError(err).Error("unable to get history from WeatherClient")
+			render.Render(w, r, InternalServerError(err))
+			return
+		}
+
+		err = wcr.storageClient.SaveWeatherObservations(weatherClient.ID, resolution, observations)
+		if err != nil {
+			logger.WithError(err).Error("unable to store weather observations")
+			render.Render(w, r, InternalServerError(err))
+			return
+		}
+	}
+
+	resp := &WeatherClientHistoryResponse{
+		Start:      start,
+		End:        end,
+		Resolution: resolution.String(),
+		History:    observations,
+	}
+	if err := render.Render(w, r, resp); err != nil {
+		logger.WithError(err).Error("unable to render WeatherClientHistoryResponse")
+		render.Render(w, r, ErrRender(err))
+	}
+}
+
+// parseHistoryDuration parses a whole number of days ("30d") or weeks ("1w"), or any time.Duration string
+func parseHistoryDuration(input string) (time.Duration, error) {
+	var unit time.Duration
+	switch {
+	case strings.HasSuffix(input, "d"):
+		unit = 24 * time.Hour
+	case strings.HasSuffix(input, "w"):
+		unit = 7 * 24 * time.Hour
+	default:
+		d, err := time.ParseDuration(input)
+		if err != nil {
+			return 0, err
+		}
+		if d <= 0 {
+			return 0, errors.New("must be a positive duration")
+		}
+		return d, nil
+	}
+
+	n, err := strconv.Atoi(input[:len(input)-1])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid duration %q", input)
+	}
+	return time.Duration(n) * unit, nil
+}
+
+// authorizeWeatherClient redirects to the OAuth authorization page so the user can allow access and get new tokens
+// for the WeatherClient. Only Netatmo uses OAuth. The redirect_uri query parameter can be used when the server is
+// behind a proxy and the URI cannot be determined from the request
+func (wcr WeatherClientsResource) authorizeWeatherClient(w http.ResponseWriter, r *http.Request) {
+	logger := getLoggerFromContext(r.Context())
+	logger.Info("received request to authorize WeatherClient")
+
+	weatherClient := getWeatherClientFromContext(r.Context())
+	if weatherClient.Type != "netatmo" {
+		err := fmt.Errorf("authorization is not supported for %q WeatherClients", weatherClient.Type)
+		logger.WithError(err).Error("invalid request to authorize WeatherClient")
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	redirectURI := r.URL.Query().Get("redirect_uri")
+	if redirectURI == "" {
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+			scheme = proto
+		}
+		redirectURI = fmt.Sprintf("%s://%s%s/%s/authorize/callback", scheme, r.Host, weatherClientsBasePath, weatherClient.ID)
+	}
+
+	stateBytes := make([]byte, 16)
+	if _, err := rand.Read(stateBytes); err != nil {
+		logger.WithError(err).Error("unable to generate OAuth state")
+		render.Render(w, r, InternalServerError(err))
+		return
+	}
+	state := hex.EncodeToString(stateBytes)
+
+	authURL, err := netatmo.AuthorizationURL(weatherClient.Options, redirectURI, state)
+	if err != nil {
+		logger.WithError(err).Error("unable to create authorization URL")
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	wcr.oauthStates.SetDefault(state, pendingAuthorization{weatherClient.ID, redirectURI})
+
+	logger.Debugf("redirecting to authorization URL: %s", authURL)
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// authorizeWeatherClientCallback finishes authorization by exchanging the code for tokens and saving them
+func (wcr WeatherClientsResource) authorizeWeatherClientCallback(w http.ResponseWriter, r *http.Request) {
+	logger := getLoggerFromContext(r.Context())
+	logger.Info("received OAuth callback for WeatherClient")
+
+	weatherClient := getWeatherClientFromContext(r.Context())
+
+	if authErr := r.URL.Query().Get("error"); authErr != "" {
+		err := fmt.Errorf("authorization failed: %s", authErr)
+		logger.WithError(err).Error("user did not authorize WeatherClient")
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	state := r.URL.Query().Get("state")
+	pending, ok := wcr.oauthStates.Get(state)
+	if !ok || pending.(pendingAuthorization).clientID != weatherClient.ID {
+		err := errors.New("invalid or expired state")
+		logger.WithError(err).Error("invalid OAuth callback")
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	wcr.oauthStates.Delete(state)
+
+	options, err := netatmo.ExchangeAuthorizationCode(weatherClient.Options, r.URL.Query().Get("code"), pending.(pendingAuthorization).redirectURI)
+	if err != nil {
+		logger.WithError(err).Error("unable to get tokens for WeatherClient")
+		render.Render(w, r, InternalServerError(err))
+		return
+	}
+	weatherClient.Options = options
+
+	logger.Debug("saving WeatherClient with new tokens")
+	if err := wcr.storageClient.SaveWeatherClientConfig(weatherClient); err != nil {
+		logger.WithError(err).Error("unable to save WeatherClient Config")
+		render.Render(w, r, InternalServerError(err))
+		return
+	}
+
+	resp := wcr.NewWeatherClientResponse(r.Context(), weatherClient)
+	resp.AuthStatus, err = weatherClient.AuthStatus()
+	if err != nil {
+		logger.WithError(err).Warn("unable to get WeatherClient's auth status")
+	}
 	if err := render.Render(w, r, resp); err != nil {
 		logger.WithError(err).Error("unable to render WeatherClientResponse")
 		render.Render(w, r, ErrRender(err))
@@ -4,14 +4,19 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather/history"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather/netatmo"
+	"github.com/rs/xid"
 )
 
 // WeatherClientResponse is a simple struct being used to render and return a WeatherClient
 type WeatherClientResponse struct {
 	*weather.Config
-	Links []Link `json:"links,omitempty"`
+	AuthStatus *netatmo.AuthStatus `json:"auth_status,omitempty"`
+	Links      []Link              `json:"links,omitempty"`
 }
 
 // NewWeatherClientResponse creates a new WeatherClientResponse
@@ -54,12 +59,45 @@ func (wr *AllWeatherClientsResponse) Render(_ http.ResponseWriter, _ *http.Reque
 	return nil
 }
 
-// WeatherClientTestResponse is used to return WeatherData from testing that the client works
+// WeatherClientTestResponse is used to return WeatherData from testing that the client works. Composite clients also
+// include results from each of their sub-clients, even if getting the combined data fails
 type WeatherClientTestResponse struct {
 	WeatherData
+	Errors  []string                   `json:"errors,omitempty"`
+	Clients []*WeatherClientTestResult `json:"clients,omitempty"`
+}
+
+// WeatherClientTestResult is the WeatherData and any errors from testing one of a composite client's sub-clients
+type WeatherClientTestResult struct {
+	ClientID xid.ID `json:"client_id"`
+	WeatherData
+	Errors []string `json:"errors,omitempty"`
 }
 
 // Render ...
 func (resp *WeatherClientTestResponse) Render(_ http.ResponseWriter, _ *http.Request) error {
 	return nil
 }
+
+// WeatherClientCacheResponse is used to show a WeatherClient's cached data
+type WeatherClientCacheResponse struct {
+	Entries []*weather.CacheEntry `json:"entries"`
+}
+
+// Render ...
+func (resp *WeatherClientCacheResponse) Render(_ http.ResponseWriter, _ *http.Request) error {
+	return nil
+}
+
+// WeatherClientHistoryResponse shows a WeatherClient's rain and temperature for each period between Start and End
+type WeatherClientHistoryResponse struct {
+	Start      time.Time              `json:"start"`
+	End        time.Time              `json:"end"`
+	Resolution string                 `json:"resolution"`
+	History    []*history.Observation `json:"history"`
+}
+
+// Render ...
+func (resp *WeatherClientHistoryResponse) Render(_ http.ResponseWriter, _ *http.Request) error {
+	return nil
+}
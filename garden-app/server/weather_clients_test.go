@@ -8,6 +8,7 @@ import (
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/calvinmclean/automated-garden/garden-app/pkg/storage"
 	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather"
@@ -117,6 +118,105 @@ func TestGetWeatherClient(t *testing.T) {
 	}
 }
 
+func TestGetWeatherClientAuthStatus(t *testing.T) {
+	weatherClient := &weather.Config{
+		ID:   id,
+		Type: "netatmo",
+		Options: map[string]interface{}{
+			"authentication": map[string]interface{}{
+				"access_token":       "access_token",
+				"expiration_date":    "2023-08-01T00:00:00Z",
+				"last_refresh":       "2023-07-31T21:00:00Z",
+				"last_refresh_error": "received unexpected status 400",
+			},
+		},
+	}
+
+	wcr := WeatherClientsResource{}
+	weatherClientCtx := context.WithValue(context.Background(), weatherClientCtxKey, weatherClient)
+	r := httptest.NewRequest("GET", "/weather_clients", nil).WithContext(weatherClientCtx)
+	w := httptest.NewRecorder()
+	h := http.HandlerFunc(wcr.getWeatherClient)
+
+	h.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"auth_status":{"valid":false,"valid_until":"2023-08-01T00:00:00Z","last_refresh":"2023-07-31T21:00:00Z","last_refresh_error":"received unexpected status 400"}`)
+}
+
+func TestAuthorizeWeatherClient(t *testing.T) {
+	storageClient, err := storage.NewClient(storage.Config{
+		Driver: "hashmap",
+	})
+	assert.NoError(t, err)
+
+	netatmoClient := &weather.Config{
+		ID:      id,
+		Type:    "netatmo",
+		Options: map[string]interface{}{"client_id": "netatmo_client_id"},
+	}
+	assert.NoError(t, storageClient.SaveWeatherClientConfig(netatmoClient))
+
+	fakeClient := createExampleWeatherClientConfig()
+	fakeClient.ID = id2
+	assert.NoError(t, storageClient.SaveWeatherClientConfig(fakeClient))
+
+	wcr, err := NewWeatherClientsResource(storageClient)
+	assert.NoError(t, err)
+
+	router := chi.NewRouter()
+	router.Route(fmt.Sprintf("/weather_clients/{%s}", weatherClientPathParam), func(r chi.Router) {
+		r.Use(wcr.weatherClientContextMiddleware)
+		r.Get("/authorize", wcr.authorizeWeatherClient)
+		r.Get("/authorize/callback", wcr.authorizeWeatherClientCallback)
+	})
+
+	t.Run("Redirect", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "http://garden.local/weather_clients/c5cvhpcbcv45e8bp16dg/authorize", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusFound, w.Code)
+		assert.Regexp(t,
+			`^https://api.netatmo.com/oauth2/authorize\?client_id=netatmo_client_id&redirect_uri=http%3A%2F%2Fgarden.local%2Fweather_clients%2Fc5cvhpcbcv45e8bp16dg%2Fauthorize%2Fcallback&scope=read_station&state=[0-9a-f]{32}$`,
+			w.Header().Get("Location"),
+		)
+	})
+
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{
+			"NotSupported",
+			"/weather_clients/chkodpg3lcj13q82mq40/authorize",
+			`{"status":"Invalid request.","error":"authorization is not supported for \"fake\" WeatherClients"}`,
+		},
+		{
+			"CallbackInvalidState",
+			"/weather_clients/c5cvhpcbcv45e8bp16dg/authorize/callback?code=code&state=invalid",
+			`{"status":"Invalid request.","error":"invalid or expired state"}`,
+		},
+		{
+			"CallbackAccessDenied",
+			"/weather_clients/c5cvhpcbcv45e8bp16dg/authorize/callback?error=access_denied",
+			`{"status":"Invalid request.","error":"authorization failed: access_denied"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Equal(t, tt.expected, strings.TrimSpace(w.Body.String()))
+		})
+	}
+}
+
 func TestUpdateWeatherClient(t *testing.T) {
 	weatherClient := createExampleWeatherClientConfig()
 
@@ -203,6 +303,17 @@ func TestDeleteWeatherClient(t *testing.T) {
 		},
 	}
 
+	weatherClientWithComposite := createExampleWeatherClientConfig()
+	weatherClientWithComposite.ID = xid.New()
+
+	compositeWeatherClient := &weather.Config{
+		ID:   xid.New(),
+		Type: weather.CompositeType,
+		Options: map[string]interface{}{
+			"client_ids": []string{weatherClientWithComposite.ID.String()},
+		},
+	}
+
 	storageClient, err := storage.NewClient(storage.Config{
 		Driver: "hashmap",
 	})
@@ -212,6 +323,10 @@ func TestDeleteWeatherClient(t *testing.T) {
 	assert.NoError(t, err)
 	err = storageClient.SaveWeatherClientConfig(weatherClientWithWS)
 	assert.NoError(t, err)
+	err = storageClient.SaveWeatherClientConfig(weatherClientWithComposite)
+	assert.NoError(t, err)
+	err = storageClient.SaveWeatherClientConfig(compositeWeatherClient)
+	assert.NoError(t, err)
 	err = storageClient.SaveWaterSchedule(ws1)
 	assert.NoError(t, err)
 	err = storageClient.SaveWaterSchedule(ws2)
@@ -235,7 +350,14 @@ func TestDeleteWeatherClient(t *testing.T) {
 			"UnableToDeleteUsedByWaterSchedules",
 			id2.String(),
 			createExampleWeatherClientConfig(),
-			`{"status":"Invalid request.","error":"unable to delete WeatherClient used by 2 WaterSchedules"}`,
+			`{"status":"Invalid request.","error":"unable to delete WeatherClient used by 1 WaterSchedules"}`,
+			http.StatusBadRequest,
+		},
+		{
+			"UnableToDeleteUsedByCompositeWeatherClients",
+			weatherClientWithComposite.ID.String(),
+			weatherClientWithComposite,
+			`{"status":"Invalid request.","error":"unable to delete WeatherClient used by 1 composite WeatherClients"}`,
 			http.StatusBadRequest,
 		},
 	}
@@ -308,6 +430,19 @@ func TestCreateWeatherClient(t *testing.T) {
 	})
 	assert.NoError(t, err)
 
+	err = storageClient.SaveWeatherClientConfig(createExampleWeatherClientConfig())
+	assert.NoError(t, err)
+
+	compositeWeatherClient := &weather.Config{
+		ID:   id2,
+		Type: weather.CompositeType,
+		Options: map[string]interface{}{
+			"client_ids": []string{id.String()},
+		},
+	}
+	err = storageClient.SaveWeatherClientConfig(compositeWeatherClient)
+	assert.NoError(t, err)
+
 	tests := []struct {
 		name           string
 		body           string
@@ -320,6 +455,24 @@ func TestCreateWeatherClient(t *testing.T) {
 			`{"id":"[0-9a-v]{20}","type":"fake","options":{"avg_high_temperature":80,"rain_interval":"24h","rain_mm":25.4},"links":\[{"rel":"self","href":"/weather_clients/[0-9a-v]{20}"}\]}`,
 			http.StatusCreated,
 		},
+		{
+			"SuccessfulComposite",
+			`{"type":"composite","options":{"client_ids":["c5cvhpcbcv45e8bp16dg"],"strategy":"average"}}`,
+			`{"id":"[0-9a-v]{20}","type":"composite","options":{"client_ids":\["c5cvhpcbcv45e8bp16dg"\],"strategy":"average"},"links":\[{"rel":"self","href":"/weather_clients/[0-9a-v]{20}"}\]}`,
+			http.StatusCreated,
+		},
+		{
+			"ErrorCompositeClientNotFound",
+			`{"type":"composite","options":{"client_ids":["c5cvhpcbcv45e8bp16d0"]}}`,
+			`{"status":"Invalid request.","error":"WeatherClient \\"c5cvhpcbcv45e8bp16d0\\" not found"}`,
+			http.StatusBadRequest,
+		},
+		{
+			"ErrorNestedCompositeClient",
+			`{"type":"composite","options":{"client_ids":["c5cvhpcbcv45e8bp16dg","` + id2.String() + `"]}}`,
+			`{"status":"Invalid request.","error":"composite WeatherClients cannot be used by other composite WeatherClients"}`,
+			http.StatusBadRequest,
+		},
 		{
 			"ErrorBadRequestBadJSON",
 			"this is not json",
@@ -356,36 +509,101 @@ func TestCreateWeatherClient(t *testing.T) {
 }
 
 func TestTestWeatherClient(t *testing.T) {
-	weatherClient := createExampleWeatherClientConfig()
-
-	storageClient, err := storage.NewClient(storage.Config{
-		Driver: "hashmap",
-	})
-	assert.NoError(t, err)
-
-	err = storageClient.SaveWeatherClientConfig(weatherClient)
-	assert.NoError(t, err)
+	wundergroundServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Daily summaries are returned with recent timestamps so they are always in range
+		today := time.Now().Unix()
+		fmt.Fprintf(w,
+			`{"summaries":[{"epoch":%d,"metric":{"tempHigh":30,"precipTotal":1.5}},{"epoch":%d,"metric":{"tempHigh":36,"precipTotal":2}},{"epoch":%d,"metric":{"tempHigh":45,"precipTotal":0.5}}]}`,
+			today-2*86400, today-86400, today,
+		)
+	}))
+	defer wundergroundServer.Close()
+	defer weather.ResetCache()
+
+	missingID := xid.New()
 
 	tests := []struct {
 		name           string
+		weatherClient  *weather.Config
+		subClients     []*weather.Config
 		expected       string
 		expectedStatus int
 	}{
 		{
 			"Successful",
+			createExampleWeatherClientConfig(),
+			nil,
 			`{"rain":{"mm":76.2,"scale_factor":0},"average_temperature":{"celsius":80,"scale_factor":0}}`,
 			http.StatusOK,
 		},
+		{
+			"SuccessfulComposite",
+			&weather.Config{
+				ID:   id2,
+				Type: weather.CompositeType,
+				Options: map[string]interface{}{
+					"client_ids": []string{missingID.String(), id.String()},
+				},
+			},
+			[]*weather.Config{createExampleWeatherClientConfig()},
+			fmt.Sprintf(
+				`{"rain":{"mm":76.2,"scale_factor":0},"average_temperature":{"celsius":80,"scale_factor":0},"clients":[{"client_id":"%s","errors":["weather client config not found"]},{"client_id":"c5cvhpcbcv45e8bp16dg","rain":{"mm":76.2,"scale_factor":0},"average_temperature":{"celsius":80,"scale_factor":0}}]}`,
+				missingID,
+			),
+			http.StatusOK,
+		},
+		{
+			"CompositeAllClientsFailed",
+			&weather.Config{
+				ID:   xid.New(),
+				Type: weather.CompositeType,
+				Options: map[string]interface{}{
+					"client_ids": []string{missingID.String()},
+				},
+			},
+			nil,
+			fmt.Sprintf(
+				`{"errors":["unable to get total rain in the last 72 hours: all weather clients failed: %[1]s: weather client config not found","unable to get average high temperature in the last 72 hours: all weather clients failed: %[1]s: weather client config not found"],"clients":[{"client_id":"%[1]s","errors":["weather client config not found"]}]}`,
+				missingID,
+			),
+			http.StatusBadGateway,
+		},
+		{
+			"SuccessfulWeatherUnderground",
+			&weather.Config{
+				ID:   xid.New(),
+				Type: "wunderground",
+				Options: map[string]interface{}{
+					"station_id": "KAZPHOEN123",
+					"api_key":    "api-key",
+					"base_url":   wundergroundServer.URL,
+				},
+			},
+			nil,
+			`{"rain":{"mm":4,"scale_factor":0},"average_temperature":{"celsius":33,"scale_factor":0}}`,
+			http.StatusOK,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			storageClient, err := storage.NewClient(storage.Config{
+				Driver: "hashmap",
+			})
+			assert.NoError(t, err)
+
+			err = storageClient.SaveWeatherClientConfig(tt.weatherClient)
+			assert.NoError(t, err)
+			for _, subClient := range tt.subClients {
+				err = storageClient.SaveWeatherClientConfig(subClient)
+				assert.NoError(t, err)
+			}
+
 			wcr, _ := NewWeatherClientsResource(storageClient)
-			weatherClient := createExampleWeatherClientConfig()
 
-			weatherClientCtx := context.WithValue(context.Background(), weatherClientCtxKey, weatherClient)
+			weatherClientCtx := context.WithValue(context.Background(), weatherClientCtxKey, tt.weatherClient)
 
-			r := httptest.NewRequest("GET", "/weather_clients/c5cvhpcbcv45e8bp16dg", nil).WithContext(weatherClientCtx)
+			r := httptest.NewRequest("GET", fmt.Sprintf("/weather_clients/%s", tt.weatherClient.ID), nil).WithContext(weatherClientCtx)
 			w := httptest.NewRecorder()
 
 			router := chi.NewRouter()
@@ -404,3 +622,169 @@ func TestTestWeatherClient(t *testing.T) {
 		})
 	}
 }
+
+func TestWeatherClientCache(t *testing.T) {
+	defer weather.ResetCache()
+
+	storageClient, err := storage.NewClient(storage.Config{
+		Driver: "hashmap",
+	})
+	assert.NoError(t, err)
+	weather.SetCache(storageClient.NewWeatherCache(0))
+
+	weatherClient := createExampleWeatherClientConfig()
+	err = storageClient.SaveWeatherClientConfig(weatherClient)
+	assert.NoError(t, err)
+
+	wc, err := storageClient.GetWeatherClient(weatherClient.ID)
+	assert.NoError(t, err)
+	_, err = wc.GetTotalRain(72 * time.Hour)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		method         string
+		expectedRegexp string
+	}{
+		{
+			"Get",
+			"GET",
+			`{"entries":\[{"key":"c5cvhpcbcv45e8bp16dg_total_rain_72h0m0s","value":76.2,"expires_at":"[0-9T:\.\-\+Z]+"}\]}`,
+		},
+		{
+			"Flush",
+			"DELETE",
+			`{"entries":\[{"key":"c5cvhpcbcv45e8bp16dg_total_rain_72h0m0s","value":76.2,"expires_at":"[0-9T:\.\-\+Z]+"}\]}`,
+		},
+		{
+			"GetAfterFlush",
+			"GET",
+			`{"entries":\[\]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wcr, _ := NewWeatherClientsResource(storageClient)
+
+			r := httptest.NewRequest(tt.method, "/weather_clients/c5cvhpcbcv45e8bp16dg/cache", nil)
+			w := httptest.NewRecorder()
+
+			router := chi.NewRouter()
+			router.Route(fmt.Sprintf("/weather_clients/{%s}", weatherClientPathParam), func(r chi.Router) {
+				r.Use(wcr.weatherClientContextMiddleware)
+				r.Get("/cache", wcr.getWeatherClientCache)
+				r.Delete("/cache", wcr.flushWeatherClientCache)
+			})
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Regexp(t, "^"+tt.expectedRegexp+"$", strings.TrimSpace(w.Body.String()))
+		})
+	}
+}
+
+func TestGetWeatherClientHistory(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		expectedCode   int
+		expectedRegexp string
+	}{
+		{
+			"SuccessfulDefault",
+			"",
+			http.StatusOK,
+			`{"start":"[0-9T:\.\-\+Z]+","end":"[0-9T:\.\-\+Z]+","resolution":"24h0m0s","history":\[({"time":"[0-9T:\.\-\+Z]+","rain_mm":25.4,"high_temperature":80},?){7}\]}`,
+		},
+		{
+			"SuccessfulHourly",
+			"?range=3h&resolution=1h",
+			http.StatusOK,
+			`{"start":"[0-9T:\.\-\+Z]+","end":"[0-9T:\.\-\+Z]+","resolution":"1h0m0s","history":\[({"time":"[0-9T:\.\-\+Z]+","rain_mm":1.05833\d*,"high_temperature":80},?){3}\]}`,
+		},
+		{
+			"InvalidRange",
+			"?range=abc",
+			http.StatusBadRequest,
+			`{"status":"Invalid request.","error":"invalid range: time: invalid duration \\"abc\\""}`,
+		},
+		{
+			"InvalidResolution",
+			"?resolution=0d",
+			http.StatusBadRequest,
+			`{"status":"Invalid request.","error":"invalid resolution: invalid duration \\"0d\\""}`,
+		},
+		{
+			"RangeSmallerThanResolution",
+			"?range=1h&resolution=1d",
+			http.StatusBadRequest,
+			`{"status":"Invalid request.","error":"range must be at least one resolution"}`,
+		},
+		{
+			"TooManyPeriods",
+			"?range=365d&resolution=1h",
+			http.StatusBadRequest,
+			`{"status":"Invalid request.","error":"range can have at most 1024 periods of the resolution"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storageClient, err := storage.NewClient(storage.Config{
+				Driver: "hashmap",
+			})
+			assert.NoError(t, err)
+
+			err = storageClient.SaveWeatherClientConfig(createExampleWeatherClientConfig())
+			assert.NoError(t, err)
+
+			wcr, _ := NewWeatherClientsResource(storageClient)
+
+			r := httptest.NewRequest("GET", "/weather_clients/c5cvhpcbcv45e8bp16dg/history"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			router := chi.NewRouter()
+			router.Route(fmt.Sprintf("/weather_clients/{%s}", weatherClientPathParam), func(r chi.Router) {
+				r.Use(wcr.weatherClientContextMiddleware)
+				r.Get("/history", wcr.getWeatherClientHistory)
+			})
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Regexp(t, "^"+tt.expectedRegexp+"$", strings.TrimSpace(w.Body.String()))
+		})
+	}
+
+	t.Run("UsesStoredObservations", func(t *testing.T) {
+		storageClient, err := storage.NewClient(storage.Config{
+			Driver: "hashmap",
+		})
+		assert.NoError(t, err)
+
+		weatherClient := createExampleWeatherClientConfig()
+		err = storageClient.SaveWeatherClientConfig(weatherClient)
+		assert.NoError(t, err)
+
+		wcr, _ := NewWeatherClientsResource(storageClient)
+		router := chi.NewRouter()
+		router.Route(fmt.Sprintf("/weather_clients/{%s}", weatherClientPathParam), func(r chi.Router) {
+			r.Use(wcr.weatherClientContextMiddleware)
+			r.Get("/history", wcr.getWeatherClientHistory)
+		})
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/weather_clients/c5cvhpcbcv45e8bp16dg/history?range=2d", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		// Change the configured rain without using the API so the stored observations are not removed
+		weatherClient.Options["rain_mm"] = 0
+		err = storageClient.SaveWeatherClientConfig(weatherClient)
+		assert.NoError(t, err)
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/weather_clients/c5cvhpcbcv45e8bp16dg/history?range=2d", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Regexp(t, `"history":\[({"time":"[0-9T:\.\-\+Z]+","rain_mm":25.4,"high_temperature":80},?){2}\]`, w.Body.String())
+	})
+}
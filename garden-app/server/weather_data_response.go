@@ -6,27 +6,98 @@ import (
 
 	"github.com/calvinmclean/automated-garden/garden-app/pkg"
 	"github.com/calvinmclean/automated-garden/garden-app/pkg/storage"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/units"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather"
 )
 
 // WeatherData is used to represent the data used for WeatherControl to a user
 type WeatherData struct {
-	Rain                *RainData        `json:"rain,omitempty"`
-	Temperature         *TemperatureData `json:"average_temperature,omitempty"`
-	SoilMoisturePercent *float64         `json:"soil_moisture_percent,omitempty"`
+	Rain                *RainData         `json:"rain,omitempty"`
+	Temperature         *TemperatureData  `json:"average_temperature,omitempty"`
+	SoilMoisturePercent *float64          `json:"soil_moisture_percent,omitempty"`
+	SensorTemperature   *TemperatureData  `json:"sensor_temperature,omitempty"`
+	SensorHumidity      *HumidityData     `json:"sensor_humidity,omitempty"`
+	RainForecast        *RainForecastData `json:"rain_forecast,omitempty"`
+	Freeze              *ThresholdData    `json:"freeze,omitempty"`
+	Wind                *WindData         `json:"wind,omitempty"`
+	Heat                *ThresholdData    `json:"heat,omitempty"`
 }
 
-// RainData shows the total rain in the last watering interval and the scaling factor it would result in
+// RainData shows the total rain in the last watering interval and the scaling factor it would result in. Rain is in
+// MM or Inches depending on the unit system
 type RainData struct {
-	MM          float32 `json:"mm"`
-	ScaleFactor float32 `json:"scale_factor"`
+	MM          *float32 `json:"mm,omitempty"`
+	Inches      *float32 `json:"inches,omitempty"`
+	ScaleFactor float32  `json:"scale_factor"`
 }
 
-// TemperatureData shows the average high temperatures in the last watering interval and the scaling factor it would result in
+// TemperatureData shows the average high temperatures in the last watering interval and the scaling factor it would
+// result in. Temperature is in Celsius or Fahrenheit depending on the unit system
 type TemperatureData struct {
-	Celsius     float32 `json:"celsius"`
+	Celsius     *float32 `json:"celsius,omitempty"`
+	Fahrenheit  *float32 `json:"fahrenheit,omitempty"`
+	ScaleFactor float32  `json:"scale_factor"`
+}
+
+// HumidityData shows the Garden's average humidity in the last watering interval and the scaling factor it would result in
+type HumidityData struct {
+	Percentage  float32 `json:"percentage"`
 	ScaleFactor float32 `json:"scale_factor"`
 }
 
+// RainForecastData shows the forecast rain and its probability in the next forecast window and the scaling factor it
+// would result in. A scale factor of zero means watering will be skipped
+type RainForecastData struct {
+	MM          *float32 `json:"mm,omitempty"`
+	Inches      *float32 `json:"inches,omitempty"`
+	Probability float32  `json:"probability"`
+	ScaleFactor float32  `json:"scale_factor"`
+}
+
+// ThresholdData shows the temperature used by a freeze or heat rule and whether it is past the threshold. For freeze,
+// this is the lowest current or forecast temperature and Triggered means watering will be skipped. For heat, this is
+// the current temperature and Triggered means an extra watering will happen. Temperature is in Celsius or Fahrenheit
+// depending on the unit system
+type ThresholdData struct {
+	Celsius    *float32 `json:"celsius,omitempty"`
+	Fahrenheit *float32 `json:"fahrenheit,omitempty"`
+	Triggered  bool     `json:"triggered"`
+}
+
+// WindData shows the current wind speed in km/h used by the wind rule. Triggered means watering will be skipped
+type WindData struct {
+	KilometersPerHour float32 `json:"km_per_hour"`
+	Triggered         bool    `json:"triggered"`
+}
+
+// convertUnits changes the metric values in the WeatherData to the unit system
+func (wd *WeatherData) convertUnits(system units.System) {
+	if wd == nil || system != units.Imperial {
+		return
+	}
+
+	if wd.Rain != nil && wd.Rain.MM != nil {
+		inches := units.MillimetersToInches(*wd.Rain.MM)
+		wd.Rain.MM, wd.Rain.Inches = nil, &inches
+	}
+	if wd.RainForecast != nil && wd.RainForecast.MM != nil {
+		inches := units.MillimetersToInches(*wd.RainForecast.MM)
+		wd.RainForecast.MM, wd.RainForecast.Inches = nil, &inches
+	}
+	for _, td := range []*TemperatureData{wd.Temperature, wd.SensorTemperature} {
+		if td != nil && td.Celsius != nil {
+			fahrenheit := units.CelsiusToFahrenheit(*td.Celsius)
+			td.Celsius, td.Fahrenheit = nil, &fahrenheit
+		}
+	}
+	for _, td := range []*ThresholdData{wd.Freeze, wd.Heat} {
+		if td != nil && td.Celsius != nil {
+			fahrenheit := units.CelsiusToFahrenheit(*td.Celsius)
+			td.Celsius, td.Fahrenheit = nil, &fahrenheit
+		}
+	}
+}
+
 func getWeatherData(ctx context.Context, ws *pkg.WaterSchedule, storageClient *storage.Client) *WeatherData {
 	logger := getLoggerFromContext(ctx).WithField(waterScheduleIDLogField, ws.ID.String())
 	weatherData := &WeatherData{}
@@ -38,7 +109,7 @@ func getWeatherData(ctx context.Context, ws *pkg.WaterSchedule, storageClient *s
 			logger.WithError(err).Warn("unable to get rain data for WaterSchedule")
 		} else {
 			weatherData.Rain = &RainData{
-				MM:          *rainMM,
+				MM:          rainMM,
 				ScaleFactor: ws.WeatherControl.Rain.InvertedScaleDownOnly(*rainMM),
 			}
 		}
@@ -51,11 +122,51 @@ func getWeatherData(ctx context.Context, ws *pkg.WaterSchedule, storageClient *s
 			logger.WithError(err).Warn("unable to get average high temperature from weather client")
 		} else {
 			weatherData.Temperature = &TemperatureData{
-				Celsius:     *celsius,
+				Celsius:     celsius,
 				ScaleFactor: ws.WeatherControl.Temperature.Scale(*celsius),
 			}
 		}
 	}
+
+	if ws.HasRainForecastControl() {
+		logger.Debug("getting rain forecast for WaterSchedule")
+		rainForecast, err := getRainForecastData(ws, storageClient)
+		if err != nil {
+			logger.WithError(err).Warn("unable to get rain forecast from weather client")
+		} else {
+			weatherData.RainForecast = rainForecast
+		}
+	}
+
+	if ws.HasFreezeControl() {
+		logger.Debug("getting freeze data for WaterSchedule")
+		freeze, err := getFreezeData(ws, storageClient)
+		if err != nil {
+			logger.WithError(err).Warn("unable to get temperature for freeze control from weather client")
+		} else {
+			weatherData.Freeze = freeze
+		}
+	}
+
+	if ws.HasWindControl() {
+		logger.Debug("getting wind data for WaterSchedule")
+		wind, err := getWindData(ws, storageClient)
+		if err != nil {
+			logger.WithError(err).Warn("unable to get wind speed from weather client")
+		} else {
+			weatherData.Wind = wind
+		}
+	}
+
+	if ws.HasHeatControl() {
+		logger.Debug("getting heat data for WaterSchedule")
+		heat, err := getHeatData(ws, storageClient)
+		if err != nil {
+			logger.WithError(err).Warn("unable to get temperature for heat control from weather client")
+		} else {
+			weatherData.Heat = heat
+		}
+	}
 	return weatherData
 }
 
@@ -84,3 +195,71 @@ func getTemperatureData(ws *pkg.WaterSchedule, storageClient *storage.Client) (*
 	}
 	return &avgTemperature, nil
 }
+
+func getRainForecastData(ws *pkg.WaterSchedule, storageClient *storage.Client) (*RainForecastData, error) {
+	rfc := ws.WeatherControl.RainForecast
+	weatherClient, err := storageClient.GetWeatherClient(rfc.ClientID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting WeatherClient for RainForecastControl: %w", err)
+	}
+
+	rainMM, err := weatherClient.GetForecastRain(rfc.Window.Duration)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get forecast rain from weather client: %w", err)
+	}
+	probability, err := weatherClient.GetForecastRainProbability(rfc.Window.Duration)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get forecast rain probability from weather client: %w", err)
+	}
+
+	result := &RainForecastData{MM: &rainMM, Probability: probability, ScaleFactor: 1}
+	if rfc.IsLikely(probability) {
+		result.ScaleFactor = rfc.Scale(rainMM)
+		if rfc.ShouldSkip(rainMM) {
+			result.ScaleFactor = 0
+		}
+	}
+	return result, nil
+}
+
+func getFreezeData(ws *pkg.WaterSchedule, storageClient *storage.Client) (*ThresholdData, error) {
+	freeze := ws.WeatherControl.Freeze
+	weatherClient, err := storageClient.GetWeatherClient(freeze.ClientID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting WeatherClient for FreezeControl: %w", err)
+	}
+
+	celsius, err := weather.LowestTemperature(weatherClient, freeze.ForecastWindow())
+	if err != nil {
+		return nil, fmt.Errorf("unable to get temperature from weather client: %w", err)
+	}
+	return &ThresholdData{Celsius: &celsius, Triggered: freeze.IsBelow(celsius)}, nil
+}
+
+func getWindData(ws *pkg.WaterSchedule, storageClient *storage.Client) (*WindData, error) {
+	wind := ws.WeatherControl.Wind
+	weatherClient, err := storageClient.GetWeatherClient(wind.ClientID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting WeatherClient for WindControl: %w", err)
+	}
+
+	windSpeed, err := weatherClient.GetCurrentWindSpeed()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get wind speed from weather client: %w", err)
+	}
+	return &WindData{KilometersPerHour: windSpeed, Triggered: wind.IsAbove(windSpeed)}, nil
+}
+
+func getHeatData(ws *pkg.WaterSchedule, storageClient *storage.Client) (*ThresholdData, error) {
+	heat := ws.WeatherControl.Heat
+	weatherClient, err := storageClient.GetWeatherClient(heat.ClientID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting WeatherClient for HeatControl: %w", err)
+	}
+
+	celsius, err := weatherClient.GetCurrentTemperature()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get temperature from weather client: %w", err)
+	}
+	return &ThresholdData{Celsius: &celsius, Triggered: heat.IsAbove(celsius)}, nil
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/calvinmclean/automated-garden/garden-app/pkg"
 	"github.com/calvinmclean/automated-garden/garden-app/pkg/influxdb"
 	"github.com/calvinmclean/automated-garden/garden-app/pkg/storage"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather"
 	"github.com/calvinmclean/automated-garden/garden-app/worker"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
@@ -90,14 +91,17 @@ func (zr ZonesResource) zoneAction(w http.ResponseWriter, r *http.Request) {
 	}
 	logger.Debugf("zone action: %+v", action)
 
-	if err := zr.worker.ExecuteZoneAction(garden, zone, action.ZoneAction); err != nil {
+	commandIDs, err := zr.worker.ExecuteZoneAction(garden, zone, action.ZoneAction)
+	if err != nil {
 		logger.WithError(err).Error("unable to execute ZoneAction")
 		render.Render(w, r, InternalServerError(err))
 		return
 	}
 
-	render.Status(r, http.StatusAccepted)
-	render.DefaultResponder(w, r, nil)
+	if err := render.Render(w, r, &ActionResponse{commandIDs}); err != nil {
+		logger.WithError(err).Error("unable to render ActionResponse")
+		render.Render(w, r, ErrRender(err))
+	}
 }
 
 // getZone simply returns the Zone requested by the provided ID
@@ -157,6 +161,8 @@ func (zr ZonesResource) updateZone(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	zr.worker.UpdateHomeAssistantDiscovery(garden)
+
 	if err := render.Render(w, r, zr.NewZoneResponse(r.Context(), garden, zone, excludeWeatherData(r))); err != nil {
 		logger.WithError(err).Error("unable to render ZoneResponse")
 		render.Render(w, r, ErrRender(err))
@@ -226,6 +232,8 @@ func (zr ZonesResource) endDateZone(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	zr.worker.UpdateHomeAssistantDiscovery(garden)
+
 	if err := render.Render(w, r, zr.NewZoneResponse(r.Context(), garden, zone, excludeWeatherData(r))); err != nil {
 		logger.WithError(err).Error("unable to render ZoneResponse")
 		render.Render(w, r, ErrRender(err))
@@ -315,6 +323,8 @@ func (zr ZonesResource) createZone(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	zr.worker.UpdateHomeAssistantDiscovery(garden)
+
 	render.Status(r, http.StatusCreated)
 	if err := render.Render(w, r, zr.NewZoneResponse(r.Context(), garden, zone, excludeWeatherData(r))); err != nil {
 		logger.WithError(err).Error("unable to render ZoneResponse")
@@ -372,16 +382,81 @@ func (zr ZonesResource) waterHistory(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (zr ZonesResource) getMoisture(ctx context.Context, g *pkg.Garden, z *pkg.Zone) (float64, error) {
+// waterPulses responds with the Zone's recorded closed-loop watering pulses. The "range" query parameter limits the
+// results to recent pulses
+func (zr ZonesResource) waterPulses(w http.ResponseWriter, r *http.Request) {
+	logger := getLoggerFromContext(r.Context())
+	logger.Info("received request to get Zone water pulses")
+
+	zone := getZoneFromContext(r.Context())
+
+	var since time.Time
+	if timeRangeString := r.URL.Query().Get("range"); timeRangeString != "" {
+		timeRange, err := time.ParseDuration(timeRangeString)
+		if err != nil {
+			logger.WithError(err).Error("unable to parse time range")
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
+		since = time.Now().Add(-timeRange)
+	}
+
+	pulses, err := zr.storageClient.GetWaterPulses(zone.ID)
+	if err != nil {
+		logger.WithError(err).Error("unable to get water pulses")
+		render.Render(w, r, InternalServerError(err))
+		return
+	}
+
+	result := []*pkg.WaterPulse{}
+	for _, pulse := range pulses {
+		if !pulse.Time.Before(since) {
+			result = append(result, pulse)
+		}
+	}
+
+	if err := render.Render(w, r, &ZoneWaterPulsesResponse{result}); err != nil {
+		logger.WithError(err).Error("unable to render Zone water pulses response")
+		render.Render(w, r, ErrRender(err))
+	}
+}
+
+func (zr ZonesResource) getMoisture(ctx context.Context, g *pkg.Garden, z *pkg.Zone, smc *weather.SoilMoistureControl) (float64, error) {
 	defer zr.influxdbClient.Close()
 
-	moisture, err := zr.influxdbClient.GetMoisture(ctx, *z.Position, g.TopicPrefix)
+	moisture, err := zr.influxdbClient.GetMoisture(ctx, *z.Position, g.TopicPrefix, smc.QueryWindow(), smc.Aggregation)
 	if err != nil {
 		return 0, err
 	}
 	return moisture, err
 }
 
+// addSensorWeatherData gets the Garden's average temperature and humidity over the WaterSchedule's interval and adds
+// it to the WeatherData for any sensor controls the WaterSchedule has
+func (zr ZonesResource) addSensorWeatherData(ctx context.Context, g *pkg.Garden, ws *pkg.WaterSchedule, weatherData *WeatherData) error {
+	defer zr.influxdbClient.Close()
+
+	temperature, humidity, err := zr.influxdbClient.GetTemperatureAndHumidity(ctx, g.TopicPrefix, ws.Interval.Window(time.Now()), influxdb.AggregationMean)
+	if err != nil {
+		return err
+	}
+
+	if ws.HasSensorTemperatureControl() {
+		celsius := float32(temperature)
+		weatherData.SensorTemperature = &TemperatureData{
+			Celsius:     &celsius,
+			ScaleFactor: ws.WeatherControl.SensorTemperature.Scale(float32(temperature)),
+		}
+	}
+	if ws.HasSensorHumidityControl() {
+		weatherData.SensorHumidity = &HumidityData{
+			Percentage:  float32(humidity),
+			ScaleFactor: ws.WeatherControl.SensorHumidity.InvertedScale(float32(humidity)),
+		}
+	}
+	return nil
+}
+
 // getWaterHistory gets previous WaterEvents for this Zone from InfluxDB
 func (zr ZonesResource) getWaterHistory(ctx context.Context, zone *pkg.Zone, garden *pkg.Garden, timeRange time.Duration, limit uint64) (result []pkg.WaterHistory, err error) {
 	defer zr.influxdbClient.Close()
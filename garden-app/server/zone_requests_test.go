@@ -201,3 +201,7 @@ func float32Pointer(n float64) *float32 {
 	f := float32(n)
 	return &f
 }
+
+func intPointer(n int) *int {
+	return &n
+}
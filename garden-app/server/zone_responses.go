@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/calvinmclean/automated-garden/garden-app/pkg"
+	"github.com/calvinmclean/automated-garden/garden-app/worker"
 )
 
 // AllZonesResponse is a simple struct being used to render and return a list of all Zones
@@ -32,9 +33,10 @@ func (zr *AllZonesResponse) Render(_ http.ResponseWriter, _ *http.Request) error
 // and hypermedia Links fields
 type ZoneResponse struct {
 	*pkg.Zone
-	WeatherData *WeatherData     `json:"weather_data,omitempty"`
-	NextWater   NextWaterDetails `json:"next_water,omitempty"`
-	Links       []Link           `json:"links,omitempty"`
+	WeatherData *WeatherData      `json:"weather_data,omitempty"`
+	NextWater   NextWaterDetails  `json:"next_water,omitempty"`
+	State       *worker.ZoneState `json:"state,omitempty"`
+	Links       []Link            `json:"links,omitempty"`
 }
 
 // NewZoneResponse creates a self-referencing ZoneResponse
@@ -78,6 +80,8 @@ func (zr ZonesResource) NewZoneResponse(ctx context.Context, garden *pkg.Garden,
 		},
 	)
 
+	response.State = zr.worker.ZoneState(garden, zone)
+
 	nextWaterSchedule := zr.worker.GetNextActiveWaterSchedule(ws)
 
 	if nextWaterSchedule == nil {
@@ -87,7 +91,7 @@ func (zr ZonesResource) NewZoneResponse(ctx context.Context, garden *pkg.Garden,
 		return response
 	}
 
-	response.NextWater = GetNextWaterDetails(nextWaterSchedule, zr.worker, excludeWeatherData)
+	response.NextWater = GetNextWaterDetails(garden, nextWaterSchedule, zr.worker, excludeWeatherData)
 	response.NextWater.WaterScheduleID = &nextWaterSchedule.ID
 
 	if zone.SkipCount != nil && *zone.SkipCount > 0 {
@@ -99,9 +103,9 @@ func (zr ZonesResource) NewZoneResponse(ctx context.Context, garden *pkg.Garden,
 	if nextWaterSchedule.HasWeatherControl() && !excludeWeatherData {
 		response.WeatherData = getWeatherData(ctx, nextWaterSchedule, zr.storageClient)
 
-		if nextWaterSchedule.HasSoilMoistureControl() && garden != nil {
+		if (nextWaterSchedule.HasSoilMoistureControl() || nextWaterSchedule.HasClosedLoopMoistureControl()) && garden != nil {
 			logger.Debug("getting moisture data for Zone")
-			soilMoisture, err := zr.getMoisture(ctx, garden, zone)
+			soilMoisture, err := zr.getMoisture(ctx, garden, zone, nextWaterSchedule.WeatherControl.SoilMoisture)
 			if err != nil {
 				logger.WithError(err).Warn("unable to get moisture data for Zone")
 			} else {
@@ -109,6 +113,16 @@ func (zr ZonesResource) NewZoneResponse(ctx context.Context, garden *pkg.Garden,
 				response.WeatherData.SoilMoisturePercent = &soilMoisture
 			}
 		}
+
+		if (nextWaterSchedule.HasSensorTemperatureControl() || nextWaterSchedule.HasSensorHumidityControl()) && garden != nil && garden.HasTemperatureHumiditySensor() {
+			logger.Debug("getting temperature and humidity data for Garden")
+			err := zr.addSensorWeatherData(ctx, garden, nextWaterSchedule, response.WeatherData)
+			if err != nil {
+				logger.WithError(err).Warn("unable to get temperature and humidity data for Garden")
+			}
+		}
+
+		response.WeatherData.convertUnits(getUnitSystem(ctx, garden))
 	}
 
 	return response
@@ -153,3 +167,14 @@ func NewZoneWaterHistoryResponse(history []pkg.WaterHistory) ZoneWaterHistoryRes
 func (resp ZoneWaterHistoryResponse) Render(_ http.ResponseWriter, _ *http.Request) error {
 	return nil
 }
+
+// ZoneWaterPulsesResponse is used to return the closed-loop watering pulses for a Zone
+type ZoneWaterPulsesResponse struct {
+	Pulses []*pkg.WaterPulse `json:"pulses"`
+}
+
+// Render is used to make this struct compatible with the go-chi webserver for writing
+// the JSON response
+func (resp *ZoneWaterPulsesResponse) Render(_ http.ResponseWriter, _ *http.Request) error {
+	return nil
+}
@@ -202,7 +202,7 @@ func TestGetZone(t *testing.T) {
 				},
 			}},
 			func(influxdbClient *influxdb.MockClient) {
-				influxdbClient.On("GetMoisture", mock.Anything, mock.Anything, mock.Anything).Return(float64(2), nil)
+				influxdbClient.On("GetMoisture", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(float64(2), nil)
 				influxdbClient.On("Close")
 			},
 			`{"name":"test-zone","id":"c5cvhpcbcv45e8bp16dg","position":0,"created_at":"2021-10-03T11:24:52.891386-07:00","water_schedule_ids":\["c5cvhpcbcv45e8bp16dg"\],"skip_count":null,"weather_data":{"soil_moisture_percent":2},"next_water":{"time":"\d\d\d\d-\d\d-\d\dT11:24:52.891386-07:00","duration":"1s","water_schedule_id":"c5cvhpcbcv45e8bp16dg"},"links":\[{"rel":"self","href":"/gardens/c5cvhpcbcv45e8bp16dg/zones/c5cvhpcbcv45e8bp16dg"},{"rel":"garden","href":"/gardens/c5cvhpcbcv45e8bp16dg"},{"rel":"action","href":"/gardens/c5cvhpcbcv45e8bp16dg/zones/c5cvhpcbcv45e8bp16dg/action"},{"rel":"history","href":"/gardens/c5cvhpcbcv45e8bp16dg/zones/c5cvhpcbcv45e8bp16dg/history"}\]}`,
@@ -234,7 +234,7 @@ func TestGetZone(t *testing.T) {
 				},
 			}},
 			func(influxdbClient *influxdb.MockClient) {
-				influxdbClient.On("GetMoisture", mock.Anything, mock.Anything, mock.Anything).Return(float64(2), nil)
+				influxdbClient.On("GetMoisture", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(float64(2), nil)
 				influxdbClient.On("Close")
 			},
 			`{"name":"test-zone","id":"c5cvhpcbcv45e8bp16dg","position":0,"created_at":"2021-10-03T11:24:52.891386-07:00","water_schedule_ids":\["c5cvhpcbcv45e8bp16dg"\],"skip_count":null,"weather_data":{"rain":{"mm":25.4,"scale_factor":0},"average_temperature":{"celsius":80,"scale_factor":1.5},"soil_moisture_percent":2},"next_water":{"time":"2023-\d\d-\d\dT11:24:52.891386-07:00","duration":"0s","water_schedule_id":"c5cvhpcbcv45e8bp16dg"},"links":\[{"rel":"self","href":"/gardens/c5cvhpcbcv45e8bp16dg/zones/c5cvhpcbcv45e8bp16dg"},{"rel":"garden","href":"/gardens/c5cvhpcbcv45e8bp16dg"},{"rel":"action","href":"/gardens/c5cvhpcbcv45e8bp16dg/zones/c5cvhpcbcv45e8bp16dg/action"},{"rel":"history","href":"/gardens/c5cvhpcbcv45e8bp16dg/zones/c5cvhpcbcv45e8bp16dg/history"}\]}`,
@@ -283,7 +283,7 @@ func TestGetZone(t *testing.T) {
 				},
 			}},
 			func(influxdbClient *influxdb.MockClient) {
-				influxdbClient.On("GetMoisture", mock.Anything, mock.Anything, mock.Anything).Return(float64(2), errors.New("influxdb error"))
+				influxdbClient.On("GetMoisture", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(float64(2), errors.New("influxdb error"))
 				influxdbClient.On("Close")
 			},
 			`{"name":"test-zone","id":"c5cvhpcbcv45e8bp16dg","position":0,"created_at":"2021-10-03T11:24:52.891386-07:00","water_schedule_ids":\["c5cvhpcbcv45e8bp16dg"\],"skip_count":null,"weather_data":{},"next_water":{"time":"\d\d\d\d-\d\d-\d\dT11:24:52.891386-07:00","duration":"1s","water_schedule_id":"c5cvhpcbcv45e8bp16dg"},"links":\[{"rel":"self","href":"/gardens/c5cvhpcbcv45e8bp16dg/zones/c5cvhpcbcv45e8bp16dg"},{"rel":"garden","href":"/gardens/c5cvhpcbcv45e8bp16dg"},{"rel":"action","href":"/gardens/c5cvhpcbcv45e8bp16dg/zones/c5cvhpcbcv45e8bp16dg/action"},{"rel":"history","href":"/gardens/c5cvhpcbcv45e8bp16dg/zones/c5cvhpcbcv45e8bp16dg/history"}\]}`,
@@ -368,17 +368,17 @@ func TestZoneAction(t *testing.T) {
 		{
 			"SuccessfulWaterAction",
 			func(mqttClient *mqtt.MockClient) {
-				mqttClient.On("WaterTopic", "test-garden").Return("garden/action/water", nil)
-				mqttClient.On("Publish", "garden/action/water", mock.Anything).Return(nil)
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "test-garden", GardenID: id, ZoneID: id}).Return("garden/action/water", nil)
+				mqttClient.On("PublishCommand", mqtt.WaterCommand, "test-garden", "garden/action/water", mock.Anything).Return("id", nil)
 			},
 			`{"water":{"duration":1000}}`,
-			"null",
+			`{"command_ids":["id"]}`,
 			http.StatusAccepted,
 		},
 		{
 			"ExecuteErrorForWaterAction",
 			func(mqttClient *mqtt.MockClient) {
-				mqttClient.On("WaterTopic", "test-garden").Return("", errors.New("template error"))
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "test-garden", GardenID: id, ZoneID: id}).Return("", errors.New("template error"))
 			},
 			`{"water":{"duration":1000}}`,
 			`{"status":"Server Error.","error":"unable to execute WaterAction: unable to fill MQTT topic template: template error"}`,
@@ -855,6 +855,70 @@ func TestWaterHistory(t *testing.T) {
 	}
 }
 
+func TestWaterPulses(t *testing.T) {
+	now := time.Now()
+	oldTime, _ := time.Parse(time.RFC3339, "2021-10-03T11:24:52Z")
+	tests := []struct {
+		name        string
+		queryParams string
+		expected    string
+		status      int
+	}{
+		{
+			"BadRequestInvalidTimeRange",
+			"?range=notTime",
+			`{"status":"Invalid request.","error":"time: invalid duration \"notTime\""}`,
+			http.StatusBadRequest,
+		},
+		{
+			"SuccessfulAllPulses",
+			"",
+			fmt.Sprintf(`{"pulses":[{"zone_id":"c5cvhpcbcv45e8bp16dg","water_schedule_id":"c5cvhpcbcv45e8bp16dg","duration":"5m0s","moisture":20,"time":"2021-10-03T11:24:52Z"},{"zone_id":"c5cvhpcbcv45e8bp16dg","water_schedule_id":"c5cvhpcbcv45e8bp16dg","duration":"5m0s","moisture":40,"time":"%s"}]}`, now.Format(time.RFC3339Nano)),
+			http.StatusOK,
+		},
+		{
+			"SuccessfulRecentPulses",
+			"?range=1h",
+			fmt.Sprintf(`{"pulses":[{"zone_id":"c5cvhpcbcv45e8bp16dg","water_schedule_id":"c5cvhpcbcv45e8bp16dg","duration":"5m0s","moisture":40,"time":"%s"}]}`, now.Format(time.RFC3339Nano)),
+			http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storageClient, err := storage.NewClient(storage.Config{
+				Driver: "hashmap",
+			})
+			assert.NoError(t, err)
+
+			// Save the newest pulse first to make sure they are sorted
+			for _, pulse := range []*pkg.WaterPulse{
+				{ZoneID: id, WaterScheduleID: id, Duration: &pkg.Duration{Duration: 5 * time.Minute}, Moisture: 40, Time: now},
+				{ZoneID: id, WaterScheduleID: id, Duration: &pkg.Duration{Duration: 5 * time.Minute}, Moisture: 20, Time: oldTime},
+			} {
+				assert.NoError(t, storageClient.SaveWaterPulse(pulse))
+			}
+
+			zr := ZonesResource{
+				storageClient: storageClient,
+			}
+			garden := createExampleGarden()
+			zone := createExampleZone()
+
+			gardenCtx := context.WithValue(context.Background(), gardenCtxKey, garden)
+			zoneCtx := context.WithValue(gardenCtx, zoneCtxKey, zone)
+			r := httptest.NewRequest("GET", fmt.Sprintf("/pulses%s", tt.queryParams), nil).WithContext(zoneCtx)
+			w := httptest.NewRecorder()
+			h := http.HandlerFunc(zr.waterPulses)
+
+			h.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.expected, strings.TrimSpace(w.Body.String()))
+		})
+	}
+}
+
 func TestGetNextWaterTime(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -0,0 +1,67 @@
+package worker
+
+import (
+	"fmt"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/mqtt"
+)
+
+// topicData returns the data used to fill MQTT topic templates for commands sent to the Garden. The Zone is only used
+// for water commands and is nil otherwise
+func topicData(g *pkg.Garden, z *pkg.Zone) mqtt.TopicData {
+	data := mqtt.TopicData{
+		Garden:   g.TopicPrefix,
+		GardenID: g.ID,
+	}
+	if z != nil {
+		data.ZoneID = z.ID
+		if z.Position != nil {
+			data.ZonePosition = *z.Position
+		}
+	}
+	return data
+}
+
+// GardenCommands returns the status of recent commands that were sent to the Garden. Commands are only tracked if
+// command acknowledgements are enabled
+func (w *Worker) GardenCommands(g *pkg.Garden) ([]mqtt.TrackedCommand, error) {
+	topics := map[string]bool{}
+	addTopic := func(topicFunc func(mqtt.TopicData) (string, error), data mqtt.TopicData) error {
+		topic, err := topicFunc(data)
+		if err != nil {
+			return fmt.Errorf("unable to fill MQTT topic template: %w", err)
+		}
+		topics[topic] = true
+		return nil
+	}
+
+	for _, topicFunc := range []func(mqtt.TopicData) (string, error){
+		w.mqttClient.StopTopic,
+		w.mqttClient.StopAllTopic,
+		w.mqttClient.LightTopic,
+	} {
+		if err := addTopic(topicFunc, topicData(g, nil)); err != nil {
+			return nil, err
+		}
+	}
+
+	// Water topics can be different for each Zone, including Zones that were recently end-dated
+	zones, err := w.storageClient.GetZones(g.ID, true)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get Zones: %w", err)
+	}
+	for _, z := range zones {
+		if err := addTopic(w.mqttClient.WaterTopic, topicData(g, z)); err != nil {
+			return nil, err
+		}
+	}
+
+	result := []mqtt.TrackedCommand{}
+	for _, cmd := range w.mqttClient.Commands() {
+		if topics[cmd.Topic] {
+			result = append(result, cmd)
+		}
+	}
+	return result, nil
+}
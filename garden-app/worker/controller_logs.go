@@ -0,0 +1,203 @@
+package worker
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/logs"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/mqtt"
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/rs/xid"
+)
+
+const (
+	logsDataTopic = "+/data/logs"
+	// tailBufferSize is how many log entries can be waiting for a slow tail before new entries are dropped
+	tailBufferSize = 100
+	// controllerLogsFlushInterval is how often new log entries are saved to storage
+	controllerLogsFlushInterval = 10 * time.Second
+)
+
+// controllerLogs keeps log entries from garden-controllers in memory by Garden ID and sends new entries to tails.
+// Entries are loaded from storage the first time they are used and new entries are saved in batches
+type controllerLogs struct {
+	config logs.Config
+	// mu protects entries, changed, which are the Garden IDs with entries that are not saved yet, and tails, which
+	// are channels receiving new entries
+	mu      sync.Mutex
+	entries map[xid.ID][]logs.Entry
+	changed map[xid.ID]bool
+	tails   map[xid.ID]map[chan logs.Entry]struct{}
+	stop    chan struct{}
+}
+
+// StartControllerLogs subscribes to log messages published by controllers so they can be stored for each Garden
+func (w *Worker) StartControllerLogs(config logs.Config) error {
+	w.controllerLogs = &controllerLogs{
+		config:  config,
+		entries: map[xid.ID][]logs.Entry{},
+		changed: map[xid.ID]bool{},
+		tails:   map[xid.ID]map[chan logs.Entry]struct{}{},
+		stop:    make(chan struct{}),
+	}
+
+	err := w.mqttClient.Subscribe(mqtt.TopicHandler{Topic: logsDataTopic, Handler: w.handleControllerLog})
+	if err != nil {
+		return fmt.Errorf("unable to subscribe to topic %q: %w", logsDataTopic, err)
+	}
+
+	go w.flushControllerLogsPeriodically(controllerLogsFlushInterval)
+	return nil
+}
+
+// ControllerLogsEnabled returns true if log messages from controllers are stored
+func (w *Worker) ControllerLogsEnabled() bool {
+	return w.controllerLogs != nil
+}
+
+// ControllerLogs returns the Garden's log entries that are within the retention and selected by the Filter, oldest first
+func (w *Worker) ControllerLogs(g *pkg.Garden, filter logs.Filter) ([]logs.Entry, error) {
+	w.controllerLogs.mu.Lock()
+	defer w.controllerLogs.mu.Unlock()
+
+	entries, err := w.loadControllerLogs(g.ID)
+	if err != nil {
+		return nil, err
+	}
+	return filter.Apply(w.controllerLogs.config.Trim(entries, time.Now())), nil
+}
+
+// TailControllerLogs returns a channel that receives the Garden's new log entries and a function that must be called
+// to stop receiving them. Entries are dropped if the channel is not read quickly enough
+func (w *Worker) TailControllerLogs(g *pkg.Garden) (<-chan logs.Entry, func()) {
+	w.controllerLogs.mu.Lock()
+	defer w.controllerLogs.mu.Unlock()
+
+	tail := make(chan logs.Entry, tailBufferSize)
+	if w.controllerLogs.tails[g.ID] == nil {
+		w.controllerLogs.tails[g.ID] = map[chan logs.Entry]struct{}{}
+	}
+	w.controllerLogs.tails[g.ID][tail] = struct{}{}
+
+	return tail, func() {
+		w.controllerLogs.mu.Lock()
+		defer w.controllerLogs.mu.Unlock()
+		delete(w.controllerLogs.tails[g.ID], tail)
+	}
+}
+
+func (w *Worker) handleControllerLog(_ paho.Client, msg paho.Message) {
+	logger := w.logger.WithField("topic", msg.Topic())
+
+	now := time.Now()
+	entry, err := logs.ParseEntry(msg.Payload(), now)
+	if err != nil {
+		logger.WithError(err).Warn("unable to parse controller log")
+		return
+	}
+
+	topicPrefix := topicPrefixFromDataTopic(msg.Topic())
+	g, err := w.gardenByTopicPrefix(topicPrefix)
+	if err != nil {
+		logger.WithError(err).Error("unable to get Garden for controller log")
+		return
+	}
+	if g == nil {
+		logger.Warn("dropping controller log for unknown Garden")
+		return
+	}
+
+	err = w.addControllerLog(g.ID, entry, now)
+	if err != nil {
+		logger.WithError(err).Error("unable to add controller log")
+	}
+}
+
+// gardenByTopicPrefix returns the active Garden using the topic prefix, or nil if there isn't one
+func (w *Worker) gardenByTopicPrefix(topicPrefix string) (*pkg.Garden, error) {
+	gardens, err := w.storageClient.GetGardens(false)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get Gardens: %w", err)
+	}
+	for _, g := range gardens {
+		if g.TopicPrefix == topicPrefix {
+			return g, nil
+		}
+	}
+	return nil, nil
+}
+
+// addControllerLog adds the entry to the Garden's logs in memory and sends it to tails. It is saved to storage by
+// the next flush
+func (w *Worker) addControllerLog(gardenID xid.ID, entry logs.Entry, now time.Time) error {
+	w.controllerLogs.mu.Lock()
+	defer w.controllerLogs.mu.Unlock()
+
+	entries, err := w.loadControllerLogs(gardenID)
+	if err != nil {
+		return err
+	}
+	w.controllerLogs.entries[gardenID] = w.controllerLogs.config.Append(entries, entry, now)
+	w.controllerLogs.changed[gardenID] = true
+
+	for tail := range w.controllerLogs.tails[gardenID] {
+		select {
+		case tail <- entry:
+		default:
+		}
+	}
+	return nil
+}
+
+// loadControllerLogs returns the Garden's entries from memory, or reads them from storage the first time. It must be
+// called while holding the lock
+func (w *Worker) loadControllerLogs(gardenID xid.ID) ([]logs.Entry, error) {
+	if entries, ok := w.controllerLogs.entries[gardenID]; ok {
+		return entries, nil
+	}
+
+	entries, err := w.storageClient.GetControllerLogs(gardenID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get logs: %w", err)
+	}
+	w.controllerLogs.entries[gardenID] = entries
+	return entries, nil
+}
+
+// flushControllerLogs saves the logs of each Garden that has new entries
+func (w *Worker) flushControllerLogs() {
+	w.controllerLogs.mu.Lock()
+	defer w.controllerLogs.mu.Unlock()
+
+	for gardenID := range w.controllerLogs.changed {
+		err := w.storageClient.SaveControllerLogs(gardenID, w.controllerLogs.entries[gardenID])
+		if err != nil {
+			w.logger.WithError(err).WithField("garden_id", gardenID.String()).Error("unable to save controller logs")
+			continue
+		}
+		delete(w.controllerLogs.changed, gardenID)
+	}
+}
+
+// flushControllerLogsPeriodically saves new log entries on an interval until the controller logs are stopped
+func (w *Worker) flushControllerLogsPeriodically(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.controllerLogs.stop:
+			return
+		case <-ticker.C:
+			w.flushControllerLogs()
+		}
+	}
+}
+
+// stopControllerLogs stops flushing periodically and saves any remaining new entries
+func (w *Worker) stopControllerLogs() {
+	close(w.controllerLogs.stop)
+	w.flushControllerLogs()
+}
@@ -0,0 +1,175 @@
+package worker
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/ingest"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/logs"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/mqtt"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/mqtt/broker"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/storage"
+	"github.com/rs/xid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func setupControllerLogsStorage(t *testing.T, gardens ...*pkg.Garden) *storage.Client {
+	t.Helper()
+
+	storageClient, err := storage.NewClient(storage.Config{
+		Driver: "hashmap",
+	})
+	require.NoError(t, err)
+
+	for _, g := range gardens {
+		require.NoError(t, storageClient.SaveGarden(g))
+	}
+	return storageClient
+}
+
+func TestControllerLogs(t *testing.T) {
+	garden := &pkg.Garden{ID: xid.New(), Name: "garden", TopicPrefix: "garden"}
+	otherGarden := &pkg.Garden{ID: xid.New(), Name: "other-garden", TopicPrefix: "other-garden"}
+	storageClient := setupControllerLogsStorage(t, garden, otherGarden)
+
+	mqttClient := mqtt.NewMockClient(t)
+	mqttClient.On("Subscribe", mock.MatchedBy(func(h mqtt.TopicHandler) bool { return h.Topic == "+/data/logs" })).Return(nil)
+
+	w := NewWorker(storageClient, nil, mqttClient, logrus.New())
+	assert.False(t, w.ControllerLogsEnabled())
+	assert.NoError(t, w.StartControllerLogs(logs.Config{MaxEntries: 2}))
+	assert.True(t, w.ControllerLogsEnabled())
+
+	tail, stop := w.TailControllerLogs(garden)
+
+	for _, payload := range []string{
+		`logs message="first"`,
+		`logs,level=error message="second"`,
+		`invalid`,
+		`logs message="third"`,
+	} {
+		w.handleControllerLog(nil, dataMessage{topic: "garden/data/logs", payload: []byte(payload)})
+	}
+	w.handleControllerLog(nil, dataMessage{topic: "other-garden/data/logs", payload: []byte(`logs message="other"`)})
+	w.handleControllerLog(nil, dataMessage{topic: "unknown-garden/data/logs", payload: []byte(`logs message="unknown"`)})
+
+	t.Run("GetLogs", func(t *testing.T) {
+		entries, err := w.ControllerLogs(garden, logs.Filter{Level: logrus.TraceLevel})
+		assert.NoError(t, err)
+		assert.Len(t, entries, 2)
+		assert.Equal(t, "second", entries[0].Message)
+		assert.Equal(t, "third", entries[1].Message)
+
+		entries, err = w.ControllerLogs(otherGarden, logs.Filter{Level: logrus.TraceLevel})
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1)
+	})
+
+	t.Run("FilterLevel", func(t *testing.T) {
+		entries, err := w.ControllerLogs(garden, logs.Filter{Level: logrus.ErrorLevel})
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1)
+		assert.Equal(t, "second", entries[0].Message)
+	})
+
+	t.Run("Tail", func(t *testing.T) {
+		stop()
+		w.handleControllerLog(nil, dataMessage{topic: "garden/data/logs", payload: []byte(`logs message="after stop"`)})
+
+		messages := []string{}
+		for len(tail) > 0 {
+			messages = append(messages, (<-tail).Message)
+		}
+		assert.Equal(t, []string{"first", "second", "third"}, messages)
+	})
+
+	t.Run("SavedWhenFlushed", func(t *testing.T) {
+		stored, err := storageClient.GetControllerLogs(garden.ID)
+		assert.NoError(t, err)
+		assert.Empty(t, stored)
+
+		w.stopControllerLogs()
+
+		stored, err = storageClient.GetControllerLogs(garden.ID)
+		assert.NoError(t, err)
+		assert.Len(t, stored, 2)
+		assert.Equal(t, "after stop", stored[1].Message)
+		assert.WithinDuration(t, time.Now(), stored[1].Time, time.Minute)
+
+		stored, err = storageClient.GetControllerLogs(otherGarden.ID)
+		assert.NoError(t, err)
+		assert.Len(t, stored, 1)
+	})
+}
+
+func TestControllerLogsRetentionOnRead(t *testing.T) {
+	garden := &pkg.Garden{ID: xid.New(), Name: "garden", TopicPrefix: "garden"}
+	storageClient := setupControllerLogsStorage(t, garden)
+
+	now := time.Now()
+	require.NoError(t, storageClient.SaveControllerLogs(garden.ID, []logs.Entry{
+		{Time: now.Add(-2 * time.Hour), Level: "info", Message: "expired"},
+		{Time: now.Add(-time.Minute), Level: "info", Message: "recent"},
+	}))
+
+	mqttClient := mqtt.NewMockClient(t)
+	mqttClient.On("Subscribe", mock.Anything).Return(nil)
+
+	w := NewWorker(storageClient, nil, mqttClient, logrus.New())
+	require.NoError(t, w.StartControllerLogs(logs.Config{Retention: time.Hour}))
+	defer w.stopControllerLogs()
+
+	entries, err := w.ControllerLogs(garden, logs.Filter{Level: logrus.TraceLevel})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "recent", entries[0].Message)
+}
+
+func TestControllerLogsWithIngest(t *testing.T) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	require.NoError(t, listener.Close())
+
+	b, err := broker.New(broker.Config{Enabled: true, Port: port}, logrus.New())
+	require.NoError(t, err)
+	require.NoError(t, b.Start())
+	defer b.Stop()
+
+	// Ingesting data subscribes to the same topic that is used for controller logs
+	written := make(chan struct{})
+	sink := ingest.NewMockSink(t)
+	sink.On("Write", mock.Anything, mock.MatchedBy(func(points []ingest.Point) bool {
+		return len(points) == 1 && points[0].Measurement == "logs"
+	})).Return(nil).Once().Run(func(mock.Arguments) { close(written) })
+	ingester := ingest.NewIngester(sink, logrus.New())
+	defer ingester.Stop()
+	ingestHandlers := ingester.TopicHandlers(ingest.Config{Topics: []string{logsDataTopic}})
+
+	mqttClient, err := mqtt.NewClient(mqtt.Config{ClientID: "test", Broker: "localhost", Port: port}, nil, ingestHandlers...)
+	require.NoError(t, err)
+	require.NoError(t, mqttClient.Connect())
+	defer mqttClient.Disconnect(100)
+
+	garden := &pkg.Garden{ID: xid.New(), Name: "garden", TopicPrefix: "garden"}
+	w := NewWorker(setupControllerLogsStorage(t, garden), nil, mqttClient, logrus.New())
+	require.NoError(t, w.StartControllerLogs(logs.Config{}))
+	defer w.stopControllerLogs()
+
+	require.NoError(t, mqttClient.Publish("garden/data/logs", []byte(`logs message="hello"`)))
+
+	assert.Eventually(t, func() bool {
+		entries, err := w.ControllerLogs(garden, logs.Filter{Level: logrus.TraceLevel})
+		return err == nil && len(entries) == 1
+	}, time.Second, 5*time.Millisecond)
+	select {
+	case <-written:
+	case <-time.After(time.Second):
+		t.Error("timed out waiting for ingested data")
+	}
+}
@@ -24,10 +24,11 @@ func TestGardenAction(t *testing.T) {
 	}
 
 	tests := []struct {
-		name      string
-		action    *action.GardenAction
-		setupMock func(*mqtt.MockClient, *influxdb.MockClient)
-		assert    func(error, *testing.T)
+		name               string
+		action             *action.GardenAction
+		setupMock          func(*mqtt.MockClient, *influxdb.MockClient)
+		assert             func(error, *testing.T)
+		expectedCommandIDs []string
 	}{
 		{
 			"SuccessfulGardenActionWithLightAction",
@@ -35,12 +36,13 @@ func TestGardenAction(t *testing.T) {
 				Light: &action.LightAction{},
 			},
 			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient) {
-				mqttClient.On("LightTopic", "garden").Return("garden/action/light", nil)
-				mqttClient.On("Publish", "garden/action/light", mock.Anything).Return(nil)
+				mqttClient.On("LightTopic", mqtt.TopicData{Garden: "garden"}).Return("garden/action/light", nil)
+				mqttClient.On("PublishCommand", mqtt.LightCommand, "garden", "garden/action/light", mock.Anything).Return("id", nil)
 			},
 			func(err error, t *testing.T) {
 				assert.NoError(t, err)
 			},
+			[]string{"id"},
 		},
 		{
 			"FailedGardenActionWithLightAction",
@@ -48,7 +50,7 @@ func TestGardenAction(t *testing.T) {
 				Light: &action.LightAction{},
 			},
 			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient) {
-				mqttClient.On("LightTopic", "garden").Return("", errors.New("template error"))
+				mqttClient.On("LightTopic", mqtt.TopicData{Garden: "garden"}).Return("", errors.New("template error"))
 			},
 			func(err error, t *testing.T) {
 				if err == nil {
@@ -58,6 +60,7 @@ func TestGardenAction(t *testing.T) {
 					t.Errorf("Unexpected error string: %v", err)
 				}
 			},
+			[]string{},
 		},
 		{
 			"SuccessfulGardenActionWithStopAction",
@@ -65,12 +68,13 @@ func TestGardenAction(t *testing.T) {
 				Stop: &action.StopAction{},
 			},
 			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient) {
-				mqttClient.On("StopTopic", "garden").Return("garden/action/stop", nil)
-				mqttClient.On("Publish", "garden/action/stop", mock.Anything).Return(nil)
+				mqttClient.On("StopTopic", mqtt.TopicData{Garden: "garden"}).Return("garden/action/stop", nil)
+				mqttClient.On("PublishCommand", mqtt.StopCommand, "garden", "garden/action/stop", mock.Anything).Return("id", nil)
 			},
 			func(err error, t *testing.T) {
 				assert.NoError(t, err)
 			},
+			[]string{"id"},
 		},
 		{
 			"FailedGardenActionWithStopAction",
@@ -78,7 +82,7 @@ func TestGardenAction(t *testing.T) {
 				Stop: &action.StopAction{},
 			},
 			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient) {
-				mqttClient.On("StopTopic", "garden").Return("", errors.New("template error"))
+				mqttClient.On("StopTopic", mqtt.TopicData{Garden: "garden"}).Return("", errors.New("template error"))
 			},
 			func(err error, t *testing.T) {
 				if err == nil {
@@ -88,6 +92,7 @@ func TestGardenAction(t *testing.T) {
 					t.Errorf("Unexpected error string: %v", err)
 				}
 			},
+			[]string{},
 		},
 	}
 
@@ -97,8 +102,9 @@ func TestGardenAction(t *testing.T) {
 			influxdbClient := new(influxdb.MockClient)
 			tt.setupMock(mqttClient, influxdbClient)
 
-			err := NewWorker(nil, influxdbClient, mqttClient, logrus.New()).ExecuteGardenAction(garden, tt.action)
+			commandIDs, err := NewWorker(nil, influxdbClient, mqttClient, logrus.New()).ExecuteGardenAction(garden, tt.action)
 			tt.assert(err, t)
+			assert.Equal(t, tt.expectedCommandIDs, commandIDs)
 			mqttClient.AssertExpectations(t)
 			influxdbClient.AssertExpectations(t)
 		})
@@ -128,8 +134,8 @@ func TestLightActionExecute(t *testing.T) {
 			"Successful",
 			&action.LightAction{},
 			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient) {
-				mqttClient.On("LightTopic", "garden").Return("garden/action/light", nil)
-				mqttClient.On("Publish", "garden/action/light", mock.Anything).Return(nil)
+				mqttClient.On("LightTopic", mqtt.TopicData{Garden: "garden", GardenID: garden.ID}).Return("garden/action/light", nil)
+				mqttClient.On("PublishCommand", mqtt.LightCommand, "garden", "garden/action/light", mock.Anything).Return("id", nil)
 			},
 			func(err error, t *testing.T) {
 				assert.NoError(t, err)
@@ -139,8 +145,8 @@ func TestLightActionExecute(t *testing.T) {
 			"SuccessfulWithDelay",
 			&action.LightAction{State: pkg.LightStateOff, ForDuration: &pkg.Duration{Duration: 30 * time.Second}},
 			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient) {
-				mqttClient.On("LightTopic", "garden").Return("garden/action/light", nil)
-				mqttClient.On("Publish", "garden/action/light", mock.Anything).Return(nil)
+				mqttClient.On("LightTopic", mqtt.TopicData{Garden: "garden", GardenID: garden.ID}).Return("garden/action/light", nil)
+				mqttClient.On("PublishCommand", mqtt.LightCommand, "garden", "garden/action/light", mock.Anything).Return("id", nil)
 			},
 			func(err error, t *testing.T) {
 				assert.NoError(t, err)
@@ -150,8 +156,8 @@ func TestLightActionExecute(t *testing.T) {
 			"PublishError",
 			&action.LightAction{State: pkg.LightStateOff, ForDuration: &pkg.Duration{Duration: 30 * time.Second}},
 			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient) {
-				mqttClient.On("LightTopic", "garden").Return("garden/action/light", nil)
-				mqttClient.On("Publish", "garden/action/light", mock.Anything).Return(errors.New("publish error"))
+				mqttClient.On("LightTopic", mqtt.TopicData{Garden: "garden", GardenID: garden.ID}).Return("garden/action/light", nil)
+				mqttClient.On("PublishCommand", mqtt.LightCommand, "garden", "garden/action/light", mock.Anything).Return("", errors.New("publish error"))
 			},
 			func(err error, t *testing.T) {
 				if err == nil {
@@ -166,7 +172,7 @@ func TestLightActionExecute(t *testing.T) {
 			"TopicTemplateError",
 			&action.LightAction{},
 			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient) {
-				mqttClient.On("LightTopic", "garden").Return("", errors.New("template error"))
+				mqttClient.On("LightTopic", mqtt.TopicData{Garden: "garden", GardenID: garden.ID}).Return("", errors.New("template error"))
 			},
 			func(err error, t *testing.T) {
 				if err == nil {
@@ -198,7 +204,7 @@ func TestLightActionExecute(t *testing.T) {
 			assert.NoError(t, err)
 			worker.StartAsync()
 
-			err = worker.ExecuteLightAction(garden, tt.action)
+			_, err = worker.ExecuteLightAction(garden, tt.action)
 			tt.assert(err, t)
 
 			worker.Stop()
@@ -224,8 +230,8 @@ func TestStopActionExecute(t *testing.T) {
 			"Successful",
 			&action.StopAction{},
 			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient) {
-				mqttClient.On("StopTopic", "garden").Return("garden/action/stop", nil)
-				mqttClient.On("Publish", "garden/action/stop", mock.Anything).Return(nil)
+				mqttClient.On("StopTopic", mqtt.TopicData{Garden: "garden"}).Return("garden/action/stop", nil)
+				mqttClient.On("PublishCommand", mqtt.StopCommand, "garden", "garden/action/stop", mock.Anything).Return("id", nil)
 			},
 			func(err error, t *testing.T) {
 				assert.NoError(t, err)
@@ -235,8 +241,8 @@ func TestStopActionExecute(t *testing.T) {
 			"SuccessfulStopAll",
 			&action.StopAction{All: true},
 			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient) {
-				mqttClient.On("StopAllTopic", "garden").Return("garden/action/stop_all", nil)
-				mqttClient.On("Publish", "garden/action/stop_all", mock.Anything).Return(nil)
+				mqttClient.On("StopAllTopic", mqtt.TopicData{Garden: "garden"}).Return("garden/action/stop_all", nil)
+				mqttClient.On("PublishCommand", mqtt.StopAllCommand, "garden", "garden/action/stop_all", mock.Anything).Return("id", nil)
 			},
 			func(err error, t *testing.T) {
 				assert.NoError(t, err)
@@ -246,7 +252,7 @@ func TestStopActionExecute(t *testing.T) {
 			"TopicTemplateError",
 			&action.StopAction{},
 			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient) {
-				mqttClient.On("StopTopic", "garden").Return("", errors.New("template error"))
+				mqttClient.On("StopTopic", mqtt.TopicData{Garden: "garden"}).Return("", errors.New("template error"))
 			},
 			func(err error, t *testing.T) {
 				if err == nil {
@@ -265,7 +271,7 @@ func TestStopActionExecute(t *testing.T) {
 			influxdbClient := new(influxdb.MockClient)
 			tt.setupMock(mqttClient, influxdbClient)
 
-			err := NewWorker(nil, influxdbClient, mqttClient, logrus.New()).ExecuteStopAction(garden, tt.action)
+			_, err := NewWorker(nil, influxdbClient, mqttClient, logrus.New()).ExecuteStopAction(garden, tt.action)
 			tt.assert(err, t)
 			mqttClient.AssertExpectations(t)
 			influxdbClient.AssertExpectations(t)
@@ -6,62 +6,84 @@ import (
 
 	"github.com/calvinmclean/automated-garden/garden-app/pkg"
 	"github.com/calvinmclean/automated-garden/garden-app/pkg/action"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/mqtt"
 )
 
-// ExecuteGardenAction will execute a GardenAction
-func (w *Worker) ExecuteGardenAction(g *pkg.Garden, input *action.GardenAction) error {
+// ExecuteGardenAction will execute a GardenAction and return the correlation IDs of the published commands
+func (w *Worker) ExecuteGardenAction(g *pkg.Garden, input *action.GardenAction) ([]string, error) {
+	commandIDs := []string{}
 	if input.Light != nil {
-		err := w.ExecuteLightAction(g, input.Light)
+		id, err := w.ExecuteLightAction(g, input.Light)
 		if err != nil {
-			return fmt.Errorf("unable to execute LightAction: %v", err)
+			return commandIDs, fmt.Errorf("unable to execute LightAction: %v", err)
 		}
+		commandIDs = append(commandIDs, id)
 	}
 	if input.Stop != nil {
-		err := w.ExecuteStopAction(g, input.Stop)
+		id, err := w.ExecuteStopAction(g, input.Stop)
 		if err != nil {
-			return fmt.Errorf("unable to execute StopAction: %v", err)
+			return commandIDs, fmt.Errorf("unable to execute StopAction: %v", err)
 		}
+		commandIDs = append(commandIDs, id)
 	}
-	return nil
+	return commandIDs, nil
 }
 
 // ExecuteStopAction sends the message over MQTT to the embedded garden controller
-func (w *Worker) ExecuteStopAction(g *pkg.Garden, input *action.StopAction) error {
-	topicFunc := w.mqttClient.StopTopic
+// and stops any closed-loop moisture watering that is running in the Garden. It returns the command's correlation ID
+func (w *Worker) ExecuteStopAction(g *pkg.Garden, input *action.StopAction) (string, error) {
+	if stopped := w.stopClosedLoops(g.ID); stopped > 0 {
+		w.contextLogger(g, nil, nil).Infof("stopped %d closed-loop moisture waterings", stopped)
+	}
+
+	commandType, topicFunc := mqtt.StopCommand, w.mqttClient.StopTopic
 	if input.All {
-		topicFunc = w.mqttClient.StopAllTopic
+		commandType, topicFunc = mqtt.StopAllCommand, w.mqttClient.StopAllTopic
 	}
-	topic, err := topicFunc(g.TopicPrefix)
+	topic, err := topicFunc(topicData(g, nil))
 	if err != nil {
-		return fmt.Errorf("unable to fill MQTT topic template: %v", err)
+		return "", fmt.Errorf("unable to fill MQTT topic template: %v", err)
 	}
 
-	return w.mqttClient.Publish(topic, []byte("no message"))
+	return w.mqttClient.PublishCommand(commandType, g.TopicPrefix, topic, nil)
 }
 
-// ExecuteLightAction sends an MQTT message to the garden controller to change the state of the light
-func (w *Worker) ExecuteLightAction(g *pkg.Garden, input *action.LightAction) error {
+// ExecuteLightAction sends an MQTT message to the garden controller to change the state of the light. If the
+// controller's light state is known, toggling is replaced by the opposite state. It returns the command's correlation ID
+func (w *Worker) ExecuteLightAction(g *pkg.Garden, input *action.LightAction) (string, error) {
+	// Copy the action so the caller's state is not changed, since scheduled actions are executed more than once
+	if input != nil && input.State == pkg.LightStateToggle {
+		if state, ok := w.knownLightState(g); ok {
+			toggled := *input
+			toggled.State = pkg.LightStateOn
+			if state == pkg.LightStateOn {
+				toggled.State = pkg.LightStateOff
+			}
+			input = &toggled
+		}
+	}
+
 	msg, err := json.Marshal(input)
 	if err != nil {
-		return fmt.Errorf("unable to marshal LightAction to JSON: %v", err)
+		return "", fmt.Errorf("unable to marshal LightAction to JSON: %v", err)
 	}
 
-	topic, err := w.mqttClient.LightTopic(g.TopicPrefix)
+	topic, err := w.mqttClient.LightTopic(topicData(g, nil))
 	if err != nil {
-		return fmt.Errorf("unable to fill MQTT topic template: %v", err)
+		return "", fmt.Errorf("unable to fill MQTT topic template: %v", err)
 	}
 
-	err = w.mqttClient.Publish(topic, msg)
+	id, err := w.mqttClient.PublishCommand(mqtt.LightCommand, g.TopicPrefix, topic, msg)
 	if err != nil {
-		return fmt.Errorf("unable to publish LightAction: %v", err)
+		return "", fmt.Errorf("unable to publish LightAction: %v", err)
 	}
 
 	// If this is a LightAction with specified duration, additional steps are necessary
 	if input != nil && input.ForDuration != nil {
 		err := w.ScheduleLightDelay(g, input)
 		if err != nil {
-			return fmt.Errorf("unable to handle light delay: %v", err)
+			return id, fmt.Errorf("unable to handle light delay: %v", err)
 		}
 	}
-	return nil
+	return id, nil
 }
@@ -0,0 +1,230 @@
+package worker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/action"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/homeassistant"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/mqtt"
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+// StartHomeAssistant subscribes to commands from Home Assistant and publishes discovery configs for all Gardens.
+// State tracking must be started first since it is used to update the water switches
+func (w *Worker) StartHomeAssistant(config homeassistant.Config) error {
+	if w.state == nil {
+		return errors.New("controller state tracking must be started before the Home Assistant integration")
+	}
+	w.homeAssistant = &config
+
+	for _, topic := range config.SubscriptionTopics() {
+		err := w.mqttClient.Subscribe(mqtt.TopicHandler{Topic: topic, Handler: w.handleHomeAssistantMessage})
+		if err != nil {
+			return fmt.Errorf("unable to subscribe to topic %q: %w", topic, err)
+		}
+	}
+
+	gardens, err := w.storageClient.GetGardens(true)
+	if err != nil {
+		return fmt.Errorf("unable to get Gardens: %w", err)
+	}
+	for _, g := range gardens {
+		err = w.publishHomeAssistantDiscovery(g)
+		if err != nil {
+			return fmt.Errorf("unable to publish discovery for Garden %q: %w", g.ID, err)
+		}
+	}
+	return nil
+}
+
+// UpdateHomeAssistantDiscovery republishes the discovery configs for a Garden and its Zones after they are created,
+// updated, or end-dated. It does nothing if the Home Assistant integration is not enabled. Errors are only logged
+// since this should not cause API requests to fail
+func (w *Worker) UpdateHomeAssistantDiscovery(g *pkg.Garden) {
+	if w.homeAssistant == nil {
+		return
+	}
+
+	// Get the latest Garden from storage since it might be outdated after creating a Zone
+	latest, err := w.storageClient.GetGarden(g.ID)
+	if err != nil {
+		w.contextLogger(g, nil, nil).WithError(err).Error("unable to get Garden for Home Assistant discovery")
+		return
+	}
+	if latest != nil {
+		g = latest
+	}
+
+	err = w.publishHomeAssistantDiscovery(g)
+	if err != nil {
+		w.contextLogger(g, nil, nil).WithError(err).Error("unable to publish Home Assistant discovery")
+	}
+}
+
+func (w *Worker) publishHomeAssistantDiscovery(g *pkg.Garden) error {
+	for _, entity := range w.homeAssistant.Entities(g) {
+		var payload []byte
+		if !entity.Remove {
+			var err error
+			payload, err = json.Marshal(entity.Config)
+			if err != nil {
+				return fmt.Errorf("unable to marshal discovery config: %w", err)
+			}
+		}
+
+		err := w.mqttClient.PublishRetained(w.homeAssistant.DiscoveryTopic(entity), payload)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handleHomeAssistantMessage executes commands from Home Assistant entities
+func (w *Worker) handleHomeAssistantMessage(_ paho.Client, msg paho.Message) {
+	logger := w.logger.WithField("topic", msg.Topic())
+
+	err := w.executeHomeAssistantCommand(msg.Topic(), strings.TrimSpace(string(msg.Payload())))
+	if err != nil {
+		logger.WithError(err).Error("unable to execute command from Home Assistant")
+		return
+	}
+	logger.Debug("executed command from Home Assistant")
+}
+
+func (w *Worker) executeHomeAssistantCommand(topic, payload string) error {
+	cmd, err := w.homeAssistant.ParseTopic(topic)
+	if err != nil {
+		return err
+	}
+
+	g, err := w.storageClient.GetGarden(cmd.GardenID)
+	if err != nil {
+		return fmt.Errorf("unable to get Garden: %w", err)
+	}
+	if g == nil || g.EndDated() {
+		return fmt.Errorf("unable to find Garden %q", cmd.GardenID)
+	}
+
+	if cmd.Type == homeassistant.LightCommand {
+		return w.executeHomeAssistantLightCommand(g, payload)
+	}
+
+	z, ok := g.Zones[cmd.ZoneID]
+	if !ok || z.EndDated() {
+		return fmt.Errorf("unable to find Zone %q", cmd.ZoneID)
+	}
+
+	switch cmd.Type {
+	case homeassistant.WaterCommand:
+		return w.executeHomeAssistantWaterCommand(g, z, payload)
+	case homeassistant.DurationCommand, homeassistant.DurationState:
+		seconds, err := strconv.ParseFloat(payload, 64)
+		if err != nil || seconds <= 0 {
+			return fmt.Errorf("invalid duration %q", payload)
+		}
+
+		w.homeAssistantMu.Lock()
+		w.waterDurations[z.ID] = time.Duration(seconds * float64(time.Second))
+		w.homeAssistantMu.Unlock()
+
+		// Only commands are published since the state topic already has the retained value
+		if cmd.Type == homeassistant.DurationCommand {
+			return w.mqttClient.PublishRetained(w.homeAssistant.DurationStateTopic(g.ID, z.ID), []byte(payload))
+		}
+	}
+	return nil
+}
+
+func (w *Worker) executeHomeAssistantLightCommand(g *pkg.Garden, payload string) error {
+	var state pkg.LightState
+	switch payload {
+	case homeassistant.PayloadOn:
+		state = pkg.LightStateOn
+	case homeassistant.PayloadOff:
+		state = pkg.LightStateOff
+	default:
+		return fmt.Errorf("invalid light payload %q", payload)
+	}
+
+	_, err := w.ExecuteLightAction(g, &action.LightAction{State: state})
+	if err != nil {
+		return fmt.Errorf("unable to execute LightAction: %w", err)
+	}
+
+	return w.mqttClient.PublishRetained(w.homeAssistant.LightStateTopic(g.ID), []byte(payload))
+}
+
+// executeHomeAssistantWaterCommand waters or stops the Zone. The switch's state is not published here since the
+// controller might queue the watering. Instead, it is updated by the water events that the controller publishes
+// when the Zone starts and finishes watering
+func (w *Worker) executeHomeAssistantWaterCommand(g *pkg.Garden, z *pkg.Zone, payload string) error {
+	switch payload {
+	case homeassistant.PayloadOn:
+		_, err := w.ExecuteZoneAction(g, z, &action.ZoneAction{
+			Water: &action.WaterAction{Duration: &pkg.Duration{Duration: w.homeAssistantWaterDuration(z)}},
+		})
+		if err != nil {
+			return fmt.Errorf("unable to execute ZoneAction: %w", err)
+		}
+	case homeassistant.PayloadOff:
+		// Stopping interrupts whichever Zone is watering, so only stop if it is this Zone
+		state := w.ZoneState(g, z)
+		if state == nil || !state.Watering {
+			w.contextLogger(g, z, nil).Debug("not stopping Zone from Home Assistant since it is not watering")
+			return nil
+		}
+		_, err := w.ExecuteStopAction(g, &action.StopAction{})
+		if err != nil {
+			return fmt.Errorf("unable to execute StopAction: %w", err)
+		}
+	default:
+		return fmt.Errorf("invalid water payload %q", payload)
+	}
+	return nil
+}
+
+// publishHomeAssistantWaterState updates the water switch of the Zone at the position when its controller reports
+// that watering started or finished. It does nothing if the Home Assistant integration is not enabled
+func (w *Worker) publishHomeAssistantWaterState(topicPrefix string, position uint, payload string) {
+	if w.homeAssistant == nil {
+		return
+	}
+	logger := w.logger.WithField("topic_prefix", topicPrefix)
+
+	g, err := w.gardenByTopicPrefix(topicPrefix)
+	if err != nil {
+		logger.WithError(err).Error("unable to get Garden for Home Assistant water state")
+		return
+	}
+	if g == nil {
+		return
+	}
+	z := zoneAtPosition(g, position)
+	if z == nil {
+		return
+	}
+
+	err = w.mqttClient.Publish(w.homeAssistant.WaterStateTopic(g.ID, z.ID), []byte(payload))
+	if err != nil {
+		w.contextLogger(g, z, nil).WithError(err).Error("unable to publish Zone state to Home Assistant")
+	}
+}
+
+// homeAssistantWaterDuration gets the duration set in Home Assistant for the Zone or the configured default
+func (w *Worker) homeAssistantWaterDuration(z *pkg.Zone) time.Duration {
+	w.homeAssistantMu.Lock()
+	defer w.homeAssistantMu.Unlock()
+
+	duration, ok := w.waterDurations[z.ID]
+	if !ok {
+		return w.homeAssistant.WaterDuration()
+	}
+	return duration
+}
@@ -0,0 +1,265 @@
+package worker
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/homeassistant"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/mqtt"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/storage"
+	"github.com/rs/xid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHomeAssistantCommands(t *testing.T) {
+	gardenID, _ := xid.FromString("c5cvhpcbcv45e8bp16dg")
+	zoneID, _ := xid.FromString("chkodpg3lcj13q82mq40")
+	position := uint(0)
+	garden := &pkg.Garden{
+		Name:        "garden",
+		TopicPrefix: "garden",
+		ID:          gardenID,
+		Zones: map[xid.ID]*pkg.Zone{
+			zoneID: {Name: "zone", ID: zoneID, Position: &position},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		topic         string
+		payload       string
+		setupMock     func(*mqtt.MockClient)
+		expectedError string
+	}{
+		{
+			"LightOn",
+			"garden-app/c5cvhpcbcv45e8bp16dg/light/set",
+			"ON",
+			func(mqttClient *mqtt.MockClient) {
+				mqttClient.On("LightTopic", mqtt.TopicData{Garden: "garden", GardenID: gardenID}).Return("garden/command/light", nil)
+				mqttClient.On("PublishCommand", mqtt.LightCommand, "garden", "garden/command/light", []byte(`{"state":"ON","for_duration":null}`)).Return("id", nil)
+				mqttClient.On("PublishRetained", "garden-app/c5cvhpcbcv45e8bp16dg/light", []byte("ON")).Return(nil)
+			},
+			"",
+		},
+		{
+			"InvalidLightPayload",
+			"garden-app/c5cvhpcbcv45e8bp16dg/light/set",
+			"TOGGLE",
+			func(mqttClient *mqtt.MockClient) {},
+			`invalid light payload "TOGGLE"`,
+		},
+		{
+			"WaterOnWithDefaultDuration",
+			"garden-app/c5cvhpcbcv45e8bp16dg/zone/chkodpg3lcj13q82mq40/water/set",
+			"ON",
+			func(mqttClient *mqtt.MockClient) {
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden", GardenID: gardenID, ZoneID: zoneID}).Return("garden/command/water", nil)
+				mqttClient.On("PublishCommand", mqtt.WaterCommand, "garden", "garden/command/water", []byte(`{"duration":60000,"id":"chkodpg3lcj13q82mq40","position":0}`)).Return("id", nil)
+			},
+			"",
+		},
+		{
+			"SetDuration",
+			"garden-app/c5cvhpcbcv45e8bp16dg/zone/chkodpg3lcj13q82mq40/duration/set",
+			"30",
+			func(mqttClient *mqtt.MockClient) {
+				mqttClient.On("PublishRetained", "garden-app/c5cvhpcbcv45e8bp16dg/zone/chkodpg3lcj13q82mq40/duration", []byte("30")).Return(nil)
+			},
+			"",
+		},
+		{
+			"InvalidDuration",
+			"garden-app/c5cvhpcbcv45e8bp16dg/zone/chkodpg3lcj13q82mq40/duration/set",
+			"-1",
+			func(mqttClient *mqtt.MockClient) {},
+			`invalid duration "-1"`,
+		},
+		{
+			"GardenNotFound",
+			"garden-app/chkodpg3lcj13q82mq40/light/set",
+			"ON",
+			func(mqttClient *mqtt.MockClient) {},
+			`unable to find Garden "chkodpg3lcj13q82mq40"`,
+		},
+		{
+			"ZoneNotFound",
+			"garden-app/c5cvhpcbcv45e8bp16dg/zone/c5cvhpcbcv45e8bp16dg/water/set",
+			"ON",
+			func(mqttClient *mqtt.MockClient) {},
+			`unable to find Zone "c5cvhpcbcv45e8bp16dg"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storageClient, err := storage.NewClient(storage.Config{
+				Driver: "hashmap",
+			})
+			assert.NoError(t, err)
+			assert.NoError(t, storageClient.SaveGarden(garden))
+
+			mqttClient := mqtt.NewMockClient(t)
+			tt.setupMock(mqttClient)
+
+			w := NewWorker(storageClient, nil, mqttClient, logrus.New())
+			w.homeAssistant = &homeassistant.Config{}
+			w.state = newStateTracker()
+
+			err = w.executeHomeAssistantCommand(tt.topic, tt.payload)
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestHomeAssistantWaterOff(t *testing.T) {
+	gardenID, _ := xid.FromString("c5cvhpcbcv45e8bp16dg")
+	zoneID, _ := xid.FromString("chkodpg3lcj13q82mq40")
+	position0, position1 := uint(0), uint(1)
+	garden := &pkg.Garden{
+		Name:        "garden",
+		TopicPrefix: "garden",
+		ID:          gardenID,
+		Zones: map[xid.ID]*pkg.Zone{
+			zoneID: {Name: "zone", ID: zoneID, Position: &position0},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		watering  *uint
+		setupMock func(*mqtt.MockClient)
+	}{
+		{
+			"ZoneIsWatering",
+			&position0,
+			func(mqttClient *mqtt.MockClient) {
+				mqttClient.On("StopTopic", mqtt.TopicData{Garden: "garden", GardenID: gardenID}).Return("garden/command/stop", nil)
+				mqttClient.On("PublishCommand", mqtt.StopCommand, "garden", "garden/command/stop", mock.Anything).Return("id", nil)
+			},
+		},
+		{
+			"OtherZoneIsWatering",
+			&position1,
+			func(mqttClient *mqtt.MockClient) {},
+		},
+		{
+			"NotWatering",
+			nil,
+			func(mqttClient *mqtt.MockClient) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storageClient, err := storage.NewClient(storage.Config{
+				Driver: "hashmap",
+			})
+			assert.NoError(t, err)
+			assert.NoError(t, storageClient.SaveGarden(garden))
+
+			mqttClient := mqtt.NewMockClient(t)
+			tt.setupMock(mqttClient)
+
+			w := NewWorker(storageClient, nil, mqttClient, logrus.New())
+			w.homeAssistant = &homeassistant.Config{}
+			w.state = newStateTracker()
+			if tt.watering != nil {
+				w.state.startWatering("garden", *tt.watering, time.Minute, time.Now())
+			}
+
+			err = w.executeHomeAssistantCommand("garden-app/c5cvhpcbcv45e8bp16dg/zone/chkodpg3lcj13q82mq40/water/set", "OFF")
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestHomeAssistantWaterStateFromWaterEvents(t *testing.T) {
+	zoneID, _ := xid.FromString("chkodpg3lcj13q82mq40")
+	position := uint(1)
+	garden := &pkg.Garden{
+		Name:        "garden",
+		TopicPrefix: "garden",
+		ID:          xid.New(),
+		Zones: map[xid.ID]*pkg.Zone{
+			zoneID: {Name: "zone", ID: zoneID, Position: &position},
+		},
+	}
+
+	storageClient, err := storage.NewClient(storage.Config{
+		Driver: "hashmap",
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, storageClient.SaveGarden(garden))
+
+	stateTopic := fmt.Sprintf("garden-app/%s/zone/chkodpg3lcj13q82mq40/water", garden.ID)
+	mqttClient := mqtt.NewMockClient(t)
+	mqttClient.On("Publish", stateTopic, []byte("ON")).Return(nil).Once()
+	mqttClient.On("Publish", stateTopic, []byte("OFF")).Return(nil).Once()
+
+	w := NewWorker(storageClient, nil, mqttClient, logrus.New())
+	w.homeAssistant = &homeassistant.Config{}
+	w.state = newStateTracker()
+
+	// Events for unknown Gardens and Zones are ignored
+	w.handleWaterData(nil, dataMessage{topic: "other-garden/data/water", payload: []byte("water_started,zone=1 millis=1000")})
+	w.handleWaterData(nil, dataMessage{topic: "garden/data/water", payload: []byte("water_started,zone=5 millis=1000")})
+
+	w.handleWaterData(nil, dataMessage{topic: "garden/data/water", payload: []byte("water_started,zone=1 millis=1000")})
+	w.handleWaterData(nil, dataMessage{topic: "garden/data/water", payload: []byte("water,zone=1 millis=1000")})
+}
+
+func TestStartHomeAssistantRequiresState(t *testing.T) {
+	w := NewWorker(nil, nil, mqtt.NewMockClient(t), logrus.New())
+	err := w.StartHomeAssistant(homeassistant.Config{})
+	assert.Error(t, err)
+	assert.Equal(t, "controller state tracking must be started before the Home Assistant integration", err.Error())
+}
+
+func TestHomeAssistantWaterDuration(t *testing.T) {
+	zone := &pkg.Zone{ID: xid.New()}
+	w := NewWorker(nil, nil, nil, logrus.New())
+	w.homeAssistant = &homeassistant.Config{DefaultWaterDuration: 5 * time.Minute}
+
+	assert.Equal(t, 5*time.Minute, w.homeAssistantWaterDuration(zone))
+
+	w.waterDurations[zone.ID] = 90 * time.Second
+	assert.Equal(t, 90*time.Second, w.homeAssistantWaterDuration(zone))
+}
+
+func TestUpdateHomeAssistantDiscovery(t *testing.T) {
+	storageClient, err := storage.NewClient(storage.Config{
+		Driver: "hashmap",
+	})
+	assert.NoError(t, err)
+	garden := &pkg.Garden{Name: "garden", TopicPrefix: "garden", ID: xid.New()}
+	assert.NoError(t, storageClient.SaveGarden(garden))
+
+	t.Run("DisabledDoesNothing", func(t *testing.T) {
+		w := NewWorker(storageClient, nil, mqtt.NewMockClient(t), logrus.New())
+		w.UpdateHomeAssistantDiscovery(garden)
+	})
+
+	t.Run("PublishesRetainedConfigs", func(t *testing.T) {
+		mqttClient := mqtt.NewMockClient(t)
+		mqttClient.On("PublishRetained", "homeassistant/light/"+garden.ID.String()+"_light/config", mock.MatchedBy(func(b []byte) bool {
+			return len(b) > 0
+		})).Return(nil)
+		// Sensors are removed since the Garden does not have a temperature and humidity sensor
+		mqttClient.On("PublishRetained", "homeassistant/sensor/"+garden.ID.String()+"_temperature/config", []byte(nil)).Return(nil)
+		mqttClient.On("PublishRetained", "homeassistant/sensor/"+garden.ID.String()+"_humidity/config", []byte(nil)).Return(nil)
+
+		w := NewWorker(storageClient, nil, mqttClient, logrus.New())
+		w.homeAssistant = &homeassistant.Config{}
+		w.UpdateHomeAssistantDiscovery(garden)
+	})
+}
@@ -0,0 +1,120 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/action"
+	"github.com/rs/xid"
+)
+
+// executeClosedLoopWatering waters the Zone in pulses until the WaterSchedule's TargetMoisture is reached or the total
+// watering time reaches the maximum. After each pulse, it waits for the pulse to finish and the SoakDuration before reading
+// the moisture again. The loop is stopped early if a StopAction is executed for the Garden
+func (w *Worker) executeClosedLoopWatering(g *pkg.Garden, z *pkg.Zone, ws *pkg.WaterSchedule, maxDuration time.Duration) error {
+	logger := w.contextLogger(g, z, ws)
+
+	control := ws.WeatherControl.SoilMoisture
+	if control.MaxDuration != nil {
+		maxDuration = control.MaxDuration.Duration
+	}
+	soakDuration := time.Duration(0)
+	if control.SoakDuration != nil {
+		soakDuration = control.SoakDuration.Duration
+	}
+
+	ctx, done := w.startClosedLoop(g, z)
+	defer done()
+
+	logger.Infof("starting closed-loop watering to reach %d%% soil moisture in at most %s", *control.TargetMoisture, maxDuration)
+
+	total := time.Duration(0)
+	for total < maxDuration {
+		moisture, err := w.getZoneMoisture(g, z, control)
+		if err != nil {
+			return fmt.Errorf("error getting Zone's moisture data: %w", err)
+		}
+		logger.Infof("soil moisture is %f%%", moisture)
+
+		if moisture >= float64(*control.TargetMoisture) {
+			logger.Infof("closed-loop watering reached target moisture after watering for %s", total)
+			return nil
+		}
+
+		pulse := control.PulseDuration.Duration
+		if remaining := maxDuration - total; pulse > remaining {
+			pulse = remaining
+		}
+
+		_, err = w.ExecuteWaterAction(g, z, &action.WaterAction{Duration: &pkg.Duration{Duration: pulse}})
+		if err != nil {
+			return fmt.Errorf("unable to execute WaterAction for pulse: %w", err)
+		}
+		total += pulse
+
+		err = w.storageClient.SaveWaterPulse(&pkg.WaterPulse{
+			ZoneID:          z.ID,
+			WaterScheduleID: ws.ID,
+			Duration:        &pkg.Duration{Duration: pulse},
+			Moisture:        moisture,
+			Time:            time.Now(),
+		})
+		if err != nil {
+			logger.WithError(err).Warn("unable to save WaterPulse")
+		}
+
+		// Wait for the pulse to finish and the water to soak in before reading moisture again. After the final pulse,
+		// moisture is not read again so there is no need to wait for it to soak in
+		wait := pulse + soakDuration
+		if total >= maxDuration {
+			wait = pulse
+		}
+		select {
+		case <-ctx.Done():
+			logger.Infof("closed-loop watering was stopped after watering for %s", total)
+			return nil
+		case <-time.After(wait):
+		}
+	}
+
+	logger.Infof("closed-loop watering reached maximum duration of %s", maxDuration)
+	return nil
+}
+
+// startClosedLoop registers a running closed-loop watering for the Zone and returns a Context that is cancelled when the
+// loop is stopped. Each loop has its own ID so multiple loops for the same Zone do not replace each other. The returned
+// function must be called when the loop is finished
+func (w *Worker) startClosedLoop(g *pkg.Garden, z *pkg.Zone) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	loopID := xid.New()
+
+	w.closedLoopsMu.Lock()
+	w.closedLoops[loopID] = closedLoop{gardenID: g.ID, cancel: cancel}
+	w.closedLoopsMu.Unlock()
+
+	return ctx, func() {
+		w.closedLoopsMu.Lock()
+		delete(w.closedLoops, loopID)
+		w.closedLoopsMu.Unlock()
+		cancel()
+	}
+}
+
+// stopClosedLoops cancels all running closed-loop waterings in the Garden and returns the number that were stopped
+func (w *Worker) stopClosedLoops(gardenID xid.ID) int {
+	w.closedLoopsMu.Lock()
+	defer w.closedLoopsMu.Unlock()
+
+	stopped := 0
+	for loopID, loop := range w.closedLoops {
+		if loop.gardenID != gardenID {
+			continue
+		}
+		loop.cancel()
+		delete(w.closedLoops, loopID)
+		stopped++
+	}
+	return stopped
+}
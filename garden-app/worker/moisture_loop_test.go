@@ -0,0 +1,264 @@
+package worker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/action"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/influxdb"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/mqtt"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/storage"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestExecuteScheduledWaterActionClosedLoop(t *testing.T) {
+	garden := &pkg.Garden{
+		ID:          id,
+		Name:        "garden",
+		TopicPrefix: "garden",
+	}
+	fifty := 50
+
+	closedLoopSchedule := func(pulseDuration, maxDuration *pkg.Duration) *pkg.WaterSchedule {
+		return &pkg.WaterSchedule{
+			ID:       id,
+			Duration: &pkg.Duration{Duration: 3 * time.Millisecond},
+			Interval: &pkg.Duration{Duration: time.Hour * 24},
+			WeatherControl: &weather.Control{
+				SoilMoisture: &weather.SoilMoistureControl{
+					TargetMoisture: &fifty,
+					PulseDuration:  pulseDuration,
+					MaxDuration:    maxDuration,
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name           string
+		waterSchedule  *pkg.WaterSchedule
+		setupMock      func(*mqtt.MockClient, *influxdb.MockClient)
+		expectedPulses int
+		expectedError  string
+	}{
+		{
+			"AlreadyAtTarget",
+			closedLoopSchedule(&pkg.Duration{Duration: time.Millisecond}, nil),
+			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient) {
+				influxdbClient.On("GetMoisture", mock.Anything, uint(0), "garden", time.Duration(0), influxdb.Aggregation("")).Return(float64(50), nil)
+				influxdbClient.On("Close")
+			},
+			0,
+			"",
+		},
+		{
+			"ReachTargetAfterTwoPulses",
+			closedLoopSchedule(&pkg.Duration{Duration: time.Millisecond}, nil),
+			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient) {
+				influxdbClient.On("GetMoisture", mock.Anything, uint(0), "garden", time.Duration(0), influxdb.Aggregation("")).Return(float64(20), nil).Once()
+				influxdbClient.On("GetMoisture", mock.Anything, uint(0), "garden", time.Duration(0), influxdb.Aggregation("")).Return(float64(40), nil).Once()
+				influxdbClient.On("GetMoisture", mock.Anything, uint(0), "garden", time.Duration(0), influxdb.Aggregation("")).Return(float64(55), nil).Once()
+				influxdbClient.On("Close")
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden", GardenID: id, ZoneID: id}).Return("garden/action/water", nil)
+				mqttClient.On("PublishCommand", mqtt.WaterCommand, "garden", "garden/action/water", []byte(`{"duration":1,"id":"c5cvhpcbcv45e8bp16dg","position":0}`)).Return("id", nil).Twice()
+			},
+			2,
+			"",
+		},
+		{
+			"StopAtScheduleDuration",
+			closedLoopSchedule(&pkg.Duration{Duration: time.Millisecond}, nil),
+			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient) {
+				influxdbClient.On("GetMoisture", mock.Anything, uint(0), "garden", time.Duration(0), influxdb.Aggregation("")).Return(float64(20), nil)
+				influxdbClient.On("Close")
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden", GardenID: id, ZoneID: id}).Return("garden/action/water", nil)
+				mqttClient.On("PublishCommand", mqtt.WaterCommand, "garden", "garden/action/water", []byte(`{"duration":1,"id":"c5cvhpcbcv45e8bp16dg","position":0}`)).Return("id", nil).Times(3)
+			},
+			3,
+			"",
+		},
+		{
+			"StopAtMaxDurationWithPartialPulse",
+			closedLoopSchedule(&pkg.Duration{Duration: 2 * time.Millisecond}, &pkg.Duration{Duration: 3 * time.Millisecond}),
+			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient) {
+				influxdbClient.On("GetMoisture", mock.Anything, uint(0), "garden", time.Duration(0), influxdb.Aggregation("")).Return(float64(20), nil)
+				influxdbClient.On("Close")
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden", GardenID: id, ZoneID: id}).Return("garden/action/water", nil)
+				mqttClient.On("PublishCommand", mqtt.WaterCommand, "garden", "garden/action/water", []byte(`{"duration":2,"id":"c5cvhpcbcv45e8bp16dg","position":0}`)).Return("id", nil).Once()
+				mqttClient.On("PublishCommand", mqtt.WaterCommand, "garden", "garden/action/water", []byte(`{"duration":1,"id":"c5cvhpcbcv45e8bp16dg","position":0}`)).Return("id", nil).Once()
+			},
+			2,
+			"",
+		},
+		{
+			"InfluxDBError",
+			closedLoopSchedule(&pkg.Duration{Duration: time.Millisecond}, nil),
+			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient) {
+				influxdbClient.On("GetMoisture", mock.Anything, uint(0), "garden", time.Duration(0), influxdb.Aggregation("")).Return(float64(0), errors.New("influxdb error"))
+				influxdbClient.On("Close")
+			},
+			0,
+			"error getting Zone's moisture data: influxdb error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, err := storage.NewClient(storage.Config{
+				Driver: "hashmap",
+			})
+			assert.NoError(t, err)
+			mqttClient := new(mqtt.MockClient)
+			influxdbClient := new(influxdb.MockClient)
+			tt.setupMock(mqttClient, influxdbClient)
+
+			zone := &pkg.Zone{ID: id, Position: uintPointer(0)}
+			err = NewWorker(sc, influxdbClient, mqttClient, logrus.New()).ExecuteScheduledWaterAction(garden, zone, tt.waterSchedule)
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			pulses, err := sc.GetWaterPulses(id)
+			assert.NoError(t, err)
+			assert.Len(t, pulses, tt.expectedPulses)
+
+			mqttClient.AssertExpectations(t)
+			influxdbClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestClosedLoopStopAction(t *testing.T) {
+	garden := &pkg.Garden{
+		ID:          id,
+		Name:        "garden",
+		TopicPrefix: "garden",
+	}
+	fifty := 50
+	ws := &pkg.WaterSchedule{
+		ID:       id,
+		Duration: &pkg.Duration{Duration: time.Hour},
+		Interval: &pkg.Duration{Duration: time.Hour * 24},
+		WeatherControl: &weather.Control{
+			SoilMoisture: &weather.SoilMoistureControl{
+				TargetMoisture: &fifty,
+				PulseDuration:  &pkg.Duration{Duration: time.Millisecond},
+				SoakDuration:   &pkg.Duration{Duration: time.Hour},
+			},
+		},
+	}
+
+	sc, err := storage.NewClient(storage.Config{
+		Driver: "hashmap",
+	})
+	assert.NoError(t, err)
+	mqttClient := new(mqtt.MockClient)
+	influxdbClient := new(influxdb.MockClient)
+
+	influxdbClient.On("GetMoisture", mock.Anything, uint(0), "garden", time.Duration(0), influxdb.Aggregation("")).Return(float64(20), nil)
+	influxdbClient.On("Close")
+	mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden", GardenID: id, ZoneID: id}).Return("garden/action/water", nil)
+	mqttClient.On("PublishCommand", mqtt.WaterCommand, "garden", "garden/action/water", mock.Anything).Return("id", nil).Once()
+	mqttClient.On("StopTopic", mqtt.TopicData{Garden: "garden", GardenID: id}).Return("garden/action/stop", nil)
+	mqttClient.On("PublishCommand", mqtt.StopCommand, "garden", "garden/action/stop", mock.Anything).Return("id", nil)
+
+	w := NewWorker(sc, influxdbClient, mqttClient, logrus.New())
+
+	done := make(chan error)
+	go func() {
+		done <- w.ExecuteScheduledWaterAction(garden, &pkg.Zone{ID: id, Position: uintPointer(0)}, ws)
+	}()
+
+	// Wait for the first pulse to be recorded before stopping
+	assert.Eventually(t, func() bool {
+		pulses, err := sc.GetWaterPulses(id)
+		return err == nil && len(pulses) == 1
+	}, time.Second, time.Millisecond)
+
+	_, err = w.ExecuteStopAction(garden, &action.StopAction{})
+	assert.NoError(t, err)
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("closed-loop watering was not stopped")
+	}
+
+	mqttClient.AssertExpectations(t)
+	influxdbClient.AssertExpectations(t)
+}
+
+func TestClosedLoopDoesNotSoakAfterFinalPulse(t *testing.T) {
+	garden := &pkg.Garden{
+		ID:          id,
+		Name:        "garden",
+		TopicPrefix: "garden",
+	}
+	fifty := 50
+	ws := &pkg.WaterSchedule{
+		ID:       id,
+		Duration: &pkg.Duration{Duration: time.Millisecond},
+		Interval: &pkg.Duration{Duration: time.Hour * 24},
+		WeatherControl: &weather.Control{
+			SoilMoisture: &weather.SoilMoistureControl{
+				TargetMoisture: &fifty,
+				PulseDuration:  &pkg.Duration{Duration: time.Millisecond},
+				SoakDuration:   &pkg.Duration{Duration: time.Hour},
+			},
+		},
+	}
+
+	sc, err := storage.NewClient(storage.Config{
+		Driver: "hashmap",
+	})
+	assert.NoError(t, err)
+	mqttClient := new(mqtt.MockClient)
+	influxdbClient := new(influxdb.MockClient)
+
+	influxdbClient.On("GetMoisture", mock.Anything, uint(0), "garden", time.Duration(0), influxdb.Aggregation("")).Return(float64(20), nil)
+	influxdbClient.On("Close")
+	mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden", GardenID: id, ZoneID: id}).Return("garden/action/water", nil)
+	mqttClient.On("PublishCommand", mqtt.WaterCommand, "garden", "garden/action/water", mock.Anything).Return("id", nil).Once()
+
+	done := make(chan error)
+	go func() {
+		done <- NewWorker(sc, influxdbClient, mqttClient, logrus.New()).ExecuteScheduledWaterAction(garden, &pkg.Zone{ID: id, Position: uintPointer(0)}, ws)
+	}()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("closed-loop watering waited to soak after the final pulse")
+	}
+
+	mqttClient.AssertExpectations(t)
+	influxdbClient.AssertExpectations(t)
+}
+
+func TestClosedLoopsForSameZone(t *testing.T) {
+	garden := &pkg.Garden{ID: id}
+	zone := &pkg.Zone{ID: id}
+	w := NewWorker(nil, nil, nil, logrus.New())
+
+	firstCtx, firstDone := w.startClosedLoop(garden, zone)
+	secondCtx, secondDone := w.startClosedLoop(garden, zone)
+	defer secondDone()
+
+	// Finishing the first loop does not remove the second loop
+	firstDone()
+	assert.Error(t, firstCtx.Err())
+	assert.NoError(t, secondCtx.Err())
+
+	assert.Equal(t, 1, w.stopClosedLoops(id))
+	assert.Error(t, secondCtx.Err())
+}
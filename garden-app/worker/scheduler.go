@@ -14,8 +14,10 @@ import (
 )
 
 const (
-	lightInterval = 24 * time.Hour
-	adhocTag      = "ADHOC"
+	lightInterval     = 24 * time.Hour
+	adhocTag          = "ADHOC"
+	heatCheckTag      = "HEAT_CHECK"
+	heatCheckInterval = time.Hour
 )
 
 // ScheduleWaterAction will schedule water actions for the Zone based off the CreatedAt date,
@@ -52,6 +54,30 @@ func (w *Worker) ScheduleWaterAction(ws *pkg.WaterSchedule) error {
 				}
 			}
 		}, logger.WithField("source", "scheduled_job"))
+	if err != nil {
+		return err
+	}
+
+	if ws.HasHeatControl() {
+		err = w.scheduleHeatCheck(ws)
+	}
+	return err
+}
+
+// scheduleHeatCheck schedules a Job that periodically checks the current temperature and adds an extra watering
+// for the WaterSchedule's HeatControl. It is tagged with the WaterSchedule's ID so it is removed with the regular Job
+func (w *Worker) scheduleHeatCheck(ws *pkg.WaterSchedule) error {
+	logger := w.contextLogger(nil, nil, ws)
+	logger.Infof("creating heat check Job for WaterSchedule")
+
+	scheduleJobsGauge.WithLabelValues(waterScheduleLabels(ws)...).Inc()
+	_, err := w.scheduler.
+		Every(heatCheckInterval).
+		StartAt(time.Now().Add(heatCheckInterval)).
+		Tag("water_schedule").
+		Tag(ws.ID.String()).
+		Tag(heatCheckTag).
+		Do(w.executeHeatCheck, ws, logger.WithField("source", "scheduled_job"))
 	return err
 }
 
@@ -116,6 +142,9 @@ func (w *Worker) GetNextWaterTime(ws *pkg.WaterSchedule) *time.Time {
 	logger.Debugf("getting next water time for water_schedule")
 
 	for _, job := range w.scheduler.Jobs() {
+		if isHeatCheckJob(job) {
+			continue
+		}
 		for _, tag := range job.Tags() {
 			if tag == ws.ID.String() {
 				result := job.NextRun()
@@ -126,6 +155,15 @@ func (w *Worker) GetNextWaterTime(ws *pkg.WaterSchedule) *time.Time {
 	return nil
 }
 
+func isHeatCheckJob(job *gocron.Job) bool {
+	for _, tag := range job.Tags() {
+		if tag == heatCheckTag {
+			return true
+		}
+	}
+	return false
+}
+
 // ScheduleLightActions will schedule LightActions to turn the light on and off based off the CreatedAt date,
 // LightSchedule time, and Interval. The scheduled Jobs are tagged with the Garden's ID so they can
 // easily be removed
@@ -385,7 +423,7 @@ func (w *Worker) scheduleAdhocLightAction(g *pkg.Garden) error {
 			"adhoc": "true",
 		})
 		actionLogger.Infof("executing adhoc LightAction with state %s", a.State)
-		err := w.ExecuteLightAction(g, a)
+		_, err := w.ExecuteLightAction(g, a)
 		if err != nil {
 			actionLogger.Errorf("error executing scheduled adhoc LightAction: %v", err)
 		}
@@ -446,7 +484,7 @@ func waterScheduleLabels(ws *pkg.WaterSchedule) []string {
 func (w *Worker) executeLightActionInScheduledJob(g *pkg.Garden, input *action.LightAction, actionLogger *logrus.Entry) {
 	actionLogger = actionLogger.WithField("state", input.State.String())
 	actionLogger.Infof("executing LightAction with state %s", input.State)
-	err := w.ExecuteLightAction(g, input)
+	_, err := w.ExecuteLightAction(g, input)
 	if err != nil {
 		actionLogger.Errorf("error executing scheduled LightAction: %v", err)
 		schedulerErrors.WithLabelValues(gardenLabels(g)...).Inc()
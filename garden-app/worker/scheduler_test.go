@@ -74,7 +74,7 @@ func TestScheduleWaterAction(t *testing.T) {
 	mqttClient := new(mqtt.MockClient)
 
 	mqttClient.On("WaterTopic", mock.Anything).Return("test-garden/action/water", nil)
-	mqttClient.On("Publish", "test-garden/action/water", mock.Anything).Return(nil)
+	mqttClient.On("PublishCommand", mqtt.WaterCommand, "test-garden", "test-garden/action/water", mock.Anything).Return("id", nil)
 	mqttClient.On("Disconnect", uint(100)).Return()
 	influxdbClient.On("Close").Return()
 
@@ -167,6 +167,48 @@ func TestGetNextWaterTime(t *testing.T) {
 	mqttClient.AssertExpectations(t)
 }
 
+func TestGetNextWaterTimeWithHeatControl(t *testing.T) {
+	storageClient, err := storage.NewClient(storage.Config{
+		Driver: "hashmap",
+	})
+	assert.NoError(t, err)
+	defer weather.ResetCache()
+
+	influxdbClient := new(influxdb.MockClient)
+	mqttClient := new(mqtt.MockClient)
+	mqttClient.On("Disconnect", uint(100)).Return()
+	influxdbClient.On("Close").Return()
+
+	worker := NewWorker(storageClient, influxdbClient, mqttClient, logrus.New())
+	worker.StartAsync()
+
+	ws := createExampleWaterSchedule()
+	ws.WeatherControl = &weather.Control{
+		Heat: &weather.HeatControl{Threshold: float32Pointer(40), ClientID: id},
+	}
+	// Set WaterSchedule.StartTime to a time that won't cause it to run
+	startTime := time.Now().Add(-1 * time.Hour)
+	ws.StartTime = &startTime
+	err = worker.ScheduleWaterAction(ws)
+	assert.NoError(t, err)
+
+	jobs, err := worker.scheduler.FindJobsByTag(ws.ID.String())
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 2)
+
+	// The heat check Job runs sooner, but is not used for the next water time
+	nextWaterTime := worker.GetNextWaterTime(ws)
+	assert.Equal(t, startTime.Add(24*time.Hour), *nextWaterTime)
+
+	err = worker.RemoveJobsByID(ws.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, worker.GetNextWaterTime(ws))
+
+	worker.Stop()
+	influxdbClient.AssertExpectations(t)
+	mqttClient.AssertExpectations(t)
+}
+
 func TestScheduleLightActions(t *testing.T) {
 	// TODO: this test was consistently failing when running in GitHub Workflow, but worked fine locally until this commit which
 	// changed line 199 of `scheduler.go` (ScheduleLightActions) to delete and re-create Job instead of updating. It's interesting
@@ -0,0 +1,292 @@
+package worker
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/action"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/homeassistant"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/ingest"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/mqtt"
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/rs/xid"
+)
+
+const (
+	lightDataTopic = "+/data/light"
+	waterDataTopic = "+/data/water"
+)
+
+// StateConfig enables tracking the live state of each Garden's controller from the data it publishes
+type StateConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+}
+
+// LightState is the latest state of a Garden's light reported by its controller
+type LightState struct {
+	State     pkg.LightState `json:"state"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// ZoneWatering is a Zone that is currently watering and when it is expected to finish
+type ZoneWatering struct {
+	ZoneID     *xid.ID      `json:"zone_id,omitempty"`
+	Position   uint         `json:"zone_position"`
+	StartedAt  time.Time    `json:"started_at"`
+	FinishesAt time.Time    `json:"finishes_at"`
+	Remaining  pkg.Duration `json:"remaining"`
+}
+
+// GardenState is the live state of a Garden. Light is nil until the controller publishes its light state and
+// Watering is nil when no Zone is watering
+type GardenState struct {
+	Light    *LightState   `json:"light,omitempty"`
+	Watering *ZoneWatering `json:"watering,omitempty"`
+}
+
+// ZoneState is the live watering state of a Zone
+type ZoneState struct {
+	Watering   bool          `json:"watering"`
+	FinishesAt *time.Time    `json:"finishes_at,omitempty"`
+	Remaining  *pkg.Duration `json:"remaining,omitempty"`
+}
+
+// currentWatering is the watering that the controller reported starting
+type currentWatering struct {
+	position  uint
+	duration  time.Duration
+	startedAt time.Time
+}
+
+func (c currentWatering) finishesAt() time.Time {
+	return c.startedAt.Add(c.duration)
+}
+
+// controllerState is the state of one controller, identified by its topic prefix
+type controllerState struct {
+	light    *LightState
+	watering *currentWatering
+}
+
+// stateTracker keeps the live state of controllers by topic prefix
+type stateTracker struct {
+	mu          sync.Mutex
+	controllers map[string]*controllerState
+	now         func() time.Time
+}
+
+func newStateTracker() *stateTracker {
+	return &stateTracker{
+		controllers: map[string]*controllerState{},
+		now:         time.Now,
+	}
+}
+
+// controller returns the state for a topic prefix and must be called while holding the lock. A watering is
+// removed once it is expected to be finished in case the finished water data was missed
+func (t *stateTracker) controller(topicPrefix string) *controllerState {
+	state, ok := t.controllers[topicPrefix]
+	if !ok {
+		state = &controllerState{}
+		t.controllers[topicPrefix] = state
+	}
+	if state.watering != nil && !state.watering.finishesAt().After(t.now()) {
+		state.watering = nil
+	}
+	return state
+}
+
+func (t *stateTracker) setLight(topicPrefix string, state pkg.LightState, updatedAt time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.controller(topicPrefix).light = &LightState{State: state, UpdatedAt: updatedAt}
+}
+
+// startWatering handles water data that the controller publishes when a Zone starts watering
+func (t *stateTracker) startWatering(topicPrefix string, position uint, duration time.Duration, startedAt time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.controller(topicPrefix).watering = &currentWatering{position: position, duration: duration, startedAt: startedAt}
+}
+
+// finishWatering handles water data that the controller publishes after a Zone is done watering
+func (t *stateTracker) finishWatering(topicPrefix string, position uint) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state := t.controller(topicPrefix)
+	if state.watering != nil && state.watering.position == position {
+		state.watering = nil
+	}
+}
+
+func (t *stateTracker) gardenState(g *pkg.Garden) *GardenState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state := t.controller(g.TopicPrefix)
+	result := &GardenState{Light: state.light}
+	if state.watering != nil {
+		result.Watering = &ZoneWatering{
+			Position:   state.watering.position,
+			StartedAt:  state.watering.startedAt,
+			FinishesAt: state.watering.finishesAt(),
+			Remaining:  pkg.Duration{Duration: state.watering.finishesAt().Sub(t.now()).Round(time.Second)},
+		}
+		if z := zoneAtPosition(g, state.watering.position); z != nil {
+			result.Watering.ZoneID = &z.ID
+		}
+	}
+	return result
+}
+
+func (t *stateTracker) zoneState(g *pkg.Garden, z *pkg.Zone) *ZoneState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	result := &ZoneState{}
+	watering := t.controller(g.TopicPrefix).watering
+	if watering == nil || z.Position == nil || watering.position != *z.Position {
+		return result
+	}
+	finishesAt := watering.finishesAt()
+	result.Watering = true
+	result.FinishesAt = &finishesAt
+	result.Remaining = &pkg.Duration{Duration: finishesAt.Sub(t.now()).Round(time.Second)}
+	return result
+}
+
+// zoneAtPosition returns the Garden's active Zone at the position, or nil if there isn't one
+func zoneAtPosition(g *pkg.Garden, position uint) *pkg.Zone {
+	for _, z := range g.Zones {
+		if z.Position != nil && *z.Position == position && !z.EndDated() {
+			return z
+		}
+	}
+	return nil
+}
+
+// StartStateTracking subscribes to light and water data published by controllers to track the live state of Gardens
+func (w *Worker) StartStateTracking() error {
+	w.state = newStateTracker()
+
+	for _, handler := range []mqtt.TopicHandler{
+		{Topic: lightDataTopic, Handler: w.handleLightData},
+		{Topic: waterDataTopic, Handler: w.handleWaterData},
+	} {
+		err := w.mqttClient.Subscribe(handler)
+		if err != nil {
+			return fmt.Errorf("unable to subscribe to topic %q: %w", handler.Topic, err)
+		}
+	}
+	return nil
+}
+
+// GardenState returns the live state of the Garden, or nil if state tracking is not enabled
+func (w *Worker) GardenState(g *pkg.Garden) *GardenState {
+	if w.state == nil {
+		return nil
+	}
+	return w.state.gardenState(g)
+}
+
+// ZoneState returns the live state of the Zone, or nil if state tracking is not enabled
+func (w *Worker) ZoneState(g *pkg.Garden, z *pkg.Zone) *ZoneState {
+	if w.state == nil || g == nil {
+		return nil
+	}
+	return w.state.zoneState(g, z)
+}
+
+// knownLightState returns the latest light state reported by the Garden's controller. It returns false if state
+// tracking is not enabled or the controller has not reported its light state yet
+func (w *Worker) knownLightState(g *pkg.Garden) (pkg.LightState, bool) {
+	state := w.GardenState(g)
+	if state == nil || state.Light == nil {
+		return pkg.LightStateToggle, false
+	}
+	return state.Light.State, true
+}
+
+func (w *Worker) handleLightData(_ paho.Client, msg paho.Message) {
+	logger := w.logger.WithField("topic", msg.Topic())
+
+	point, err := parseDataPoint(msg)
+	if err != nil {
+		logger.WithError(err).Warn("unable to parse light data")
+		return
+	}
+	state, err := numberField(point, "state")
+	if err != nil {
+		logger.WithError(err).Warn("unable to parse light data")
+		return
+	}
+
+	w.state.setLight(topicPrefixFromDataTopic(msg.Topic()), pkg.LightState(state), point.Time)
+}
+
+// handleWaterData tracks the watering Zone using the events that the controller publishes when watering starts
+// and finishes
+func (w *Worker) handleWaterData(_ paho.Client, msg paho.Message) {
+	logger := w.logger.WithField("topic", msg.Topic())
+
+	point, err := parseDataPoint(msg)
+	if err != nil {
+		logger.WithError(err).Warn("unable to parse water data")
+		return
+	}
+	position, err := strconv.ParseUint(point.Tags["zone"], 10, 64)
+	if err != nil {
+		logger.WithError(err).Warn("unable to parse zone position from water data")
+		return
+	}
+	topicPrefix := topicPrefixFromDataTopic(msg.Topic())
+
+	switch point.Measurement {
+	case action.WaterStartedMeasurement:
+		millis, err := numberField(point, "millis")
+		if err != nil {
+			logger.WithError(err).Warn("unable to parse water data")
+			return
+		}
+		w.state.startWatering(topicPrefix, uint(position), time.Duration(millis)*time.Millisecond, point.Time)
+		w.publishHomeAssistantWaterState(topicPrefix, uint(position), homeassistant.PayloadOn)
+	case action.WaterMeasurement:
+		w.state.finishWatering(topicPrefix, uint(position))
+		w.publishHomeAssistantWaterState(topicPrefix, uint(position), homeassistant.PayloadOff)
+	}
+}
+
+// parseDataPoint parses the first line protocol Point in the message
+func parseDataPoint(msg paho.Message) (ingest.Point, error) {
+	points, err := ingest.ParseLineProtocol(msg.Topic(), msg.Payload(), time.Now())
+	if err != nil {
+		return ingest.Point{}, err
+	}
+	return points[0], nil
+}
+
+// numberField gets a field's value as an int since line protocol numbers are floats unless they have the "i" suffix
+func numberField(point ingest.Point, key string) (int, error) {
+	switch v := point.Fields[key].(type) {
+	case float64:
+		return int(v), nil
+	case int64:
+		return int(v), nil
+	case uint64:
+		return int(v), nil
+	default:
+		return 0, fmt.Errorf("missing or invalid field %q", key)
+	}
+}
+
+// topicPrefixFromDataTopic gets the Garden's topic prefix from a "{prefix}/data/{type}" topic
+func topicPrefixFromDataTopic(topic string) string {
+	return topic[:strings.LastIndex(topic, "/data/")]
+}
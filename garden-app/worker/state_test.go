@@ -0,0 +1,288 @@
+package worker
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/calvinmclean/automated-garden/garden-app/pkg"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/action"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/ingest"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/mqtt"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/mqtt/broker"
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/rs/xid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// dataMessage implements paho.Message for testing data handlers
+type dataMessage struct {
+	paho.Message
+	topic   string
+	payload []byte
+}
+
+func (m dataMessage) Topic() string   { return m.topic }
+func (m dataMessage) Payload() []byte { return m.payload }
+
+func TestStateTrackerWatering(t *testing.T) {
+	position0, position1 := uint(0), uint(1)
+	zone0 := &pkg.Zone{ID: xid.New(), Position: &position0}
+	zone1 := &pkg.Zone{ID: xid.New(), Position: &position1}
+	garden := &pkg.Garden{TopicPrefix: "garden", Zones: map[xid.ID]*pkg.Zone{zone0.ID: zone0, zone1.ID: zone1}}
+	start := time.Date(2023, time.August, 23, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name              string
+		setup             func(*stateTracker, *time.Time)
+		expectedZone      *pkg.Zone
+		expectedFinish    time.Time
+		expectedRemaining time.Duration
+	}{
+		{
+			"NotWatering",
+			func(*stateTracker, *time.Time) {},
+			nil,
+			time.Time{},
+			0,
+		},
+		{
+			"Watering",
+			func(tracker *stateTracker, now *time.Time) {
+				tracker.startWatering("garden", 0, 5*time.Minute, *now)
+				*now = now.Add(time.Minute)
+			},
+			zone0,
+			start.Add(5 * time.Minute),
+			4 * time.Minute,
+		},
+		{
+			"NextZoneStarted",
+			func(tracker *stateTracker, now *time.Time) {
+				tracker.startWatering("garden", 0, 5*time.Minute, *now)
+				*now = now.Add(5 * time.Minute)
+				tracker.finishWatering("garden", 0)
+				tracker.startWatering("garden", 1, 5*time.Minute, *now)
+			},
+			zone1,
+			start.Add(10 * time.Minute),
+			5 * time.Minute,
+		},
+		{
+			"Finished",
+			func(tracker *stateTracker, now *time.Time) {
+				tracker.startWatering("garden", 0, 5*time.Minute, *now)
+				*now = now.Add(2 * time.Minute)
+				tracker.finishWatering("garden", 0)
+			},
+			nil,
+			time.Time{},
+			0,
+		},
+		{
+			"FinishedOtherZoneIgnored",
+			func(tracker *stateTracker, now *time.Time) {
+				tracker.startWatering("garden", 0, 5*time.Minute, *now)
+				tracker.finishWatering("garden", 1)
+			},
+			zone0,
+			start.Add(5 * time.Minute),
+			5 * time.Minute,
+		},
+		{
+			"OtherGardenIgnored",
+			func(tracker *stateTracker, now *time.Time) {
+				tracker.startWatering("other-garden", 0, 5*time.Minute, *now)
+			},
+			nil,
+			time.Time{},
+			0,
+		},
+		{
+			"Expired",
+			func(tracker *stateTracker, now *time.Time) {
+				tracker.startWatering("garden", 0, 5*time.Minute, *now)
+				*now = now.Add(10 * time.Minute)
+			},
+			nil,
+			time.Time{},
+			0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := start
+			tracker := newStateTracker()
+			tracker.now = func() time.Time { return now }
+
+			tt.setup(tracker, &now)
+
+			state := tracker.gardenState(garden)
+			if tt.expectedZone == nil {
+				assert.Nil(t, state.Watering)
+				assert.False(t, tracker.zoneState(garden, zone0).Watering)
+				return
+			}
+			assert.Equal(t, tt.expectedZone.ID, *state.Watering.ZoneID)
+			assert.Equal(t, *tt.expectedZone.Position, state.Watering.Position)
+			assert.Equal(t, tt.expectedFinish, state.Watering.FinishesAt)
+			assert.Equal(t, tt.expectedRemaining, state.Watering.Remaining.Duration)
+
+			zoneState := tracker.zoneState(garden, tt.expectedZone)
+			assert.True(t, zoneState.Watering)
+			assert.Equal(t, tt.expectedFinish, *zoneState.FinishesAt)
+		})
+	}
+}
+
+func TestHandleLightData(t *testing.T) {
+	garden := &pkg.Garden{TopicPrefix: "garden"}
+
+	tests := []struct {
+		name     string
+		payload  string
+		expected *LightState
+	}{
+		{
+			"On",
+			`light,garden="garden" state=1 1692784800000000000`,
+			&LightState{State: pkg.LightStateOn, UpdatedAt: time.Unix(1692784800, 0)},
+		},
+		{
+			"Off",
+			`light,garden="garden" state=0 1692784800000000000`,
+			&LightState{State: pkg.LightStateOff, UpdatedAt: time.Unix(1692784800, 0)},
+		},
+		{
+			"InvalidLineProtocol",
+			`not line protocol`,
+			nil,
+		},
+		{
+			"MissingState",
+			`light,garden="garden" value=1`,
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWorker(nil, nil, nil, logrus.New())
+			w.state = newStateTracker()
+
+			w.handleLightData(nil, dataMessage{topic: "garden/data/light", payload: []byte(tt.payload)})
+
+			state := w.GardenState(garden)
+			if tt.expected == nil {
+				assert.Nil(t, state.Light)
+				return
+			}
+			assert.Equal(t, tt.expected.State, state.Light.State)
+			assert.True(t, tt.expected.UpdatedAt.Equal(state.Light.UpdatedAt))
+		})
+	}
+}
+
+func TestHandleWaterData(t *testing.T) {
+	position := uint(2)
+	zone := &pkg.Zone{ID: xid.New(), Position: &position}
+	garden := &pkg.Garden{TopicPrefix: "garden", Zones: map[xid.ID]*pkg.Zone{zone.ID: zone}}
+
+	w := NewWorker(nil, nil, nil, logrus.New())
+	w.state = newStateTracker()
+
+	w.handleWaterData(nil, dataMessage{topic: "garden/data/water", payload: []byte("water_started,zone=2 millis=3600000")})
+	zoneState := w.ZoneState(garden, zone)
+	assert.True(t, zoneState.Watering)
+	assert.Equal(t, time.Hour, zoneState.Remaining.Duration)
+	assert.Equal(t, zone.ID, *w.GardenState(garden).Watering.ZoneID)
+
+	w.handleWaterData(nil, dataMessage{topic: "garden/data/water", payload: []byte("water,zone=1 millis=1000")})
+	assert.True(t, w.ZoneState(garden, zone).Watering)
+
+	w.handleWaterData(nil, dataMessage{topic: "garden/data/water", payload: []byte("water,zone=2 millis=1000")})
+	assert.False(t, w.ZoneState(garden, zone).Watering)
+	assert.Nil(t, w.GardenState(garden).Watering)
+}
+
+func TestStateTrackingDisabled(t *testing.T) {
+	w := NewWorker(nil, nil, nil, logrus.New())
+	garden := &pkg.Garden{TopicPrefix: "garden"}
+
+	assert.Nil(t, w.GardenState(garden))
+	assert.Nil(t, w.ZoneState(garden, &pkg.Zone{}))
+}
+
+func TestStartStateTracking(t *testing.T) {
+	mqttClient := mqtt.NewMockClient(t)
+	mqttClient.On("Subscribe", mock.MatchedBy(func(h mqtt.TopicHandler) bool { return h.Topic == "+/data/light" })).Return(nil)
+	mqttClient.On("Subscribe", mock.MatchedBy(func(h mqtt.TopicHandler) bool { return h.Topic == "+/data/water" })).Return(nil)
+
+	w := NewWorker(nil, nil, mqttClient, logrus.New())
+	assert.NoError(t, w.StartStateTracking())
+	assert.NotNil(t, w.GardenState(&pkg.Garden{TopicPrefix: "garden"}))
+}
+
+func TestStateTrackingWithIngest(t *testing.T) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	require.NoError(t, listener.Close())
+
+	b, err := broker.New(broker.Config{Enabled: true, Port: port}, logrus.New())
+	require.NoError(t, err)
+	require.NoError(t, b.Start())
+	defer b.Stop()
+
+	// Ingesting data subscribes to the same topics that are used for state tracking
+	written := make(chan struct{})
+	sink := ingest.NewMockSink(t)
+	sink.On("Write", mock.Anything, mock.MatchedBy(func(points []ingest.Point) bool {
+		return len(points) == 1 && points[0].Measurement == "light"
+	})).Return(nil).Once().Run(func(mock.Arguments) { close(written) })
+	ingester := ingest.NewIngester(sink, logrus.New())
+	defer ingester.Stop()
+	ingestHandlers := ingester.TopicHandlers(ingest.Config{Topics: []string{lightDataTopic}})
+
+	mqttClient, err := mqtt.NewClient(mqtt.Config{ClientID: "test", Broker: "localhost", Port: port}, nil, ingestHandlers...)
+	require.NoError(t, err)
+	require.NoError(t, mqttClient.Connect())
+	defer mqttClient.Disconnect(100)
+
+	w := NewWorker(nil, nil, mqttClient, logrus.New())
+	require.NoError(t, w.StartStateTracking())
+
+	require.NoError(t, mqttClient.Publish("garden/data/light", []byte(`light,garden="garden" state=1`)))
+
+	garden := &pkg.Garden{TopicPrefix: "garden"}
+	assert.Eventually(t, func() bool {
+		state := w.GardenState(garden)
+		return state.Light != nil && state.Light.State == pkg.LightStateOn
+	}, time.Second, 5*time.Millisecond)
+	select {
+	case <-written:
+	case <-time.After(time.Second):
+		t.Error("timed out waiting for ingested data")
+	}
+}
+
+func TestExecuteLightActionUsesKnownState(t *testing.T) {
+	garden := &pkg.Garden{TopicPrefix: "garden"}
+
+	mqttClient := mqtt.NewMockClient(t)
+	mqttClient.On("LightTopic", mqtt.TopicData{Garden: "garden"}).Return("garden/command/light", nil)
+	mqttClient.On("PublishCommand", mqtt.LightCommand, "garden", "garden/command/light", []byte(`{"state":"OFF","for_duration":null}`)).Return("id", nil)
+
+	w := NewWorker(nil, nil, mqttClient, logrus.New())
+	w.state = newStateTracker()
+	w.state.setLight("garden", pkg.LightStateOn, time.Now())
+
+	input := &action.LightAction{State: pkg.LightStateToggle}
+	_, err := w.ExecuteLightAction(garden, input)
+	assert.NoError(t, err)
+	assert.Equal(t, pkg.LightStateToggle, input.State)
+}
@@ -2,13 +2,15 @@ package worker
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/calvinmclean/automated-garden/garden-app/pkg"
 	"github.com/calvinmclean/automated-garden/garden-app/pkg/action"
 	"github.com/calvinmclean/automated-garden/garden-app/pkg/influxdb"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/weather"
+	"github.com/rs/xid"
+	"github.com/sirupsen/logrus"
 )
 
 // ExecuteScheduledWaterAction will get all of the Zones that use the schedule and execute WaterActions on them after
@@ -34,21 +36,12 @@ func (w *Worker) ExecuteScheduledWaterAction(g *pkg.Garden, z *pkg.Zone, ws *pkg
 		return nil
 	}
 
-	msg, err := json.Marshal(action.WaterMessage{
-		Duration: duration.Milliseconds(),
-		ZoneID:   z.ID,
-		Position: *z.Position,
-	})
-	if err != nil {
-		return fmt.Errorf("unable to marshal WaterMessage to JSON: %w", err)
-	}
-
-	topic, err := w.mqttClient.WaterTopic(g.TopicPrefix)
-	if err != nil {
-		return fmt.Errorf("unable to fill MQTT topic template: %w", err)
+	if ws.HasClosedLoopMoistureControl() {
+		return w.executeClosedLoopWatering(g, z, ws, duration)
 	}
 
-	return w.mqttClient.Publish(topic, msg)
+	_, err = w.publishWaterCommand(g, z, duration)
+	return err
 }
 
 func (w *Worker) exerciseWeatherControl(g *pkg.Garden, z *pkg.Zone, ws *pkg.WaterSchedule) (time.Duration, error) {
@@ -56,6 +49,10 @@ func (w *Worker) exerciseWeatherControl(g *pkg.Garden, z *pkg.Zone, ws *pkg.Wate
 		return ws.Duration.Duration, nil
 	}
 
+	if w.shouldThresholdSkip(ws) || w.shouldRainForecastSkip(ws) {
+		return 0, nil
+	}
+
 	skipMoisture, err := w.shouldMoistureSkip(g, z, ws)
 	if err != nil {
 		return 0, err
@@ -64,20 +61,160 @@ func (w *Worker) exerciseWeatherControl(g *pkg.Garden, z *pkg.Zone, ws *pkg.Wate
 		return 0, nil
 	}
 
-	duration, _ := w.ScaleWateringDuration(ws)
+	duration, _ := w.ScaleWateringDuration(g, ws)
 	return duration, nil
 }
 
+// shouldThresholdSkip checks the WaterSchedule's freeze and wind rules. Errors getting weather data are logged and do
+// not cause watering to be skipped
+func (w *Worker) shouldThresholdSkip(ws *pkg.WaterSchedule) bool {
+	logger := w.contextLogger(nil, nil, ws)
+
+	if ws.HasFreezeControl() {
+		freeze := ws.WeatherControl.Freeze
+		temperature, err := w.getLowestTemperature(freeze.ClientID, freeze.ForecastWindow())
+		if err != nil {
+			logger.WithError(err).Warn("error getting temperature for FreezeControl")
+		} else if freeze.IsBelow(temperature) {
+			logger.Infof("lowest current or forecast temperature in the next %s %fC is below freeze threshold %fC", freeze.ForecastWindow(), temperature, freeze.MetricThreshold())
+			return true
+		}
+	}
+
+	if ws.HasWindControl() {
+		windSpeed, err := w.getCurrentWindSpeed(ws.WeatherControl.Wind.ClientID)
+		if err != nil {
+			logger.WithError(err).Warn("error getting current wind speed for WindControl")
+		} else if ws.WeatherControl.Wind.IsAbove(windSpeed) {
+			logger.Infof("current wind speed %fkm/h is above wind threshold %fkm/h", windSpeed, ws.WeatherControl.Wind.MetricThreshold())
+			return true
+		}
+	}
+
+	return false
+}
+
+// executeHeatCheck is run periodically for WaterSchedules with HeatControl. If the current temperature is above the
+// threshold, all Zones using the WaterSchedule get an extra watering. This happens at most once per WaterSchedule interval
+func (w *Worker) executeHeatCheck(ws *pkg.WaterSchedule, logger *logrus.Entry) {
+	now := time.Now()
+	if !ws.IsActive(now) {
+		return
+	}
+
+	heatControl := ws.WeatherControl.Heat
+	temperature, err := w.getCurrentTemperature(heatControl.ClientID)
+	if err != nil {
+		logger.WithError(err).Error("error getting current temperature for HeatControl")
+		schedulerErrors.WithLabelValues(waterScheduleLabels(ws)...).Inc()
+		return
+	}
+	if !heatControl.IsAbove(temperature) {
+		return
+	}
+
+	w.heatRunsMu.Lock()
+	lastRun, ok := w.heatRuns[ws.ID]
+	// Window is used since the Duration of a cron interval is zero
+	if ok && now.Sub(lastRun) < ws.Interval.Window(now) {
+		w.heatRunsMu.Unlock()
+		logger.Debugf("skipping extra watering for HeatControl since one already happened at %v", lastRun)
+		return
+	}
+	w.heatRuns[ws.ID] = now
+	w.heatRunsMu.Unlock()
+
+	duration := ws.Duration
+	if heatControl.Duration != nil {
+		duration = heatControl.Duration
+	}
+	logger.Infof("current temperature %fC is above heat threshold %fC, adding extra watering for %s", temperature, heatControl.MetricThreshold(), duration)
+
+	zonesAndGardens, err := w.storageClient.GetZonesUsingWaterSchedule(ws.ID)
+	if err != nil {
+		logger.WithError(err).Error("error getting Zones for WaterSchedule when executing heat check")
+		schedulerErrors.WithLabelValues(waterScheduleLabels(ws)...).Inc()
+		return
+	}
+
+	for _, zg := range zonesAndGardens {
+		_, err = w.ExecuteWaterAction(zg.Garden, zg.Zone, &action.WaterAction{Duration: duration})
+		if err != nil {
+			logger.WithField("zone_id", zg.Zone.ID.String()).WithError(err).Error("error executing extra watering for HeatControl")
+			schedulerErrors.WithLabelValues(zoneLabels(zg.Zone)...).Inc()
+		}
+	}
+}
+
+// shouldRainForecastSkip checks if enough rain is forecast to skip watering. Errors getting the forecast are logged
+// and do not cause watering to be skipped
+func (w *Worker) shouldRainForecastSkip(ws *pkg.WaterSchedule) bool {
+	if !ws.HasRainForecastControl() {
+		return false
+	}
+	logger := w.contextLogger(nil, nil, ws)
+
+	forecastRain, err := w.getRainForecast(ws.WeatherControl.RainForecast)
+	if err != nil {
+		logger.WithError(err).Warn("error getting rain forecast for RainForecastControl")
+		return false
+	}
+	if ws.WeatherControl.RainForecast.ShouldSkip(forecastRain) {
+		logger.Infof("forecast rain of %fmm in the next %s is above skip threshold", forecastRain, ws.WeatherControl.RainForecast.Window.String())
+		return true
+	}
+	return false
+}
+
+// getRainForecast returns the forecast rain for the RainForecastControl's Window. If the probability of rain is
+// too low, zero is returned
+func (w *Worker) getRainForecast(rfc *weather.RainForecastControl) (float32, error) {
+	weatherClient, err := w.storageClient.GetWeatherClient(rfc.ClientID)
+	if err != nil {
+		return 0, fmt.Errorf("error getting WeatherClient: %w", err)
+	}
+
+	probability, err := weatherClient.GetForecastRainProbability(rfc.Window.Duration)
+	if err != nil {
+		return 0, err
+	}
+	if !rfc.IsLikely(probability) {
+		return 0, nil
+	}
+
+	return weatherClient.GetForecastRain(rfc.Window.Duration)
+}
+
+func (w *Worker) getCurrentTemperature(clientID xid.ID) (float32, error) {
+	weatherClient, err := w.storageClient.GetWeatherClient(clientID)
+	if err != nil {
+		return 0, fmt.Errorf("error getting WeatherClient: %w", err)
+	}
+	return weatherClient.GetCurrentTemperature()
+}
+
+func (w *Worker) getLowestTemperature(clientID xid.ID, within time.Duration) (float32, error) {
+	weatherClient, err := w.storageClient.GetWeatherClient(clientID)
+	if err != nil {
+		return 0, fmt.Errorf("error getting WeatherClient: %w", err)
+	}
+	return weather.LowestTemperature(weatherClient, within)
+}
+
+func (w *Worker) getCurrentWindSpeed(clientID xid.ID) (float32, error) {
+	weatherClient, err := w.storageClient.GetWeatherClient(clientID)
+	if err != nil {
+		return 0, fmt.Errorf("error getting WeatherClient: %w", err)
+	}
+	return weatherClient.GetCurrentWindSpeed()
+}
+
 func (w *Worker) shouldMoistureSkip(g *pkg.Garden, z *pkg.Zone, ws *pkg.WaterSchedule) (bool, error) {
 	if !ws.HasSoilMoistureControl() {
 		return false, nil
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), influxdb.QueryTimeout)
-	defer cancel()
-
-	defer w.influxdbClient.Close()
-	moisture, err := w.influxdbClient.GetMoisture(ctx, *z.Position, g.TopicPrefix)
+	moisture, err := w.getZoneMoisture(g, z, ws.WeatherControl.SoilMoisture)
 	if err != nil {
 		return false, fmt.Errorf("error getting Zone's moisture data: %w", err)
 	}
@@ -87,9 +224,19 @@ func (w *Worker) shouldMoistureSkip(g *pkg.Garden, z *pkg.Zone, ws *pkg.WaterSch
 	return moisture > float64(*ws.WeatherControl.SoilMoisture.MinimumMoisture), nil
 }
 
+// getZoneMoisture queries InfluxDB for the Zone's current soil moisture using the SoilMoistureControl's window and aggregation
+func (w *Worker) getZoneMoisture(g *pkg.Garden, z *pkg.Zone, smc *weather.SoilMoistureControl) (float64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), influxdb.QueryTimeout)
+	defer cancel()
+
+	defer w.influxdbClient.Close()
+	return w.influxdbClient.GetMoisture(ctx, *z.Position, g.TopicPrefix, smc.QueryWindow(), smc.Aggregation)
+}
+
 // ScaleWateringDuration returns a new watering duration based on weather scaling. It will not return
-// any errors if they are encountered because there are multiple factors impacting watering
-func (w *Worker) ScaleWateringDuration(ws *pkg.WaterSchedule) (time.Duration, bool) {
+// any errors if they are encountered because there are multiple factors impacting watering. The Garden is used
+// for sensor-based controls, which are skipped if it is nil
+func (w *Worker) ScaleWateringDuration(g *pkg.Garden, ws *pkg.WaterSchedule) (time.Duration, bool) {
 	scaleFactor := float32(1)
 	hadError := false
 
@@ -128,7 +275,58 @@ func (w *Worker) ScaleWateringDuration(ws *pkg.WaterSchedule) (time.Duration, bo
 		}
 	}
 
+	if ws.HasRainForecastControl() {
+		forecastRain, err := w.getRainForecast(ws.WeatherControl.RainForecast)
+		if err != nil {
+			hadError = true
+			w.logger.WithError(err).Warn("error getting rain forecast")
+		} else {
+			forecastScaleFactor := ws.WeatherControl.RainForecast.Scale(forecastRain)
+			w.logger.Infof("weather client forecast %fmm of rain in the next %s, resulting in scale factor of %f", forecastRain, ws.WeatherControl.RainForecast.Window.String(), forecastScaleFactor)
+			scaleFactor *= forecastScaleFactor
+		}
+	}
+
+	// Sensor controls are ignored if the Garden is unknown or does not have a temperature and humidity sensor
+	if g != nil && g.HasTemperatureHumiditySensor() && (ws.HasSensorTemperatureControl() || ws.HasSensorHumidityControl()) {
+		sensorScaleFactor, err := w.scaleFromGardenSensor(g, ws)
+		if err != nil {
+			hadError = true
+			w.logger.WithError(err).Warn("error getting Garden's temperature and humidity data")
+		} else {
+			scaleFactor *= sensorScaleFactor
+		}
+	}
+
 	w.logger.Infof("compounded scale factor: %f", scaleFactor)
 
 	return time.Duration(float32(ws.Duration.Duration) * scaleFactor), hadError
 }
+
+// scaleFromGardenSensor returns the scale factor from the WaterSchedule's sensor controls using the Garden's average
+// temperature and humidity over the WaterSchedule's interval
+func (w *Worker) scaleFromGardenSensor(g *pkg.Garden, ws *pkg.WaterSchedule) (float32, error) {
+	window := ws.Interval.Window(time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), influxdb.QueryTimeout)
+	defer cancel()
+
+	defer w.influxdbClient.Close()
+	temperature, humidity, err := w.influxdbClient.GetTemperatureAndHumidity(ctx, g.TopicPrefix, window, influxdb.AggregationMean)
+	if err != nil {
+		return 0, err
+	}
+
+	scaleFactor := float32(1)
+	if ws.HasSensorTemperatureControl() {
+		temperatureScaleFactor := ws.WeatherControl.SensorTemperature.Scale(float32(temperature))
+		w.logger.Infof("garden sensor recorded %fC as the average temperature over the last %s, resulting in scale factor of %f", temperature, window.String(), temperatureScaleFactor)
+		scaleFactor *= temperatureScaleFactor
+	}
+	if ws.HasSensorHumidityControl() {
+		humidityScaleFactor := ws.WeatherControl.SensorHumidity.InvertedScale(float32(humidity))
+		w.logger.Infof("garden sensor recorded %f%% as the average humidity over the last %s, resulting in scale factor of %f", humidity, window.String(), humidityScaleFactor)
+		scaleFactor *= humidityScaleFactor
+	}
+	return scaleFactor, nil
+}
@@ -54,8 +54,8 @@ func TestExecuteScheduledWaterAction(t *testing.T) {
 				Position: uintPointer(0),
 			},
 			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient, sc *storage.Client) {
-				mqttClient.On("WaterTopic", "garden").Return("garden/action/water", nil)
-				mqttClient.On("Publish", "garden/action/water", mock.Anything).Return(nil)
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden", GardenID: id}).Return("garden/action/water", nil)
+				mqttClient.On("PublishCommand", mqtt.WaterCommand, "garden", "garden/action/water", mock.Anything).Return("id", nil)
 			},
 			"",
 		},
@@ -68,7 +68,7 @@ func TestExecuteScheduledWaterAction(t *testing.T) {
 				Position: uintPointer(0),
 			},
 			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient, sc *storage.Client) {
-				mqttClient.On("WaterTopic", "garden").Return("", errors.New("template error"))
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden", GardenID: id}).Return("", errors.New("template error"))
 			},
 			"unable to fill MQTT topic template: template error",
 		},
@@ -87,9 +87,9 @@ func TestExecuteScheduledWaterAction(t *testing.T) {
 				Position: uintPointer(0),
 			},
 			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient, sc *storage.Client) {
-				mqttClient.On("WaterTopic", "garden").Return("garden/action/water", nil)
-				mqttClient.On("Publish", "garden/action/water", mock.Anything).Return(nil)
-				influxdbClient.On("GetMoisture", mock.Anything, uint(0), garden.Name).Return(float64(0), nil)
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden", GardenID: id}).Return("garden/action/water", nil)
+				mqttClient.On("PublishCommand", mqtt.WaterCommand, "garden", "garden/action/water", mock.Anything).Return("id", nil)
+				influxdbClient.On("GetMoisture", mock.Anything, uint(0), garden.Name, time.Duration(0), influxdb.Aggregation("")).Return(float64(0), nil)
 				influxdbClient.On("Close")
 			},
 			"",
@@ -109,7 +109,7 @@ func TestExecuteScheduledWaterAction(t *testing.T) {
 				Position: uintPointer(0),
 			},
 			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient, sc *storage.Client) {
-				influxdbClient.On("GetMoisture", mock.Anything, uint(0), garden.Name).Return(float64(51), nil)
+				influxdbClient.On("GetMoisture", mock.Anything, uint(0), garden.Name, time.Duration(0), influxdb.Aggregation("")).Return(float64(51), nil)
 				influxdbClient.On("Close")
 				// No MQTT calls made
 			},
@@ -130,9 +130,9 @@ func TestExecuteScheduledWaterAction(t *testing.T) {
 				Position: uintPointer(0),
 			},
 			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient, sc *storage.Client) {
-				mqttClient.On("WaterTopic", "garden").Return("garden/action/water", nil)
-				mqttClient.On("Publish", "garden/action/water", mock.Anything).Return(nil)
-				influxdbClient.On("GetMoisture", mock.Anything, uint(0), garden.Name).Return(float64(0), errors.New("influxdb error"))
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden", GardenID: id}).Return("garden/action/water", nil)
+				mqttClient.On("PublishCommand", mqtt.WaterCommand, "garden", "garden/action/water", mock.Anything).Return("id", nil)
+				influxdbClient.On("GetMoisture", mock.Anything, uint(0), garden.Name, time.Duration(0), influxdb.Aggregation("")).Return(float64(0), errors.New("influxdb error"))
 				influxdbClient.On("Close")
 			},
 			"",
@@ -185,8 +185,8 @@ func TestExecuteScheduledWaterAction(t *testing.T) {
 					},
 				})
 				assert.NoError(t, err)
-				mqttClient.On("WaterTopic", "garden").Return("garden/action/water", nil)
-				mqttClient.On("Publish", "garden/action/water", []byte(`{"duration":1000,"id":null,"position":0}`)).Return(nil)
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden", GardenID: id}).Return("garden/action/water", nil)
+				mqttClient.On("PublishCommand", mqtt.WaterCommand, "garden", "garden/action/water", []byte(`{"duration":1000,"id":null,"position":0}`)).Return("id", nil)
 			},
 			"",
 		},
@@ -212,8 +212,8 @@ func TestExecuteScheduledWaterAction(t *testing.T) {
 					},
 				})
 				assert.NoError(t, err)
-				mqttClient.On("WaterTopic", "garden").Return("garden/action/water", nil)
-				mqttClient.On("Publish", "garden/action/water", []byte(`{"duration":1000,"id":null,"position":0}`)).Return(nil)
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden", GardenID: id}).Return("garden/action/water", nil)
+				mqttClient.On("PublishCommand", mqtt.WaterCommand, "garden", "garden/action/water", []byte(`{"duration":1000,"id":null,"position":0}`)).Return("id", nil)
 			},
 			"",
 		},
@@ -239,8 +239,8 @@ func TestExecuteScheduledWaterAction(t *testing.T) {
 					},
 				})
 				assert.NoError(t, err)
-				mqttClient.On("WaterTopic", "garden").Return("garden/action/water", nil)
-				mqttClient.On("Publish", "garden/action/water", []byte(`{"duration":500,"id":null,"position":0}`)).Return(nil)
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden", GardenID: id}).Return("garden/action/water", nil)
+				mqttClient.On("PublishCommand", mqtt.WaterCommand, "garden", "garden/action/water", []byte(`{"duration":500,"id":null,"position":0}`)).Return("id", nil)
 			},
 			"",
 		},
@@ -266,8 +266,8 @@ func TestExecuteScheduledWaterAction(t *testing.T) {
 					},
 				})
 				assert.NoError(t, err)
-				mqttClient.On("WaterTopic", "garden").Return("garden/action/water", nil)
-				mqttClient.On("Publish", "garden/action/water", []byte(`{"duration":1000,"id":null,"position":0}`)).Return(nil)
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden", GardenID: id}).Return("garden/action/water", nil)
+				mqttClient.On("PublishCommand", mqtt.WaterCommand, "garden", "garden/action/water", []byte(`{"duration":1000,"id":null,"position":0}`)).Return("id", nil)
 			},
 			"",
 		},
@@ -293,8 +293,8 @@ func TestExecuteScheduledWaterAction(t *testing.T) {
 					},
 				})
 				assert.NoError(t, err)
-				mqttClient.On("WaterTopic", "garden").Return("garden/action/water", nil)
-				mqttClient.On("Publish", "garden/action/water", []byte(`{"duration":1250,"id":null,"position":0}`)).Return(nil)
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden", GardenID: id}).Return("garden/action/water", nil)
+				mqttClient.On("PublishCommand", mqtt.WaterCommand, "garden", "garden/action/water", []byte(`{"duration":1250,"id":null,"position":0}`)).Return("id", nil)
 			},
 			"",
 		},
@@ -320,8 +320,8 @@ func TestExecuteScheduledWaterAction(t *testing.T) {
 					},
 				})
 				assert.NoError(t, err)
-				mqttClient.On("WaterTopic", "garden").Return("garden/action/water", nil)
-				mqttClient.On("Publish", "garden/action/water", []byte(`{"duration":1500,"id":null,"position":0}`)).Return(nil)
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden", GardenID: id}).Return("garden/action/water", nil)
+				mqttClient.On("PublishCommand", mqtt.WaterCommand, "garden", "garden/action/water", []byte(`{"duration":1500,"id":null,"position":0}`)).Return("id", nil)
 			},
 			"",
 		},
@@ -347,8 +347,8 @@ func TestExecuteScheduledWaterAction(t *testing.T) {
 					},
 				})
 				assert.NoError(t, err)
-				mqttClient.On("WaterTopic", "garden").Return("garden/action/water", nil)
-				mqttClient.On("Publish", "garden/action/water", []byte(`{"duration":1500,"id":null,"position":0}`)).Return(nil)
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden", GardenID: id}).Return("garden/action/water", nil)
+				mqttClient.On("PublishCommand", mqtt.WaterCommand, "garden", "garden/action/water", []byte(`{"duration":1500,"id":null,"position":0}`)).Return("id", nil)
 			},
 			"",
 		},
@@ -374,8 +374,8 @@ func TestExecuteScheduledWaterAction(t *testing.T) {
 					},
 				})
 				assert.NoError(t, err)
-				mqttClient.On("WaterTopic", "garden").Return("garden/action/water", nil)
-				mqttClient.On("Publish", "garden/action/water", []byte(`{"duration":750,"id":null,"position":0}`)).Return(nil)
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden", GardenID: id}).Return("garden/action/water", nil)
+				mqttClient.On("PublishCommand", mqtt.WaterCommand, "garden", "garden/action/water", []byte(`{"duration":750,"id":null,"position":0}`)).Return("id", nil)
 			},
 			"",
 		},
@@ -401,8 +401,8 @@ func TestExecuteScheduledWaterAction(t *testing.T) {
 					},
 				})
 				assert.NoError(t, err)
-				mqttClient.On("WaterTopic", "garden").Return("garden/action/water", nil)
-				mqttClient.On("Publish", "garden/action/water", []byte(`{"duration":500,"id":null,"position":0}`)).Return(nil)
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden", GardenID: id}).Return("garden/action/water", nil)
+				mqttClient.On("PublishCommand", mqtt.WaterCommand, "garden", "garden/action/water", []byte(`{"duration":500,"id":null,"position":0}`)).Return("id", nil)
 			},
 			"",
 		},
@@ -428,8 +428,8 @@ func TestExecuteScheduledWaterAction(t *testing.T) {
 					},
 				})
 				assert.NoError(t, err)
-				mqttClient.On("WaterTopic", "garden").Return("garden/action/water", nil)
-				mqttClient.On("Publish", "garden/action/water", []byte(`{"duration":500,"id":null,"position":0}`)).Return(nil)
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden", GardenID: id}).Return("garden/action/water", nil)
+				mqttClient.On("PublishCommand", mqtt.WaterCommand, "garden", "garden/action/water", []byte(`{"duration":500,"id":null,"position":0}`)).Return("id", nil)
 			},
 			"",
 		},
@@ -455,8 +455,8 @@ func TestExecuteScheduledWaterAction(t *testing.T) {
 					},
 				})
 				assert.NoError(t, err)
-				mqttClient.On("WaterTopic", "garden").Return("garden/action/water", nil)
-				mqttClient.On("Publish", "garden/action/water", []byte(`{"duration":1000,"id":null,"position":0}`)).Return(nil)
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden", GardenID: id}).Return("garden/action/water", nil)
+				mqttClient.On("PublishCommand", mqtt.WaterCommand, "garden", "garden/action/water", []byte(`{"duration":1000,"id":null,"position":0}`)).Return("id", nil)
 			},
 			"",
 		},
@@ -486,8 +486,8 @@ func TestExecuteScheduledWaterAction(t *testing.T) {
 					},
 				})
 				assert.NoError(t, err)
-				mqttClient.On("WaterTopic", "garden").Return("garden/action/water", nil)
-				mqttClient.On("Publish", "garden/action/water", []byte(`{"duration":625,"id":null,"position":0}`)).Return(nil)
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden", GardenID: id}).Return("garden/action/water", nil)
+				mqttClient.On("PublishCommand", mqtt.WaterCommand, "garden", "garden/action/water", []byte(`{"duration":625,"id":null,"position":0}`)).Return("id", nil)
 			},
 			"",
 		},
@@ -518,8 +518,245 @@ func TestExecuteScheduledWaterAction(t *testing.T) {
 					},
 				})
 				assert.NoError(t, err)
-				mqttClient.On("WaterTopic", "garden").Return("garden/action/water", nil)
-				mqttClient.On("Publish", "garden/action/water", []byte(`{"duration":375,"id":null,"position":0}`)).Return(nil)
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden", GardenID: id}).Return("garden/action/water", nil)
+				mqttClient.On("PublishCommand", mqtt.WaterCommand, "garden", "garden/action/water", []byte(`{"duration":375,"id":null,"position":0}`)).Return("id", nil)
+			},
+			"",
+		},
+		{
+			"FreezeControlSkips",
+			&pkg.WaterSchedule{
+				Duration: &pkg.Duration{Duration: time.Second},
+				Interval: &pkg.Duration{Duration: time.Hour * 24},
+				WeatherControl: &weather.Control{
+					Freeze: &weather.ThresholdControl{Threshold: float32Pointer(2), ClientID: weatherClientID},
+				},
+			},
+			&pkg.Zone{
+				Position: uintPointer(0),
+			},
+			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient, sc *storage.Client) {
+				err := sc.SaveWeatherClientConfig(&weather.Config{
+					ID:   weatherClientID,
+					Type: "fake",
+					Options: map[string]interface{}{
+						"rain_interval":       "24h",
+						"current_temperature": -1,
+					},
+				})
+				assert.NoError(t, err)
+				// no other mock calls are made because watering is skipped
+			},
+			"",
+		},
+		{
+			"FreezeControlForecastSkips",
+			&pkg.WaterSchedule{
+				Duration: &pkg.Duration{Duration: time.Second},
+				Interval: &pkg.Duration{Duration: time.Hour * 24},
+				WeatherControl: &weather.Control{
+					Freeze: &weather.ThresholdControl{Threshold: float32Pointer(2), ClientID: weatherClientID},
+				},
+			},
+			&pkg.Zone{
+				Position: uintPointer(0),
+			},
+			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient, sc *storage.Client) {
+				err := sc.SaveWeatherClientConfig(&weather.Config{
+					ID:   weatherClientID,
+					Type: "fake",
+					Options: map[string]interface{}{
+						"rain_interval":            "24h",
+						"current_temperature":      10,
+						"forecast_low_temperature": -3,
+					},
+				})
+				assert.NoError(t, err)
+				// no other mock calls are made because watering is skipped
+			},
+			"",
+		},
+		{
+			"FreezeControlAboveThresholdWaters",
+			&pkg.WaterSchedule{
+				Duration: &pkg.Duration{Duration: time.Second},
+				Interval: &pkg.Duration{Duration: time.Hour * 24},
+				WeatherControl: &weather.Control{
+					Freeze: &weather.ThresholdControl{Threshold: float32Pointer(2), ClientID: weatherClientID},
+				},
+			},
+			&pkg.Zone{
+				Position: uintPointer(0),
+			},
+			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient, sc *storage.Client) {
+				err := sc.SaveWeatherClientConfig(&weather.Config{
+					ID:   weatherClientID,
+					Type: "fake",
+					Options: map[string]interface{}{
+						"rain_interval":       "24h",
+						"current_temperature": 10,
+					},
+				})
+				assert.NoError(t, err)
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden", GardenID: id}).Return("garden/action/water", nil)
+				mqttClient.On("PublishCommand", mqtt.WaterCommand, "garden", "garden/action/water", []byte(`{"duration":1000,"id":null,"position":0}`)).Return("id", nil)
+			},
+			"",
+		},
+		{
+			"WindControlSkips",
+			&pkg.WaterSchedule{
+				Duration: &pkg.Duration{Duration: time.Second},
+				Interval: &pkg.Duration{Duration: time.Hour * 24},
+				WeatherControl: &weather.Control{
+					Wind: &weather.ThresholdControl{Threshold: float32Pointer(25), ClientID: weatherClientID},
+				},
+			},
+			&pkg.Zone{
+				Position: uintPointer(0),
+			},
+			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient, sc *storage.Client) {
+				err := sc.SaveWeatherClientConfig(&weather.Config{
+					ID:   weatherClientID,
+					Type: "fake",
+					Options: map[string]interface{}{
+						"rain_interval": "24h",
+						"wind_speed":    40,
+					},
+				})
+				assert.NoError(t, err)
+				// no other mock calls are made because watering is skipped
+			},
+			"",
+		},
+		{
+			"WindControlErrorWaters",
+			&pkg.WaterSchedule{
+				Duration: &pkg.Duration{Duration: time.Second},
+				Interval: &pkg.Duration{Duration: time.Hour * 24},
+				WeatherControl: &weather.Control{
+					Wind: &weather.ThresholdControl{Threshold: float32Pointer(25), ClientID: weatherClientID},
+				},
+			},
+			&pkg.Zone{
+				Position: uintPointer(0),
+			},
+			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient, sc *storage.Client) {
+				err := sc.SaveWeatherClientConfig(&weather.Config{
+					ID:   weatherClientID,
+					Type: "fake",
+					Options: map[string]interface{}{
+						"rain_interval": "24h",
+						"error":         "weather error",
+					},
+				})
+				assert.NoError(t, err)
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden", GardenID: id}).Return("garden/action/water", nil)
+				mqttClient.On("PublishCommand", mqtt.WaterCommand, "garden", "garden/action/water", []byte(`{"duration":1000,"id":null,"position":0}`)).Return("id", nil)
+			},
+			"",
+		},
+		{
+			"RainForecastSkips",
+			&pkg.WaterSchedule{
+				Duration: &pkg.Duration{Duration: time.Second},
+				Interval: &pkg.Duration{Duration: time.Hour * 24},
+				WeatherControl: &weather.Control{
+					RainForecast: &weather.RainForecastControl{
+						Window:             &pkg.Duration{Duration: 6 * time.Hour},
+						MinimumProbability: float32Pointer(50),
+						SkipThreshold:      float32Pointer(10),
+						Factor:             float32Pointer(0),
+						Range:              float32Pointer(20),
+						ClientID:           weatherClientID,
+					},
+				},
+			},
+			&pkg.Zone{
+				Position: uintPointer(0),
+			},
+			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient, sc *storage.Client) {
+				err := sc.SaveWeatherClientConfig(&weather.Config{
+					ID:   weatherClientID,
+					Type: "fake",
+					Options: map[string]interface{}{
+						"rain_interval":             "24h",
+						"forecast_rain_mm":          15,
+						"forecast_rain_probability": 90,
+					},
+				})
+				assert.NoError(t, err)
+				// no other mock calls are made because watering is skipped
+			},
+			"",
+		},
+		{
+			"RainForecastScalesDown",
+			&pkg.WaterSchedule{
+				Duration: &pkg.Duration{Duration: time.Second},
+				Interval: &pkg.Duration{Duration: time.Hour * 24},
+				WeatherControl: &weather.Control{
+					RainForecast: &weather.RainForecastControl{
+						Window:             &pkg.Duration{Duration: 6 * time.Hour},
+						MinimumProbability: float32Pointer(50),
+						SkipThreshold:      float32Pointer(10),
+						Factor:             float32Pointer(0),
+						Range:              float32Pointer(20),
+						ClientID:           weatherClientID,
+					},
+				},
+			},
+			&pkg.Zone{
+				Position: uintPointer(0),
+			},
+			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient, sc *storage.Client) {
+				err := sc.SaveWeatherClientConfig(&weather.Config{
+					ID:   weatherClientID,
+					Type: "fake",
+					Options: map[string]interface{}{
+						"rain_interval":             "24h",
+						"forecast_rain_mm":          5,
+						"forecast_rain_probability": 90,
+					},
+				})
+				assert.NoError(t, err)
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden", GardenID: id}).Return("garden/action/water", nil)
+				mqttClient.On("PublishCommand", mqtt.WaterCommand, "garden", "garden/action/water", []byte(`{"duration":750,"id":null,"position":0}`)).Return("id", nil)
+			},
+			"",
+		},
+		{
+			"RainForecastUnlikelyDoesNotScale",
+			&pkg.WaterSchedule{
+				Duration: &pkg.Duration{Duration: time.Second},
+				Interval: &pkg.Duration{Duration: time.Hour * 24},
+				WeatherControl: &weather.Control{
+					RainForecast: &weather.RainForecastControl{
+						Window:             &pkg.Duration{Duration: 6 * time.Hour},
+						MinimumProbability: float32Pointer(50),
+						SkipThreshold:      float32Pointer(10),
+						Factor:             float32Pointer(0),
+						Range:              float32Pointer(20),
+						ClientID:           weatherClientID,
+					},
+				},
+			},
+			&pkg.Zone{
+				Position: uintPointer(0),
+			},
+			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient, sc *storage.Client) {
+				err := sc.SaveWeatherClientConfig(&weather.Config{
+					ID:   weatherClientID,
+					Type: "fake",
+					Options: map[string]interface{}{
+						"rain_interval":             "24h",
+						"forecast_rain_mm":          15,
+						"forecast_rain_probability": 20,
+					},
+				})
+				assert.NoError(t, err)
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden", GardenID: id}).Return("garden/action/water", nil)
+				mqttClient.On("PublishCommand", mqtt.WaterCommand, "garden", "garden/action/water", []byte(`{"duration":1000,"id":null,"position":0}`)).Return("id", nil)
 			},
 			"",
 		},
@@ -572,3 +809,270 @@ func TestExecuteScheduledWaterAction(t *testing.T) {
 		})
 	}
 }
+
+func TestExecuteScheduledWaterActionSensorControl(t *testing.T) {
+	temperatureHumiditySensor := true
+	garden := &pkg.Garden{
+		ID:                        id,
+		Name:                      "garden",
+		TopicPrefix:               "garden",
+		TemperatureHumiditySensor: &temperatureHumiditySensor,
+	}
+	temperatureControl := &weather.ScaleControl{
+		BaselineValue: float32Pointer(70),
+		Factor:        float32Pointer(0.5),
+		Range:         float32Pointer(30),
+	}
+	humidityControl := &weather.ScaleControl{
+		BaselineValue: float32Pointer(50),
+		Factor:        float32Pointer(0.5),
+		Range:         float32Pointer(40),
+	}
+
+	tests := []struct {
+		name          string
+		garden        *pkg.Garden
+		control       *weather.Control
+		setupMock     func(*mqtt.MockClient, *influxdb.MockClient)
+		expectedError string
+	}{
+		{
+			"SensorTemperatureScaleUp",
+			garden,
+			&weather.Control{SensorTemperature: temperatureControl},
+			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient) {
+				influxdbClient.On("GetTemperatureAndHumidity", mock.Anything, "garden", time.Hour*24, influxdb.AggregationMean).Return(float64(85), float64(50), nil)
+				influxdbClient.On("Close")
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden", GardenID: id}).Return("garden/action/water", nil)
+				mqttClient.On("PublishCommand", mqtt.WaterCommand, "garden", "garden/action/water", []byte(`{"duration":1250,"id":null,"position":0}`)).Return("id", nil)
+			},
+			"",
+		},
+		{
+			"SensorHumidityLowScaleUp",
+			garden,
+			&weather.Control{SensorHumidity: humidityControl},
+			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient) {
+				influxdbClient.On("GetTemperatureAndHumidity", mock.Anything, "garden", time.Hour*24, influxdb.AggregationMean).Return(float64(85), float64(30), nil)
+				influxdbClient.On("Close")
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden", GardenID: id}).Return("garden/action/water", nil)
+				mqttClient.On("PublishCommand", mqtt.WaterCommand, "garden", "garden/action/water", []byte(`{"duration":1250,"id":null,"position":0}`)).Return("id", nil)
+			},
+			"",
+		},
+		{
+			"CompoundSensorScalingHotAndHumid",
+			garden,
+			&weather.Control{SensorTemperature: temperatureControl, SensorHumidity: humidityControl},
+			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient) {
+				influxdbClient.On("GetTemperatureAndHumidity", mock.Anything, "garden", time.Hour*24, influxdb.AggregationMean).Return(float64(85), float64(70), nil).Once()
+				influxdbClient.On("Close")
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden", GardenID: id}).Return("garden/action/water", nil)
+				mqttClient.On("PublishCommand", mqtt.WaterCommand, "garden", "garden/action/water", []byte(`{"duration":937,"id":null,"position":0}`)).Return("id", nil)
+			},
+			"",
+		},
+		{
+			"InfluxDBErrorDoesNotScale",
+			garden,
+			&weather.Control{SensorTemperature: temperatureControl},
+			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient) {
+				influxdbClient.On("GetTemperatureAndHumidity", mock.Anything, "garden", time.Hour*24, influxdb.AggregationMean).Return(float64(0), float64(0), errors.New("influxdb error"))
+				influxdbClient.On("Close")
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden", GardenID: id}).Return("garden/action/water", nil)
+				mqttClient.On("PublishCommand", mqtt.WaterCommand, "garden", "garden/action/water", []byte(`{"duration":1000,"id":null,"position":0}`)).Return("id", nil)
+			},
+			"",
+		},
+		{
+			"GardenWithoutSensorDoesNotScale",
+			&pkg.Garden{ID: id, Name: "garden", TopicPrefix: "garden"},
+			&weather.Control{SensorTemperature: temperatureControl},
+			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient) {
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden", GardenID: id}).Return("garden/action/water", nil)
+				mqttClient.On("PublishCommand", mqtt.WaterCommand, "garden", "garden/action/water", []byte(`{"duration":1000,"id":null,"position":0}`)).Return("id", nil)
+			},
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, err := storage.NewClient(storage.Config{
+				Driver: "hashmap",
+			})
+			assert.NoError(t, err)
+
+			mqttClient := new(mqtt.MockClient)
+			influxdbClient := new(influxdb.MockClient)
+			tt.setupMock(mqttClient, influxdbClient)
+
+			ws := &pkg.WaterSchedule{
+				Duration:       &pkg.Duration{Duration: time.Second},
+				Interval:       &pkg.Duration{Duration: time.Hour * 24},
+				WeatherControl: tt.control,
+			}
+			err = NewWorker(sc, influxdbClient, mqttClient, logrus.New()).ExecuteScheduledWaterAction(tt.garden, &pkg.Zone{Position: uintPointer(0)}, ws)
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			mqttClient.AssertExpectations(t)
+			influxdbClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestScaleWateringDurationSensorControl(t *testing.T) {
+	temperatureHumiditySensor := true
+	temperatureControl := &weather.Control{SensorTemperature: &weather.ScaleControl{
+		BaselineValue: float32Pointer(70),
+		Factor:        float32Pointer(0.5),
+		Range:         float32Pointer(30),
+	}}
+
+	tests := []struct {
+		name             string
+		garden           *pkg.Garden
+		interval         *pkg.Duration
+		setupMock        func(*influxdb.MockClient)
+		expectedDuration time.Duration
+		expectedHadError bool
+	}{
+		{
+			"GardenWithoutSensorIsNotAnError",
+			&pkg.Garden{ID: id, TopicPrefix: "garden"},
+			&pkg.Duration{Duration: time.Hour * 24},
+			func(influxdbClient *influxdb.MockClient) {},
+			time.Second,
+			false,
+		},
+		{
+			"CronIntervalUsesTimeBetweenRuns",
+			&pkg.Garden{ID: id, TopicPrefix: "garden", TemperatureHumiditySensor: &temperatureHumiditySensor},
+			&pkg.Duration{Cron: "0 * * * *"},
+			func(influxdbClient *influxdb.MockClient) {
+				influxdbClient.On("GetTemperatureAndHumidity", mock.Anything, "garden", time.Hour, influxdb.AggregationMean).Return(float64(85), float64(50), nil)
+				influxdbClient.On("Close")
+			},
+			1250 * time.Millisecond,
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			influxdbClient := new(influxdb.MockClient)
+			tt.setupMock(influxdbClient)
+
+			ws := &pkg.WaterSchedule{
+				Duration:       &pkg.Duration{Duration: time.Second},
+				Interval:       tt.interval,
+				WeatherControl: temperatureControl,
+			}
+			duration, hadError := NewWorker(nil, influxdbClient, nil, logrus.New()).ScaleWateringDuration(tt.garden, ws)
+			assert.Equal(t, tt.expectedDuration, duration)
+			assert.Equal(t, tt.expectedHadError, hadError)
+			influxdbClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestExecuteHeatCheck(t *testing.T) {
+	weatherClientID, _ := xid.FromString("c5cvhpcbcv45e8bp16dg")
+
+	tests := []struct {
+		name               string
+		currentTemperature float32
+		heatDuration       *pkg.Duration
+		interval           *pkg.Duration
+		expectedMessage    []byte
+	}{
+		{
+			"BelowThresholdNoWatering",
+			30,
+			nil,
+			&pkg.Duration{Duration: time.Hour * 24},
+			nil,
+		},
+		{
+			"AboveThresholdUsesScheduleDuration",
+			42,
+			nil,
+			&pkg.Duration{Duration: time.Hour * 24},
+			[]byte(`{"duration":1000,"id":"c5cvhpcbcv45e8bp16dg","position":0}`),
+		},
+		{
+			"AboveThresholdUsesHeatDuration",
+			42,
+			&pkg.Duration{Duration: 500 * time.Millisecond},
+			&pkg.Duration{Duration: time.Hour * 24},
+			[]byte(`{"duration":500,"id":"c5cvhpcbcv45e8bp16dg","position":0}`),
+		},
+		{
+			"AboveThresholdWithCronInterval",
+			42,
+			nil,
+			&pkg.Duration{Cron: "0 8 * * *"},
+			[]byte(`{"duration":1000,"id":"c5cvhpcbcv45e8bp16dg","position":0}`),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, err := storage.NewClient(storage.Config{
+				Driver: "hashmap",
+			})
+			assert.NoError(t, err)
+			defer weather.ResetCache()
+
+			err = sc.SaveWeatherClientConfig(&weather.Config{
+				ID:   weatherClientID,
+				Type: "fake",
+				Options: map[string]interface{}{
+					"rain_interval":       "24h",
+					"current_temperature": tt.currentTemperature,
+				},
+			})
+			assert.NoError(t, err)
+
+			ws := &pkg.WaterSchedule{
+				ID:       id,
+				Duration: &pkg.Duration{Duration: time.Second},
+				Interval: tt.interval,
+				WeatherControl: &weather.Control{
+					Heat: &weather.HeatControl{
+						Threshold: float32Pointer(40),
+						Duration:  tt.heatDuration,
+						ClientID:  weatherClientID,
+					},
+				},
+			}
+			err = sc.SaveWaterSchedule(ws)
+			assert.NoError(t, err)
+
+			zone := &pkg.Zone{ID: id, Position: uintPointer(0), WaterScheduleIDs: []xid.ID{id}}
+			err = sc.SaveGarden(&pkg.Garden{ID: id, TopicPrefix: "garden", Zones: map[xid.ID]*pkg.Zone{id: zone}})
+			assert.NoError(t, err)
+			err = sc.SaveZone(id, zone)
+			assert.NoError(t, err)
+
+			mqttClient := new(mqtt.MockClient)
+			influxdbClient := new(influxdb.MockClient)
+			if tt.expectedMessage != nil {
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden", GardenID: id, ZoneID: id}).Return("garden/action/water", nil)
+				// only published once because the second check is within the WaterSchedule's interval
+				mqttClient.On("PublishCommand", mqtt.WaterCommand, "garden", "garden/action/water", tt.expectedMessage).Return("id", nil).Once()
+			}
+
+			w := NewWorker(sc, influxdbClient, mqttClient, logrus.New())
+			w.executeHeatCheck(ws, w.logger)
+			w.executeHeatCheck(ws, w.logger)
+
+			mqttClient.AssertExpectations(t)
+			influxdbClient.AssertExpectations(t)
+		})
+	}
+}
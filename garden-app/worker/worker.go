@@ -1,13 +1,17 @@
 package worker
 
 import (
+	"context"
+	"sync"
 	"time"
 
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/homeassistant"
 	"github.com/calvinmclean/automated-garden/garden-app/pkg/influxdb"
 	"github.com/calvinmclean/automated-garden/garden-app/pkg/mqtt"
 	"github.com/calvinmclean/automated-garden/garden-app/pkg/storage"
 	"github.com/go-co-op/gocron"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/xid"
 	"github.com/sirupsen/logrus"
 )
 
@@ -31,6 +35,33 @@ type Worker struct {
 	mqttClient     mqtt.Client
 	scheduler      *gocron.Scheduler
 	logger         *logrus.Entry
+
+	// closedLoopsMu protects closedLoops, which tracks running closed-loop waterings by a unique ID for each loop so they
+	// can be stopped
+	closedLoopsMu sync.Mutex
+	closedLoops   map[xid.ID]closedLoop
+
+	// heatRunsMu protects heatRuns, which tracks the last extra watering from HeatControl by WaterSchedule ID
+	heatRunsMu sync.Mutex
+	heatRuns   map[xid.ID]time.Time
+
+	// homeAssistant is set when the Home Assistant integration is started. homeAssistantMu protects waterDurations,
+	// which are the watering durations set for each Zone in Home Assistant
+	homeAssistant   *homeassistant.Config
+	homeAssistantMu sync.Mutex
+	waterDurations  map[xid.ID]time.Duration
+
+	// state is set when state tracking is started and tracks the live state of each Garden's controller
+	state *stateTracker
+
+	// controllerLogs is set when storing controller logs is started
+	controllerLogs *controllerLogs
+}
+
+// closedLoop holds the Garden that a running closed-loop watering belongs to and the function used to cancel it
+type closedLoop struct {
+	gardenID xid.ID
+	cancel   context.CancelFunc
 }
 
 // NewWorker creates a Worker with specified clients
@@ -46,6 +77,9 @@ func NewWorker(
 		mqttClient:     mqttClient,
 		scheduler:      gocron.NewScheduler(time.Local),
 		logger:         logger.WithField("source", "worker"),
+		closedLoops:    map[xid.ID]closedLoop{},
+		heatRuns:       map[xid.ID]time.Time{},
+		waterDurations: map[xid.ID]time.Duration{},
 	}
 }
 
@@ -64,6 +98,9 @@ func (w *Worker) Stop() {
 	if w.mqttClient != nil {
 		w.mqttClient.Disconnect(100)
 	}
+	if w.controllerLogs != nil {
+		w.stopControllerLogs()
+	}
 	if w.influxdbClient != nil {
 		w.influxdbClient.Close()
 	}
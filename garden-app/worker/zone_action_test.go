@@ -23,16 +23,18 @@ func TestZoneAction(t *testing.T) {
 	}
 
 	tests := []struct {
-		name          string
-		action        *action.ZoneAction
-		setupMock     func(*mqtt.MockClient, *influxdb.MockClient)
-		expectedError string
+		name               string
+		action             *action.ZoneAction
+		setupMock          func(*mqtt.MockClient, *influxdb.MockClient)
+		expectedError      string
+		expectedCommandIDs []string
 	}{
 		{
 			"SuccessfulEmptyZoneAction",
 			&action.ZoneAction{},
 			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient) {},
 			"",
+			[]string{},
 		},
 		{
 			"SuccessfulZoneActionWithWaterAction",
@@ -42,10 +44,11 @@ func TestZoneAction(t *testing.T) {
 				},
 			},
 			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient) {
-				mqttClient.On("WaterTopic", "garden").Return("garden/action/water", nil)
-				mqttClient.On("Publish", "garden/action/water", mock.Anything).Return(nil)
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden"}).Return("garden/action/water", nil)
+				mqttClient.On("PublishCommand", mqtt.WaterCommand, "garden", "garden/action/water", mock.Anything).Return("id", nil)
 			},
 			"",
+			[]string{"id"},
 		},
 		{
 			"FailedZoneActionWithWaterAction",
@@ -55,9 +58,10 @@ func TestZoneAction(t *testing.T) {
 				},
 			},
 			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient) {
-				mqttClient.On("WaterTopic", "garden").Return("", errors.New("template error"))
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden"}).Return("", errors.New("template error"))
 			},
 			"unable to execute WaterAction: unable to fill MQTT topic template: template error",
+			[]string{},
 		},
 	}
 
@@ -70,13 +74,14 @@ func TestZoneAction(t *testing.T) {
 			influxdbClient := new(influxdb.MockClient)
 			tt.setupMock(mqttClient, influxdbClient)
 
-			err := NewWorker(nil, influxdbClient, mqttClient, logrus.New()).ExecuteZoneAction(garden, zone, tt.action)
+			commandIDs, err := NewWorker(nil, influxdbClient, mqttClient, logrus.New()).ExecuteZoneAction(garden, zone, tt.action)
 			if tt.expectedError != "" {
 				assert.Error(t, err)
 				assert.Equal(t, tt.expectedError, err.Error())
 			} else {
 				assert.NoError(t, err)
 			}
+			assert.Equal(t, tt.expectedCommandIDs, commandIDs)
 			mqttClient.AssertExpectations(t)
 			influxdbClient.AssertExpectations(t)
 		})
@@ -104,8 +109,20 @@ func TestWaterActionExecute(t *testing.T) {
 				Position: uintPointer(0),
 			},
 			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient, wc *weather.MockClient) {
-				mqttClient.On("WaterTopic", "garden").Return("garden/action/water", nil)
-				mqttClient.On("Publish", "garden/action/water", mock.Anything).Return(nil)
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden"}).Return("garden/action/water", nil)
+				mqttClient.On("PublishCommand", mqtt.WaterCommand, "garden", "garden/action/water", mock.Anything).Return("id", nil)
+			},
+			"",
+		},
+		{
+			"SuccessfulWithZoneTopicData",
+			&pkg.Zone{
+				ID:       id,
+				Position: uintPointer(3),
+			},
+			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient, wc *weather.MockClient) {
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden", ZoneID: id, ZonePosition: 3}).Return("garden/zone/3/water", nil)
+				mqttClient.On("PublishCommand", mqtt.WaterCommand, "garden", "garden/zone/3/water", mock.Anything).Return("id", nil)
 			},
 			"",
 		},
@@ -115,7 +132,7 @@ func TestWaterActionExecute(t *testing.T) {
 				Position: uintPointer(0),
 			},
 			func(mqttClient *mqtt.MockClient, influxdbClient *influxdb.MockClient, wc *weather.MockClient) {
-				mqttClient.On("WaterTopic", "garden").Return("", errors.New("template error"))
+				mqttClient.On("WaterTopic", mqtt.TopicData{Garden: "garden"}).Return("", errors.New("template error"))
 			},
 			"unable to fill MQTT topic template: template error",
 		},
@@ -133,7 +150,7 @@ func TestWaterActionExecute(t *testing.T) {
 			wc := new(weather.MockClient)
 			tt.setupMock(mqttClient, influxdbClient, wc)
 
-			err = NewWorker(storageClient, influxdbClient, mqttClient, logrus.New()).ExecuteWaterAction(garden, tt.zone, action)
+			_, err = NewWorker(storageClient, influxdbClient, mqttClient, logrus.New()).ExecuteWaterAction(garden, tt.zone, action)
 			if tt.expectedError != "" {
 				assert.Error(t, err)
 				assert.Equal(t, tt.expectedError, err.Error())
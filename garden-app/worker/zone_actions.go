@@ -3,43 +3,55 @@ package worker
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/calvinmclean/automated-garden/garden-app/pkg"
 	"github.com/calvinmclean/automated-garden/garden-app/pkg/action"
+	"github.com/calvinmclean/automated-garden/garden-app/pkg/mqtt"
 )
 
-// ExecuteZoneAction will execute a ZoneAction
-func (w *Worker) ExecuteZoneAction(g *pkg.Garden, z *pkg.Zone, input *action.ZoneAction) error {
+// ExecuteZoneAction will execute a ZoneAction and return the correlation IDs of the published commands
+func (w *Worker) ExecuteZoneAction(g *pkg.Garden, z *pkg.Zone, input *action.ZoneAction) ([]string, error) {
+	commandIDs := []string{}
 	if input.Water != nil {
-		err := w.ExecuteWaterAction(g, z, input.Water)
+		id, err := w.ExecuteWaterAction(g, z, input.Water)
 		if err != nil {
-			return fmt.Errorf("unable to execute WaterAction: %w", err)
+			return commandIDs, fmt.Errorf("unable to execute WaterAction: %w", err)
+		}
+		if id != "" {
+			commandIDs = append(commandIDs, id)
 		}
 	}
-	return nil
+	return commandIDs, nil
 }
 
 // ExecuteWaterAction sends the message over MQTT to the embedded garden controller. This is used for a directly-requested
-// WaterAction and does not perform any of the watering checks that are usuall done for a scheduled watering
-func (w *Worker) ExecuteWaterAction(g *pkg.Garden, z *pkg.Zone, input *action.WaterAction) error {
+// WaterAction and does not perform any of the watering checks that are usuall done for a scheduled watering.
+// It returns the command's correlation ID, which is empty if watering is skipped
+func (w *Worker) ExecuteWaterAction(g *pkg.Garden, z *pkg.Zone, input *action.WaterAction) (string, error) {
 	if input.Duration.Duration == 0 {
 		w.logger.Info("weather control determined that watering should be skipped")
-		return nil
+		return "", nil
 	}
 
+	return w.publishWaterCommand(g, z, input.Duration.Duration)
+}
+
+// publishWaterCommand sends a WaterMessage to the garden controller. It returns the command's correlation ID
+func (w *Worker) publishWaterCommand(g *pkg.Garden, z *pkg.Zone, duration time.Duration) (string, error) {
 	msg, err := json.Marshal(action.WaterMessage{
-		Duration: input.Duration.Duration.Milliseconds(),
+		Duration: duration.Milliseconds(),
 		ZoneID:   z.ID,
 		Position: *z.Position,
 	})
 	if err != nil {
-		return fmt.Errorf("unable to marshal WaterMessage to JSON: %w", err)
+		return "", fmt.Errorf("unable to marshal WaterMessage to JSON: %w", err)
 	}
 
-	topic, err := w.mqttClient.WaterTopic(g.TopicPrefix)
+	topic, err := w.mqttClient.WaterTopic(topicData(g, z))
 	if err != nil {
-		return fmt.Errorf("unable to fill MQTT topic template: %w", err)
+		return "", fmt.Errorf("unable to fill MQTT topic template: %w", err)
 	}
 
-	return w.mqttClient.Publish(topic, msg)
+	return w.mqttClient.PublishCommand(mqtt.WaterCommand, g.TopicPrefix, topic, msg)
 }
@@ -14,6 +14,14 @@
  *   IP address or hostname for MQTT broker
  * MQTT_PORT
  *   Port for MQTT broker
+ * ENABLE_MQTT_TLS
+ *   Connect to the MQTT broker using TLS
+ * MQTT_CA_CERT
+ *   PEM-encoded CA certificate used to verify the broker when TLS is enabled
+ * MQTT_TLS_INSECURE
+ *   Skip verifying the broker's certificate when TLS is enabled (used instead of MQTT_CA_CERT)
+ * MQTT_CLIENT_CERT and MQTT_CLIENT_KEY
+ *   Optional PEM-encoded client certificate and key for mutual TLS
  * MQTT_CLIENT_NAME
  *   Name to use when connecting to MQTT broker. By default this is TOPIC_PREFIX
  * MQTT_WATER_TOPIC
@@ -51,7 +59,7 @@
 #endif
 
  // Size of JSON object calculated using Arduino JSON Assistant
-#define JSON_CAPACITY 48
+#define JSON_CAPACITY 96
 
 /**
  * Garden Configurations
@@ -5,6 +5,8 @@ struct WaterEvent {
     int position;
     unsigned long duration;
     const char* id;
+    // started is true when the event is published before watering with the expected duration
+    bool started;
 };
 
 struct LightEvent {
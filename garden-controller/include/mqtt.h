@@ -2,6 +2,7 @@
 #define mqtt_h
 
 #include <WiFi.h>
+#include <WiFiClientSecure.h>
 #include <ArduinoJson.h>
 #include <PubSubClient.h>
 
@@ -9,6 +10,11 @@
 #include "wifi_config.h"
 #include "config.h"
 
+// NTP server used to sync time for dropping expired commands
+#ifndef NTP_SERVER
+#define NTP_SERVER "pool.ntp.org"
+#endif
+
 extern PubSubClient client;
 
 void setupMQTT();
@@ -86,9 +86,10 @@ void loop() {}
 /*
   waterZoneTask will wait for WaterEvents on a queue and will then open the
   valve for an amount of time. The delay before closing the valve is done with
-  xTaskNotifyWait, allowing it to be interrupted with xTaskNotify. After the
-  valve is closed, the WaterEvent is pushed to the queue fro publisherTask
-  which will record the WaterEvent in InfluxDB via MQTT and Telegraf
+  xTaskNotifyWait, allowing it to be interrupted with xTaskNotify. The
+  WaterEvent is pushed to the queue for publisherTask when the valve is opened
+  and again after it is closed, which will record the WaterEvent in InfluxDB
+  via MQTT and Telegraf and allows the garden-app to track the watering Zone
 */
 void waterZoneTask(void* parameters) {
   WaterEvent we;
@@ -102,6 +103,9 @@ void waterZoneTask(void* parameters) {
         we.duration = DEFAULT_WATER_TIME;
       }
 
+      we.started = true;
+      xQueueSend(waterPublisherQueue, &we, portMAX_DELAY);
+
       unsigned long start = millis();
       zoneOn(we.position);
       // Delay for specified watering time with option to interrupt
@@ -109,6 +113,7 @@ void waterZoneTask(void* parameters) {
       unsigned long stop = millis();
       zoneOff(we.position);
       we.duration = stop - start;
+      we.started = false;
       xQueueSend(waterPublisherQueue, &we, portMAX_DELAY);
     }
     vTaskDelay(5 / portTICK_PERIOD_MS);
@@ -1,9 +1,21 @@
 #include "mqtt.h"
 #include "main.h"
 
+#ifdef ENABLE_MQTT_TLS
+WiFiClientSecure wifiClient;
+#else
 WiFiClient wifiClient;
+#endif
 PubSubClient client(wifiClient);
 
+#ifdef MQTT_USERNAME
+const char* mqttUsername = MQTT_USERNAME;
+const char* mqttPassword = MQTT_PASSWORD;
+#else
+const char* mqttUsername = NULL;
+const char* mqttPassword = NULL;
+#endif
+
 TaskHandle_t mqttConnectTaskHandle;
 TaskHandle_t mqttLoopTaskHandle;
 TaskHandle_t healthPublisherTaskHandle;
@@ -41,8 +53,22 @@ const char* healthDataTopic = "";
 #endif
 
 #define ZERO (unsigned long int) 0
+// Commands can only be checked for expiration after the time is synced with NTP (2023-01-01 UTC)
+#define MIN_SYNCED_TIME 1672531200
 
 void setupMQTT() {
+#ifdef ENABLE_MQTT_TLS
+#ifdef MQTT_TLS_INSECURE
+    wifiClient.setInsecure();
+#else
+    wifiClient.setCACert(MQTT_CA_CERT);
+#endif
+#ifdef MQTT_CLIENT_CERT
+    wifiClient.setCertificate(MQTT_CLIENT_CERT);
+    wifiClient.setPrivateKey(MQTT_CLIENT_KEY);
+#endif
+#endif
+
     // Connect to MQTT
     client.setServer(MQTT_ADDRESS, MQTT_PORT);
     client.setCallback(processIncomingMessage);
@@ -84,20 +110,24 @@ void setupWifi() {
 
     printf("Wifi connected...\n");
 
+    // Sync time with NTP so expired commands can be dropped
+    configTime(0, 0, NTP_SERVER);
+
     // Create event handler tp recpnnect to WiFi
     WiFi.onEvent(wifiDisconnectHandler, WiFiEvent_t::ARDUINO_EVENT_WIFI_STA_DISCONNECTED);
 }
 
 /*
   waterPublisherTask reads from a queue to publish WaterEvents as an InfluxDB
-  line protocol message to MQTT
+  line protocol message to MQTT. The water_started measurement has the
+  expected duration and the water measurement has the actual duration
 */
 void waterPublisherTask(void* parameters) {
     WaterEvent we;
     while (true) {
         if (xQueueReceive(waterPublisherQueue, &we, portMAX_DELAY)) {
             char message[50];
-            sprintf(message, "water,zone=%d millis=%lu", we.position, we.duration);
+            sprintf(message, "%s,zone=%d millis=%lu", we.started ? "water_started" : "water", we.position, we.duration);
             if (client.connected()) {
                 printf("publishing to MQTT:\n\ttopic=%s\n\tmessage=%s\n", waterDataTopic, message);
                 client.publish(waterDataTopic, message);
@@ -164,7 +194,7 @@ void mqttConnectTask(void* parameters) {
         if (!client.connected()) {
             printf("attempting MQTT connection...");
             // Connect with defaul arguments + cleanSession = false for persistent sessions
-            if (client.connect(MQTT_CLIENT_NAME, NULL, NULL, 0, 0, 0, 0, false)) {
+            if (client.connect(MQTT_CLIENT_NAME, mqttUsername, mqttPassword, 0, 0, 0, 0, false)) {
                 printf("connected\n");
 #ifndef DISABLE_WATERING
                 client.subscribe(waterCommandTopic, 1);
@@ -206,6 +236,7 @@ void mqttLoopTask(void* parameters) {
     - stopAllCommandTopic: ignores message, stops the currently-watering zone,
                            and clears the waterQueue
     - lightCommandTopic: accepts LightEvent JSON to control a grow light
+  Commands with an "expires_at" timestamp in the past are dropped
 */
 void processIncomingMessage(char* topic, byte* message, unsigned int length) {
     printf("message received:\n\ttopic=%s\n\tmessage=%s\n", topic, (char*)message);
@@ -216,6 +247,15 @@ void processIncomingMessage(char* topic, byte* message, unsigned int length) {
         printf("deserialize failed: %s\n", err.c_str());
     }
 
+    const char* correlationID = doc["correlation_id"] | "N/A";
+    unsigned long expiresAt = doc["expires_at"] | ZERO;
+    time_t now = time(NULL);
+    if (expiresAt > 0 && now > MIN_SYNCED_TIME && (unsigned long)now > expiresAt) {
+        printf("dropping expired command %s\n", correlationID);
+        return;
+    }
+    printf("processing command %s\n", correlationID);
+
     if (strcmp(topic, waterCommandTopic) == 0) {
         WaterEvent we = {
             doc["position"] | -1,